@@ -132,6 +132,8 @@ func convertParsedQuery(pq *postgresparser.ParsedQuery) *SQLAnalysis {
 	res.Upsert = convertUpsert(pq.Upsert)
 	res.Returning = normalizeReturning(pq.Returning)
 	res.Merge = convertMerge(pq.Merge)
+	res.Privileges = convertPrivileges(pq.Privileges)
+	res.Transaction = convertTransaction(pq.Transaction)
 	res.DDLActions = convertDDLActions(pq.DDLActions)
 	res.ColumnUsage = convertColumnUsage(pq.ColumnUsage)
 	res.Correlations = convertCorrelations(pq.Correlations)
@@ -161,6 +163,7 @@ func convertColumnUsage(usage []postgresparser.ColumnUsage) []SQLColumnUsage {
 			Operator:   u.Operator,
 			Side:       u.Side,
 			Functions:  append([]string(nil), u.Functions...),
+			Span:       convertSourceSpan(u.Span),
 		})
 	}
 	return out
@@ -179,6 +182,7 @@ func convertTables(tbls []postgresparser.TableRef) []SQLTable {
 			Alias:  t.Alias,
 			Type:   SQLTableType(t.Type),
 			Raw:    t.Raw,
+			Span:   convertSourceSpan(t.Span),
 		})
 	}
 	return out
@@ -194,6 +198,7 @@ func convertColumns(cols []postgresparser.SelectColumn) []SQLColumn {
 		out = append(out, SQLColumn{
 			Expression: c.Expression,
 			Alias:      c.Alias,
+			Span:       convertSourceSpan(c.Span),
 		})
 	}
 	return out
@@ -286,11 +291,27 @@ func convertParameters(params []postgresparser.Parameter) []SQLParameter {
 			Raw:      p.Raw,
 			Marker:   p.Marker,
 			Position: p.Position,
+			Span:     convertSourceSpan(p.Span),
 		})
 	}
 	return out
 }
 
+// convertSourceSpan maps a parser source span into an analysis span.
+func convertSourceSpan(span *postgresparser.SourceSpan) *SQLSourceSpan {
+	if span == nil {
+		return nil
+	}
+	return &SQLSourceSpan{
+		Start:       span.Start,
+		End:         span.End,
+		StartLine:   span.StartLine,
+		StartColumn: span.StartColumn,
+		EndLine:     span.EndLine,
+		EndColumn:   span.EndColumn,
+	}
+}
+
 // convertCorrelations maps parser join-correlation metadata into analysis correlations.
 func convertCorrelations(corrs []postgresparser.JoinCorrelation) []SQLJoinCorrelation {
 	if len(corrs) == 0 {
@@ -410,6 +431,54 @@ func convertMergeTable(tbl postgresparser.TableRef) SQLTable {
 		Alias:  tbl.Alias,
 		Type:   SQLTableType(tbl.Type),
 		Raw:    tbl.Raw,
+		Span:   convertSourceSpan(tbl.Span),
+	}
+}
+
+// convertPrivileges maps parser GRANT/REVOKE metadata into analysis DTOs.
+func convertPrivileges(clause *postgresparser.PrivilegeClause) *SQLPrivileges {
+	if clause == nil {
+		return nil
+	}
+	out := &SQLPrivileges{
+		Action:            string(clause.Action),
+		ObjectType:        clause.ObjectType,
+		InSchemas:         append([]string(nil), clause.InSchemas...),
+		Grantees:          append([]string(nil), clause.Grantees...),
+		GrantedBy:         clause.GrantedBy,
+		Flags:             append([]string(nil), clause.Flags...),
+		DefaultPrivileges: clause.DefaultPrivileges,
+		ForRoles:          append([]string(nil), clause.ForRoles...),
+	}
+	for _, p := range clause.Privileges {
+		out.Privileges = append(out.Privileges, SQLPrivilege{
+			Name:    p.Name,
+			Columns: append([]string(nil), p.Columns...),
+		})
+	}
+	for _, obj := range clause.Objects {
+		out.Objects = append(out.Objects, SQLPrivilegeObject{
+			Schema: obj.Schema,
+			Name:   obj.Name,
+			Args:   obj.Args,
+		})
+	}
+	return out
+}
+
+// convertTransaction maps parser transaction control metadata into an analysis DTO.
+func convertTransaction(clause *postgresparser.TransactionClause) *SQLTransaction {
+	if clause == nil {
+		return nil
+	}
+	return &SQLTransaction{
+		Action:         string(clause.Action),
+		IsolationLevel: clause.IsolationLevel,
+		AccessMode:     clause.AccessMode,
+		Deferrable:     clause.Deferrable,
+		Chain:          clause.Chain,
+		Savepoint:      clause.Savepoint,
+		GID:            clause.GID,
 	}
 }
 
@@ -431,6 +500,16 @@ func convertDDLActions(actions []postgresparser.DDLAction) []SQLDDLAction {
 			IndexType:     a.IndexType,
 			Target:        a.Target,
 			Comment:       a.Comment,
+			Query:         convertParsedQuery(a.Query),
+			Constraints:   convertDDLConstraints(a.Constraints),
+			AlterOp:       convertDDLAlterOp(a.AlterOp),
+			Function:      convertDDLFunction(a.Function),
+			Sequence:      convertDDLSequence(a.Sequence),
+			TypeDef:       convertDDLType(a.TypeDef),
+			Trigger:       convertDDLTrigger(a.Trigger),
+			Version:       a.Version,
+			Owner:         a.Owner,
+			Span:          convertSourceSpan(a.Span),
 		})
 	}
 	return out
@@ -454,6 +533,144 @@ func convertDDLColumns(cols []postgresparser.DDLColumn) []SQLDDLColumn {
 	return out
 }
 
+// convertDDLConstraints maps parser constraint metadata into analysis DTOs.
+func convertDDLConstraints(constraints []postgresparser.DDLConstraint) []SQLDDLConstraint {
+	if len(constraints) == 0 {
+		return nil
+	}
+	out := make([]SQLDDLConstraint, 0, len(constraints))
+	for _, c := range constraints {
+		out = append(out, SQLDDLConstraint{
+			Type:              string(c.Type),
+			Name:              c.Name,
+			Columns:           append([]string(nil), c.Columns...),
+			ReferencedSchema:  c.ReferencedSchema,
+			ReferencedTable:   c.ReferencedTable,
+			ReferencedColumns: append([]string(nil), c.ReferencedColumns...),
+			OnDelete:          c.OnDelete,
+			OnUpdate:          c.OnUpdate,
+			Match:             c.Match,
+			Expression:        c.Expression,
+			Operators:         append([]string(nil), c.Operators...),
+			IndexType:         c.IndexType,
+			UsingIndex:        c.UsingIndex,
+			Flags:             append([]string(nil), c.Flags...),
+		})
+	}
+	return out
+}
+
+// convertDDLAlterOp maps a parser ALTER TABLE sub-command into an analysis DTO.
+func convertDDLAlterOp(op *postgresparser.DDLAlterOp) *SQLDDLAlterOp {
+	if op == nil {
+		return nil
+	}
+	return &SQLDDLAlterOp{
+		Type:           string(op.Type),
+		Column:         op.Column,
+		DataType:       op.DataType,
+		Collation:      op.Collation,
+		Using:          op.Using,
+		Default:        op.Default,
+		OldName:        op.OldName,
+		NewName:        op.NewName,
+		Constraint:     op.Constraint,
+		NewSchema:      op.NewSchema,
+		Owner:          op.Owner,
+		Trigger:        op.Trigger,
+		Partition:      op.Partition,
+		PartitionBound: op.PartitionBound,
+	}
+}
+
+// convertDDLSequence maps parser sequence options into an analysis DTO.
+func convertDDLSequence(seq *postgresparser.DDLSequence) *SQLDDLSequence {
+	if seq == nil {
+		return nil
+	}
+	return &SQLDDLSequence{
+		DataType:  seq.DataType,
+		Start:     seq.Start,
+		Restart:   seq.Restart,
+		Increment: seq.Increment,
+		MinValue:  seq.MinValue,
+		MaxValue:  seq.MaxValue,
+		Cache:     seq.Cache,
+		OwnedBy:   seq.OwnedBy,
+	}
+}
+
+// convertDDLType maps a parser type definition into an analysis DTO.
+func convertDDLType(def *postgresparser.DDLType) *SQLDDLType {
+	if def == nil {
+		return nil
+	}
+	return &SQLDDLType{
+		Kind:       def.Kind,
+		Labels:     append([]string(nil), def.Labels...),
+		Before:     def.Before,
+		After:      def.After,
+		Attributes: convertDDLColumns(def.Attributes),
+		Definition: def.Definition,
+		BaseType:   def.BaseType,
+		Default:    def.Default,
+		NotNull:    def.NotNull,
+		Collation:  def.Collation,
+	}
+}
+
+// convertDDLTrigger maps a parser trigger definition into an analysis DTO.
+func convertDDLTrigger(trigger *postgresparser.DDLTrigger) *SQLDDLTrigger {
+	if trigger == nil {
+		return nil
+	}
+	return &SQLDDLTrigger{
+		Table:        trigger.Table,
+		TableSchema:  trigger.TableSchema,
+		Timing:       trigger.Timing,
+		Events:       append([]string(nil), trigger.Events...),
+		ForEach:      trigger.ForEach,
+		When:         trigger.When,
+		Function:     trigger.Function,
+		FunctionArgs: append([]string(nil), trigger.FunctionArgs...),
+	}
+}
+
+// convertDDLFunction maps parser routine metadata into an analysis DTO.
+func convertDDLFunction(fn *postgresparser.DDLFunction) *SQLDDLFunction {
+	if fn == nil {
+		return nil
+	}
+	out := &SQLDDLFunction{
+		Returns:    fn.Returns,
+		ReturnsSet: fn.ReturnsSet,
+		Language:   fn.Language,
+		Volatility: fn.Volatility,
+		Parallel:   fn.Parallel,
+		Body:       fn.Body,
+	}
+	for _, arg := range fn.Args {
+		out.Args = append(out.Args, SQLDDLFunctionArg{
+			Name:    arg.Name,
+			Mode:    arg.Mode,
+			Type:    arg.Type,
+			Default: arg.Default,
+			Value:   arg.Value,
+		})
+	}
+	for _, stmt := range fn.Statements {
+		out.Statements = append(out.Statements, convertParsedQuery(stmt))
+	}
+	if fn.PLpgSQL != nil {
+		for _, stmt := range fn.PLpgSQL.Statements {
+			if stmt.Kind == postgresparser.PLpgSQLStatementExecute {
+				out.DynamicSQL = append(out.DynamicSQL, stmt.SQL)
+			}
+		}
+	}
+	return out
+}
+
 // convertMergeActions maps parser MERGE actions into analysis MERGE actions.
 func convertMergeActions(actions []postgresparser.MergeAction) []SQLMergeAction {
 	if len(actions) == 0 {
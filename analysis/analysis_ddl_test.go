@@ -1020,9 +1020,17 @@ func TestAnalyzeSQL_DDL_AlterTableOnlySchemaQualifiedTableRef(t *testing.T) {
 		t.Fatalf("expected raw table text with ONLY, got %q", res.Tables[0].Raw)
 	}
 
-	// ADD CONSTRAINT is currently skipped in DDL action extraction.
-	if len(res.DDLActions) != 0 {
-		t.Fatalf("expected no DDL actions for ADD CONSTRAINT, got %+v", res.DDLActions)
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action for ADD CONSTRAINT, got %+v", res.DDLActions)
+	}
+	act := res.DDLActions[0]
+	assertAnalysisFlag(t, act.Flags, "ADD_CONSTRAINT")
+	if len(act.Constraints) != 1 {
+		t.Fatalf("expected 1 constraint, got %+v", act.Constraints)
+	}
+	c := act.Constraints[0]
+	if c.Type != "PRIMARY_KEY" || c.Name != "schema_migrations_pkey" || !reflect.DeepEqual(c.Columns, []string{"version"}) {
+		t.Fatalf("unexpected constraint %+v", c)
 	}
 }
 
@@ -1046,9 +1054,17 @@ func TestAnalyzeSQL_DDL_AlterTableOnlyUnqualifiedTableRef(t *testing.T) {
 		t.Fatalf("expected raw table text with ONLY, got %q", res.Tables[0].Raw)
 	}
 
-	// ADD CONSTRAINT is currently skipped in DDL action extraction.
-	if len(res.DDLActions) != 0 {
-		t.Fatalf("expected no DDL actions for ADD CONSTRAINT, got %+v", res.DDLActions)
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action for ADD CONSTRAINT, got %+v", res.DDLActions)
+	}
+	act := res.DDLActions[0]
+	assertAnalysisFlag(t, act.Flags, "ADD_CONSTRAINT")
+	if len(act.Constraints) != 1 {
+		t.Fatalf("expected 1 constraint, got %+v", act.Constraints)
+	}
+	c := act.Constraints[0]
+	if c.Type != "PRIMARY_KEY" || c.Name != "schema_migrations_pkey" || !reflect.DeepEqual(c.Columns, []string{"version"}) {
+		t.Fatalf("unexpected constraint %+v", c)
 	}
 }
 
@@ -1194,6 +1210,118 @@ func TestAnalyzeSQL_DDL_Truncate(t *testing.T) {
 	}
 }
 
+// TestAnalyzeSQL_DDL_CreateView validates that the view's defining query is
+// carried through to the analysis DTO.
+func TestAnalyzeSQL_DDL_CreateView(t *testing.T) {
+	res, err := AnalyzeSQL("CREATE MATERIALIZED VIEW reporting.daily AS SELECT o.day, SUM(o.total) FROM orders o GROUP BY o.day WITH NO DATA")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action, got %d", len(res.DDLActions))
+	}
+	act := res.DDLActions[0]
+	if act.Type != string(postgresparser.DDLCreateMaterializedView) {
+		t.Fatalf("expected CREATE_MATERIALIZED_VIEW, got %s", act.Type)
+	}
+	if act.ObjectName != "daily" || act.Schema != "reporting" {
+		t.Fatalf("unexpected view name %q.%q", act.Schema, act.ObjectName)
+	}
+	assertAnalysisFlag(t, act.Flags, "WITH_NO_DATA")
+	if act.Query == nil {
+		t.Fatal("expected defining query analysis")
+	}
+	if act.Query.Command != SQLCommandSelect {
+		t.Fatalf("expected SELECT defining query, got %s", act.Query.Command)
+	}
+	if len(act.Query.Tables) != 1 || act.Query.Tables[0].Name != "orders" {
+		t.Fatalf("expected defining query to read orders, got %+v", act.Query.Tables)
+	}
+	if !reflect.DeepEqual(act.Query.GroupBy, []string{"o.day"}) {
+		t.Fatalf("unexpected GROUP BY %v", act.Query.GroupBy)
+	}
+}
+
+func TestAnalyzeSQL_DDL_AlterTableAlterOp(t *testing.T) {
+	res, err := AnalyzeSQL("ALTER TABLE users ALTER COLUMN age TYPE bigint USING age::bigint, ALTER COLUMN age SET DEFAULT 0")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if len(res.DDLActions) != 2 {
+		t.Fatalf("expected 2 DDL actions, got %d", len(res.DDLActions))
+	}
+	want := []*SQLDDLAlterOp{
+		{Type: string(postgresparser.DDLAlterOpSetDataType), Column: "age", DataType: "bigint", Using: "age::bigint"},
+		{Type: string(postgresparser.DDLAlterOpSetDefault), Column: "age", Default: "0"},
+	}
+	for i, act := range res.DDLActions {
+		if !reflect.DeepEqual(act.AlterOp, want[i]) {
+			t.Fatalf("action %d: expected alter op %+v, got %+v", i, want[i], act.AlterOp)
+		}
+	}
+}
+
+func TestAnalyzeSQL_DDL_CreateTrigger(t *testing.T) {
+	res, err := AnalyzeSQL("CREATE TRIGGER users_touch BEFORE UPDATE ON app.users FOR EACH ROW EXECUTE FUNCTION touch_updated_at()")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action, got %d", len(res.DDLActions))
+	}
+	want := &SQLDDLTrigger{
+		Table:       "users",
+		TableSchema: "app",
+		Timing:      "BEFORE",
+		Events:      []string{"UPDATE"},
+		ForEach:     "ROW",
+		Function:    "touch_updated_at",
+	}
+	if got := res.DDLActions[0].Trigger; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected trigger %+v, got %+v", want, got)
+	}
+}
+
+func TestAnalyzeSQL_DCL_Grant(t *testing.T) {
+	res, err := AnalyzeSQL("GRANT SELECT, UPDATE (email) ON app.users TO app_rw WITH GRANT OPTION")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if res.Command != SQLCommandDCL {
+		t.Fatalf("expected DCL command, got %s", res.Command)
+	}
+	want := &SQLPrivileges{
+		Action:     "GRANT",
+		Privileges: []SQLPrivilege{{Name: "SELECT"}, {Name: "UPDATE", Columns: []string{"email"}}},
+		ObjectType: "TABLE",
+		Objects:    []SQLPrivilegeObject{{Schema: "app", Name: "users"}},
+		Grantees:   []string{"app_rw"},
+		Flags:      []string{"WITH_GRANT_OPTION"},
+	}
+	if !reflect.DeepEqual(res.Privileges, want) {
+		t.Fatalf("expected privileges %+v, got %+v", want, res.Privileges)
+	}
+}
+
+func TestAnalyzeSQL_Transaction(t *testing.T) {
+	res, err := AnalyzeSQL("START TRANSACTION ISOLATION LEVEL SERIALIZABLE, READ ONLY, DEFERRABLE")
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if res.Command != SQLCommandTransaction {
+		t.Fatalf("expected TRANSACTION command, got %s", res.Command)
+	}
+	want := &SQLTransaction{
+		Action:         "START_TRANSACTION",
+		IsolationLevel: "SERIALIZABLE",
+		AccessMode:     "READ ONLY",
+		Deferrable:     "DEFERRABLE",
+	}
+	if !reflect.DeepEqual(res.Transaction, want) {
+		t.Fatalf("expected transaction %+v, got %+v", want, res.Transaction)
+	}
+}
+
 func assertAnalysisFlag(t *testing.T, flags []string, flag string) {
 	t.Helper()
 	for _, f := range flags {
@@ -1,8 +1,11 @@
 package analysis
 
 import (
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/valkdb/postgresparser"
 )
 
 // TestAnalyzeSQLSelect verifies that SELECT statements populate projection,
@@ -260,3 +263,22 @@ func TestAnalyzeSQLParseError(t *testing.T) {
 		t.Fatalf("expected nil result on parse error")
 	}
 }
+
+// TestAnalyzeSQLWithSourcePositions verifies spans are carried into analysis DTOs.
+func TestAnalyzeSQLWithSourcePositions(t *testing.T) {
+	sql := "SELECT id\nFROM users WHERE id = $1"
+	result, err := AnalyzeSQLWithOptions(sql, postgresparser.ParseOptions{IncludeSourcePositions: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := &SQLSourceSpan{Start: 15, End: 20, StartLine: 2, StartColumn: 6, EndLine: 2, EndColumn: 11}
+	if len(result.Tables) != 1 || !reflect.DeepEqual(result.Tables[0].Span, want) {
+		t.Fatalf("expected table span %+v, got %+v", want, result.Tables)
+	}
+	if len(result.Parameters) != 1 || result.Parameters[0].Span == nil || sql[result.Parameters[0].Span.Start:result.Parameters[0].Span.End] != "$1" {
+		t.Fatalf("expected parameter span for $1, got %+v", result.Parameters)
+	}
+	if len(result.Columns) != 1 || result.Columns[0].Span == nil {
+		t.Fatalf("expected column span, got %+v", result.Columns)
+	}
+}
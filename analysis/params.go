@@ -0,0 +1,467 @@
+// Package analysis provides query analysis for the PostgreSQL parser.
+// This file implements type inference for $n and ? parameter placeholders.
+package analysis
+
+import (
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/ident"
+)
+
+// paramInferrer infers placeholder types from the tokens around them.
+type paramInferrer struct {
+	*resolver
+	// clauses holds, per word, the clause keyword in effect at its depth.
+	clauses []string
+	// slots maps a placeholder that is a whole INSERT VALUES entry to its
+	// column position.
+	slots map[int]int
+}
+
+// clauseKeywords are the keywords that start a clause for paramInferrer.clauses.
+var clauseKeywords = map[string]struct{}{
+	"SELECT": {}, "FROM": {}, "WHERE": {}, "SET": {}, "VALUES": {}, "RETURNING": {}, "GROUP": {},
+	"HAVING": {}, "ORDER": {}, "LIMIT": {}, "OFFSET": {}, "FETCH": {}, "ON": {}, "USING": {}, "WINDOW": {},
+}
+
+// InferParameterTypes sets InferredType, Nullable, Column, and Clause on
+// query.Parameters from the context each placeholder appears in, looking
+// column types up in schemaMap (keyed like ResolveColumns expects).
+// Recognized contexts are:
+//   - explicit casts: $1::int, CAST($1 AS text)
+//   - LIMIT, OFFSET, and FETCH FIRST counts: bigint
+//   - INSERT VALUES entries, typed by their target column
+//   - SET targets of UPDATE and ON CONFLICT DO UPDATE: col = $1
+//   - comparisons, LIKE, BETWEEN, IS DISTINCT FROM, and IN lists against a
+//     column: id = $1
+//   - col = ANY($1), typed as an array of the column's type
+//
+// A cast placeholder stands for the whole operand, so col = $1::int and
+// col = CAST($1 AS int) are typed int and bound to col. An operand of
+// arithmetic or another operator, as in $1 + 0 = col or col = $1 || '%', is
+// not bound to the column, since the operator decides its type. BETWEEN
+// bounds are recognized only as bare placeholders.
+//
+// Nullable is set for INSERT values and SET targets bound to nullable
+// columns. Column names the column of every context but casts and counts,
+// even when schemaMap does not have it. A $n used more than once takes its
+// type from the first occurrence that has one. Placeholders in other contexts
+// keep an empty InferredType. Clause is the clause of the innermost query
+// around the placeholder; parentheses that are not a subquery, such as
+// EXTRACT(YEAR FROM $1), start no clause. The parameters are updated in place
+// and returned.
+func InferParameterTypes(query *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) []postgresparser.Parameter {
+	if query == nil {
+		return nil
+	}
+	if len(query.Parameters) == 0 {
+		return query.Parameters
+	}
+	r := newResolver(query, schemaMap)
+	var placeholders []int
+	for i, tok := range r.words() {
+		if tok.Kind == postgresparser.TokenParam {
+			placeholders = append(placeholders, i)
+		}
+	}
+	if len(placeholders) != len(query.Parameters) {
+		return query.Parameters
+	}
+
+	inf := &paramInferrer{resolver: r, clauses: clauseAt(r.words(), false), slots: r.valuesSlots()}
+	enclosing := clauseAt(r.words(), true)
+	for n, i := range placeholders {
+		p := &query.Parameters[n]
+		p.InferredType, p.Nullable, p.Column = inf.infer(i)
+		p.Clause = enclosing[i]
+	}
+
+	first := make(map[int]postgresparser.Parameter)
+	for _, p := range query.Parameters {
+		if _, ok := first[p.Position]; !ok && p.Marker == "$" && p.InferredType != "" {
+			first[p.Position] = p
+		}
+	}
+	for n := range query.Parameters {
+		p := &query.Parameters[n]
+		if known, ok := first[p.Position]; ok && p.Marker == "$" && p.InferredType == "" {
+			p.InferredType, p.Nullable = known.InferredType, known.Nullable
+		}
+	}
+	return query.Parameters
+}
+
+// infer returns the type and nullability of the placeholder at word i, and
+// the name of the column it is bound to.
+func (inf *paramInferrer) infer(i int) (string, bool, string) {
+	typ, nullable, column := inf.context(i)
+	if cast := castType(inf.words(), i); cast != "" {
+		return cast, false, column
+	}
+	return typ, nullable, column
+}
+
+// context returns the type, nullability, and column name the words around
+// the placeholder at word i imply, ignoring casts.
+func (inf *paramInferrer) context(i int) (string, bool, string) {
+	w := inf.words()
+	switch upperAt(w, i-1) {
+	case "LIMIT", "OFFSET":
+		return "bigint", false, ""
+	case "FIRST", "NEXT":
+		if upperAt(w, i-2) == "FETCH" {
+			return "bigint", false, ""
+		}
+	}
+	if k, ok := inf.slots[i]; ok {
+		name, col := inf.insertColumn(k)
+		if col != nil {
+			return col.PGType, col.IsNullable, name
+		}
+		return "", false, name
+	}
+	if name, col, set := inf.comparedColumn(i); name != "" {
+		if col != nil {
+			return col.PGType, set && col.IsNullable, name
+		}
+		return "", false, name
+	}
+	name, col := inf.anyColumn(i)
+	if col != nil && col.PGType != "" {
+		return col.PGType + "[]", false, name
+	}
+	return "", false, name
+}
+
+// comparedColumn returns the name and schema entry of the column the
+// placeholder at word i is compared with or assigned to, and whether it is a
+// SET assignment.
+func (inf *paramInferrer) comparedColumn(i int) (string, *ColumnSchema, bool) {
+	w := inf.words()
+	lo, hi := operand(w, i)
+	inSet := inf.clauses[i] == "SET"
+	switch {
+	case isOperator(w, lo-1) || isOperator(w, hi+1):
+		return "", nil, false
+	case isComparison(w, lo-1):
+		end := lo - 2
+		if upperAt(w, end) == "NOT" {
+			end--
+		}
+		name, col := inf.columnEndingAt(end, inSet)
+		return name, col, inSet
+	case isComparison(w, hi+1):
+		name, col := inf.columnStartingAt(hi + 2)
+		return name, col, false
+	case upperAt(w, i-1) == "BETWEEN":
+		name, col := inf.columnEndingAt(skipNot(w, i-2), false)
+		return name, col, false
+	case upperAt(w, i-1) == "SYMMETRIC" && upperAt(w, i-2) == "BETWEEN":
+		name, col := inf.columnEndingAt(skipNot(w, i-3), false)
+		return name, col, false
+	case upperAt(w, i-1) == "AND" && upperAt(w, i-3) == "BETWEEN":
+		name, col := inf.columnEndingAt(skipNot(w, i-4), false)
+		return name, col, false
+	case upperAt(w, lo-1) == "FROM" && upperAt(w, lo-2) == "DISTINCT":
+		name, col := inf.columnEndingAt(skipNot(w, lo-3)-1, false)
+		return name, col, false
+	}
+
+	// col [NOT] IN (..., $1, ...)
+	if lo < 1 || hi+1 >= len(w) || (w[lo-1].Text != "(" && w[lo-1].Text != ",") || (w[hi+1].Text != "," && w[hi+1].Text != ")") {
+		return "", nil, false
+	}
+	open, depth := lo-1, 0
+	for ; open >= 0; open-- {
+		if w[open].Text == ")" {
+			depth++
+		} else if w[open].Text == "(" {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+	}
+	if upperAt(w, open-1) != "IN" {
+		return "", nil, false
+	}
+	name, col := inf.columnEndingAt(skipNot(w, open-2), false)
+	return name, col, false
+}
+
+// anyColumn returns the column of "col op ANY($1)" for the placeholder at word i.
+func (inf *paramInferrer) anyColumn(i int) (string, *ColumnSchema) {
+	w := inf.words()
+	lo, hi := operand(w, i)
+	if upperAt(w, hi+1) != ")" || upperAt(w, lo-1) != "(" {
+		return "", nil
+	}
+	switch upperAt(w, lo-2) {
+	case "ANY", "SOME", "ALL":
+	default:
+		return "", nil
+	}
+	if !isComparison(w, lo-3) {
+		return "", nil
+	}
+	return inf.columnEndingAt(lo-4, false)
+}
+
+// columnEndingAt resolves the column reference whose last word is end.
+func (inf *paramInferrer) columnEndingAt(end int, inSet bool) (string, *ColumnSchema) {
+	w := inf.words()
+	if end < 0 || end >= len(w) || w[end].Kind != postgresparser.TokenIdentifier {
+		return "", nil
+	}
+	start := end
+	for start >= 2 && w[start-1].Text == "." && w[start-2].Kind == postgresparser.TokenIdentifier {
+		start -= 2
+	}
+	if isOperator(w, start-1) {
+		return "", nil // an operand of a + col = $1
+	}
+	return inf.column(start, end, inSet)
+}
+
+// columnStartingAt resolves the column reference whose first word is start.
+func (inf *paramInferrer) columnStartingAt(start int) (string, *ColumnSchema) {
+	w := inf.words()
+	if start >= len(w) || w[start].Kind != postgresparser.TokenIdentifier {
+		return "", nil
+	}
+	end := start
+	for end+2 < len(w) && w[end+1].Text == "." && w[end+2].Kind == postgresparser.TokenIdentifier {
+		end += 2
+	}
+	if end+1 < len(w) && w[end+1].Text == "(" {
+		return "", nil // a function call, not a column
+	}
+	if isOperator(w, end+1) {
+		return "", nil // an operand of $1 = col + a
+	}
+	return inf.column(start, end, false)
+}
+
+// column resolves the dotted name in words start..end to its unqualified
+// column name and its schema entry, which is nil when the owning table is
+// unknown. SET targets prefer the statement's target table.
+func (inf *paramInferrer) column(start, end int, inSet bool) (string, *ColumnSchema) {
+	usage := postgresparser.ColumnUsage{Column: ident.TrimQuotes(inf.words()[end].Text)}
+	if end > start {
+		usage.TableAlias = ident.TrimQuotes(inf.words()[end-2].Text)
+	}
+	if inSet {
+		usage.UsageType = postgresparser.ColumnUsageTypeDMLSet
+	}
+	rel := inf.resolve(usage)
+	if rel == nil {
+		return usage.Column, nil
+	}
+	return usage.Column, rel.column(usage.Column)
+}
+
+// insertColumn returns the name and schema entry of the target column at
+// position k of an INSERT.
+func (inf *paramInferrer) insertColumn(k int) (string, *ColumnSchema) {
+	target := inf.target
+	if len(inf.query.InsertColumns) > 0 {
+		if k >= len(inf.query.InsertColumns) {
+			return "", nil
+		}
+		name := baseColumnName(inf.query.InsertColumns[k])
+		if target == nil || !target.known {
+			return name, nil
+		}
+		return name, target.column(name)
+	}
+	if target == nil || !target.known || k >= len(target.columns) {
+		return "", nil
+	}
+	return target.columns[k].Name, &target.columns[k]
+}
+
+// valuesSlots maps each placeholder that, with any cast, forms a whole entry
+// of an INSERT's VALUES list to the entry's column position.
+func (r *resolver) valuesSlots() map[int]int {
+	if r.query.Command != postgresparser.QueryCommandInsert {
+		return nil
+	}
+	w := r.words()
+	slots := make(map[int]int)
+	for _, row := range r.valuesRows() {
+		for k, entry := range row {
+			p := entry[0]
+			if upperAt(w, p) == "CAST" {
+				p += 2
+			}
+			if p >= entry[1] || w[p].Kind != postgresparser.TokenParam {
+				continue
+			}
+			if lo, hi := operand(w, p); lo == entry[0] && hi == entry[1]-1 {
+				slots[p] = k
+			}
+		}
+	}
+	return slots
+}
+
+// operand returns the first and last word of the operand the placeholder at
+// word i forms: the placeholder with any ::type suffixes, or a whole
+// CAST($1 AS type) call.
+func operand(w []postgresparser.Token, i int) (int, int) {
+	lo, hi := i, i
+	if upperAt(w, i-1) == "(" && upperAt(w, i-2) == "CAST" && upperAt(w, i+1) == "AS" {
+		lo, hi = i-2, closingParen(w, i-1)
+	}
+	for upperAt(w, hi+1) == "::" {
+		_, next := parseType(w, hi+2)
+		if next == hi+2 {
+			break
+		}
+		hi = next - 1
+	}
+	return lo, hi
+}
+
+// castType returns the type of "$1::type" or "CAST($1 AS type)" for the
+// placeholder at word i.
+func castType(w []postgresparser.Token, i int) string {
+	if i+1 < len(w) && w[i+1].Text == "::" {
+		typ, _ := parseType(w, i+2)
+		return typ
+	}
+	if upperAt(w, i-1) == "(" && upperAt(w, i-2) == "CAST" && upperAt(w, i+1) == "AS" {
+		typ, _ := parseType(w, i+2)
+		return typ
+	}
+	return ""
+}
+
+// parseType reads a type name starting at word k, lowercased and including
+// multi-word names, modifiers, and array brackets, e.g. "numeric(10,2)" or
+// "timestamp with time zone[]". It also returns the index of the word after
+// the type, which is k when there is none.
+func parseType(w []postgresparser.Token, k int) (string, int) {
+	if k >= len(w) || (w[k].Kind != postgresparser.TokenIdentifier && w[k].Kind != postgresparser.TokenKeyword) {
+		return "", k
+	}
+	parts := []string{strings.ToLower(w[k].Text)}
+	for k++; k+1 < len(w) && w[k].Text == "."; k += 2 {
+		parts[0] += "." + strings.ToLower(w[k+1].Text)
+	}
+	for ; k < len(w) && continuesType(parts[len(parts)-1], strings.ToLower(w[k].Text)); k++ {
+		parts = append(parts, strings.ToLower(w[k].Text))
+	}
+	typ := strings.Join(parts, " ")
+	if k < len(w) && w[k].Text == "(" {
+		var mods []string
+		for k++; k < len(w) && w[k].Text != ")"; k++ {
+			mods = append(mods, w[k].Text)
+		}
+		typ += "(" + strings.Join(mods, "") + ")"
+		k++
+	}
+	for k < len(w) && w[k].Text == "[" {
+		for k < len(w) && w[k].Text != "]" {
+			k++
+		}
+		typ += "[]"
+		k++
+	}
+	return typ, k
+}
+
+// continuesType reports whether next extends a multi-word type name ending in last.
+func continuesType(last, next string) bool {
+	switch last {
+	case "double":
+		return next == "precision"
+	case "character", "char", "bit":
+		return next == "varying"
+	case "timestamp", "time":
+		return next == "with" || next == "without" || next == "zone"
+	case "with", "without":
+		return next == "time"
+	}
+	return false
+}
+
+// clauseAt returns, for each word, the clause keyword in effect at its
+// parenthesis depth. Only the statement and parenthesized queries start
+// clauses, so the FROM of EXTRACT(YEAR FROM x) or IS DISTINCT FROM and the
+// ORDER BY of an OVER clause do not. With inherit, a parenthesized group
+// that starts no clause of its own takes the clause around it.
+func clauseAt(words []postgresparser.Token, inherit bool) []string {
+	type group struct {
+		clause string
+		query  bool
+	}
+	out := make([]string, len(words))
+	stack := []group{{query: true}}
+	for i, tok := range words {
+		top := &stack[len(stack)-1]
+		switch {
+		case tok.Text == "(":
+			g := group{}
+			if inherit {
+				g.clause = top.clause
+			}
+			switch upperAt(words, i+1) {
+			case "SELECT", "VALUES", "WITH":
+				g.query = true
+			}
+			stack = append(stack, g)
+		case tok.Text == ")":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case tok.Kind == postgresparser.TokenKeyword && top.query:
+			word := strings.ToUpper(tok.Text)
+			if _, ok := clauseKeywords[word]; ok &&
+				!(word == "FROM" && upperAt(words, i-1) == "DISTINCT") && !(word == "GROUP" && upperAt(words, i-1) == "WITHIN") {
+				top.clause = word
+			}
+		}
+		out[i] = stack[len(stack)-1].clause
+	}
+	return out
+}
+
+// isComparison reports whether word i is a comparison or LIKE operator.
+func isComparison(w []postgresparser.Token, i int) bool {
+	switch upperAt(w, i) {
+	case "=", "<>", "!=", "<", ">", "<=", ">=", "LIKE", "ILIKE":
+		return true
+	}
+	return false
+}
+
+// isOperator reports whether word i is an operator other than a comparison,
+// such as + or ||, which makes the words beside it operands of an expression.
+func isOperator(w []postgresparser.Token, i int) bool {
+	if i < 0 || i >= len(w) || w[i].Kind != postgresparser.TokenPunctuation || isComparison(w, i) {
+		return false
+	}
+	switch w[i].Text {
+	case "(", ")", "[", "]", ",", ";", ".", "::":
+		return false
+	}
+	return true
+}
+
+// skipNot steps back over a NOT at word i.
+func skipNot(w []postgresparser.Token, i int) int {
+	if upperAt(w, i) == "NOT" {
+		return i - 1
+	}
+	return i
+}
+
+// upperAt returns the uppercased text of word i, or "" when out of range.
+func upperAt(w []postgresparser.Token, i int) string {
+	if i < 0 || i >= len(w) {
+		return ""
+	}
+	return strings.ToUpper(w[i].Text)
+}
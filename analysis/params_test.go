@@ -0,0 +1,144 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+var paramSchema = map[string][]ColumnSchema{
+	"users": {
+		{Name: "id", PGType: "bigint", IsPrimaryKey: true},
+		{Name: "name", PGType: "text"},
+		{Name: "email", PGType: "text", IsNullable: true},
+		{Name: "created_at", PGType: "timestamptz"},
+	},
+	"orders": {
+		{Name: "id", PGType: "bigint", IsPrimaryKey: true},
+		{Name: "user_id", PGType: "bigint"},
+		{Name: "total", PGType: "numeric(10,2)"},
+		{Name: "status", PGType: "text", IsNullable: true},
+	},
+}
+
+type paramType struct {
+	Type     string
+	Nullable bool
+	Column   string
+	Clause   string
+}
+
+func TestInferParameterTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []paramType
+	}{
+		{
+			name: "comparisons, LIKE, and BETWEEN",
+			sql:  "SELECT * FROM users WHERE id = $1 AND name NOT LIKE $2 AND created_at BETWEEN $3 AND $4",
+			want: []paramType{
+				{"bigint", false, "id", "WHERE"}, {"text", false, "name", "WHERE"},
+				{"timestamptz", false, "created_at", "WHERE"}, {"timestamptz", false, "created_at", "WHERE"},
+			},
+		},
+		{
+			name: "IN lists, reversed comparisons, LIMIT, and OFFSET",
+			sql: "SELECT * FROM users u JOIN orders o ON o.user_id = u.id " +
+				"WHERE o.status IN ($1, $2) AND $3 = u.email ORDER BY u.id LIMIT $4 OFFSET $5",
+			want: []paramType{
+				{"text", false, "status", "WHERE"}, {"text", false, "status", "WHERE"}, {"text", false, "email", "WHERE"},
+				{"bigint", false, "", "LIMIT"}, {"bigint", false, "", "OFFSET"},
+			},
+		},
+		{
+			name: "explicit casts",
+			sql:  "SELECT $1::int, CAST($2 AS character varying(20)), $3::timestamp with time zone, $4::text[]",
+			want: []paramType{
+				{"int", false, "", "SELECT"}, {"character varying(20)", false, "", "SELECT"},
+				{"timestamp with time zone", false, "", "SELECT"}, {"text[]", false, "", "SELECT"},
+			},
+		},
+		{
+			name: "casts stand for the whole operand",
+			sql:  "SELECT * FROM users WHERE id = CAST($1 AS int) AND $2::text = name AND email = ANY($3::text[])",
+			want: []paramType{{"int", false, "id", "WHERE"}, {"text", false, "name", "WHERE"}, {"text[]", false, "email", "WHERE"}},
+		},
+		{
+			name: "operands of other operators are not bound to the column",
+			sql:  "SELECT * FROM orders WHERE $1 + 0 = user_id AND total = $2 * 2 AND status LIKE $3 || '%' AND id - 1 = $4",
+			want: []paramType{{"", false, "", "WHERE"}, {"", false, "", "WHERE"}, {"", false, "", "WHERE"}, {"", false, "", "WHERE"}},
+		},
+		{
+			name: "clauses of nested expressions and subqueries",
+			sql: "SELECT EXTRACT(YEAR FROM $1::date), count(*) OVER (ORDER BY $2) FROM users " +
+				"WHERE created_at IS DISTINCT FROM $3 AND id IN (SELECT user_id FROM orders WHERE total > $4)",
+			want: []paramType{
+				{"date", false, "", "SELECT"}, {"", false, "", "SELECT"},
+				{"timestamptz", false, "created_at", "WHERE"}, {"numeric(10,2)", false, "total", "WHERE"},
+			},
+		},
+		{
+			name: "INSERT column positions",
+			sql:  "INSERT INTO users (id, email) VALUES ($1, $2), ($3, lower($4)) RETURNING id",
+			want: []paramType{
+				{"bigint", false, "id", "VALUES"}, {"text", true, "email", "VALUES"},
+				{"bigint", false, "id", "VALUES"}, {"", false, "", "VALUES"},
+			},
+		},
+		{
+			name: "INSERT without a column list",
+			sql:  "INSERT INTO orders VALUES ($1, $2, $3, $4)",
+			want: []paramType{
+				{"bigint", false, "id", "VALUES"}, {"bigint", false, "user_id", "VALUES"},
+				{"numeric(10,2)", false, "total", "VALUES"}, {"text", true, "status", "VALUES"},
+			},
+		},
+		{
+			name: "UPDATE SET targets and ANY",
+			sql:  "UPDATE orders SET status = $1, total = $2 WHERE id = ANY($3) AND user_id = $4",
+			want: []paramType{
+				{"text", true, "status", "SET"}, {"numeric(10,2)", false, "total", "SET"},
+				{"bigint[]", false, "id", "WHERE"}, {"bigint", false, "user_id", "WHERE"},
+			},
+		},
+		{
+			name: "INSERT values with casts",
+			sql:  "INSERT INTO users (id, email) VALUES ($1::bigint, CAST($2 AS text))",
+			want: []paramType{{"bigint", false, "id", "VALUES"}, {"text", false, "email", "VALUES"}},
+		},
+		{
+			name: "ON CONFLICT DO UPDATE",
+			sql:  "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET email = $3",
+			want: []paramType{{"bigint", false, "id", "VALUES"}, {"text", false, "name", "VALUES"}, {"text", true, "email", "SET"}},
+		},
+		{
+			name: "repeated placeholder shares its type",
+			sql:  "SELECT * FROM users WHERE $1 IS NULL OR id = $1",
+			want: []paramType{{"bigint", false, "", "WHERE"}, {"bigint", false, "id", "WHERE"}},
+		},
+		{
+			name: "unknown table",
+			sql:  "SELECT * FROM events WHERE kind = $1",
+			want: []paramType{{"", false, "kind", "WHERE"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pq, err := postgresparser.ParseSQL(tt.sql)
+			require.NoError(t, err)
+			params := InferParameterTypes(pq, paramSchema)
+			got := make([]paramType, 0, len(params))
+			for _, p := range params {
+				got = append(got, paramType{p.InferredType, p.Nullable, p.Column, p.Clause})
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, params, pq.Parameters)
+		})
+	}
+
+	assert.Nil(t, InferParameterTypes(nil, paramSchema))
+}
@@ -0,0 +1,412 @@
+// Package analysis provides query analysis for the PostgreSQL parser.
+// This file implements schema-aware column resolution: every ColumnUsage is
+// assigned to the table that owns it, and references that cannot be assigned
+// are reported as diagnostics.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/ident"
+)
+
+// DiagnosticCode identifies the kind of problem a Diagnostic reports.
+type DiagnosticCode string
+
+const (
+	// DiagnosticAmbiguousColumn reports an unqualified column that exists in
+	// more than one table of the statement.
+	DiagnosticAmbiguousColumn DiagnosticCode = "AMBIGUOUS_COLUMN"
+	// DiagnosticUnknownColumn reports a column that no table of the statement has.
+	DiagnosticUnknownColumn DiagnosticCode = "UNKNOWN_COLUMN"
+	// DiagnosticUnresolvedColumn reports a column that ResolveColumns could not
+	// assign to a table because the statement's scopes are merged in the IR.
+	DiagnosticUnresolvedColumn DiagnosticCode = "UNRESOLVED_COLUMN"
+	// DiagnosticUnknownTable reports a table missing from the schema.
+	DiagnosticUnknownTable DiagnosticCode = "UNKNOWN_TABLE"
+	// DiagnosticInsertArity reports an INSERT whose VALUES rows or SELECT
+	// list do not match its target columns.
+	DiagnosticInsertArity DiagnosticCode = "INSERT_ARITY"
+	// DiagnosticUngroupedColumn reports a projected column that is neither
+	// grouped nor aggregated.
+	DiagnosticUngroupedColumn DiagnosticCode = "UNGROUPED_COLUMN"
+	// DiagnosticOrderByPosition reports an ORDER BY position outside the
+	// select list.
+	DiagnosticOrderByPosition DiagnosticCode = "ORDER_BY_POSITION"
+)
+
+// Diagnostic is a problem found while checking a query against a schema.
+type Diagnostic struct {
+	Code    DiagnosticCode `json:"code"`
+	Message string         `json:"message"`
+	// Table is the table the diagnostic refers to, "schema.name" when the
+	// query qualified it, or empty when the reference was unqualified.
+	Table  string `json:"table,omitempty"`
+	Column string `json:"column,omitempty"`
+	// Candidates lists the tables an ambiguous or unresolved column could
+	// belong to.
+	Candidates []string `json:"candidates,omitempty"`
+	// Span locates the first offending reference; set only when the query was
+	// parsed with ParseOptions.IncludeSourcePositions.
+	Span *postgresparser.SourceSpan `json:"span,omitempty"`
+}
+
+// String formats the diagnostic as "CODE: message".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Code, d.Message)
+}
+
+// ResolvedColumn is a ColumnUsage together with the base table that owns it.
+// Schema and Table are empty when the owner could not be determined, for
+// example when the column comes from a CTE, a subquery, or a table missing
+// from the schema map. Like BaseTables, Schema defaults to "public" for
+// unqualified table names.
+type ResolvedColumn struct {
+	Usage  postgresparser.ColumnUsage `json:"usage"`
+	Schema string                     `json:"schema,omitempty"`
+	Table  string                     `json:"table,omitempty"`
+}
+
+// relation is a FROM-list entry a column reference can be resolved against.
+type relation struct {
+	ref     postgresparser.TableRef
+	schema  string
+	name    string
+	columns []ColumnSchema
+	// known reports whether the relation is a base table found in the schema map.
+	known bool
+	// conflict marks a key that names different tables in different scopes.
+	conflict bool
+}
+
+// column returns the relation's schema entry for name, or nil when it has none.
+func (r *relation) column(name string) *ColumnSchema {
+	for i := range r.columns {
+		if strings.EqualFold(r.columns[i].Name, name) {
+			return &r.columns[i]
+		}
+	}
+	return nil
+}
+
+// hasColumn reports whether the relation's schema lists column.
+func (r *relation) hasColumn(column string) bool {
+	return r.column(column) != nil
+}
+
+// display returns the table name as the query wrote it, schema-qualified when
+// the query qualified it.
+func (r *relation) display() string {
+	if r.ref.Schema != "" {
+		return ident.TrimQuotes(r.ref.Schema) + "." + r.name
+	}
+	return r.name
+}
+
+// resolver holds the per-statement state of ResolveColumns and Validate.
+type resolver struct {
+	query     *postgresparser.ParsedQuery
+	schemaMap map[string][]ColumnSchema
+	relations []*relation
+	byKey     map[string]*relation
+	// target is the table written by INSERT, UPDATE, DELETE, or MERGE.
+	target *relation
+	// nested is set when the statement has subqueries, CTEs, or set
+	// operations, whose scopes the IR merges into one table list.
+	nested bool
+	// unresolved reports columns of nested statements that cannot be assigned
+	// to a table; only ResolveColumns sets it.
+	unresolved bool
+	// tokens caches words; tokenized is set once it has been filled.
+	tokens    []postgresparser.Token
+	tokenized bool
+	// outputs holds the lowercased output column aliases, which ORDER BY and
+	// GROUP BY may reference.
+	outputs map[string]struct{}
+	seen    map[string]struct{}
+	diags   []Diagnostic
+}
+
+// ResolveColumns assigns every query.ColumnUsage entry to the table that owns
+// it, using schemaMap to look up the columns of each table. The map is keyed
+// by lowercase table name, optionally also by "schema.table"; pass
+// catalog.ColumnSchemas() to resolve against a catalog.
+//
+// The returned slice is parallel to query.ColumnUsage. Qualified references
+// are resolved through table aliases. An unqualified reference is assigned to
+// the single known table that has the column; SET clauses, ON CONFLICT
+// columns, and the RETURNING list of INSERT prefer the statement's target
+// table.
+//
+// Diagnostics report ambiguous and unknown columns once per distinct column.
+// The IR merges the tables of subqueries, CTEs, and set operation arms into
+// one list, so in statements that have them an unqualified column is assigned
+// only when exactly one known table has it. A column that several known
+// tables have, or that none has, is reported as UNRESOLVED_COLUMN instead,
+// with the candidate tables when there are any; so is a qualifier that names
+// different tables in different scopes. A column that no known table has is
+// reported only when every table in scope is in schemaMap, since it may come
+// from a derived relation.
+func ResolveColumns(query *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) ([]ResolvedColumn, []Diagnostic) {
+	if query == nil || len(query.ColumnUsage) == 0 {
+		return nil, nil
+	}
+	r := newResolver(query, schemaMap)
+	r.unresolved = true
+	resolved := make([]ResolvedColumn, len(query.ColumnUsage))
+	for i, usage := range query.ColumnUsage {
+		resolved[i].Usage = usage
+		if rel := r.resolve(usage); rel != nil {
+			resolved[i].Schema = rel.schema
+			resolved[i].Table = rel.name
+		}
+	}
+	return resolved, r.diags
+}
+
+// newResolver indexes the statement's relations and finds its target table.
+func newResolver(query *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) *resolver {
+	r := &resolver{
+		query:     query,
+		schemaMap: schemaMap,
+		byKey:     make(map[string]*relation),
+		outputs:   make(map[string]struct{}),
+		seen:      make(map[string]struct{}),
+	}
+	for _, tbl := range query.Tables {
+		key := strings.ToLower(ident.TrimQuotes(strings.TrimSpace(tbl.Alias)))
+		if key == "" {
+			key = strings.ToLower(ident.TrimQuotes(strings.TrimSpace(tbl.Name)))
+		}
+		if key == "" {
+			continue
+		}
+		rel := newRelation(tbl, schemaMap)
+		if prev, ok := r.byKey[key]; ok {
+			if prev.ref.Type != rel.ref.Type || !strings.EqualFold(prev.display(), rel.display()) {
+				prev.conflict = true
+			}
+			continue
+		}
+		r.byKey[key] = rel
+		r.relations = append(r.relations, rel)
+	}
+	for _, col := range query.Columns {
+		if col.Alias != "" {
+			r.outputs[strings.ToLower(ident.TrimQuotes(col.Alias))] = struct{}{}
+		}
+	}
+
+	r.nested = len(query.CTEs) > 0 || len(query.SetOperations) > 0 || len(query.Subqueries) > 0 || query.HasSubqueries
+	for _, tbl := range query.Tables {
+		if tbl.Target {
+			r.target = r.lookupTable(tbl.Schema, tbl.Name)
+			break
+		}
+	}
+	return r
+}
+
+// words returns the statement's tokens without whitespace and comments,
+// tokenizing RawSQL on first use. It returns nil when RawSQL does not
+// tokenize, which leaves the token-based inferences empty.
+func (r *resolver) words() []postgresparser.Token {
+	if r.tokenized {
+		return r.tokens
+	}
+	r.tokenized = true
+	tokens, err := postgresparser.Tokenize(r.query.RawSQL)
+	if err != nil {
+		return nil
+	}
+	for _, tok := range tokens {
+		if tok.Kind != postgresparser.TokenWhitespace && tok.Kind != postgresparser.TokenComment {
+			r.tokens = append(r.tokens, tok)
+		}
+	}
+	return r.tokens
+}
+
+// newRelation looks up the columns of tbl in schemaMap.
+func newRelation(tbl postgresparser.TableRef, schemaMap map[string][]ColumnSchema) *relation {
+	rel := &relation{ref: tbl, name: ident.TrimQuotes(strings.TrimSpace(tbl.Name))}
+	if tbl.Type != postgresparser.TableTypeBase {
+		return rel
+	}
+	rel.schema = ident.TrimQuotes(strings.TrimSpace(tbl.Schema))
+	if rel.schema != "" {
+		rel.columns, rel.known = schemaMap[strings.ToLower(rel.schema+"."+rel.name)]
+	}
+	if !rel.known {
+		rel.columns, rel.known = schemaMap[strings.ToLower(rel.name)]
+	}
+	if rel.schema == "" {
+		rel.schema = "public"
+	}
+	return rel
+}
+
+// lookupTable returns the base relation with the given name.
+func (r *resolver) lookupTable(schema, name string) *relation {
+	schema = ident.TrimQuotes(schema)
+	name = ident.TrimQuotes(name)
+	for _, rel := range r.relations {
+		if rel.ref.Type != postgresparser.TableTypeBase || !strings.EqualFold(rel.name, name) {
+			continue
+		}
+		if schema == "" || strings.EqualFold(rel.schema, schema) {
+			return rel
+		}
+	}
+	return nil
+}
+
+// resolve returns the relation owning usage, recording diagnostics.
+func (r *resolver) resolve(usage postgresparser.ColumnUsage) *relation {
+	column := ident.TrimQuotes(usage.Column)
+	if usage.TableAlias != "" {
+		return r.resolveQualified(usage, column)
+	}
+	if column == "" || column == "*" {
+		return nil
+	}
+
+	if r.target != nil && prefersTarget(r.query.Command, usage.UsageType) {
+		if r.target.hasColumn(column) {
+			return r.target
+		}
+		if r.query.Command == postgresparser.QueryCommandInsert {
+			// Only the target (and EXCLUDED, which must be qualified) is in scope.
+			if r.target.known {
+				r.report(usage.Span, DiagnosticUnknownColumn, r.target.display(), column, nil,
+					fmt.Sprintf("column %q of relation %q does not exist", column, r.target.name))
+			}
+			return nil
+		}
+	}
+
+	var matches []*relation
+	complete := true
+	for _, rel := range r.relations {
+		if r.isInsertTarget(rel) {
+			continue
+		}
+		if !rel.known {
+			complete = false
+			continue
+		}
+		if rel.hasColumn(column) {
+			matches = append(matches, rel)
+		}
+	}
+
+	if len(matches) == 0 && r.target != nil && r.query.Command == postgresparser.QueryCommandInsert && r.target.hasColumn(column) {
+		// INSERT INTO t SELECT ... FROM t reads the target too.
+		return r.target
+	}
+
+	var candidates []string
+	for _, rel := range matches {
+		candidates = append(candidates, rel.display())
+	}
+	sort.Strings(candidates)
+	switch {
+	case len(matches) == 1:
+		return matches[0]
+	case r.nested && (len(matches) > 1 || complete) && !r.isOutputAlias(usage, column):
+		if r.unresolved {
+			r.report(usage.Span, DiagnosticUnresolvedColumn, "", column, candidates,
+				fmt.Sprintf("column %q cannot be assigned to a table", column))
+		}
+	case len(matches) > 1:
+		r.report(usage.Span, DiagnosticAmbiguousColumn, "", column, candidates,
+			fmt.Sprintf("column reference %q is ambiguous", column))
+	case complete && !r.isOutputAlias(usage, column):
+		r.report(usage.Span, DiagnosticUnknownColumn, "", column, nil,
+			fmt.Sprintf("column %q does not exist", column))
+	}
+	return nil
+}
+
+// resolveQualified resolves a column reference written as qualifier.column.
+func (r *resolver) resolveQualified(usage postgresparser.ColumnUsage, column string) *relation {
+	qualifier := strings.ToLower(ident.TrimQuotes(usage.TableAlias))
+	rel, ok := r.byKey[qualifier]
+	if !ok && qualifier == "excluded" && r.query.Command == postgresparser.QueryCommandInsert {
+		rel = r.target
+	}
+	if rel != nil && rel.conflict && r.unresolved {
+		r.report(usage.Span, DiagnosticUnresolvedColumn, "", column, nil,
+			fmt.Sprintf("column %s.%s cannot be assigned to a table", usage.TableAlias, column))
+	}
+	if rel == nil || rel.conflict || rel.ref.Type != postgresparser.TableTypeBase {
+		return nil
+	}
+	if column == "*" || rel.hasColumn(column) || !rel.known {
+		return rel
+	}
+	r.report(usage.Span, DiagnosticUnknownColumn, rel.display(), column, nil,
+		fmt.Sprintf("column %s.%s does not exist", usage.TableAlias, column))
+	return rel
+}
+
+// isInsertTarget reports whether rel is the target of INSERT, which is not in
+// scope of the statement's SELECT or VALUES.
+func (r *resolver) isInsertTarget(rel *relation) bool {
+	return r.query.Command == postgresparser.QueryCommandInsert && rel == r.target
+}
+
+// isOutputAlias reports whether an ORDER BY or GROUP BY reference names an
+// output column alias rather than a table column.
+func (r *resolver) isOutputAlias(usage postgresparser.ColumnUsage, column string) bool {
+	if usage.UsageType != postgresparser.ColumnUsageTypeOrderBy && usage.UsageType != postgresparser.ColumnUsageTypeGroupBy {
+		return false
+	}
+	_, ok := r.outputs[strings.ToLower(column)]
+	return ok
+}
+
+// report records a diagnostic unless one with the same code, table, and
+// column (or message, when there is no column) was already recorded. A
+// diagnostic without a table is also dropped when the column was already
+// reported against a table, so that a missing SET target is reported once.
+func (r *resolver) report(span *postgresparser.SourceSpan, code DiagnosticCode, table, column string, candidates []string, message string) {
+	key := string(code) + "|" + strings.ToLower(table) + "|" + strings.ToLower(column)
+	if column == "" {
+		key += "|" + message
+	}
+	columnKey := string(code) + "|*|" + strings.ToLower(column)
+	if _, ok := r.seen[key]; ok {
+		return
+	}
+	if _, ok := r.seen[columnKey]; ok && table == "" && column != "" {
+		return
+	}
+	r.seen[key] = struct{}{}
+	r.seen[columnKey] = struct{}{}
+	r.diags = append(r.diags, Diagnostic{
+		Code:       code,
+		Message:    message,
+		Table:      table,
+		Column:     column,
+		Candidates: candidates,
+		Span:       span,
+	})
+}
+
+// prefersTarget reports whether unqualified columns of the given usage type
+// belong to the statement's target table: SET clauses, ON CONFLICT columns,
+// and the RETURNING list of INSERT.
+func prefersTarget(command postgresparser.QueryCommand, usageType postgresparser.ColumnUsageType) bool {
+	switch usageType {
+	case postgresparser.ColumnUsageTypeDMLSet, postgresparser.ColumnUsageTypeUpsertTarget,
+		postgresparser.ColumnUsageTypeUpsertSet, postgresparser.ColumnUsageTypeMergeSet:
+		return true
+	case postgresparser.ColumnUsageTypeReturning:
+		return command == postgresparser.QueryCommandInsert
+	}
+	return false
+}
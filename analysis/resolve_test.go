@@ -0,0 +1,221 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+var resolveSchema = map[string][]ColumnSchema{
+	"users":        {{Name: "id"}, {Name: "name"}, {Name: "email"}},
+	"orders":       {{Name: "id"}, {Name: "user_id"}, {Name: "total"}, {Name: "status"}},
+	"audit":        {{Name: "id"}, {Name: "note"}},
+	"sales.orders": {{Name: "id"}, {Name: "region"}},
+}
+
+// resolveSQL parses sql and resolves its columns against resolveSchema.
+func resolveSQL(t *testing.T, sql string) ([]ResolvedColumn, []Diagnostic) {
+	t.Helper()
+	pq, err := postgresparser.ParseSQL(sql)
+	require.NoError(t, err)
+	resolved, diags := ResolveColumns(pq, resolveSchema)
+	require.Len(t, resolved, len(pq.ColumnUsage))
+	return resolved, diags
+}
+
+// owners maps "usage expression" to the owning "schema.table", or "" when unresolved.
+func owners(resolved []ResolvedColumn) map[string]string {
+	out := make(map[string]string, len(resolved))
+	for _, rc := range resolved {
+		owner := ""
+		if rc.Table != "" {
+			owner = rc.Schema + "." + rc.Table
+		}
+		out[string(rc.Usage.UsageType)+" "+rc.Usage.Expression] = owner
+	}
+	return out
+}
+
+func TestResolveColumns(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want map[string]string
+	}{
+		{
+			name: "unqualified columns of joined tables",
+			sql:  "SELECT name, total FROM users u JOIN orders o ON o.user_id = u.id WHERE status = 'paid'",
+			want: map[string]string{
+				"projection name":  "public.users",
+				"projection total": "public.orders",
+				"join o.user_id":   "public.orders",
+				"join u.id":        "public.users",
+				"filter status":    "public.orders",
+			},
+		},
+		{
+			name: "schema-qualified table",
+			sql:  "SELECT region, o.id FROM sales.orders o",
+			want: map[string]string{
+				"projection region": "sales.orders",
+				"projection o.id":   "sales.orders",
+			},
+		},
+		{
+			name: "UPDATE SET prefers the target",
+			sql:  "UPDATE orders SET status = 'void', total = 0 FROM users u WHERE user_id = u.id AND u.name = $1",
+			want: map[string]string{
+				"dml_set status": "public.orders",
+				"dml_set total":  "public.orders",
+				"filter user_id": "public.orders",
+				"filter u.id":    "public.users",
+				"filter u.name":  "public.users",
+			},
+		},
+		{
+			name: "INSERT target is out of scope of its SELECT",
+			sql:  "INSERT INTO users (id, name) SELECT id, note FROM audit RETURNING email",
+			want: map[string]string{
+				"projection id":   "public.audit",
+				"projection note": "public.audit",
+				"returning email": "public.users",
+			},
+		},
+		{
+			name: "derived relations and unknown tables stay unresolved",
+			sql:  "WITH recent AS (SELECT user_id FROM orders) SELECT r.user_id, e.kind FROM recent r, events e",
+			want: map[string]string{
+				"projection r.user_id": "",
+				"projection e.kind":    "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, diags := resolveSQL(t, tt.sql)
+			assert.Empty(t, diags)
+			got := owners(resolved)
+			for key, want := range tt.want {
+				assert.Contains(t, got, key)
+				assert.Equal(t, want, got[key], key)
+			}
+		})
+	}
+}
+
+func TestResolveColumns_Diagnostics(t *testing.T) {
+	resolved, diags := resolveSQL(t, "SELECT id, name FROM users u JOIN orders o ON o.user_id = u.id ORDER BY id")
+	require.Len(t, diags, 1)
+	assert.Equal(t, DiagnosticAmbiguousColumn, diags[0].Code)
+	assert.Equal(t, "id", diags[0].Column)
+	assert.Equal(t, []string{"orders", "users"}, diags[0].Candidates)
+	assert.Equal(t, `AMBIGUOUS_COLUMN: column reference "id" is ambiguous`, diags[0].String())
+	assert.Equal(t, "", owners(resolved)["projection id"])
+	assert.Equal(t, "public.users", owners(resolved)["projection name"])
+
+	_, diags = resolveSQL(t, "SELECT nickname, u.nick FROM users u WHERE nickname <> ''")
+	require.Len(t, diags, 2)
+	assert.Equal(t, Diagnostic{
+		Code:    DiagnosticUnknownColumn,
+		Message: `column "nickname" does not exist`,
+		Column:  "nickname",
+	}, diags[0])
+	assert.Equal(t, Diagnostic{
+		Code:    DiagnosticUnknownColumn,
+		Message: "column u.nick does not exist",
+		Table:   "users",
+		Column:  "nick",
+	}, diags[1])
+
+	_, diags = resolveSQL(t, "INSERT INTO audit (id) VALUES (1) ON CONFLICT (id) DO UPDATE SET notes = excluded.notes")
+	require.NotEmpty(t, diags)
+	assert.Equal(t, `column "notes" of relation "audit" does not exist`, diags[0].Message)
+}
+
+func TestResolveColumns_NestedDiagnostics(t *testing.T) {
+	// byColumn maps each diagnostic's column to the diagnostic.
+	byColumn := func(diags []Diagnostic) map[string]Diagnostic {
+		out := make(map[string]Diagnostic, len(diags))
+		for _, d := range diags {
+			out[d.Column] = d
+		}
+		return out
+	}
+
+	resolved, diags := resolveSQL(t, "SELECT name FROM users WHERE email IN (SELECT note FROM orders o JOIN audit a ON a.id = o.id WHERE id > 0)")
+	require.Len(t, diags, 1)
+	assert.Equal(t, Diagnostic{
+		Code:       DiagnosticUnresolvedColumn,
+		Message:    `column "id" cannot be assigned to a table`,
+		Column:     "id",
+		Candidates: []string{"audit", "orders", "users"},
+	}, diags[0])
+	assert.Equal(t, "public.users", owners(resolved)["projection name"])
+	assert.Equal(t, "public.audit", owners(resolved)["projection note"])
+
+	resolved, diags = resolveSQL(t, "WITH recent AS (SELECT note, id FROM audit, orders) SELECT note FROM recent")
+	require.Len(t, diags, 1)
+	assert.Equal(t, DiagnosticUnresolvedColumn, diags[0].Code)
+	assert.Equal(t, "id", diags[0].Column)
+	assert.Equal(t, []string{"audit", "orders"}, diags[0].Candidates)
+	assert.Equal(t, "public.audit", owners(resolved)["projection note"])
+
+	_, diags = resolveSQL(t, "SELECT id FROM users UNION SELECT nickname FROM orders")
+	got := byColumn(diags)
+	require.Len(t, got, 2)
+	assert.Equal(t, []string{"orders", "users"}, got["id"].Candidates)
+	assert.Equal(t, `UNRESOLVED_COLUMN: column "nickname" cannot be assigned to a table`, got["nickname"].String())
+	assert.Empty(t, got["nickname"].Candidates)
+
+	_, diags = resolveSQL(t, "SELECT u.name FROM users u WHERE EXISTS (SELECT 1 FROM orders u WHERE u.status = 'void')")
+	got = byColumn(diags)
+	require.Len(t, got, 2)
+	assert.Equal(t, `UNRESOLVED_COLUMN: column u.name cannot be assigned to a table`, got["name"].String())
+	assert.Equal(t, DiagnosticUnresolvedColumn, got["status"].Code)
+}
+
+func TestResolveColumns_TargetAndNestingFromIR(t *testing.T) {
+	// The resolver reads the target and nesting from the IR, so a RawSQL that
+	// does not tokenize (here with its trailing // comment) changes nothing.
+	parse := func(sql string) *postgresparser.ParsedQuery {
+		pq, err := postgresparser.ParseSQL(sql)
+		require.NoError(t, err)
+		pq.RawSQL = sql
+		_, err = postgresparser.Tokenize(sql)
+		require.Error(t, err)
+		return pq
+	}
+
+	pq := parse("UPDATE orders SET status = 'void' FROM users u WHERE user_id = u.id // request 42")
+	resolved, diags := ResolveColumns(pq, resolveSchema)
+	assert.Empty(t, diags)
+	assert.Equal(t, "public.orders", owners(resolved)["dml_set status"])
+	assert.Equal(t, "public.orders", owners(resolved)["filter user_id"])
+
+	pq = parse("SELECT name FROM users WHERE email IN (SELECT note FROM audit WHERE id > 0) // request 42")
+	_, diags = ResolveColumns(pq, resolveSchema)
+	require.Len(t, diags, 1)
+	assert.Equal(t, DiagnosticUnresolvedColumn, diags[0].Code)
+	assert.Equal(t, "id", diags[0].Column)
+}
+
+func TestResolveColumns_NoFalsePositives(t *testing.T) {
+	for _, sql := range []string{
+		"SELECT name AS n FROM users ORDER BY n",
+		"SELECT email FROM users UNION SELECT status FROM orders",
+		"SELECT name FROM users WHERE email IN (SELECT status FROM orders)",
+		"SELECT kind FROM users, events",
+		"SELECT kind FROM users WHERE id IN (SELECT user_id FROM events)",
+		"SELECT g FROM users, generate_series(1, 3) g",
+	} {
+		_, diags := resolveSQL(t, sql)
+		assert.Empty(t, diags, sql)
+	}
+
+	resolved, diags := ResolveColumns(nil, resolveSchema)
+	assert.Nil(t, resolved)
+	assert.Nil(t, diags)
+}
@@ -0,0 +1,701 @@
+// Package analysis provides query analysis for the PostgreSQL parser.
+// This file implements output type and nullability inference for SELECT lists
+// and RETURNING clauses.
+package analysis
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/ident"
+)
+
+// ErrNoExpressionTrees is returned by InferResultColumns for a query parsed
+// without ParseOptions.IncludeExpressionTrees.
+var ErrNoExpressionTrees = errors.New("query was parsed without ParseOptions.IncludeExpressionTrees")
+
+// ResultColumn is one column of a statement's result set.
+type ResultColumn struct {
+	// Name is the output column name: the alias, or the name PostgreSQL gives
+	// an unaliased expression ("?column?" when it has none).
+	Name string `json:"name"`
+	// Expression is the projected expression as written; columns expanded
+	// from * are written qualifier.column.
+	Expression string `json:"expression"`
+	// Type is the PostgreSQL type, empty when it could not be inferred.
+	Type     string `json:"type,omitempty"`
+	Nullable bool   `json:"nullable"`
+}
+
+// resultTyper infers expression types against one statement's relations.
+type resultTyper struct {
+	*resolver
+	usage postgresparser.ColumnUsageType
+	// outer holds the keys of relations on the nullable side of an outer join.
+	outer map[string]struct{}
+	// parent types the correlated references of a scalar subquery.
+	parent *resultTyper
+}
+
+// numericRanks orders the numeric types by PostgreSQL's implicit promotion.
+var numericRanks = map[string]int{
+	"smallint": 1, "integer": 2, "bigint": 3, "numeric": 4, "real": 5, "double precision": 6,
+}
+
+// typeAliases maps alternative spellings to PostgreSQL's canonical type names.
+var typeAliases = map[string]string{
+	"int": "integer", "int4": "integer", "serial": "integer", "serial4": "integer",
+	"int2": "smallint", "smallserial": "smallint", "serial2": "smallint",
+	"int8": "bigint", "bigserial": "bigint", "serial8": "bigint",
+	"decimal": "numeric", "float4": "real", "float": "double precision", "float8": "double precision",
+	"bool": "boolean", "varchar": "character varying", "char": "character", "bpchar": "character",
+	"timestamptz": "timestamp with time zone", "timestamp": "timestamp without time zone",
+	"timetz": "time with time zone", "time": "time without time zone",
+}
+
+// fixedResultTypes maps functions whose result type does not depend on their
+// arguments to that type.
+var fixedResultTypes = map[string]string{
+	"count": "bigint", "row_number": "bigint", "rank": "bigint", "dense_rank": "bigint", "ntile": "bigint",
+	"nextval": "bigint", "currval": "bigint", "setval": "bigint", "lastval": "bigint",
+	"percent_rank": "double precision", "cume_dist": "double precision", "random": "double precision",
+	"date_part": "double precision", "extract": "numeric",
+	"now": "timestamp with time zone", "current_timestamp": "timestamp with time zone",
+	"transaction_timestamp": "timestamp with time zone", "statement_timestamp": "timestamp with time zone",
+	"clock_timestamp": "timestamp with time zone", "to_timestamp": "timestamp with time zone",
+	"localtimestamp": "timestamp without time zone", "current_date": "date", "to_date": "date",
+	"make_date": "date", "current_time": "time with time zone", "localtime": "time without time zone",
+	"age": "interval", "make_interval": "interval",
+	"current_user": "name", "session_user": "name", "user": "name", "current_role": "name",
+	"lower": "text", "upper": "text", "initcap": "text", "trim": "text", "btrim": "text", "ltrim": "text",
+	"rtrim": "text", "substring": "text", "substr": "text", "replace": "text", "left": "text", "right": "text",
+	"lpad": "text", "rpad": "text", "repeat": "text", "reverse": "text", "md5": "text", "translate": "text",
+	"split_part": "text", "regexp_replace": "text", "to_char": "text", "overlay": "text", "concat": "text",
+	"concat_ws": "text", "format": "text", "string_agg": "text", "quote_ident": "text", "quote_literal": "text",
+	"length": "integer", "char_length": "integer", "character_length": "integer", "octet_length": "integer",
+	"position": "integer", "strpos": "integer", "array_length": "integer", "cardinality": "integer",
+	"grouping": "integer",
+	"bool_and": "boolean", "bool_or": "boolean", "every": "boolean",
+	"gen_random_uuid": "uuid", "uuid_generate_v4": "uuid",
+	"json_agg": "json", "json_object_agg": "json", "to_json": "json", "row_to_json": "json",
+	"json_build_object": "json", "json_build_array": "json", "array_to_json": "json",
+	"jsonb_agg": "jsonb", "jsonb_object_agg": "jsonb", "to_jsonb": "jsonb", "jsonb_build_object": "jsonb",
+	"jsonb_build_array": "jsonb", "jsonb_set": "jsonb", "jsonb_strip_nulls": "jsonb",
+}
+
+// notNullFunctions lists functions that never return NULL.
+var notNullFunctions = map[string]struct{}{
+	"count": {}, "row_number": {}, "rank": {}, "dense_rank": {}, "percent_rank": {}, "cume_dist": {},
+	"random": {}, "now": {}, "current_timestamp": {}, "transaction_timestamp": {}, "statement_timestamp": {},
+	"clock_timestamp": {}, "localtimestamp": {}, "current_date": {}, "current_time": {}, "localtime": {},
+	"current_user": {}, "session_user": {}, "user": {}, "current_role": {}, "concat": {},
+	"gen_random_uuid": {}, "uuid_generate_v4": {}, "nextval": {}, "grouping": {},
+	"json_build_object": {}, "json_build_array": {}, "jsonb_build_object": {}, "jsonb_build_array": {},
+}
+
+// InferResultColumns returns the result set of a statement: the select list
+// of a SELECT, or the RETURNING list of INSERT, UPDATE, DELETE, and MERGE.
+// Column types and nullability come from schemaMap, keyed like
+// ResolveColumns expects, and propagate through:
+//   - casts and typed literals
+//   - common functions and aggregates: count → bigint, sum(integer) →
+//     bigint, coalesce, now(), lower, ...
+//   - arithmetic with PostgreSQL's numeric promotion and date/time rules
+//   - CASE, whose type is the common type of its branches
+//   - comparisons and other predicates, typed boolean
+//   - scalar, EXISTS, and ARRAY subqueries
+//
+// Columns on the nullable side of a LEFT, RIGHT, or FULL join are nullable.
+// Types read from the schema or a cast are reported as written; types
+// PostgreSQL derives use its canonical names, e.g. "timestamp with time zone".
+//
+// A * is expanded through the schema when every table it covers is known and
+// the statement has no subqueries, CTEs, or set operations; otherwise it is
+// returned as a single untyped column. InferredType and Nullable are also set
+// on query.Columns and query.ReturningItems.
+//
+// Types are read from the expression trees of the select list and RETURNING
+// list, so query must be parsed with ParseOptions.IncludeExpressionTrees;
+// otherwise ErrNoExpressionTrees is returned.
+func InferResultColumns(query *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) ([]ResultColumn, error) {
+	if query == nil {
+		return nil, nil
+	}
+	columns, returning, err := exprTrees(query)
+	if err != nil {
+		return nil, err
+	}
+	r := newResolver(query, schemaMap)
+	ty := &resultTyper{resolver: r, outer: outerJoined(r.words())}
+
+	ty.usage = postgresparser.ColumnUsageTypeProjection
+	for i := range query.Columns {
+		query.Columns[i].InferredType, query.Columns[i].Nullable = ty.typeOf(columns[i])
+	}
+	ty.usage = postgresparser.ColumnUsageTypeReturning
+	for i := range query.ReturningItems {
+		query.ReturningItems[i].InferredType, query.ReturningItems[i].Nullable = ty.typeOf(returning[i])
+	}
+
+	items, exprs := query.Columns, columns
+	if query.Command != postgresparser.QueryCommandSelect {
+		items, exprs = query.ReturningItems, returning
+	}
+	var out []ResultColumn
+	for i, item := range items {
+		expr := strings.TrimSpace(item.Expression)
+		if expr == "*" || strings.HasSuffix(expr, ".*") {
+			out = append(out, ty.expandStar(expr, query.Command != postgresparser.QueryCommandSelect)...)
+			continue
+		}
+		name := ident.TrimQuotes(strings.TrimSpace(item.Alias))
+		if name == "" {
+			name = postgresparser.OutputColumnName(exprs[i])
+		}
+		out = append(out, ResultColumn{Name: name, Expression: expr, Type: item.InferredType, Nullable: item.Nullable})
+	}
+	return out, nil
+}
+
+// exprTrees returns the expression trees of query.Columns and
+// query.ReturningItems, or ErrNoExpressionTrees when they were not built.
+func exprTrees(query *postgresparser.ParsedQuery) (columns, returning []*postgresparser.Expr, err error) {
+	columns = make([]*postgresparser.Expr, len(query.Columns))
+	returning = make([]*postgresparser.Expr, len(query.ReturningItems))
+	for i, col := range query.Columns {
+		if col.Expr == nil {
+			return nil, nil, ErrNoExpressionTrees
+		}
+		columns[i] = col.Expr
+	}
+	for i, col := range query.ReturningItems {
+		if col.Expr == nil {
+			return nil, nil, ErrNoExpressionTrees
+		}
+		returning[i] = col.Expr
+	}
+	return columns, returning, nil
+}
+
+// expandStar returns the columns covered by a * or qualifier.* entry.
+func (t *resultTyper) expandStar(expr string, returning bool) []ResultColumn {
+	unknown := []ResultColumn{{Name: expr, Expression: expr, Nullable: true}}
+	var rels []*relation
+	switch {
+	case expr != "*":
+		rel, ok := t.byKey[strings.ToLower(ident.TrimQuotes(strings.TrimSuffix(expr, ".*")))]
+		if !ok || rel.conflict {
+			return unknown
+		}
+		rels = append(rels, rel)
+	case t.nested:
+		return unknown
+	case returning && t.query.Command == postgresparser.QueryCommandInsert:
+		rels = append(rels, t.target)
+	default:
+		for _, rel := range t.relations {
+			if !t.isInsertTarget(rel) {
+				rels = append(rels, rel)
+			}
+		}
+		if len(rels) == 0 && t.target != nil {
+			// INSERT INTO t SELECT * FROM t.
+			rels = append(rels, t.target)
+		}
+	}
+
+	var out []ResultColumn
+	for _, rel := range rels {
+		if rel == nil || !rel.known {
+			return unknown
+		}
+		key := relationKey(rel)
+		for _, col := range rel.columns {
+			out = append(out, ResultColumn{
+				Name:       col.Name,
+				Expression: key + "." + col.Name,
+				Type:       col.PGType,
+				Nullable:   col.IsNullable || t.isOuter(rel),
+			})
+		}
+	}
+	return out
+}
+
+// typeOf returns the type and nullability of e.
+func (t *resultTyper) typeOf(e *postgresparser.Expr) (string, bool) {
+	if e == nil {
+		return "", true
+	}
+	switch e.Kind {
+	case postgresparser.ExprKindColumn:
+		return t.columnType(e)
+	case postgresparser.ExprKindLiteral:
+		return literalType(e)
+	case postgresparser.ExprKindCast:
+		_, nullable := t.typeOf(firstArg(e))
+		return normalizeType(e.TypeName), nullable
+	case postgresparser.ExprKindCollate:
+		return t.typeOf(firstArg(e))
+	case postgresparser.ExprKindUnary:
+		switch e.Op {
+		case "-", "+", "@":
+			return t.typeOf(firstArg(e))
+		}
+		return "", t.anyNullable(e.Args)
+	case postgresparser.ExprKindBinary:
+		return t.binaryType(e)
+	case postgresparser.ExprKindBool, postgresparser.ExprKindBetween, postgresparser.ExprKindLike:
+		return "boolean", t.anyNullable(e.Args)
+	case postgresparser.ExprKindIn:
+		return "boolean", e.Query != nil || t.anyNullable(e.Args)
+	case postgresparser.ExprKindIs:
+		return "boolean", false
+	case postgresparser.ExprKindFunc:
+		return t.funcType(e)
+	case postgresparser.ExprKindCase:
+		return t.caseType(e)
+	case postgresparser.ExprKindSubquery:
+		return t.subqueryType(e)
+	case postgresparser.ExprKindArray:
+		typ := ""
+		for _, arg := range e.Args {
+			elem, _ := t.typeOf(arg)
+			typ = commonType(typ, elem)
+		}
+		if typ != "" {
+			typ += "[]"
+		}
+		return typ, false
+	case postgresparser.ExprKindRow:
+		return "record", false
+	}
+	return "", true
+}
+
+// columnType looks a column reference up in the schema.
+func (t *resultTyper) columnType(e *postgresparser.Expr) (string, bool) {
+	usage := postgresparser.ColumnUsage{TableAlias: e.Table, Column: e.Column, UsageType: t.usage}
+	rel := t.resolve(usage)
+	if rel == nil && t.parent != nil {
+		return t.parent.columnType(e)
+	}
+	if rel == nil {
+		return "", true
+	}
+	col := rel.column(ident.TrimQuotes(e.Column))
+	if col == nil {
+		return "", true
+	}
+	return col.PGType, col.IsNullable || t.isOuter(rel)
+}
+
+// binaryType types an operator expression.
+func (t *resultTyper) binaryType(e *postgresparser.Expr) (string, bool) {
+	var left, right *postgresparser.Expr
+	if len(e.Args) == 2 {
+		left, right = e.Args[0], e.Args[1]
+	}
+	lt, ln := t.typeOf(left)
+	rt, rn := t.typeOf(right)
+	nullable := ln || rn
+	if e.Quantifier != "" {
+		return "boolean", true
+	}
+	switch strings.ToUpper(e.Op) {
+	case "=", "<>", "!=", "<", ">", "<=", ">=", "~", "~*", "!~", "!~*", "@>", "<@", "&&", "?", "?|", "?&", "OVERLAPS":
+		return "boolean", nullable
+	case "||":
+		switch {
+		case strings.HasSuffix(lt, "[]"):
+			return lt, nullable
+		case strings.HasSuffix(rt, "[]"):
+			return rt, nullable
+		case canonicalType(lt) == "jsonb":
+			return lt, nullable
+		}
+		return "text", nullable
+	case "->", "#>":
+		return lt, true
+	case "->>", "#>>":
+		return "text", true
+	case "AT TIME ZONE":
+		switch canonicalType(lt) {
+		case "timestamp with time zone":
+			return "timestamp without time zone", nullable
+		case "timestamp without time zone":
+			return "timestamp with time zone", nullable
+		}
+		return "", nullable
+	case "+", "-", "*", "/", "%", "^":
+		return arithmeticType(e.Op, lt, rt), nullable
+	}
+	return "", nullable
+}
+
+// funcType types a function call.
+func (t *resultTyper) funcType(e *postgresparser.Expr) (string, bool) {
+	name := strings.ToLower(ident.TrimQuotes(e.FuncName))
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	arg, argNullable := t.typeOf(firstArg(e))
+	argType := canonicalType(arg)
+
+	nullable := t.anyNullable(e.Args)
+	if _, ok := notNullFunctions[name]; ok {
+		nullable = false
+	} else if postgresparser.IsAggregateFunction(name) {
+		nullable = true
+	}
+	if typ, ok := fixedResultTypes[name]; ok {
+		return typ, nullable
+	}
+
+	switch name {
+	case "sum":
+		switch argType {
+		case "smallint", "integer":
+			return "bigint", true
+		case "bigint", "numeric":
+			return "numeric", true
+		case "real", "double precision", "interval", "money":
+			return arg, true
+		}
+	case "avg", "stddev", "stddev_samp", "stddev_pop", "variance", "var_samp", "var_pop":
+		switch argType {
+		case "smallint", "integer", "bigint", "numeric":
+			return "numeric", true
+		case "real", "double precision":
+			return "double precision", true
+		case "interval":
+			if name == "avg" {
+				return "interval", true
+			}
+		}
+	case "min", "max", "lag", "lead", "first_value", "last_value", "nth_value", "mode":
+		return arg, true
+	case "array_agg":
+		if arg != "" {
+			return arg + "[]", true
+		}
+	case "coalesce", "greatest", "least":
+		typ := ""
+		nullable = true
+		for _, a := range e.Args {
+			at, an := t.typeOf(a)
+			typ = commonType(typ, at)
+			nullable = nullable && an
+		}
+		return typ, len(e.Args) == 0 || nullable
+	case "nullif":
+		return arg, true
+	case "abs", "ceil", "ceiling", "floor", "sign", "round", "trunc":
+		if len(e.Args) > 1 {
+			return "numeric", nullable
+		}
+		return arg, argNullable
+	case "sqrt", "cbrt", "exp", "ln", "log", "power", "pow":
+		if argType == "numeric" {
+			return "numeric", nullable
+		}
+		if argType != "" {
+			return "double precision", nullable
+		}
+	case "date_trunc":
+		if len(e.Args) > 1 {
+			typ, _ := t.typeOf(e.Args[1])
+			return typ, nullable
+		}
+	case "unnest":
+		return strings.TrimSuffix(arg, "[]"), true
+	case "generate_series":
+		return arg, false
+	}
+	return "", true
+}
+
+// caseType types a CASE expression as the common type of its branches.
+func (t *resultTyper) caseType(e *postgresparser.Expr) (string, bool) {
+	typ, nullable := "", e.Else == nil
+	branches := make([]*postgresparser.Expr, 0, len(e.Whens)+1)
+	for _, w := range e.Whens {
+		branches = append(branches, w.Result)
+	}
+	if e.Else != nil {
+		branches = append(branches, e.Else)
+	}
+	for _, b := range branches {
+		bt, bn := t.typeOf(b)
+		typ = commonType(typ, bt)
+		nullable = nullable || bn
+	}
+	return typ, nullable
+}
+
+// subqueryType types EXISTS, ARRAY, and scalar subqueries.
+func (t *resultTyper) subqueryType(e *postgresparser.Expr) (string, bool) {
+	switch e.Op {
+	case "EXISTS", "UNIQUE":
+		return "boolean", false
+	}
+	if e.Query == nil || len(e.Query.Columns) == 0 {
+		return "", true
+	}
+	r := newResolver(e.Query, t.schemaMap)
+	sub := &resultTyper{resolver: r, usage: postgresparser.ColumnUsageTypeProjection, outer: outerJoined(r.words()), parent: t}
+	typ, _ := sub.typeOf(e.Query.Columns[0].Expr)
+	if e.Op == "ARRAY" {
+		if typ != "" {
+			typ += "[]"
+		}
+		return typ, false
+	}
+	// A scalar subquery that returns no row yields NULL.
+	return typ, true
+}
+
+// anyNullable reports whether any of args is nullable.
+func (t *resultTyper) anyNullable(args []*postgresparser.Expr) bool {
+	for _, arg := range args {
+		if _, nullable := t.typeOf(arg); nullable {
+			return true
+		}
+	}
+	return false
+}
+
+// isOuter reports whether rel is on the nullable side of an outer join.
+func (t *resultTyper) isOuter(rel *relation) bool {
+	_, ok := t.outer[relationKey(rel)]
+	return ok
+}
+
+// relationKey returns the lowercased alias, or name, a relation is referenced by.
+func relationKey(rel *relation) string {
+	if alias := ident.TrimQuotes(strings.TrimSpace(rel.ref.Alias)); alias != "" {
+		return strings.ToLower(alias)
+	}
+	return strings.ToLower(rel.name)
+}
+
+// outerJoined returns the keys of the relations on the nullable side of a
+// top-level LEFT, RIGHT, or FULL join.
+func outerJoined(words []postgresparser.Token) map[string]struct{} {
+	out := make(map[string]struct{})
+	// group holds the keys joined so far in the current FROM item.
+	var group []string
+	depth, inFrom := 0, false
+	for i := 0; i < len(words); i++ {
+		switch words[i].Text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+		switch word := upperAt(words, i); {
+		case (word == "FROM" && upperAt(words, i-1) != "DISTINCT") || (word == "USING" && upperAt(words, i+1) != "("):
+			inFrom = true
+			group = []string{fromItemKey(words, i+1)}
+		case word == "," && inFrom:
+			group = []string{fromItemKey(words, i+1)}
+		case word == "JOIN" && inFrom:
+			kind := upperAt(words, i-1)
+			if kind == "OUTER" {
+				kind = upperAt(words, i-2)
+			}
+			key := fromItemKey(words, i+1)
+			if kind == "RIGHT" || kind == "FULL" {
+				for _, k := range group {
+					out[k] = struct{}{}
+				}
+			}
+			if kind == "LEFT" || kind == "FULL" {
+				out[key] = struct{}{}
+			}
+			group = append(group, key)
+		case word == "WHERE" || word == "GROUP" || word == "HAVING" || word == "ORDER" || word == "LIMIT" ||
+			word == "WINDOW" || word == "RETURNING" || word == "UNION" || word == "INTERSECT" || word == "EXCEPT":
+			inFrom = false
+		}
+	}
+	delete(out, "")
+	return out
+}
+
+// fromItemKey returns the lowercased alias, or name, of the FROM item
+// starting at word k.
+func fromItemKey(w []postgresparser.Token, k int) string {
+	for upperAt(w, k) == "LATERAL" || upperAt(w, k) == "ONLY" {
+		k++
+	}
+	name := ""
+	if upperAt(w, k) != "(" {
+		if k >= len(w) || w[k].Kind != postgresparser.TokenIdentifier {
+			return ""
+		}
+		name = w[k].Text
+		for k++; upperAt(w, k) == "." && k+1 < len(w); k += 2 {
+			name = w[k+1].Text
+		}
+	}
+	if upperAt(w, k) == "(" {
+		// A derived table or a function call.
+		for depth := 0; k < len(w); k++ {
+			if w[k].Text == "(" {
+				depth++
+			} else if w[k].Text == ")" {
+				depth--
+				if depth == 0 {
+					k++
+					break
+				}
+			}
+		}
+	}
+	if upperAt(w, k) == "*" {
+		k++
+	}
+	if upperAt(w, k) == "AS" {
+		k++
+	}
+	if k < len(w) && w[k].Kind == postgresparser.TokenIdentifier {
+		name = w[k].Text
+	}
+	return strings.ToLower(ident.TrimQuotes(name))
+}
+
+// literalType types a constant.
+func literalType(e *postgresparser.Expr) (string, bool) {
+	switch e.LiteralType {
+	case postgresparser.LiteralInteger:
+		n, err := strconv.ParseInt(e.Value, 10, 64)
+		switch {
+		case err != nil:
+			return "numeric", false
+		case n > math.MaxInt32 || n < math.MinInt32:
+			return "bigint", false
+		}
+		return "integer", false
+	case postgresparser.LiteralNumeric:
+		return "numeric", false
+	case postgresparser.LiteralString:
+		return "text", false
+	case postgresparser.LiteralBitString:
+		return "bit", false
+	case postgresparser.LiteralBoolean:
+		return "boolean", false
+	case postgresparser.LiteralTyped:
+		return normalizeType(e.TypeName), false
+	}
+	return "", true
+}
+
+// arithmeticType returns the result type of left op right, following
+// PostgreSQL's numeric promotion and date/time arithmetic.
+func arithmeticType(op, left, right string) string {
+	l, r := canonicalType(left), canonicalType(right)
+	if l == "" || r == "" {
+		return ""
+	}
+	isTimestamp := func(typ string) bool { return strings.HasPrefix(typ, "timestamp") }
+	_, lNum := numericRanks[l]
+	_, rNum := numericRanks[r]
+	switch {
+	case l == "date" && r == "date" && op == "-":
+		return "integer"
+	case l == "date" && (r == "integer" || r == "smallint") && (op == "+" || op == "-"):
+		return "date"
+	case l == "date" && r == "interval" && (op == "+" || op == "-"):
+		return "timestamp without time zone"
+	case isTimestamp(l) && isTimestamp(r) && op == "-":
+		return "interval"
+	case isTimestamp(l) && r == "interval" && (op == "+" || op == "-"):
+		return l
+	case l == "interval" && isTimestamp(r) && op == "+":
+		return r
+	case l == "interval" && (r == "interval" || rNum), lNum && r == "interval" && op == "*":
+		return "interval"
+	case lNum && rNum:
+		if op == "^" {
+			if l == "numeric" || r == "numeric" {
+				return "numeric"
+			}
+			return "double precision"
+		}
+		if numericRanks[l] >= numericRanks[r] {
+			return l
+		}
+		return r
+	case l == r:
+		return l
+	}
+	return ""
+}
+
+// commonType returns the type two branches of CASE or COALESCE resolve to:
+// the wider of two numeric types, otherwise the first known type.
+func commonType(a, b string) string {
+	if a == "" {
+		return b
+	}
+	ca, cb := canonicalType(a), canonicalType(b)
+	ra, aNum := numericRanks[ca]
+	rb, bNum := numericRanks[cb]
+	if aNum && bNum && rb > ra {
+		return cb
+	}
+	if aNum && bNum && ra > rb {
+		return ca
+	}
+	return a
+}
+
+// canonicalType returns the canonical name of typ without its modifiers,
+// e.g. "numeric" for "numeric(10,2)" and "bigint" for "int8".
+func canonicalType(typ string) string {
+	typ = normalizeType(typ)
+	suffix := ""
+	for strings.HasSuffix(typ, "[]") {
+		typ = strings.TrimSuffix(typ, "[]")
+		suffix += "[]"
+	}
+	if i := strings.Index(typ, "("); i >= 0 {
+		rest := ""
+		if j := strings.Index(typ[i:], ")"); j >= 0 {
+			rest = typ[i+j+1:]
+		}
+		typ = strings.TrimSpace(typ[:i] + rest)
+	}
+	typ = strings.TrimPrefix(typ, "pg_catalog.")
+	if alias, ok := typeAliases[typ]; ok {
+		typ = alias
+	}
+	return typ + suffix
+}
+
+// normalizeType lowercases a type name and collapses its whitespace, e.g.
+// "NUMERIC(10, 2)" becomes "numeric(10,2)".
+func normalizeType(typ string) string {
+	typ = strings.Join(strings.Fields(strings.ToLower(typ)), " ")
+	for _, r := range []struct{ from, to string }{{" (", "("}, {"( ", "("}, {" )", ")"}, {", ", ","}, {" [", "["}} {
+		typ = strings.ReplaceAll(typ, r.from, r.to)
+	}
+	return typ
+}
+
+// firstArg returns the first operand of e, or nil when it has none.
+func firstArg(e *postgresparser.Expr) *postgresparser.Expr {
+	if e == nil || len(e.Args) == 0 {
+		return nil
+	}
+	return e.Args[0]
+}
@@ -0,0 +1,145 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+type resultType struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
+
+func TestInferResultColumns(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []resultType
+	}{
+		{
+			name: "column references and aliases",
+			sql:  "SELECT id, email AS contact, created_at FROM users",
+			want: []resultType{{"id", "bigint", false}, {"contact", "text", true}, {"created_at", "timestamptz", false}},
+		},
+		{
+			name: "LEFT JOIN makes the joined side nullable",
+			sql:  "SELECT u.name, o.total FROM users u LEFT JOIN orders o ON o.user_id = u.id",
+			want: []resultType{{"name", "text", false}, {"total", "numeric(10,2)", true}},
+		},
+		{
+			name: "RIGHT JOIN makes the preceding side nullable",
+			sql:  "SELECT u.name, o.id FROM users u RIGHT OUTER JOIN orders o ON o.user_id = u.id",
+			want: []resultType{{"name", "text", true}, {"id", "bigint", false}},
+		},
+		{
+			name: "aggregates and common functions",
+			sql: "SELECT status, count(*) AS n, sum(total) AS revenue, coalesce(status, 'none') AS label, now() " +
+				"FROM orders GROUP BY status",
+			want: []resultType{
+				{"status", "text", true},
+				{"n", "bigint", false},
+				{"revenue", "numeric", true},
+				{"label", "text", false},
+				{"now", "timestamp with time zone", false},
+			},
+		},
+		{
+			name: "casts, CASE, arithmetic, and predicates",
+			sql: "SELECT id::text, CAST(total AS int) AS t, CASE WHEN total > 100 THEN 'big' ELSE 'small' END AS size, " +
+				"CASE WHEN status IS NULL THEN 1 END AS flag, total * 2 AS doubled, id > 5 FROM orders",
+			want: []resultType{
+				{"id", "text", false},
+				{"t", "int", false},
+				{"size", "text", false},
+				{"flag", "integer", true},
+				{"doubled", "numeric", false},
+				{"?column?", "boolean", false},
+			},
+		},
+		{
+			name: "subqueries",
+			sql: "SELECT EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id) AS has_orders, " +
+				"(SELECT max(total) FROM orders o WHERE o.user_id = u.id) AS top FROM users u",
+			want: []resultType{{"has_orders", "boolean", false}, {"top", "numeric(10,2)", true}},
+		},
+		{
+			name: "star expansion",
+			sql:  "SELECT * FROM users",
+			want: []resultType{
+				{"id", "bigint", false},
+				{"name", "text", false},
+				{"email", "text", true},
+				{"created_at", "timestamptz", false},
+			},
+		},
+		{
+			name: "INSERT RETURNING",
+			sql:  "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id, created_at, lower(email) AS e",
+			want: []resultType{{"id", "bigint", false}, {"created_at", "timestamptz", false}, {"e", "text", true}},
+		},
+		{
+			name: "UPDATE RETURNING *",
+			sql:  "UPDATE orders SET status = 'paid' WHERE id = $1 RETURNING *",
+			want: []resultType{
+				{"id", "bigint", false},
+				{"user_id", "bigint", false},
+				{"total", "numeric(10,2)", false},
+				{"status", "text", true},
+			},
+		},
+		{
+			name: "unknown table",
+			sql:  "SELECT kind, 1 FROM events",
+			want: []resultType{{"kind", "", true}, {"?column?", "integer", false}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pq, err := postgresparser.ParseSQLWithOptions(tt.sql, postgresparser.ParseOptions{IncludeExpressionTrees: true})
+			require.NoError(t, err)
+			cols, err := InferResultColumns(pq, paramSchema)
+			require.NoError(t, err)
+			got := make([]resultType, 0, len(cols))
+			for _, c := range cols {
+				got = append(got, resultType{c.Name, c.Type, c.Nullable})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInferResultColumns_AnnotatesQuery(t *testing.T) {
+	pq, err := postgresparser.ParseSQLWithOptions(
+		"DELETE FROM orders o USING users u WHERE o.user_id = u.id RETURNING o.id, u.email, *",
+		postgresparser.ParseOptions{IncludeExpressionTrees: true},
+	)
+	require.NoError(t, err)
+	cols, err := InferResultColumns(pq, paramSchema)
+	require.NoError(t, err)
+	require.Len(t, cols, 10)
+	assert.Equal(t, "o.user_id", cols[3].Expression)
+
+	require.Len(t, pq.ReturningItems, 3)
+	assert.Equal(t, "bigint", pq.ReturningItems[0].InferredType)
+	assert.Equal(t, "text", pq.ReturningItems[1].InferredType)
+	assert.True(t, pq.ReturningItems[1].Nullable)
+	assert.Empty(t, pq.ReturningItems[2].InferredType)
+
+	cols, err = InferResultColumns(nil, paramSchema)
+	assert.NoError(t, err)
+	assert.Nil(t, cols)
+}
+
+func TestInferResultColumns_RequiresExpressionTrees(t *testing.T) {
+	pq, err := postgresparser.ParseSQL("SELECT id FROM orders")
+	require.NoError(t, err)
+	cols, err := InferResultColumns(pq, paramSchema)
+	assert.ErrorIs(t, err, ErrNoExpressionTrees)
+	assert.Nil(t, cols)
+	assert.Empty(t, pq.Columns[0].InferredType, "the query is left unannotated")
+}
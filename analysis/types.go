@@ -8,13 +8,15 @@ package analysis
 type SQLCommand string
 
 const (
-	SQLCommandSelect  SQLCommand = "SELECT"
-	SQLCommandInsert  SQLCommand = "INSERT"
-	SQLCommandUpdate  SQLCommand = "UPDATE"
-	SQLCommandDelete  SQLCommand = "DELETE"
-	SQLCommandMerge   SQLCommand = "MERGE"
-	SQLCommandDDL     SQLCommand = "DDL"
-	SQLCommandUnknown SQLCommand = "UNKNOWN"
+	SQLCommandSelect      SQLCommand = "SELECT"
+	SQLCommandInsert      SQLCommand = "INSERT"
+	SQLCommandUpdate      SQLCommand = "UPDATE"
+	SQLCommandDelete      SQLCommand = "DELETE"
+	SQLCommandMerge       SQLCommand = "MERGE"
+	SQLCommandDDL         SQLCommand = "DDL"
+	SQLCommandDCL         SQLCommand = "DCL"
+	SQLCommandTransaction SQLCommand = "TRANSACTION"
+	SQLCommandUnknown     SQLCommand = "UNKNOWN"
 )
 
 // SQLTableType captures the origin of a table reference.
@@ -28,6 +30,17 @@ const (
 	SQLTableTypeUnknown  SQLTableType = "unknown"
 )
 
+// SQLSourceSpan locates an element in the analyzed SQL text. Start and End are
+// byte offsets (End exclusive); lines and rune columns are 1-based.
+type SQLSourceSpan struct {
+	Start       int
+	End         int
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+}
+
 // SQLTable describes a relation referenced in the query.
 type SQLTable struct {
 	Schema string
@@ -35,12 +48,14 @@ type SQLTable struct {
 	Alias  string
 	Type   SQLTableType
 	Raw    string
+	Span   *SQLSourceSpan // Set only when source positions are requested
 }
 
 // SQLColumn records a projected expression and optional alias.
 type SQLColumn struct {
 	Expression string
 	Alias      string
+	Span       *SQLSourceSpan // Set only when source positions are requested
 }
 
 // SQLOrderExpression models an ORDER BY item.
@@ -62,6 +77,7 @@ type SQLParameter struct {
 	Raw      string
 	Marker   string
 	Position int
+	Span     *SQLSourceSpan // Set only when source positions are requested
 }
 
 // SQLSetOperationType enumerates supported set-operation modifiers.
@@ -130,6 +146,44 @@ type SQLMerge struct {
 	Actions   []SQLMergeAction
 }
 
+// SQLPrivilege is one privilege from a GRANT/REVOKE list.
+type SQLPrivilege struct {
+	Name    string
+	Columns []string
+}
+
+// SQLPrivilegeObject names one object that privileges apply to.
+type SQLPrivilegeObject struct {
+	Schema string
+	Name   string
+	Args   string
+}
+
+// SQLPrivileges stores GRANT, REVOKE, and ALTER DEFAULT PRIVILEGES metadata.
+type SQLPrivileges struct {
+	Action            string // GRANT or REVOKE
+	Privileges        []SQLPrivilege
+	ObjectType        string
+	Objects           []SQLPrivilegeObject
+	InSchemas         []string
+	Grantees          []string
+	GrantedBy         string
+	Flags             []string
+	DefaultPrivileges bool
+	ForRoles          []string
+}
+
+// SQLTransaction stores transaction control metadata.
+type SQLTransaction struct {
+	Action         string // BEGIN, COMMIT, ROLLBACK, SAVEPOINT, ...
+	IsolationLevel string
+	AccessMode     string
+	Deferrable     string
+	Chain          bool
+	Savepoint      string
+	GID            string
+}
+
 // SQLUsageType classifies how a column participates in the query.
 type SQLUsageType string
 
@@ -170,6 +224,7 @@ type SQLColumnUsage struct {
 	Functions  []string
 	Operator   string
 	Side       string
+	Span       *SQLSourceSpan // Set only when source positions are requested
 }
 
 // SQLDDLColumn describes column-level metadata extracted from CREATE TABLE statements.
@@ -181,6 +236,104 @@ type SQLDDLColumn struct {
 	Comment  []string
 }
 
+// SQLDDLConstraint describes a table or column constraint from CREATE TABLE or ALTER TABLE.
+type SQLDDLConstraint struct {
+	Type              string
+	Name              string
+	Columns           []string
+	ReferencedSchema  string
+	ReferencedTable   string
+	ReferencedColumns []string
+	OnDelete          string
+	OnUpdate          string
+	Match             string
+	Expression        string
+	Operators         []string
+	IndexType         string
+	UsingIndex        string
+	Flags             []string
+}
+
+// SQLDDLAlterOp describes a detailed ALTER TABLE sub-command.
+type SQLDDLAlterOp struct {
+	Type           string
+	Column         string
+	DataType       string
+	Collation      string
+	Using          string
+	Default        string
+	OldName        string
+	NewName        string
+	Constraint     string
+	NewSchema      string
+	Owner          string
+	Trigger        string
+	Partition      string
+	PartitionBound string
+}
+
+// SQLDDLFunctionArg is one routine argument, or one CALL argument expression.
+type SQLDDLFunctionArg struct {
+	Name    string
+	Mode    string
+	Type    string
+	Default string
+	Value   string
+}
+
+// SQLDDLFunction describes a routine from CREATE FUNCTION/PROCEDURE, DROP
+// FUNCTION, or CALL.
+type SQLDDLFunction struct {
+	Args       []SQLDDLFunctionArg
+	Returns    string
+	ReturnsSet bool
+	Language   string
+	Volatility string
+	Parallel   string
+	Body       string
+	Statements []*SQLAnalysis // Parsed LANGUAGE sql or plpgsql body statements
+	DynamicSQL []string       // EXECUTE command expressions of a plpgsql body
+}
+
+// SQLDDLSequence describes the options of CREATE/ALTER SEQUENCE.
+type SQLDDLSequence struct {
+	DataType  string
+	Start     string
+	Restart   string
+	Increment string
+	MinValue  string
+	MaxValue  string
+	Cache     string
+	OwnedBy   string
+}
+
+// SQLDDLType describes a type from CREATE TYPE, CREATE DOMAIN, or ALTER TYPE
+// ... ADD VALUE.
+type SQLDDLType struct {
+	Kind       string
+	Labels     []string
+	Before     string
+	After      string
+	Attributes []SQLDDLColumn
+	Definition string
+	BaseType   string
+	Default    string
+	NotNull    bool
+	Collation  string
+}
+
+// SQLDDLTrigger describes a trigger from CREATE/DROP TRIGGER.
+type SQLDDLTrigger struct {
+	Table        string
+	TableSchema  string
+	Timing       string
+	Events       []string
+	ForEach      string
+	When         string
+	Function     string
+	FunctionArgs []string
+}
+
 // SQLDDLAction describes a single DDL operation in the analysis result.
 type SQLDDLAction struct {
 	Type          string
@@ -193,6 +346,16 @@ type SQLDDLAction struct {
 	IndexType     string
 	Target        string
 	Comment       string
+	Query         *SQLAnalysis // Defining SELECT for CREATE VIEW / CREATE MATERIALIZED VIEW
+	Constraints   []SQLDDLConstraint
+	AlterOp       *SQLDDLAlterOp // Detailed ALTER TABLE sub-command, when recognized
+	Function      *SQLDDLFunction
+	Sequence      *SQLDDLSequence
+	TypeDef       *SQLDDLType
+	Trigger       *SQLDDLTrigger
+	Version       string
+	Owner         string
+	Span          *SQLSourceSpan // Set only when source positions are requested
 }
 
 // SQLParseWarningCode identifies non-fatal parser notices in analysis batch results.
@@ -231,6 +394,8 @@ type SQLAnalysis struct {
 	Returning      []string
 	Upsert         *SQLUpsert
 	Merge          *SQLMerge
+	Privileges     *SQLPrivileges
+	Transaction    *SQLTransaction
 	DDLActions     []SQLDDLAction
 	ColumnUsage    []SQLColumnUsage
 	Correlations   []SQLJoinCorrelation
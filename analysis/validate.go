@@ -0,0 +1,506 @@
+// Package analysis provides query analysis for the PostgreSQL parser.
+// This file implements semantic validation of DML statements against a schema.
+package analysis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/ident"
+)
+
+// Validate checks a SELECT, INSERT, UPDATE, DELETE, or MERGE statement
+// against schemaMap, keyed like ResolveColumns expects, and returns the
+// problems PostgreSQL would reject the statement for:
+//   - tables missing from schemaMap (UNKNOWN_TABLE)
+//   - unknown or ambiguous column references, INSERT columns, and SET
+//     targets (UNKNOWN_COLUMN, AMBIGUOUS_COLUMN)
+//   - INSERT VALUES rows or SELECT lists whose width does not match the
+//     target columns (INSERT_ARITY)
+//   - projected columns that are neither grouped nor aggregated in a grouped
+//     query (UNGROUPED_COLUMN)
+//   - ORDER BY positions outside the select list (ORDER_BY_POSITION)
+//
+// Validate is conservative: checks that depend on scoping are skipped for
+// statements with subqueries, CTEs, or set operations, and a column grouped
+// through a table's primary key counts as grouped. Other statements return nil.
+func Validate(query *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) []Diagnostic {
+	if query == nil {
+		return nil
+	}
+	switch query.Command {
+	case postgresparser.QueryCommandSelect, postgresparser.QueryCommandInsert, postgresparser.QueryCommandUpdate,
+		postgresparser.QueryCommandDelete, postgresparser.QueryCommandMerge:
+	default:
+		return nil
+	}
+
+	r := newResolver(query, schemaMap)
+	r.checkTables()
+	r.checkInsert()
+	r.checkSetTargets()
+	owners := make([]*relation, len(query.ColumnUsage))
+	for i, usage := range query.ColumnUsage {
+		owners[i] = r.resolve(usage)
+	}
+	r.checkGroupBy(owners)
+	r.checkOrderBy()
+	return r.diags
+}
+
+// checkTables reports base tables missing from the schema. System catalogs
+// are never reported.
+func (r *resolver) checkTables() {
+	for _, tbl := range r.query.Tables {
+		if tbl.Type != postgresparser.TableTypeBase {
+			continue
+		}
+		rel := newRelation(tbl, r.schemaMap)
+		if rel.known || rel.name == "" || isSystemTable(tbl.Schema, rel.name) {
+			continue
+		}
+		r.report(tbl.Span, DiagnosticUnknownTable, rel.display(), "", nil,
+			fmt.Sprintf("relation %q does not exist", rel.display()))
+	}
+}
+
+// checkInsert validates INSERT column names and the width of VALUES rows or
+// the SELECT list against the target columns.
+func (r *resolver) checkInsert() {
+	if r.query.Command != postgresparser.QueryCommandInsert || r.target == nil || !r.target.known {
+		return
+	}
+	target := r.target
+	for _, col := range r.query.InsertColumns {
+		name := baseColumnName(col)
+		if name != "" && !target.hasColumn(name) {
+			r.report(target.ref.Span, DiagnosticUnknownColumn, target.display(), name, nil,
+				fmt.Sprintf("column %q of relation %q does not exist", name, target.name))
+		}
+	}
+
+	columns, listed := len(r.query.InsertColumns), true
+	if columns == 0 {
+		columns, listed = len(target.columns), false
+	}
+	var widths []int
+	for _, row := range r.valuesRows() {
+		widths = append(widths, len(row))
+	}
+	if widths == nil && len(r.query.Columns) > 0 && !r.nested {
+		if width, ok := r.selectWidth(); ok {
+			widths = []int{width}
+		}
+	}
+	for _, width := range widths {
+		switch {
+		case width > columns:
+			r.report(nil, DiagnosticInsertArity, target.display(), "", nil,
+				"INSERT has more expressions than target columns")
+		case width < columns && listed:
+			r.report(nil, DiagnosticInsertArity, target.display(), "", nil,
+				"INSERT has more target columns than expressions")
+		}
+	}
+}
+
+// checkSetTargets reports SET targets of UPDATE, ON CONFLICT DO UPDATE, and
+// MERGE that the target table does not have.
+func (r *resolver) checkSetTargets() {
+	if r.target == nil || !r.target.known {
+		return
+	}
+	for _, clause := range r.query.SetClauses {
+		lhs, _, _ := strings.Cut(clause, "=")
+		lhs = strings.TrimSpace(lhs)
+		lhs = strings.TrimSuffix(strings.TrimPrefix(lhs, "("), ")")
+		for _, col := range strings.Split(lhs, ",") {
+			name := baseColumnName(col)
+			if name != "" && !r.target.hasColumn(name) {
+				r.report(nil, DiagnosticUnknownColumn, r.target.display(), name, nil,
+					fmt.Sprintf("column %q of relation %q does not exist", name, r.target.name))
+			}
+		}
+	}
+}
+
+// groupedColumn identifies a column of a resolved relation.
+type groupedColumn struct {
+	rel    *relation
+	column string
+}
+
+// checkGroupBy reports projected columns of a grouped query that are neither
+// aggregated nor covered by GROUP BY. owners is parallel to ColumnUsage.
+func (r *resolver) checkGroupBy(owners []*relation) {
+	q := r.query
+	if r.nested {
+		return
+	}
+	if len(q.GroupBy) == 0 && len(q.Having) == 0 && !hasAggregateCall(r.words()) {
+		return
+	}
+
+	exprs := make(map[string]struct{})
+	for _, item := range q.GroupBy {
+		exprs[compactExpr(item)] = struct{}{}
+		if n, err := strconv.Atoi(strings.TrimSpace(item)); err == nil && n >= 1 && n <= len(q.Columns) {
+			exprs[compactExpr(q.Columns[n-1].Expression)] = struct{}{}
+		}
+		for _, col := range q.Columns {
+			if col.Alias != "" && strings.EqualFold(ident.TrimQuotes(col.Alias), ident.TrimQuotes(strings.TrimSpace(item))) {
+				exprs[compactExpr(col.Expression)] = struct{}{}
+			}
+		}
+	}
+	columns := make(map[groupedColumn]struct{})
+	for i, usage := range q.ColumnUsage {
+		if usage.UsageType == postgresparser.ColumnUsageTypeGroupBy && owners[i] != nil {
+			columns[groupedColumn{owners[i], strings.ToLower(ident.TrimQuotes(usage.Column))}] = struct{}{}
+		}
+	}
+
+	for i, usage := range q.ColumnUsage {
+		rel := owners[i]
+		if usage.UsageType != postgresparser.ColumnUsageTypeProjection || rel == nil || usage.Column == "*" {
+			continue
+		}
+		if isAggregated(usage.Functions) {
+			continue
+		}
+		if _, ok := exprs[compactExpr(usage.Context)]; ok {
+			continue
+		}
+		if _, ok := exprs[compactExpr(usage.Expression)]; ok {
+			continue
+		}
+		column := strings.ToLower(ident.TrimQuotes(usage.Column))
+		if _, ok := columns[groupedColumn{rel, column}]; ok || groupedByPrimaryKey(rel, columns) {
+			continue
+		}
+		r.report(usage.Span, DiagnosticUngroupedColumn, rel.display(), column, nil,
+			fmt.Sprintf("column %q must appear in the GROUP BY clause or be used in an aggregate function", usage.Expression))
+	}
+}
+
+// checkOrderBy reports ORDER BY positions outside the select list.
+func (r *resolver) checkOrderBy() {
+	if r.query.Command != postgresparser.QueryCommandSelect || r.nested || len(r.query.OrderBy) == 0 {
+		return
+	}
+	width, ok := r.selectWidth()
+	if !ok {
+		return
+	}
+	for _, item := range r.query.OrderBy {
+		n, err := strconv.Atoi(strings.TrimSpace(item.Expression))
+		if err != nil || (n >= 1 && n <= width) {
+			continue
+		}
+		r.report(nil, DiagnosticOrderByPosition, "", "", nil,
+			fmt.Sprintf("ORDER BY position %d is not in select list", n))
+	}
+}
+
+// selectWidth returns the number of output columns, expanding * through the
+// schema. It reports false when a * covers a table missing from the schema.
+func (r *resolver) selectWidth() (int, bool) {
+	width := 0
+	for _, col := range r.query.Columns {
+		expr := strings.TrimSpace(col.Expression)
+		if expr != "*" && !strings.HasSuffix(expr, ".*") {
+			width++
+			continue
+		}
+		var rels []*relation
+		if expr == "*" {
+			for _, rel := range r.relations {
+				if !r.isInsertTarget(rel) {
+					rels = append(rels, rel)
+				}
+			}
+			if len(rels) == 0 && r.target != nil {
+				// INSERT INTO t SELECT * FROM t.
+				rels = append(rels, r.target)
+			}
+		} else {
+			rel, ok := r.byKey[strings.ToLower(ident.TrimQuotes(strings.TrimSuffix(expr, ".*")))]
+			if !ok {
+				return 0, false
+			}
+			rels = append(rels, rel)
+		}
+		for _, rel := range rels {
+			if !rel.known {
+				return 0, false
+			}
+			width += len(rel.columns)
+		}
+	}
+	return width, true
+}
+
+// valuesRows returns the entries of the statement's top-level VALUES list as
+// [start, end) ranges of r.words(), one slice per row, or nil when it has none.
+func (r *resolver) valuesRows() [][][2]int {
+	words := r.words()
+	start := -1
+	depth := 0
+	for i, tok := range words {
+		switch tok.Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth == 0 && tok.Kind == postgresparser.TokenKeyword && strings.EqualFold(tok.Text, "VALUES") &&
+			(i == 0 || !strings.EqualFold(words[i-1].Text, "DEFAULT")) {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	var rows [][][2]int
+	for i := start; i < len(words) && words[i].Text == "("; {
+		var row [][2]int
+		entry, depth := i+1, 0
+		for ; i < len(words); i++ {
+			switch words[i].Text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			case ",":
+				if depth == 1 {
+					row = append(row, [2]int{entry, i})
+					entry = i + 1
+				}
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		rows = append(rows, append(row, [2]int{entry, i}))
+		if i+1 >= len(words) || words[i+1].Text != "," {
+			break
+		}
+		i += 2
+	}
+	return rows
+}
+
+// groupedByPrimaryKey reports whether every primary key column of rel is
+// grouped, which makes all of rel's columns functionally dependent on GROUP BY.
+func groupedByPrimaryKey(rel *relation, columns map[groupedColumn]struct{}) bool {
+	found := false
+	for _, col := range rel.columns {
+		if !col.IsPrimaryKey {
+			continue
+		}
+		if _, ok := columns[groupedColumn{rel, strings.ToLower(col.Name)}]; !ok {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// isAggregated reports whether a column usage sits inside an aggregate call.
+func isAggregated(functions []string) bool {
+	for _, fn := range functions {
+		if postgresparser.IsAggregateFunction(fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAggregateCall reports whether words call an aggregate outside a window:
+// an aggregate name followed by "(" whose closing parenthesis, after any
+// FILTER or WITHIN GROUP clause, is not followed by OVER.
+func hasAggregateCall(words []postgresparser.Token) bool {
+	for i := 0; i+1 < len(words); i++ {
+		name := words[i]
+		if words[i+1].Text != "(" || (name.Kind != postgresparser.TokenIdentifier && name.Kind != postgresparser.TokenKeyword) ||
+			!postgresparser.IsAggregateFunction(name.Text) {
+			continue
+		}
+		if upperAt(words, i-1) == "INTO" || (upperAt(words, i-1) == "." && upperAt(words, i-3) == "INTO") {
+			continue // INSERT INTO sum (a, b) names a table.
+		}
+		end := closingParen(words, i+1)
+		for {
+			if upperAt(words, end+1) == "FILTER" && upperAt(words, end+2) == "(" {
+				end = closingParen(words, end+2)
+			} else if upperAt(words, end+1) == "WITHIN" && upperAt(words, end+2) == "GROUP" && upperAt(words, end+3) == "(" {
+				end = closingParen(words, end+3)
+			} else {
+				break
+			}
+		}
+		if upperAt(words, end+1) != "OVER" {
+			return true
+		}
+	}
+	return false
+}
+
+// closingParen returns the index of the ")" matching the "(" at open, or the
+// last index when it is unbalanced.
+func closingParen(words []postgresparser.Token, open int) int {
+	depth := 0
+	for i := open; i < len(words); i++ {
+		switch words[i].Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(words) - 1
+}
+
+// isSystemTable reports whether a table lives in the system catalogs.
+func isSystemTable(schema, name string) bool {
+	switch strings.ToLower(ident.TrimQuotes(schema)) {
+	case "pg_catalog", "information_schema":
+		return true
+	case "":
+		return strings.HasPrefix(strings.ToLower(name), "pg_")
+	}
+	return false
+}
+
+// baseColumnName strips subfield and subscript suffixes from a column target
+// such as "payload.kind" or "tags[1]".
+func baseColumnName(target string) string {
+	target = strings.TrimSpace(target)
+	if i := strings.IndexAny(target, ".["); i >= 0 {
+		target = target[:i]
+	}
+	return ident.TrimQuotes(strings.TrimSpace(target))
+}
+
+// compactExpr returns a form of expr in which differently formatted copies of
+// an expression compare equal: comments and whitespace are dropped, unquoted
+// words are lowercased, and quotes are dropped from identifiers that would
+// fold to the same name. String literals and other quoted identifiers are
+// kept as written.
+func compactExpr(expr string) string {
+	var b strings.Builder
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f':
+			i++
+		case strings.HasPrefix(expr[i:], "--"):
+			end := strings.IndexByte(expr[i:], '\n')
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 1
+		case strings.HasPrefix(expr[i:], "/*"):
+			i = blockCommentEnd(expr, i)
+		case ch == '\'':
+			end := quotedEnd(expr, i, '\'')
+			b.WriteString(expr[i:end])
+			i = end
+		case ch == '"':
+			end := quotedEnd(expr, i, '"')
+			name := strings.ReplaceAll(strings.TrimSuffix(expr[i+1:end], `"`), `""`, `"`)
+			if name != "" && name == strings.ToLower(name) && isPlainIdent(name) {
+				b.WriteString(name)
+			} else {
+				b.WriteString(expr[i:end])
+			}
+			i = end
+		case ch == '$':
+			end := dollarQuoteEnd(expr, i)
+			b.WriteString(expr[i:end])
+			i = end
+		default:
+			r, size := utf8.DecodeRuneInString(expr[i:])
+			b.WriteRune(unicode.ToLower(r))
+			i += size
+		}
+	}
+	return b.String()
+}
+
+// quotedEnd returns the offset just past the string or quoted identifier
+// starting at expr[start], treating a doubled quote as an escaped one.
+func quotedEnd(expr string, start int, quote byte) int {
+	for i := start + 1; i < len(expr); i++ {
+		if expr[i] != quote {
+			continue
+		}
+		if i+1 < len(expr) && expr[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(expr)
+}
+
+// blockCommentEnd returns the offset just past the /* comment */ starting at
+// expr[start], which may nest.
+func blockCommentEnd(expr string, start int) int {
+	depth := 0
+	for i := start; i+1 < len(expr); i++ {
+		switch expr[i : i+2] {
+		case "/*":
+			depth++
+			i++
+		case "*/":
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(expr)
+}
+
+// dollarQuoteEnd returns the offset just past the $tag$ string starting at
+// expr[start], or start+1 when the $ does not open one (e.g. a $1 parameter).
+func dollarQuoteEnd(expr string, start int) int {
+	end := start + 1
+	for end < len(expr) && expr[end] != '$' && (isIdentByte(expr[end]) || (expr[end] >= 'A' && expr[end] <= 'Z')) {
+		end++
+	}
+	if end >= len(expr) || expr[end] != '$' || (end > start+1 && expr[start+1] >= '0' && expr[start+1] <= '9') {
+		return start + 1
+	}
+	tag := expr[start : end+1]
+	if close := strings.Index(expr[end+1:], tag); close >= 0 {
+		return end + 1 + close + len(tag)
+	}
+	return len(expr)
+}
+
+// isPlainIdent reports whether name can be written without quotes.
+func isPlainIdent(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if !isIdentByte(name[i]) || (i == 0 && (name[i] == '$' || (name[i] >= '0' && name[i] <= '9'))) {
+			return false
+		}
+	}
+	return true
+}
+
+// isIdentByte reports whether c can be part of an unquoted identifier.
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+var validateSchema = map[string][]ColumnSchema{
+	"users":  {{Name: "id", IsPrimaryKey: true}, {Name: "name"}, {Name: "email"}},
+	"orders": {{Name: "id", IsPrimaryKey: true}, {Name: "user_id"}, {Name: "total"}, {Name: "status"}},
+}
+
+// validateSQL parses sql and returns its diagnostics as strings.
+func validateSQL(t *testing.T, sql string) []string {
+	t.Helper()
+	pq, err := postgresparser.ParseSQL(sql)
+	require.NoError(t, err, sql)
+	var out []string
+	for _, d := range Validate(pq, validateSchema) {
+		out = append(out, d.String())
+	}
+	return out
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "unknown table",
+			sql:  "SELECT * FROM events ORDER BY 9",
+			want: []string{`UNKNOWN_TABLE: relation "events" does not exist`},
+		},
+		{
+			name: "unknown and ambiguous columns",
+			sql:  "SELECT nickname, id FROM users u JOIN orders o ON o.user_id = u.id",
+			want: []string{
+				`UNKNOWN_COLUMN: column "nickname" does not exist`,
+				`AMBIGUOUS_COLUMN: column reference "id" is ambiguous`,
+			},
+		},
+		{
+			name: "INSERT column list and VALUES arity",
+			sql:  "INSERT INTO users (id, nick) VALUES (1, 'a'), (2)",
+			want: []string{
+				`UNKNOWN_COLUMN: column "nick" of relation "users" does not exist`,
+				"INSERT_ARITY: INSERT has more target columns than expressions",
+			},
+		},
+		{
+			name: "INSERT without a column list",
+			sql:  "INSERT INTO users VALUES (1, 'a', 'b', 'c')",
+			want: []string{"INSERT_ARITY: INSERT has more expressions than target columns"},
+		},
+		{
+			name: "INSERT SELECT arity",
+			sql:  "INSERT INTO users (id) SELECT id, total FROM orders",
+			want: []string{"INSERT_ARITY: INSERT has more expressions than target columns"},
+		},
+		{
+			name: "UPDATE SET on an unknown column",
+			sql:  "UPDATE users SET nick = 'x', name = 'y' WHERE id = $1",
+			want: []string{`UNKNOWN_COLUMN: column "nick" of relation "users" does not exist`},
+		},
+		{
+			name: "ungrouped projection",
+			sql:  "SELECT status, total, count(*) FROM orders GROUP BY status",
+			want: []string{`UNGROUPED_COLUMN: column "total" must appear in the GROUP BY clause or be used in an aggregate function`},
+		},
+		{
+			name: "ungrouped column beside a statistical aggregate",
+			sql:  "SELECT total, regr_count(total, id) FROM orders",
+			want: []string{`UNGROUPED_COLUMN: column "total" must appear in the GROUP BY clause or be used in an aggregate function`},
+		},
+		{
+			name: "aggregate without GROUP BY",
+			sql:  "SELECT name, count(*) FROM users",
+			want: []string{`UNGROUPED_COLUMN: column "name" must appear in the GROUP BY clause or be used in an aggregate function`},
+		},
+		{
+			name: "aggregate aliased with a quoted over",
+			sql:  `SELECT status, count(*) "over" FROM orders`,
+			want: []string{`UNGROUPED_COLUMN: column "status" must appear in the GROUP BY clause or be used in an aggregate function`},
+		},
+		{
+			name: "aggregate beside a window call",
+			sql:  "SELECT status, max(total) + row_number() OVER () FROM orders",
+			want: []string{`UNGROUPED_COLUMN: column "status" must appear in the GROUP BY clause or be used in an aggregate function`},
+		},
+		{
+			name: "ORDER BY position",
+			sql:  "SELECT id, name FROM users ORDER BY 3",
+			want: []string{"ORDER_BY_POSITION: ORDER BY position 3 is not in select list"},
+		},
+		{
+			name: "ORDER BY position past a star",
+			sql:  "SELECT * FROM users ORDER BY 4",
+			want: []string{"ORDER_BY_POSITION: ORDER BY position 4 is not in select list"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, validateSQL(t, tt.sql))
+		})
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	for _, sql := range []string{
+		"SELECT u.id, u.name, count(o.id) FROM users u JOIN orders o ON o.user_id = u.id GROUP BY u.id ORDER BY 3 DESC",
+		"SELECT lower(email) AS e, count(*) FROM users GROUP BY 1",
+		"SELECT lower(email) AS e, count(*) FROM users GROUP BY e",
+		"SELECT * FROM users ORDER BY 3",
+		"INSERT INTO users (id, name) SELECT id, name FROM users WHERE id = $1",
+		"INSERT INTO users VALUES (1, 'a') ON CONFLICT (id) DO UPDATE SET name = excluded.name",
+		"UPDATE orders SET status = 'void' FROM users u WHERE user_id = u.id",
+		"DELETE FROM orders WHERE status = 'void' RETURNING id",
+		"SELECT relname FROM pg_class",
+		"SELECT id FROM users WHERE id IN (SELECT user_id FROM orders GROUP BY user_id)",
+		"SELECT status, any_value(user_id), corr(total, id), regr_slope(total, id) FROM orders GROUP BY status",
+		"SELECT status FROM orders GROUP BY status HAVING pg_catalog.covar_pop(total, id) > 0",
+		"SELECT id, count(*) /* running */ OVER (PARTITION BY user_id) FROM orders",
+		"SELECT id, sum(total) FILTER (WHERE status = 'paid') OVER w FROM orders WINDOW w AS (ORDER BY id)",
+		`SELECT lower("email") /* key */, count(*) FROM users GROUP BY LOWER( email )`,
+	} {
+		assert.Empty(t, validateSQL(t, sql), sql)
+	}
+
+	for _, sql := range []string{"CREATE TABLE events (id int)", "BEGIN"} {
+		assert.Nil(t, validateSQL(t, sql), sql)
+	}
+	assert.Nil(t, Validate(nil, validateSchema))
+}
@@ -174,6 +174,61 @@ func BenchmarkPostgresParser(b *testing.B) {
 	}
 }
 
+// BenchmarkParserReuse benchmarks a reused postgresparser.Parser, which pools
+// lexer, token stream and parser instances across calls. Compare allocations
+// against BenchmarkPostgresParser.
+func BenchmarkParserReuse(b *testing.B) {
+	benchmarkParserReuse(b, postgresparser.ParseOptions{})
+}
+
+// BenchmarkParserReuse_SLLPrediction benchmarks a reused Parser with
+// SLL-first prediction enabled.
+func BenchmarkParserReuse_SLLPrediction(b *testing.B) {
+	benchmarkParserReuse(b, postgresparser.ParseOptions{UseSLLPrediction: true})
+}
+
+// BenchmarkParserReuse_Parallel benchmarks one shared Parser driven from
+// GOMAXPROCS goroutines.
+func BenchmarkParserReuse_Parallel(b *testing.B) {
+	for _, q := range queries {
+		b.Run(q.Name, func(b *testing.B) {
+			p := postgresparser.NewParser(postgresparser.ParseOptions{})
+			if _, err := p.Parse(q.SQL); err != nil {
+				b.Fatalf("Parser.Parse failed: %v", err)
+			}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					p.Parse(q.SQL)
+				}
+			})
+		})
+	}
+}
+
+func benchmarkParserReuse(b *testing.B, opts postgresparser.ParseOptions) {
+	for _, q := range queries {
+		b.Run(q.Name, func(b *testing.B) {
+			p := postgresparser.NewParser(opts)
+			result, err := p.Parse(q.SQL)
+			if err != nil {
+				b.Fatalf("Parser.Parse failed: %v", err)
+			}
+			if result == nil {
+				b.Fatal("Parser.Parse returned nil")
+			}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p.Parse(q.SQL)
+			}
+		})
+	}
+}
+
 // BenchmarkPgQueryGo benchmarks the cgo-based pg_query parser.
 func BenchmarkPgQueryGo(b *testing.B) {
 	for _, q := range queries {
@@ -297,6 +352,14 @@ func BenchmarkPostgresParserWithOptions_FieldCommentsEnabled(b *testing.B) {
 	})
 }
 
+// BenchmarkPostgresParserWithOptions_SLLPrediction benchmarks
+// ParseSQLWithOptions with built-in SLL-first prediction enabled.
+func BenchmarkPostgresParserWithOptions_SLLPrediction(b *testing.B) {
+	benchmarkPostgresParserWithOptions(b, postgresparser.ParseOptions{
+		UseSLLPrediction: true,
+	})
+}
+
 func benchmarkPostgresParserWithOptions(b *testing.B, opts postgresparser.ParseOptions) {
 	for _, q := range optionBenchQueries {
 		b.Run(q.Name, func(b *testing.B) {
@@ -0,0 +1,637 @@
+// apply.go replays parsed DDL actions into a Catalog and reports migrations
+// that are inconsistent with the current catalog state.
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// Sentinel errors wrapped by ApplyError.
+var (
+	// ErrTableExists is returned when CREATE TABLE targets an existing table
+	// without IF NOT EXISTS.
+	ErrTableExists = errors.New("table already exists")
+
+	// ErrTableNotFound is returned when an action targets a missing table.
+	ErrTableNotFound = errors.New("table does not exist")
+
+	// ErrColumnExists is returned when ADD COLUMN targets an existing column
+	// without IF NOT EXISTS.
+	ErrColumnExists = errors.New("column already exists")
+
+	// ErrColumnNotFound is returned when an action targets a missing column.
+	ErrColumnNotFound = errors.New("column does not exist")
+
+	// ErrIndexExists is returned when CREATE INDEX targets an existing index
+	// without IF NOT EXISTS.
+	ErrIndexExists = errors.New("index already exists")
+
+	// ErrIndexNotFound is returned when an action targets a missing index.
+	ErrIndexNotFound = errors.New("index does not exist")
+
+	// ErrConstraintNotFound is returned when DROP CONSTRAINT targets a missing
+	// constraint without IF EXISTS.
+	ErrConstraintNotFound = errors.New("constraint does not exist")
+
+	// ErrStatementNotParsed is returned by ApplyBatch for statements that
+	// failed to parse, since skipping them would leave the catalog incomplete.
+	ErrStatementNotParsed = errors.New("statement could not be parsed")
+)
+
+// ApplyError reports a DDL action that could not be applied to the catalog.
+type ApplyError struct {
+	// Statement is the 1-based statement index within the applied batch,
+	// or 0 when the error comes from Apply on a single query.
+	Statement int
+	Action    postgresparser.DDLActionType
+	// Object is the qualified name of the object the action targeted.
+	Object string
+	Err    error
+}
+
+// Error formats the failed action with its statement index and target object.
+func (e *ApplyError) Error() string {
+	var b strings.Builder
+	if e.Statement > 0 {
+		fmt.Fprintf(&b, "statement %d: ", e.Statement)
+	}
+	if e.Action != "" {
+		b.WriteString(string(e.Action))
+		b.WriteString(" ")
+	}
+	if e.Object != "" {
+		b.WriteString(e.Object)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+// Unwrap returns the underlying sentinel error for errors.Is compatibility.
+func (e *ApplyError) Unwrap() error {
+	return e.Err
+}
+
+// ApplySQL parses sql with ParseSQLAll and applies every statement in order.
+func (c *Catalog) ApplySQL(sql string) error {
+	batch, err := postgresparser.ParseSQLAll(sql)
+	if err != nil {
+		return err
+	}
+	return c.ApplyBatch(batch)
+}
+
+// ApplyBatch applies the DDL actions of every statement in batch in order.
+// Application continues past inconsistent actions; all failures are returned
+// joined, each as an *ApplyError carrying its statement index.
+func (c *Catalog) ApplyBatch(batch *postgresparser.ParseBatchResult) error {
+	if batch == nil {
+		return nil
+	}
+	var errs []error
+	for _, stmt := range batch.Statements {
+		if stmt.Query == nil {
+			errs = append(errs, &ApplyError{Statement: stmt.Index, Err: ErrStatementNotParsed})
+			continue
+		}
+		errs = append(errs, c.apply(stmt.Query, stmt.Index)...)
+	}
+	return errors.Join(errs...)
+}
+
+// Apply applies the DDL actions of a single parsed statement. Statements
+// without DDL actions, and DDL actions the catalog does not model, are
+// ignored. All failures are returned joined, each as an *ApplyError.
+func (c *Catalog) Apply(query *postgresparser.ParsedQuery) error {
+	if query == nil {
+		return nil
+	}
+	return errors.Join(c.apply(query, 0)...)
+}
+
+// apply replays each DDL action of query and collects failures.
+func (c *Catalog) apply(query *postgresparser.ParsedQuery, statement int) []error {
+	var errs []error
+	for _, action := range query.DDLActions {
+		object, err := c.applyAction(query, action)
+		if err != nil {
+			errs = append(errs, &ApplyError{
+				Statement: statement,
+				Action:    action.Type,
+				Object:    object,
+				Err:       err,
+			})
+		}
+	}
+	return errs
+}
+
+// applyAction dispatches one DDL action and returns the qualified name of its
+// target for error reporting.
+func (c *Catalog) applyAction(query *postgresparser.ParsedQuery, action postgresparser.DDLAction) (string, error) {
+	object := qualifiedName(action.Schema, action.ObjectName)
+	switch action.Type {
+	case postgresparser.DDLCreateTable:
+		return object, c.createTable(action)
+	case postgresparser.DDLDropTable:
+		return object, c.dropTable(action)
+	case postgresparser.DDLAlterTable:
+		return object, c.alterTable(action)
+	case postgresparser.DDLDropColumn:
+		return object, c.dropColumn(action)
+	case postgresparser.DDLCreateIndex:
+		return object, c.createIndex(query, action)
+	case postgresparser.DDLDropIndex:
+		return object, c.dropIndex(action)
+	case postgresparser.DDLTruncate:
+		_, err := c.lookupTable(action)
+		return object, err
+	case postgresparser.DDLComment:
+		return commentTarget(action), c.comment(action)
+	default:
+		return object, nil
+	}
+}
+
+// createTable handles CREATE TABLE [IF NOT EXISTS].
+func (c *Catalog) createTable(action postgresparser.DDLAction) error {
+	s := c.ensureSchema(action.Schema)
+	name := foldIdent(action.ObjectName)
+	if _, exists := s.tables[name]; exists || s.indexes[name] != nil {
+		if hasFlag(action.Flags, "IF_NOT_EXISTS") {
+			return nil
+		}
+		return ErrTableExists
+	}
+
+	t := &Table{Schema: s.Name, Name: name}
+	for _, col := range action.ColumnDetails {
+		t.Columns = append(t.Columns, newColumn(col))
+	}
+	for _, con := range action.Constraints {
+		t.addConstraint(con)
+	}
+	s.tables[name] = t
+	return nil
+}
+
+// dropTable handles DROP TABLE [IF EXISTS], removing the table's indexes too.
+func (c *Catalog) dropTable(action postgresparser.DDLAction) error {
+	t, err := c.lookupTable(action)
+	if err != nil {
+		if hasFlag(action.Flags, "IF_EXISTS") {
+			return nil
+		}
+		return err
+	}
+	s := c.schemas[t.Schema]
+	delete(s.tables, t.Name)
+	for name, idx := range s.indexes {
+		if idx.Table == t.Name {
+			delete(s.indexes, name)
+		}
+	}
+	return nil
+}
+
+// alterTable handles the ALTER TABLE sub-commands the catalog models:
+// ADD COLUMN, ALTER COLUMN, ADD/DROP/RENAME CONSTRAINT, RENAME COLUMN/TABLE
+// and SET SCHEMA. Other sub-commands only require the table to exist.
+func (c *Catalog) alterTable(action postgresparser.DDLAction) error {
+	t, err := c.lookupAlteredTable(action)
+	if t == nil {
+		return err
+	}
+	switch {
+	case hasFlag(action.Flags, "ADD_COLUMN"):
+		if len(action.ColumnDetails) == 0 {
+			return nil
+		}
+		col := newColumn(action.ColumnDetails[0])
+		if t.columnIndex(col.Name) >= 0 {
+			if hasFlag(action.Flags, "IF_NOT_EXISTS") {
+				return nil
+			}
+			return fmt.Errorf("%w: %s", ErrColumnExists, col.Name)
+		}
+		t.Columns = append(t.Columns, col)
+		for _, con := range action.Constraints {
+			t.addConstraint(con)
+		}
+	case hasFlag(action.Flags, "ALTER_COLUMN"):
+		for _, name := range action.Columns {
+			col := t.Column(name)
+			if col == nil {
+				return fmt.Errorf("%w: %s", ErrColumnNotFound, foldIdent(name))
+			}
+			applyColumnOp(col, action.AlterOp)
+		}
+	case hasFlag(action.Flags, "ADD_CONSTRAINT"):
+		for _, con := range action.Constraints {
+			for _, name := range con.Columns {
+				if con.Type != postgresparser.DDLConstraintExclude && t.columnIndex(name) < 0 {
+					return fmt.Errorf("%w: %s", ErrColumnNotFound, foldIdent(name))
+				}
+			}
+			t.addConstraint(con)
+		}
+	case hasFlag(action.Flags, "DROP_CONSTRAINT"):
+		for _, con := range action.Constraints {
+			if !t.dropConstraint(foldIdent(con.Name)) && !hasFlag(action.Flags, "IF_EXISTS") {
+				return fmt.Errorf("%w: %s", ErrConstraintNotFound, foldIdent(con.Name))
+			}
+		}
+	case action.AlterOp != nil:
+		return c.applyTableOp(t, action.AlterOp)
+	}
+	return nil
+}
+
+// applyColumnOp applies an ALTER COLUMN type, default, or nullability change.
+func applyColumnOp(col *Column, op *postgresparser.DDLAlterOp) {
+	if op == nil {
+		return
+	}
+	switch op.Type {
+	case postgresparser.DDLAlterOpSetDataType:
+		col.Type = op.DataType
+	case postgresparser.DDLAlterOpSetDefault:
+		col.Default = op.Default
+	case postgresparser.DDLAlterOpDropDefault:
+		col.Default = ""
+	case postgresparser.DDLAlterOpSetNotNull:
+		col.Nullable = false
+	case postgresparser.DDLAlterOpDropNotNull:
+		col.Nullable = true
+	}
+}
+
+// applyTableOp applies table-level renames and SET SCHEMA. Sub-commands that
+// do not change the catalog shape (OWNER TO, triggers, partitions) are ignored.
+func (c *Catalog) applyTableOp(t *Table, op *postgresparser.DDLAlterOp) error {
+	switch op.Type {
+	case postgresparser.DDLAlterOpRenameColumn:
+		return t.renameColumn(c.schemas[t.Schema], foldIdent(op.OldName), foldIdent(op.NewName))
+	case postgresparser.DDLAlterOpRenameConstraint:
+		oldName, newName := foldIdent(op.OldName), foldIdent(op.NewName)
+		for i := range t.Constraints {
+			if t.Constraints[i].Name == oldName {
+				t.Constraints[i].Name = newName
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrConstraintNotFound, oldName)
+	case postgresparser.DDLAlterOpRenameTable:
+		return c.moveTable(t, t.Schema, foldIdent(op.NewName))
+	case postgresparser.DDLAlterOpSetSchema:
+		return c.moveTable(t, schemaKey(op.NewSchema), t.Name)
+	}
+	return nil
+}
+
+// renameColumn renames a column and every constraint and index reference to it.
+func (t *Table) renameColumn(s *Schema, oldName, newName string) error {
+	col := t.Column(oldName)
+	if col == nil {
+		return fmt.Errorf("%w: %s", ErrColumnNotFound, oldName)
+	}
+	if t.columnIndex(newName) >= 0 {
+		return fmt.Errorf("%w: %s", ErrColumnExists, newName)
+	}
+	col.Name = newName
+	for i := range t.Constraints {
+		renameInList(t.Constraints[i].Columns, oldName, newName)
+	}
+	for _, idx := range s.indexes {
+		if idx.Table == t.Name {
+			renameInList(idx.Columns, oldName, newName)
+		}
+	}
+	return nil
+}
+
+// moveTable renames t and/or moves it to another schema, carrying its indexes
+// along and updating foreign keys that reference it.
+func (c *Catalog) moveTable(t *Table, schema, name string) error {
+	from := c.schemas[t.Schema]
+	to := c.ensureSchema(schema)
+	if to == from && name == t.Name {
+		return nil
+	}
+	if to.tables[name] != nil || to.indexes[name] != nil {
+		return fmt.Errorf("%w: %s", ErrTableExists, qualifiedName(to.Name, name))
+	}
+
+	delete(from.tables, t.Name)
+	to.tables[name] = t
+	for key, idx := range from.indexes {
+		if idx.Table != t.Name {
+			continue
+		}
+		idx.Table = name
+		if to != from {
+			delete(from.indexes, key)
+			idx.Schema = to.Name
+			to.indexes[key] = idx
+		}
+	}
+	for _, other := range c.schemas {
+		for _, ref := range other.tables {
+			for i := range ref.Constraints {
+				con := &ref.Constraints[i]
+				if con.Type == postgresparser.DDLConstraintForeignKey &&
+					foldIdent(con.ReferencedTable) == t.Name && schemaKey(con.ReferencedSchema) == from.Name {
+					con.ReferencedTable = name
+					if to != from {
+						con.ReferencedSchema = to.Name
+					}
+				}
+			}
+		}
+	}
+	t.Schema, t.Name = to.Name, name
+	return nil
+}
+
+// dropColumn handles ALTER TABLE DROP COLUMN [IF EXISTS]. Constraints and
+// indexes that reference the column are dropped with it.
+func (c *Catalog) dropColumn(action postgresparser.DDLAction) error {
+	t, err := c.lookupAlteredTable(action)
+	if t == nil {
+		return err
+	}
+	for _, name := range action.Columns {
+		i := t.columnIndex(name)
+		if i < 0 {
+			if hasFlag(action.Flags, "IF_EXISTS") {
+				continue
+			}
+			return fmt.Errorf("%w: %s", ErrColumnNotFound, foldIdent(name))
+		}
+		folded := t.Columns[i].Name
+		t.Columns = append(t.Columns[:i], t.Columns[i+1:]...)
+
+		kept := t.Constraints[:0]
+		for _, con := range t.Constraints {
+			if !containsFolded(con.Columns, folded) {
+				kept = append(kept, con)
+			}
+		}
+		t.Constraints = kept
+
+		s := c.schemas[t.Schema]
+		for key, idx := range s.indexes {
+			if idx.Table == t.Name && containsFolded(idx.Columns, folded) {
+				delete(s.indexes, key)
+			}
+		}
+	}
+	return nil
+}
+
+// createIndex handles CREATE [UNIQUE] INDEX [IF NOT EXISTS]. The indexed
+// table comes from the statement's table references.
+func (c *Catalog) createIndex(query *postgresparser.ParsedQuery, action postgresparser.DDLAction) error {
+	if len(query.Tables) == 0 {
+		return nil
+	}
+	ref := query.Tables[0]
+	t := c.Table(ref.Schema, ref.Name)
+	if t == nil {
+		return fmt.Errorf("%w: %s", ErrTableNotFound, qualifiedName(ref.Schema, ref.Name))
+	}
+	s := c.schemas[t.Schema]
+
+	name := foldIdent(action.ObjectName)
+	if name == "" {
+		name = defaultIndexName(s, t.Name, action.Columns)
+	}
+	if _, exists := s.indexes[name]; exists || s.tables[name] != nil {
+		if hasFlag(action.Flags, "IF_NOT_EXISTS") {
+			return nil
+		}
+		return ErrIndexExists
+	}
+
+	columns := make([]string, 0, len(action.Columns))
+	for _, col := range action.Columns {
+		columns = append(columns, foldIdent(col))
+	}
+	s.indexes[name] = &Index{
+		Schema:  s.Name,
+		Name:    name,
+		Table:   t.Name,
+		Columns: columns,
+		Unique:  hasFlag(action.Flags, "UNIQUE"),
+		Method:  action.IndexType,
+	}
+	return nil
+}
+
+// dropIndex handles DROP INDEX [IF EXISTS].
+func (c *Catalog) dropIndex(action postgresparser.DDLAction) error {
+	s := c.Schema(action.Schema)
+	name := foldIdent(action.ObjectName)
+	if s == nil || s.indexes[name] == nil {
+		if hasFlag(action.Flags, "IF_EXISTS") {
+			return nil
+		}
+		return ErrIndexNotFound
+	}
+	delete(s.indexes, name)
+	return nil
+}
+
+// comment handles COMMENT ON SCHEMA, TABLE, COLUMN and INDEX. COMMENT ... IS
+// NULL arrives as empty text and clears the comment. Other object types are
+// ignored.
+func (c *Catalog) comment(action postgresparser.DDLAction) error {
+	switch action.ObjectType {
+	case "SCHEMA":
+		// COMMENT ON SCHEMA names the schema itself, not an object within one.
+		s := c.Schema(action.ObjectName)
+		if s == nil {
+			return fmt.Errorf("schema does not exist: %s", foldIdent(action.ObjectName))
+		}
+		s.Comment = action.Comment
+	case "TABLE":
+		t, err := c.lookupTable(action)
+		if err != nil {
+			return err
+		}
+		t.Comment = action.Comment
+	case "COLUMN":
+		t, err := c.lookupTable(action)
+		if err != nil {
+			return err
+		}
+		for _, name := range action.Columns {
+			col := t.Column(name)
+			if col == nil {
+				return fmt.Errorf("%w: %s", ErrColumnNotFound, foldIdent(name))
+			}
+			col.Comment = action.Comment
+		}
+	case "INDEX":
+		idx := c.Index(action.Schema, action.ObjectName)
+		if idx == nil {
+			return ErrIndexNotFound
+		}
+		idx.Comment = action.Comment
+	}
+	return nil
+}
+
+// lookupTable resolves the action's Schema and ObjectName to a table.
+func (c *Catalog) lookupTable(action postgresparser.DDLAction) (*Table, error) {
+	t := c.Table(action.Schema, action.ObjectName)
+	if t == nil {
+		return nil, ErrTableNotFound
+	}
+	return t, nil
+}
+
+// lookupAlteredTable resolves the table of an ALTER TABLE action. It returns
+// a nil table and a nil error when ALTER TABLE IF EXISTS names a missing
+// table, so the action is skipped.
+func (c *Catalog) lookupAlteredTable(action postgresparser.DDLAction) (*Table, error) {
+	t, err := c.lookupTable(action)
+	if err != nil && hasFlag(action.Flags, "TABLE_IF_EXISTS") {
+		return nil, nil
+	}
+	return t, err
+}
+
+// addConstraint records con with folded column names, assigning the name
+// PostgreSQL would generate when the DDL did not specify one.
+func (t *Table) addConstraint(con postgresparser.DDLConstraint) {
+	if con.Type != postgresparser.DDLConstraintExclude {
+		columns := make([]string, 0, len(con.Columns))
+		for _, col := range con.Columns {
+			columns = append(columns, foldIdent(col))
+		}
+		con.Columns = columns
+	}
+	if con.Name == "" {
+		con.Name = defaultConstraintName(t.Name, con)
+	} else {
+		con.Name = foldIdent(con.Name)
+	}
+	if con.Type == postgresparser.DDLConstraintPrimaryKey {
+		for _, name := range con.Columns {
+			if col := t.Column(name); col != nil {
+				col.Nullable = false
+			}
+		}
+	}
+	t.Constraints = append(t.Constraints, con)
+}
+
+// dropConstraint removes the named constraint and reports whether it existed.
+func (t *Table) dropConstraint(name string) bool {
+	for i, con := range t.Constraints {
+		if con.Name == name {
+			t.Constraints = append(t.Constraints[:i], t.Constraints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// defaultConstraintName mirrors PostgreSQL's generated constraint names
+// (table_pkey, table_col_key, table_col_fkey, table_col_check, table_col_excl).
+// Names longer than the 63-byte identifier limit are not truncated.
+func defaultConstraintName(table string, con postgresparser.DDLConstraint) string {
+	parts := []string{table}
+	switch con.Type {
+	case postgresparser.DDLConstraintPrimaryKey:
+		return table + "_pkey"
+	case postgresparser.DDLConstraintUnique:
+		parts = append(append(parts, con.Columns...), "key")
+	case postgresparser.DDLConstraintForeignKey:
+		parts = append(append(parts, con.Columns...), "fkey")
+	case postgresparser.DDLConstraintCheck:
+		// Column-level checks are named after their column only.
+		if len(con.Columns) > 0 {
+			parts = append(parts, con.Columns[0])
+		}
+		parts = append(parts, "check")
+	case postgresparser.DDLConstraintExclude:
+		parts = append(parts, "excl")
+	}
+	return strings.Join(parts, "_")
+}
+
+// defaultIndexName mirrors PostgreSQL's generated name for an unnamed index
+// (table_col_idx), appending a counter when the name is already taken.
+func defaultIndexName(s *Schema, table string, columns []string) string {
+	parts := []string{table}
+	for _, col := range columns {
+		parts = append(parts, foldIdent(col))
+	}
+	base := strings.Join(append(parts, "idx"), "_")
+	name := base
+	for i := 1; s.indexes[name] != nil || s.tables[name] != nil; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	return name
+}
+
+// newColumn converts parsed column metadata into a catalog column.
+func newColumn(col postgresparser.DDLColumn) *Column {
+	return &Column{
+		Name:     foldIdent(col.Name),
+		Type:     col.Type,
+		Nullable: col.Nullable,
+		Default:  col.Default,
+	}
+}
+
+// commentTarget returns the qualified name reported for COMMENT failures.
+func commentTarget(action postgresparser.DDLAction) string {
+	if action.Target != "" {
+		return action.Target
+	}
+	return qualifiedName(action.Schema, action.ObjectName)
+}
+
+// qualifiedName joins an optional schema and a name with a dot.
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// renameInList replaces oldName with newName in names after identifier folding.
+func renameInList(names []string, oldName, newName string) {
+	for i, name := range names {
+		if foldIdent(name) == oldName {
+			names[i] = newName
+		}
+	}
+}
+
+// containsFolded reports whether names contains target after identifier folding.
+func containsFolded(names []string, target string) bool {
+	for _, name := range names {
+		if foldIdent(name) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFlag reports whether flags contains flag.
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+// Package catalog builds an in-memory schema model by replaying DDL statements.
+// This file defines the catalog object model and its lookup and export helpers.
+package catalog
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+)
+
+// DefaultSchema is the schema used for objects whose DDL does not name one.
+const DefaultSchema = "public"
+
+// Catalog is a schema model built by applying DDL actions in order. Identifiers
+// are stored in PostgreSQL's folded form: unquoted names are lowercased and
+// quoted names keep their exact spelling.
+//
+// A Catalog is not safe for concurrent mutation.
+type Catalog struct {
+	schemas map[string]*Schema
+}
+
+// Schema holds the tables and indexes that live in one PostgreSQL schema.
+type Schema struct {
+	Name    string
+	Comment string
+
+	tables  map[string]*Table
+	indexes map[string]*Index
+}
+
+// Table describes a table and its columns in declaration order.
+type Table struct {
+	Schema      string
+	Name        string
+	Comment     string
+	Columns     []*Column
+	Constraints []postgresparser.DDLConstraint
+}
+
+// Column describes one table column.
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  string
+	Comment  string
+}
+
+// Index describes an index. Indexes share a namespace with tables in their
+// schema, which is always the schema of the indexed table.
+type Index struct {
+	Schema  string
+	Name    string
+	Table   string
+	Columns []string
+	Unique  bool
+	Method  string
+	Comment string
+}
+
+// New returns an empty Catalog containing only the default schema.
+func New() *Catalog {
+	c := &Catalog{schemas: make(map[string]*Schema)}
+	c.ensureSchema(DefaultSchema)
+	return c
+}
+
+// Schemas returns all schemas sorted by name.
+func (c *Catalog) Schemas() []*Schema {
+	out := make([]*Schema, 0, len(c.schemas))
+	for _, s := range c.schemas {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Schema returns the named schema, or nil when it does not exist.
+// An empty name refers to DefaultSchema.
+func (c *Catalog) Schema(name string) *Schema {
+	return c.schemas[schemaKey(name)]
+}
+
+// Table returns the table schema.name, or nil when it does not exist.
+// An empty schema refers to DefaultSchema.
+func (c *Catalog) Table(schema, name string) *Table {
+	s := c.Schema(schema)
+	if s == nil {
+		return nil
+	}
+	return s.Table(name)
+}
+
+// Index returns the index schema.name, or nil when it does not exist.
+// An empty schema refers to DefaultSchema.
+func (c *Catalog) Index(schema, name string) *Index {
+	s := c.Schema(schema)
+	if s == nil {
+		return nil
+	}
+	return s.Index(name)
+}
+
+// ColumnSchemas converts the catalog into the schema map accepted by the
+// analysis package (for example ExtractJoinRelationshipsWithSchema). Every
+// table is keyed by its lowercased name and by its lowercased schema-qualified
+// name. When two schemas define a table with the same name, the unqualified key
+// refers to the one in DefaultSchema, or else to the first schema by name.
+func (c *Catalog) ColumnSchemas() map[string][]analysis.ColumnSchema {
+	out := make(map[string][]analysis.ColumnSchema)
+	schemas := c.Schemas()
+	// Visit DefaultSchema first so it wins unqualified-name collisions.
+	sort.SliceStable(schemas, func(i, j int) bool {
+		return schemas[i].Name == DefaultSchema && schemas[j].Name != DefaultSchema
+	})
+	for _, s := range schemas {
+		for _, t := range s.Tables() {
+			cols := t.columnSchemas()
+			out[strings.ToLower(s.Name+"."+t.Name)] = cols
+			key := strings.ToLower(t.Name)
+			if _, taken := out[key]; !taken {
+				out[key] = cols
+			}
+		}
+	}
+	return out
+}
+
+// Tables returns the schema's tables sorted by name.
+func (s *Schema) Tables() []*Table {
+	out := make([]*Table, 0, len(s.tables))
+	for _, t := range s.tables {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Table returns the named table, or nil when it does not exist.
+func (s *Schema) Table(name string) *Table {
+	return s.tables[foldIdent(name)]
+}
+
+// Indexes returns the schema's indexes sorted by name.
+func (s *Schema) Indexes() []*Index {
+	out := make([]*Index, 0, len(s.indexes))
+	for _, idx := range s.indexes {
+		out = append(out, idx)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Index returns the named index, or nil when it does not exist.
+func (s *Schema) Index(name string) *Index {
+	return s.indexes[foldIdent(name)]
+}
+
+// Column returns the named column, or nil when it does not exist.
+func (t *Table) Column(name string) *Column {
+	if i := t.columnIndex(name); i >= 0 {
+		return t.Columns[i]
+	}
+	return nil
+}
+
+// PrimaryKey returns the primary key column names in key order, or nil when
+// the table has no primary key.
+func (t *Table) PrimaryKey() []string {
+	for _, con := range t.Constraints {
+		if con.Type == postgresparser.DDLConstraintPrimaryKey {
+			return append([]string(nil), con.Columns...)
+		}
+	}
+	return nil
+}
+
+// columnIndex returns the position of the named column, or -1.
+func (t *Table) columnIndex(name string) int {
+	folded := foldIdent(name)
+	for i, col := range t.Columns {
+		if col.Name == folded {
+			return i
+		}
+	}
+	return -1
+}
+
+// columnSchemas converts the table's columns into analysis.ColumnSchema values.
+func (t *Table) columnSchemas() []analysis.ColumnSchema {
+	pk := make(map[string]bool)
+	for _, name := range t.PrimaryKey() {
+		pk[name] = true
+	}
+	out := make([]analysis.ColumnSchema, 0, len(t.Columns))
+	for _, col := range t.Columns {
+		out = append(out, analysis.ColumnSchema{
+			Name:         col.Name,
+			PGType:       col.Type,
+			IsPrimaryKey: pk[col.Name],
+			IsNullable:   col.Nullable && !pk[col.Name],
+		})
+	}
+	return out
+}
+
+// ensureSchema returns the named schema, creating it when missing.
+func (c *Catalog) ensureSchema(name string) *Schema {
+	key := schemaKey(name)
+	s, ok := c.schemas[key]
+	if !ok {
+		s = &Schema{
+			Name:    key,
+			tables:  make(map[string]*Table),
+			indexes: make(map[string]*Index),
+		}
+		c.schemas[key] = s
+	}
+	return s
+}
+
+// schemaKey folds a schema name, mapping the empty name to DefaultSchema.
+func schemaKey(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return DefaultSchema
+	}
+	return foldIdent(name)
+}
+
+// foldIdent applies PostgreSQL identifier folding: quoted identifiers keep
+// their spelling with doubled quotes unescaped, unquoted ones are lowercased.
+func foldIdent(name string) string {
+	trimmed := strings.TrimSpace(name)
+	if len(trimmed) >= 2 && strings.HasPrefix(trimmed, `"`) && strings.HasSuffix(trimmed, `"`) {
+		return strings.ReplaceAll(trimmed[1:len(trimmed)-1], `""`, `"`)
+	}
+	return strings.ToLower(trimmed)
+}
@@ -0,0 +1,383 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+)
+
+func TestCatalog_ApplySQL_Migration(t *testing.T) {
+	c := New()
+	err := c.ApplySQL(`
+CREATE TABLE users (
+    id bigint PRIMARY KEY,
+    email text NOT NULL,
+    "DisplayName" text,
+    legacy int
+);
+CREATE SCHEMA billing;
+CREATE TABLE billing.invoices (
+    id bigserial,
+    user_id bigint REFERENCES users (id),
+    total numeric(12,2) DEFAULT 0,
+    CONSTRAINT invoices_pkey PRIMARY KEY (id)
+);
+ALTER TABLE users ADD COLUMN created_at timestamptz NOT NULL DEFAULT now();
+ALTER TABLE users DROP COLUMN legacy;
+CREATE UNIQUE INDEX users_email_key ON users (email);
+CREATE INDEX ON billing.invoices USING btree (user_id);
+COMMENT ON TABLE users IS 'Registered accounts';
+COMMENT ON COLUMN users.email IS 'Login address';
+TRUNCATE billing.invoices;
+`)
+	require.NoError(t, err)
+
+	users := c.Table("", "users")
+	require.NotNil(t, users)
+	assert.Equal(t, "public", users.Schema)
+	assert.Equal(t, "Registered accounts", users.Comment)
+
+	var names []string
+	for _, col := range users.Columns {
+		names = append(names, col.Name)
+	}
+	assert.Equal(t, []string{"id", "email", "DisplayName", "created_at"}, names)
+	assert.Equal(t, []string{"id"}, users.PrimaryKey())
+
+	createdAt := users.Column("created_at")
+	require.NotNil(t, createdAt)
+	assert.Equal(t, "timestamptz", createdAt.Type)
+	assert.False(t, createdAt.Nullable)
+	assert.Equal(t, "now()", createdAt.Default)
+	assert.Equal(t, "Login address", users.Column("EMAIL").Comment)
+	assert.NotNil(t, users.Column(`"DisplayName"`))
+	assert.Nil(t, users.Column("displayname"))
+
+	idx := c.Index("public", "users_email_key")
+	require.NotNil(t, idx)
+	assert.Equal(t, "users", idx.Table)
+	assert.Equal(t, []string{"email"}, idx.Columns)
+	assert.True(t, idx.Unique)
+
+	invoices := c.Table("billing", "invoices")
+	require.NotNil(t, invoices)
+	assert.Equal(t, []string{"id"}, invoices.PrimaryKey())
+	require.Len(t, invoices.Constraints, 2)
+	assert.Equal(t, "invoices_user_id_fkey", invoices.Constraints[0].Name)
+	assert.Equal(t, "invoices_pkey", invoices.Constraints[1].Name)
+
+	billingIdx := c.Schema("billing").Indexes()
+	require.Len(t, billingIdx, 1)
+	assert.Equal(t, "invoices_user_id_idx", billingIdx[0].Name)
+	assert.Equal(t, "btree", billingIdx[0].Method)
+}
+
+func TestCatalog_ApplySQL_InconsistentMigrations(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		wantErr   error
+		wantIndex int
+	}{
+		{
+			name:      "create existing table",
+			sql:       "CREATE TABLE t (id int); CREATE TABLE t (id int);",
+			wantErr:   ErrTableExists,
+			wantIndex: 2,
+		},
+		{
+			name:      "drop missing table",
+			sql:       "DROP TABLE missing;",
+			wantErr:   ErrTableNotFound,
+			wantIndex: 1,
+		},
+		{
+			name:      "drop missing column",
+			sql:       "CREATE TABLE t (id int); ALTER TABLE t DROP COLUMN nope;",
+			wantErr:   ErrColumnNotFound,
+			wantIndex: 2,
+		},
+		{
+			name:      "add existing column",
+			sql:       "CREATE TABLE t (id int); ALTER TABLE t ADD COLUMN ID bigint;",
+			wantErr:   ErrColumnExists,
+			wantIndex: 2,
+		},
+		{
+			name:      "alter missing column",
+			sql:       "CREATE TABLE t (id int); ALTER TABLE t ALTER COLUMN nope SET NOT NULL;",
+			wantErr:   ErrColumnNotFound,
+			wantIndex: 2,
+		},
+		{
+			name:      "index on missing table",
+			sql:       "CREATE INDEX t_id_idx ON t (id);",
+			wantErr:   ErrTableNotFound,
+			wantIndex: 1,
+		},
+		{
+			name:      "create existing index",
+			sql:       "CREATE TABLE t (id int); CREATE INDEX i ON t (id); CREATE INDEX i ON t (id);",
+			wantErr:   ErrIndexExists,
+			wantIndex: 3,
+		},
+		{
+			name:      "drop missing index",
+			sql:       "DROP INDEX i;",
+			wantErr:   ErrIndexNotFound,
+			wantIndex: 1,
+		},
+		{
+			name:      "drop missing constraint",
+			sql:       "CREATE TABLE t (id int); ALTER TABLE t DROP CONSTRAINT t_pkey;",
+			wantErr:   ErrConstraintNotFound,
+			wantIndex: 2,
+		},
+		{
+			name:      "truncate missing table",
+			sql:       "TRUNCATE missing;",
+			wantErr:   ErrTableNotFound,
+			wantIndex: 1,
+		},
+		{
+			name:      "comment on missing column",
+			sql:       "CREATE TABLE t (id int); COMMENT ON COLUMN t.nope IS 'x';",
+			wantErr:   ErrColumnNotFound,
+			wantIndex: 2,
+		},
+		{
+			name:      "table dropped before use",
+			sql:       "CREATE TABLE t (id int); DROP TABLE t; ALTER TABLE t ADD COLUMN x int;",
+			wantErr:   ErrTableNotFound,
+			wantIndex: 3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := New().ApplySQL(tc.sql)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tc.wantErr)
+
+			var applyErr *ApplyError
+			require.True(t, errors.As(err, &applyErr))
+			assert.Equal(t, tc.wantIndex, applyErr.Statement)
+		})
+	}
+}
+
+func TestCatalog_ApplySQL_IfExistsGuards(t *testing.T) {
+	c := New()
+	err := c.ApplySQL(`
+CREATE TABLE t (id int);
+CREATE TABLE IF NOT EXISTS t (other int);
+ALTER TABLE t ADD COLUMN IF NOT EXISTS id bigint;
+ALTER TABLE t DROP COLUMN IF EXISTS nope;
+ALTER TABLE t DROP CONSTRAINT IF EXISTS nope;
+CREATE INDEX IF NOT EXISTS t_id_idx ON t (id);
+CREATE INDEX IF NOT EXISTS t_id_idx ON t (id);
+DROP INDEX IF EXISTS nope;
+DROP TABLE IF EXISTS nope;
+ALTER TABLE IF EXISTS nope ADD COLUMN x int, ALTER COLUMN y SET NOT NULL;
+ALTER TABLE IF EXISTS nope DROP COLUMN x;
+ALTER TABLE IF EXISTS nope RENAME TO other;
+ALTER TABLE IF EXISTS nope SET SCHEMA archive;
+`)
+	require.NoError(t, err)
+
+	tbl := c.Table("", "t")
+	require.NotNil(t, tbl)
+	require.Len(t, tbl.Columns, 1)
+	assert.Equal(t, "int", tbl.Columns[0].Type)
+	assert.Len(t, c.Schema("").Indexes(), 1)
+	assert.Nil(t, c.Schema("archive"))
+
+	// IF EXISTS on the table does not cover missing columns of an existing table.
+	err = c.ApplySQL("ALTER TABLE IF EXISTS t DROP COLUMN nope")
+	assert.ErrorIs(t, err, ErrColumnNotFound)
+}
+
+func TestCatalog_ApplySQL_ContinuesAfterErrors(t *testing.T) {
+	c := New()
+	err := c.ApplySQL(`
+DROP TABLE missing;
+CREATE TABLE t (id int);
+ALTER TABLE t DROP COLUMN nope;
+ALTER TABLE t ADD COLUMN name text;
+`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "statement 1: DROP_TABLE missing: table does not exist")
+	assert.Contains(t, err.Error(), "statement 3: DROP_COLUMN t: column does not exist: nope")
+
+	tbl := c.Table("", "t")
+	require.NotNil(t, tbl)
+	assert.NotNil(t, tbl.Column("name"))
+}
+
+func TestCatalog_DropCascadesToDependents(t *testing.T) {
+	c := New()
+	require.NoError(t, c.ApplySQL(`
+CREATE TABLE t (id int, email text UNIQUE, note text);
+CREATE INDEX t_email_idx ON t (email);
+CREATE INDEX t_note_idx ON t (note);
+ALTER TABLE t DROP COLUMN email;
+`))
+	tbl := c.Table("", "t")
+	require.NotNil(t, tbl)
+	assert.Empty(t, tbl.Constraints)
+	require.Len(t, c.Schema("").Indexes(), 1)
+	assert.Equal(t, "t_note_idx", c.Schema("").Indexes()[0].Name)
+
+	require.NoError(t, c.ApplySQL("DROP TABLE t;"))
+	assert.Nil(t, c.Table("", "t"))
+	assert.Empty(t, c.Schema("").Indexes())
+}
+
+func TestCatalog_Constraints(t *testing.T) {
+	c := New()
+	require.NoError(t, c.ApplySQL(`
+CREATE TABLE accounts (id bigint, email text);
+ALTER TABLE accounts ADD PRIMARY KEY (id);
+ALTER TABLE accounts ADD UNIQUE (email);
+ALTER TABLE accounts DROP CONSTRAINT accounts_email_key;
+`))
+	tbl := c.Table("", "accounts")
+	require.NotNil(t, tbl)
+	require.Len(t, tbl.Constraints, 1)
+	assert.Equal(t, "accounts_pkey", tbl.Constraints[0].Name)
+	assert.False(t, tbl.Column("id").Nullable, "primary key columns are NOT NULL")
+
+	err := c.ApplySQL("ALTER TABLE accounts ADD CONSTRAINT bad UNIQUE (nope);")
+	assert.ErrorIs(t, err, ErrColumnNotFound)
+}
+
+func TestCatalog_ApplyBatch_UnparsedStatement(t *testing.T) {
+	batch := &postgresparser.ParseBatchResult{
+		Statements: []postgresparser.StatementParseResult{{Index: 1, RawSQL: "CREATE TABLE (", Query: nil}},
+	}
+	err := New().ApplyBatch(batch)
+	assert.ErrorIs(t, err, ErrStatementNotParsed)
+}
+
+func TestCatalog_Apply_IgnoresNonDDL(t *testing.T) {
+	c := New()
+	query, err := postgresparser.ParseSQL("SELECT * FROM users")
+	require.NoError(t, err)
+	assert.NoError(t, c.Apply(query))
+	assert.Empty(t, c.Schema("").Tables())
+}
+
+func TestCatalog_ColumnSchemas(t *testing.T) {
+	c := New()
+	require.NoError(t, c.ApplySQL(`
+CREATE TABLE customers (id bigint PRIMARY KEY, name text NOT NULL);
+CREATE TABLE orders (id bigint, customer_id bigint, CONSTRAINT orders_pkey PRIMARY KEY (id));
+CREATE TABLE archive.orders (legacy_id int);
+`))
+
+	schema := c.ColumnSchemas()
+	assert.Equal(t, []analysis.ColumnSchema{
+		{Name: "id", PGType: "bigint", IsPrimaryKey: true, IsNullable: false},
+		{Name: "name", PGType: "text", IsNullable: false},
+	}, schema["customers"])
+	assert.Equal(t, schema["public.orders"], schema["orders"], "default schema wins unqualified names")
+	assert.Equal(t, []analysis.ColumnSchema{
+		{Name: "legacy_id", PGType: "int", IsNullable: true},
+	}, schema["archive.orders"])
+
+	joins, err := analysis.ExtractJoinRelationshipsWithSchema(
+		"SELECT * FROM orders o JOIN customers c ON o.customer_id = c.id",
+		schema,
+	)
+	require.NoError(t, err)
+	require.Len(t, joins, 1)
+	assert.Equal(t, "orders", joins[0].ChildTable)
+	assert.Equal(t, "customer_id", joins[0].ChildColumn)
+	assert.Equal(t, "customers", joins[0].ParentTable)
+	assert.Equal(t, "id", joins[0].ParentColumn)
+}
+
+func TestCatalog_AlterOps(t *testing.T) {
+	c := New()
+	require.NoError(t, c.ApplySQL(`
+CREATE TABLE users (id int PRIMARY KEY, email text, age int DEFAULT 0);
+CREATE TABLE orders (id int, user_id int REFERENCES users (id));
+CREATE INDEX users_email_idx ON users (email);
+ALTER TABLE users ALTER COLUMN age TYPE bigint USING age::bigint;
+ALTER TABLE users ALTER COLUMN age DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
+ALTER TABLE users RENAME COLUMN email TO email_address;
+ALTER TABLE users RENAME CONSTRAINT users_pkey TO users_pk;
+ALTER TABLE users RENAME TO accounts;
+ALTER TABLE accounts SET SCHEMA auth;
+`))
+	assert.Nil(t, c.Table("", "users"))
+	assert.Nil(t, c.Table("", "accounts"))
+
+	accounts := c.Table("auth", "accounts")
+	require.NotNil(t, accounts)
+	age := accounts.Column("age")
+	require.NotNil(t, age)
+	assert.Equal(t, "bigint", age.Type)
+	assert.Empty(t, age.Default)
+
+	email := accounts.Column("email_address")
+	require.NotNil(t, email)
+	assert.False(t, email.Nullable)
+	assert.Equal(t, "users_pk", accounts.Constraints[0].Name)
+
+	idx := c.Index("auth", "users_email_idx")
+	require.NotNil(t, idx)
+	assert.Equal(t, "accounts", idx.Table)
+	assert.Equal(t, []string{"email_address"}, idx.Columns)
+	assert.Empty(t, c.Schema("").Indexes())
+
+	fk := c.Table("", "orders").Constraints[0]
+	assert.Equal(t, "auth", fk.ReferencedSchema)
+	assert.Equal(t, "accounts", fk.ReferencedTable)
+}
+
+func TestCatalog_AlterOps_Inconsistent(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		wantErr error
+	}{
+		{
+			name:    "rename missing column",
+			sql:     "CREATE TABLE t (id int); ALTER TABLE t RENAME COLUMN nope TO other;",
+			wantErr: ErrColumnNotFound,
+		},
+		{
+			name:    "rename column onto existing",
+			sql:     "CREATE TABLE t (id int, name text); ALTER TABLE t RENAME COLUMN name TO id;",
+			wantErr: ErrColumnExists,
+		},
+		{
+			name:    "rename table onto existing",
+			sql:     "CREATE TABLE t (id int); CREATE TABLE u (id int); ALTER TABLE t RENAME TO u;",
+			wantErr: ErrTableExists,
+		},
+		{
+			name:    "rename missing constraint",
+			sql:     "CREATE TABLE t (id int); ALTER TABLE t RENAME CONSTRAINT nope TO other;",
+			wantErr: ErrConstraintNotFound,
+		},
+		{
+			name:    "set schema of missing table",
+			sql:     "ALTER TABLE t SET SCHEMA archive;",
+			wantErr: ErrTableNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, New().ApplySQL(tc.sql), tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,309 @@
+// generate.go builds typed Go functions for parsed queries.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+)
+
+// goFile is the data of the generated file.
+type goFile struct {
+	Package string
+	Imports []string
+	Queries []goQuery
+}
+
+// goQuery is the data of one generated query function.
+type goQuery struct {
+	Name    string
+	Kind    queryKind
+	Const   string
+	Literal string
+	Params  []goField
+	Columns []goField
+	// Args and Scan are the argument lists passed to the query and to Scan.
+	Args string
+	Scan string
+}
+
+// goField is a struct field of a parameter or row struct.
+type goField struct {
+	Field string
+	Type  string
+	JSON  string
+}
+
+// Results returns the result list of the query function.
+func (q goQuery) Results() string {
+	switch q.Kind {
+	case kindOne:
+		return "(" + q.Name + "Row, error)"
+	case kindMany:
+		return "([]" + q.Name + "Row, error)"
+	case kindExecRows:
+		return "(int64, error)"
+	}
+	return "error"
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by pgparse-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+// DBTX is implemented by *sql.DB, *sql.Conn, and *sql.Tx.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Queries runs the generated queries against a DBTX.
+type Queries struct {
+	db DBTX
+}
+
+// New returns Queries that run against db.
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+{{range .Queries}}
+const {{.Const}} = {{.Literal}}
+{{if .Params}}
+// {{.Name}}Params holds the parameters of {{.Name}}.
+type {{.Name}}Params struct {
+{{- range .Params}}
+	{{.Field}} {{.Type}}
+{{- end}}
+}
+{{end}}{{if .Columns}}
+// {{.Name}}Row is a row returned by {{.Name}}.
+type {{.Name}}Row struct {
+{{- range .Columns}}
+	{{.Field}} {{.Type}} ` + "`" + `json:"{{.JSON}}"` + "`" + `
+{{- end}}
+}
+{{end}}
+// {{.Name}} runs {{.Const}}.
+func (q *Queries) {{.Name}}(ctx context.Context{{if .Params}}, arg {{.Name}}Params{{end}}) {{.Results}} {
+{{- if eq .Kind ":one"}}
+	row := q.db.QueryRowContext(ctx, {{.Const}}{{.Args}})
+	var i {{.Name}}Row
+	err := row.Scan({{.Scan}})
+	return i, err
+{{- else if eq .Kind ":many"}}
+	rows, err := q.db.QueryContext(ctx, {{.Const}}{{.Args}})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []{{.Name}}Row
+	for rows.Next() {
+		var i {{.Name}}Row
+		if err := rows.Scan({{.Scan}}); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+{{- else if eq .Kind ":execrows"}}
+	result, err := q.db.ExecContext(ctx, {{.Const}}{{.Args}})
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+{{- else}}
+	_, err := q.db.ExecContext(ctx, {{.Const}}{{.Args}})
+	return err
+{{- end}}
+}
+{{end}}`))
+
+// generate returns the formatted Go source for defs, typing parameters and
+// result columns against schemaMap.
+func generate(pkg string, defs []queryDef, schemaMap map[string][]analysis.ColumnSchema) ([]byte, error) {
+	imports := map[string]struct{}{"context": {}, "database/sql": {}}
+	file := goFile{Package: pkg}
+	seen := make(map[string]string)
+	for _, def := range defs {
+		if pos, ok := seen[def.Name]; ok {
+			return nil, fmt.Errorf("%s: query %s is already defined at %s", def.Pos, def.Name, pos)
+		}
+		seen[def.Name] = def.Pos
+		q, err := buildQuery(def, schemaMap, imports)
+		if err != nil {
+			return nil, err
+		}
+		file.Queries = append(file.Queries, q)
+	}
+	for imp := range imports {
+		file.Imports = append(file.Imports, imp)
+	}
+	sort.Strings(file.Imports)
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, file); err != nil {
+		return nil, err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return code, nil
+}
+
+// buildQuery validates def against the schema and types its parameters and
+// result columns, recording the imports they need.
+func buildQuery(def queryDef, schemaMap map[string][]analysis.ColumnSchema, imports map[string]struct{}) (goQuery, error) {
+	fail := func(format string, args ...any) (goQuery, error) {
+		return goQuery{}, fmt.Errorf("%s: %s: %s", def.Pos, def.Name, fmt.Sprintf(format, args...))
+	}
+	if diags := analysis.Validate(def.Query, schemaMap); len(diags) > 0 {
+		msgs := make([]string, 0, len(diags))
+		for _, d := range diags {
+			msgs = append(msgs, d.String())
+		}
+		return fail("%s", strings.Join(msgs, "; "))
+	}
+
+	q := goQuery{Name: def.Name, Kind: def.Kind, Const: unexported(def.Name) + "SQL", Literal: "`" + def.SQL + "`"}
+	if strings.Contains(def.SQL, "`") {
+		q.Literal = strconv.Quote(def.SQL)
+	}
+	use := func(typ, imp string) string {
+		if imp != "" {
+			imports[imp] = struct{}{}
+		}
+		return typ
+	}
+
+	params, err := queryParams(def, schemaMap)
+	if err != nil {
+		return fail("%v", err)
+	}
+	var args []string
+	for _, p := range params {
+		p.Type = use(goType(p.Type, p.nullable))
+		q.Params = append(q.Params, p.goField)
+		args = append(args, ", arg."+p.Field)
+	}
+	q.Args = strings.Join(args, "")
+
+	if def.Kind != kindOne && def.Kind != kindMany {
+		return q, nil
+	}
+	cols, err := analysis.InferResultColumns(def.Query, schemaMap)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if len(cols) == 0 {
+		return fail("query returns no columns; use :exec or :execrows")
+	}
+	fields := make(map[string]bool)
+	var scan []string
+	for i, col := range cols {
+		if col.Name == "*" || strings.HasSuffix(col.Name, ".*") {
+			return fail("cannot expand %s; list the columns or add their tables to the schema", col.Name)
+		}
+		field := uniqueField(fields, goName(col.Name), i+1)
+		q.Columns = append(q.Columns, goField{Field: field, Type: use(goType(col.Type, col.Nullable)), JSON: col.Name})
+		scan = append(scan, "&i."+field)
+	}
+	q.Scan = strings.Join(scan, ", ")
+	return q, nil
+}
+
+// param is a query parameter with its PostgreSQL type in Type.
+type param struct {
+	goField
+	nullable bool
+}
+
+// queryParams returns the parameters of def in argument order: one per $n
+// position, or one per ? placeholder. Each is named after the column it is
+// bound to, or after the LIMIT or OFFSET it counts.
+func queryParams(def queryDef, schemaMap map[string][]analysis.ColumnSchema) ([]param, error) {
+	inferred := analysis.InferParameterTypes(def.Query, schemaMap)
+	if len(inferred) == 0 {
+		return nil, nil
+	}
+
+	// groups holds the occurrence indices of each parameter in argument order.
+	var groups [][]int
+	byPosition := make(map[int][]int)
+	maxPosition := 0
+	for i, p := range inferred {
+		if p.Marker != "$" {
+			groups = append(groups, []int{i})
+			continue
+		}
+		byPosition[p.Position] = append(byPosition[p.Position], i)
+		maxPosition = max(maxPosition, p.Position)
+	}
+	if len(groups) > 0 && maxPosition > 0 {
+		return nil, fmt.Errorf("mixes $n and ? placeholders")
+	}
+	for pos := 1; pos <= maxPosition; pos++ {
+		group, ok := byPosition[pos]
+		if !ok {
+			return nil, fmt.Errorf("parameter $%d is never used", pos)
+		}
+		groups = append(groups, group)
+	}
+
+	out := make([]param, 0, len(groups))
+	fields := make(map[string]bool)
+	for n, group := range groups {
+		first := inferred[group[0]]
+		name := ""
+		for _, i := range group {
+			if name = paramName(inferred[i]); name != "" {
+				break
+			}
+		}
+		field := goName(name)
+		if name == "" {
+			field = "Arg" + strconv.Itoa(n+1)
+		}
+		field = uniqueField(fields, field, n+1)
+		out = append(out, param{goField: goField{Field: field, Type: first.InferredType}, nullable: first.Nullable})
+	}
+	return out, nil
+}
+
+// uniqueField returns field, or when fields already has it, field followed by
+// the first number from n up that makes it unique, and adds the result to
+// fields.
+func uniqueField(fields map[string]bool, field string, n int) string {
+	name := field
+	for ; fields[name]; n++ {
+		name = field + strconv.Itoa(n)
+	}
+	fields[name] = true
+	return name
+}
+
+// paramName names a placeholder occurrence after its column or count clause,
+// or returns "" when it has neither.
+func paramName(p postgresparser.Parameter) string {
+	switch {
+	case p.Column != "":
+		return p.Column
+	case p.Clause == "LIMIT" || p.Clause == "OFFSET":
+		return strings.ToLower(p.Clause)
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser/catalog"
+)
+
+const testMigration = `
+CREATE TABLE users (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    email text,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE TABLE orders (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users (id),
+    total numeric(10,2) NOT NULL,
+    status text
+);`
+
+const testQueries = `-- name: GetUser :one
+SELECT id, name, email FROM users WHERE id = $1;
+
+-- name: ListOrders :many
+SELECT o.id, o.total, u.email
+FROM orders o JOIN users u ON u.id = o.user_id
+WHERE o.status = $1
+LIMIT $2;
+
+-- name: CreateUser :one
+INSERT INTO users (name, email) VALUES ($1, $2) RETURNING id, created_at;
+
+-- name: DeleteOrders :execrows
+DELETE FROM orders WHERE user_id = $1;
+
+-- name: CountUsers :one
+SELECT count(*) AS n FROM users WHERE created_at BETWEEN $1 AND $2 AND $3::text IS NOT NULL AND lower(email) ILIKE $4;
+`
+
+// compact collapses whitespace so gofmt alignment does not affect assertions.
+func compact(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// testCatalog replays testMigration into a new catalog.
+func testCatalog(t *testing.T) *catalog.Catalog {
+	t.Helper()
+	cat := catalog.New()
+	require.NoError(t, cat.ApplySQL(testMigration))
+	return cat
+}
+
+func TestGenerate(t *testing.T) {
+	defs, err := parseQueries("queries.sql", testQueries)
+	require.NoError(t, err)
+	require.Len(t, defs, 5)
+	assert.Equal(t, "GetUser", defs[0].Name)
+	assert.Equal(t, kindOne, defs[0].Kind)
+	assert.Equal(t, "queries.sql:1", defs[0].Pos)
+	assert.Equal(t, "SELECT id, name, email FROM users WHERE id = $1", defs[0].SQL)
+	assert.Equal(t, "queries.sql:4", defs[1].Pos)
+
+	code, err := generate("db", defs, testCatalog(t).ColumnSchemas())
+	require.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "queries.go", code, parser.ParseComments)
+	require.NoError(t, err, string(code))
+
+	out := compact(string(code))
+	for _, want := range []string{
+		"package db",
+		`import ( "context" "database/sql" "time" )`,
+		"type GetUserParams struct { ID int64 }",
+		"type GetUserRow struct { ID int64 `json:\"id\"` Name string `json:\"name\"` Email sql.NullString `json:\"email\"` }",
+		"func (q *Queries) GetUser(ctx context.Context, arg GetUserParams) (GetUserRow, error) {",
+		"row := q.db.QueryRowContext(ctx, getUserSQL, arg.ID)",
+		"type ListOrdersParams struct { Status string Limit int64 }",
+		"type ListOrdersRow struct { ID int64 `json:\"id\"` Total string `json:\"total\"` Email sql.NullString `json:\"email\"` }",
+		"func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]ListOrdersRow, error) {",
+		"type CreateUserParams struct { Name string Email sql.NullString }",
+		"type CreateUserRow struct { ID int64 `json:\"id\"` CreatedAt time.Time `json:\"created_at\"` }",
+		"func (q *Queries) DeleteOrders(ctx context.Context, arg DeleteOrdersParams) (int64, error) {",
+		"type DeleteOrdersParams struct { UserID int64 }",
+		"type CountUsersParams struct { CreatedAt time.Time CreatedAt2 time.Time Arg3 string Arg4 any }",
+	} {
+		assert.Contains(t, out, want)
+	}
+}
+
+func TestGenerate_UniqueFields(t *testing.T) {
+	src := "-- name: Pairs :many\n" +
+		"SELECT u.id, o.id, o.user_id AS id_2 FROM users u JOIN orders o ON o.user_id = u.id WHERE u.id = $1 AND o.id = $2;"
+	defs, err := parseQueries("queries.sql", src)
+	require.NoError(t, err)
+	code, err := generate("db", defs, testCatalog(t).ColumnSchemas())
+	require.NoError(t, err)
+
+	out := compact(string(code))
+	assert.Contains(t, out, "type PairsParams struct { ID int64 ID2 int64 }")
+	assert.Contains(t, out, "type PairsRow struct { ID int64 `json:\"id\"` ID2 int64 `json:\"id\"` ID23 int64 `json:\"id_2\"` }")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	schemaMap := testCatalog(t).ColumnSchemas()
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "missing annotation",
+			src:  "-- name: A :one\nSELECT 1;\nSELECT 2;",
+			want: `queries.sql: statement 2 has no "-- name:" annotation`,
+		},
+		{
+			name: "unknown kind",
+			src:  "-- name: A :first\nSELECT 1;",
+			want: `queries.sql:1: A: unknown kind ":first"`,
+		},
+		{
+			name: "duplicate name",
+			src:  "-- name: A :exec\nDELETE FROM users;\n-- name: A :exec\nDELETE FROM orders;",
+			want: "queries.sql:3: query A is already defined at queries.sql:1",
+		},
+		{
+			name: "schema validation",
+			src:  "-- name: A :many\nSELECT nickname FROM users;",
+			want: `queries.sql:1: A: UNKNOWN_COLUMN: column "nickname" does not exist`,
+		},
+		{
+			name: "no result columns",
+			src:  "-- name: A :one\nDELETE FROM users WHERE id = $1;",
+			want: "queries.sql:1: A: query returns no columns; use :exec or :execrows",
+		},
+		{
+			name: "parameter gap",
+			src:  "-- name: A :exec\nDELETE FROM users WHERE id = $2;",
+			want: "queries.sql:1: A: parameter $1 is never used",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs, err := parseQueries("queries.sql", tt.src)
+			if err == nil {
+				_, err = generate("db", defs, schemaMap)
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestGoTypeAndName(t *testing.T) {
+	tests := []struct {
+		pgType   string
+		nullable bool
+		want     string
+	}{
+		{"bigint", false, "int64"},
+		{"int4", true, "sql.NullInt32"},
+		{"numeric(10,2)", false, "string"},
+		{"TIMESTAMP(3) WITH TIME ZONE", false, "time.Time"},
+		{"date", true, "sql.NullTime"},
+		{"jsonb", true, "json.RawMessage"},
+		{"text[]", false, "any"},
+		{"", true, "any"},
+	}
+	for _, tt := range tests {
+		got, _ := goType(tt.pgType, tt.nullable)
+		assert.Equal(t, tt.want, got, tt.pgType)
+	}
+
+	assert.Equal(t, "UserID", goName("user_id"))
+	assert.Equal(t, "APIURL", goName("api_url"))
+	assert.Equal(t, "Column", goName("?column?"))
+	assert.Equal(t, "ÉtatCommande", goName("état_commande"))
+	assert.Equal(t, "C2fa", goName("2fa"))
+	assert.Equal(t, "getUserSQL", unexported("GetUser")+"SQL")
+}
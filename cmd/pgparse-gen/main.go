@@ -0,0 +1,129 @@
+// Command pgparse-gen generates typed Go functions for annotated SQL queries.
+//
+// Usage:
+//
+//	pgparse-gen -schema migrations -queries queries [-package db] [-out db/queries.go]
+//
+// The schema is built by replaying the migration files in name order into a
+// catalog. Each query file holds statements preceded by a name annotation:
+//
+//	-- name: GetUser :one
+//	SELECT id, name, email FROM users WHERE id = $1;
+//
+// The kind after the name selects the generated signature: :one returns a
+// single row struct, :many a slice of them, :exec only an error, and
+// :execrows the number of affected rows. Parameter and column types are
+// inferred from the schema; queries that fail validation against it are
+// reported and no code is written.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser/catalog"
+)
+
+func main() {
+	schema := flag.String("schema", "", "comma-separated migration files or directories, applied in name order")
+	queries := flag.String("queries", "", "comma-separated annotated query files or directories")
+	pkg := flag.String("package", "db", "package name of the generated file")
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+
+	if *schema == "" || *queries == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*schema, *queries, *pkg, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "pgparse-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// run loads the schema and queries and writes the generated code.
+func run(schemaPaths, queryPaths, pkg, out string) error {
+	cat, err := loadCatalog(schemaPaths)
+	if err != nil {
+		return err
+	}
+	files, err := sqlFiles(queryPaths)
+	if err != nil {
+		return err
+	}
+	var defs []queryDef
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		fileDefs, err := parseQueries(file, string(src))
+		if err != nil {
+			return err
+		}
+		defs = append(defs, fileDefs...)
+	}
+
+	code, err := generate(pkg, defs, cat.ColumnSchemas())
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0o644)
+}
+
+// loadCatalog replays the migration files into a new catalog.
+func loadCatalog(paths string) (*catalog.Catalog, error) {
+	files, err := sqlFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	cat := catalog.New()
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := cat.ApplySQL(string(src)); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return cat, nil
+}
+
+// sqlFiles expands a comma-separated list of files and directories into
+// files, listing the .sql files of each directory in name order.
+func sqlFiles(paths string) ([]string, error) {
+	var files []string
+	for _, path := range strings.Split(paths, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.sql"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql files in %q", paths)
+	}
+	return files, nil
+}
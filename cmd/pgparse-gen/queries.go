@@ -0,0 +1,118 @@
+// queries.go splits annotated query files into named, parsed statements.
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// queryKind selects the signature generated for a query.
+type queryKind string
+
+const (
+	kindOne      queryKind = ":one"
+	kindMany     queryKind = ":many"
+	kindExec     queryKind = ":exec"
+	kindExecRows queryKind = ":execrows"
+)
+
+// queryDef is one annotated statement of a query file.
+type queryDef struct {
+	Name string
+	Kind queryKind
+	// SQL is the statement as written, without its annotation and
+	// terminating semicolon.
+	SQL string
+	// Pos is the "file:line" of the annotation, used in error messages.
+	Pos   string
+	Query *postgresparser.ParsedQuery
+}
+
+var (
+	// annotationPattern matches "-- name: GetUser :one".
+	annotationPattern = regexp.MustCompile(`^--\s*name:\s*(\S+)\s*(\S*)\s*$`)
+	goIdentPattern    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// parseQueries parses the statements of a query file, with the expression
+// trees InferResultColumns needs, and pairs each with the name annotation that
+// precedes it. Every statement must be annotated.
+func parseQueries(file, src string) ([]queryDef, error) {
+	batch, err := postgresparser.ParseSQLAllWithOptions(src, postgresparser.ParseOptions{IncludeExpressionTrees: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for _, stmt := range batch.Statements {
+		if len(stmt.Warnings) > 0 {
+			return nil, fmt.Errorf("%s: statement %d: %s", file, stmt.Index, stmt.Warnings[0].Message)
+		}
+		if stmt.Query == nil {
+			return nil, fmt.Errorf("%s: statement %d could not be parsed", file, stmt.Index)
+		}
+	}
+	tokens, err := postgresparser.Tokenize(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	var defs []queryDef
+	named := make(map[int]bool)
+	for _, tok := range tokens {
+		if tok.Kind != postgresparser.TokenComment {
+			continue
+		}
+		m := annotationPattern.FindStringSubmatch(strings.TrimSpace(tok.Text))
+		if m == nil {
+			continue
+		}
+		pos := fmt.Sprintf("%s:%d", file, 1+strings.Count(src[:tok.Start], "\n"))
+		if !goIdentPattern.MatchString(m[1]) {
+			return nil, fmt.Errorf("%s: query name %q is not a Go identifier", pos, m[1])
+		}
+		kind := queryKind(m[2])
+		switch kind {
+		case kindOne, kindMany, kindExec, kindExecRows:
+		default:
+			return nil, fmt.Errorf("%s: %s: unknown kind %q (want :one, :many, :exec, or :execrows)", pos, m[1], m[2])
+		}
+		if tok.Statement < 1 || tok.Statement > len(batch.Statements) {
+			return nil, fmt.Errorf("%s: %s: annotation is not followed by a statement", pos, m[1])
+		}
+		if named[tok.Statement] {
+			return nil, fmt.Errorf("%s: %s: statement already has a name annotation", pos, m[1])
+		}
+		named[tok.Statement] = true
+
+		def := queryDef{Name: m[1], Kind: kind, Pos: pos, Query: batch.Statements[tok.Statement-1].Query}
+		def.SQL = statementText(src, tokens, tok.Statement)
+		defs = append(defs, def)
+	}
+	for _, stmt := range batch.Statements {
+		if !named[stmt.Index] {
+			return nil, fmt.Errorf("%s: statement %d has no \"-- name:\" annotation", file, stmt.Index)
+		}
+	}
+	return defs, nil
+}
+
+// statementText returns the source text of statement index, from its first
+// token to its last before the terminating semicolon.
+func statementText(src string, tokens []postgresparser.Token, index int) string {
+	var words []postgresparser.Token
+	for _, tok := range tokens {
+		if tok.Statement != index || tok.Kind == postgresparser.TokenWhitespace || tok.Kind == postgresparser.TokenComment {
+			continue
+		}
+		words = append(words, tok)
+	}
+	if n := len(words); n > 0 && words[n-1].Text == ";" {
+		words = words[:n-1]
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	return src[words[0].Start:words[len(words)-1].End]
+}
@@ -0,0 +1,95 @@
+// types.go maps PostgreSQL types to Go types and SQL names to Go identifiers.
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// initialisms are name parts written in upper case, following Go style.
+var initialisms = map[string]struct{}{
+	"api": {}, "html": {}, "http": {}, "id": {}, "ip": {}, "json": {}, "sql": {}, "uri": {}, "url": {}, "uuid": {},
+}
+
+// goType returns the Go type used for a column or parameter of PostgreSQL
+// type pgType, and the package it needs imported, if any. Nullable values use
+// the database/sql Null types. Arrays and unknown types map to any; numeric
+// maps to string to keep its precision.
+func goType(pgType string, nullable bool) (string, string) {
+	typ := strings.Join(strings.Fields(strings.ToLower(pgType)), " ")
+	if typ == "" || strings.HasSuffix(typ, "[]") {
+		return "any", ""
+	}
+	if i := strings.Index(typ, "("); i >= 0 {
+		rest := ""
+		if j := strings.Index(typ[i:], ")"); j >= 0 {
+			rest = typ[i+j+1:]
+		}
+		typ = strings.TrimSpace(typ[:i] + rest)
+	}
+	typ = strings.TrimPrefix(typ, "pg_catalog.")
+
+	pick := func(plain, null string) (string, string) {
+		if nullable {
+			return null, "database/sql"
+		}
+		return plain, ""
+	}
+	switch typ {
+	case "smallint", "int2", "smallserial", "serial2":
+		return pick("int16", "sql.NullInt16")
+	case "integer", "int", "int4", "serial", "serial4":
+		return pick("int32", "sql.NullInt32")
+	case "bigint", "int8", "bigserial", "serial8":
+		return pick("int64", "sql.NullInt64")
+	case "real", "float4", "double precision", "float8", "float":
+		return pick("float64", "sql.NullFloat64")
+	case "boolean", "bool":
+		return pick("bool", "sql.NullBool")
+	case "date", "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone":
+		if nullable {
+			return "sql.NullTime", "database/sql"
+		}
+		return "time.Time", "time"
+	case "json", "jsonb":
+		return "json.RawMessage", "encoding/json"
+	case "bytea":
+		return "[]byte", ""
+	}
+	return pick("string", "sql.NullString")
+}
+
+// goName converts a SQL name such as "user_id" into an exported Go
+// identifier such as "UserID".
+func goName(name string) string {
+	parts := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		if _, ok := initialisms[part]; ok {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		first, size := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(part[size:])
+	}
+	out := b.String()
+	if out == "" {
+		return "Column"
+	}
+	if first, _ := utf8.DecodeRuneInString(out); unicode.IsDigit(first) {
+		return "C" + out
+	}
+	return out
+}
+
+// unexported returns name with its first letter lowercased.
+func unexported(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
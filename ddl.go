@@ -23,9 +23,11 @@ func populateCreateTable(result *ParsedQuery, ctx gen.ICreatestmtContext, tokens
 	}
 
 	tableRaw := ""
+	var tableSpan *SourceSpan
 	if qualified := ctx.Qualified_name(0); qualified != nil {
 		if prc, ok := qualified.(antlr.ParserRuleContext); ok {
 			tableRaw = strings.TrimSpace(ctxText(tokens, prc))
+			tableSpan = spanFor(tokens, prc)
 		}
 	}
 	schema, tableName := splitQualifiedName(tableRaw)
@@ -35,6 +37,7 @@ func populateCreateTable(result *ParsedQuery, ctx gen.ICreatestmtContext, tokens
 			Name:   tableName,
 			Type:   TableTypeBase,
 			Raw:    tableRaw,
+			Span:   tableSpan,
 		})
 	}
 
@@ -74,6 +77,7 @@ func populateCreateTable(result *ParsedQuery, ctx gen.ICreatestmtContext, tokens
 			action.Columns = append(action.Columns, col.Name)
 			action.ColumnDetails = append(action.ColumnDetails, col)
 		}
+		action.Constraints = extractCreateTableConstraints(tableElems, tokens)
 	}
 
 	result.DDLActions = append(result.DDLActions, action)
@@ -157,7 +161,9 @@ func normalizeCreateTableColumnName(name string) string {
 	return strings.ToLower(ident.TrimQuotes(trimmed))
 }
 
-// populateDropStmt handles DROP TABLE, DROP INDEX, and DROP INDEX CONCURRENTLY.
+// populateDropStmt handles DROP TABLE, DROP INDEX, DROP INDEX CONCURRENTLY,
+// DROP SEQUENCE, DROP TYPE, DROP DOMAIN, DROP TRIGGER, DROP EXTENSION, and
+// DROP SCHEMA.
 func populateDropStmt(result *ParsedQuery, ctx gen.IDropstmtContext, tokens antlr.TokenStream) error {
 	if ctx == nil {
 		return fmt.Errorf("drop statement: %w", ErrNilContext)
@@ -199,6 +205,49 @@ func populateDropStmt(result *ParsedQuery, ctx gen.IDropstmtContext, tokens antl
 		return nil
 	}
 
+	// DROP TYPE / DROP DOMAIN type_name_list.
+	if list := ctx.Type_name_list(); list != nil {
+		var names []string
+		for _, typ := range list.AllTypename() {
+			names = append(names, normalizeSpace(contextText(tokens, typ)))
+		}
+		if ctx.DOMAIN_P() != nil {
+			appendDropActions(result, DDLDropDomain, "DOMAIN", names, flags)
+		} else {
+			appendDropActions(result, DDLDropType, "TYPE", names, flags)
+		}
+		return nil
+	}
+
+	// DROP EXTENSION / DROP SCHEMA name_list.
+	if dropType := ctx.Drop_type_name(); dropType != nil && ctx.Name_list() != nil {
+		var names []string
+		for _, name := range ctx.Name_list().AllName() {
+			names = append(names, contextText(tokens, name))
+		}
+		switch {
+		case dropType.EXTENSION() != nil:
+			appendDropActions(result, DDLDropExtension, "EXTENSION", names, flags)
+		case dropType.SCHEMA() != nil:
+			appendDropActions(result, DDLDropSchema, "SCHEMA", names, flags)
+		}
+		return nil
+	}
+
+	// DROP TRIGGER name ON table.
+	if onType := ctx.Object_type_name_on_any_name(); onType != nil && onType.TRIGGER() != nil && ctx.Any_name() != nil {
+		action := DDLAction{
+			Type:       DDLDropTrigger,
+			ObjectType: "TRIGGER",
+			ObjectName: contextText(tokens, ctx.Name()),
+			Flags:      flags,
+			Trigger:    &DDLTrigger{},
+		}
+		appendTriggerTable(result, &action, ctx.Any_name(), tokens)
+		result.DDLActions = append(result.DDLActions, action)
+		return nil
+	}
+
 	// DROP object_type_any_name ... (TABLE, INDEX, VIEW, etc.)
 	if objType := ctx.Object_type_any_name(); objType != nil {
 		if nameList := ctx.Any_name_list_(); nameList != nil {
@@ -222,6 +271,7 @@ func populateDropStmt(result *ParsedQuery, ctx gen.IDropstmtContext, tokens antl
 						Name:   tableName,
 						Type:   TableTypeBase,
 						Raw:    nameText,
+						Span:   spanFor(tokens, prc),
 					})
 				}
 			case objType.INDEX() != nil:
@@ -239,6 +289,12 @@ func populateDropStmt(result *ParsedQuery, ctx gen.IDropstmtContext, tokens antl
 						Flags:      copyFlags(flags),
 					})
 				}
+			case objType.SEQUENCE() != nil:
+				var names []string
+				for _, anyName := range nameList.AllAny_name() {
+					names = append(names, contextText(tokens, anyName))
+				}
+				appendDropActions(result, DDLDropSequence, "SEQUENCE", names, flags)
 			}
 		}
 	}
@@ -265,15 +321,27 @@ func populateAlterTable(result *ParsedQuery, ctx gen.IAltertablestmtContext, tok
 			Name:   tableName,
 			Type:   TableTypeBase,
 			Raw:    tableRaw,
+			Span:   spanFor(tokens, rel),
 		})
 	}
 
+	ifExists := ctx.IF_P() != nil && ctx.EXISTS() != nil
+	if part := ctx.Partition_cmd(); part != nil {
+		first := len(result.DDLActions)
+		populateAlterTablePartition(result, part, tokens, tableName, tableSchema)
+		setActionSpans(result.DDLActions, spanFor(tokens, part))
+		markTableIfExists(result.DDLActions[first:], ifExists)
+		return nil
+	}
 	cmds := ctx.Alter_table_cmds()
 	if cmds == nil {
 		return nil
 	}
 	for _, cmd := range cmds.AllAlter_table_cmd() {
+		first := len(result.DDLActions)
 		populateAlterTableCmd(result, cmd, tokens, tableName, tableSchema)
+		setActionSpans(result.DDLActions[first:], spanFor(tokens, cmd))
+		markTableIfExists(result.DDLActions[first:], ifExists)
 	}
 	return nil
 }
@@ -294,10 +362,41 @@ func populateAlterTableCmd(result *ParsedQuery, cmd gen.IAlter_table_cmdContext,
 	}
 
 	switch {
+	case cmd.ALTER() != nil:
+		// Checked before DROP: ALTER COLUMN ... DROP NOT NULL also has a DROP token.
+		colName := extractAlterCmdColumnName(cmd, tokens)
+		if colName == "" {
+			return
+		}
+		alterFlags := copyFlags(flags)
+		alterFlags = append(alterFlags, "ALTER_COLUMN")
+		result.DDLActions = append(result.DDLActions, DDLAction{
+			Type:       DDLAlterTable,
+			ObjectName: tableName,
+			Schema:     tableSchema,
+			Columns:    []string{colName},
+			Flags:      alterFlags,
+			AlterOp:    extractAlterColumnOp(cmd, colName, tokens),
+		})
+
 	case cmd.DROP() != nil:
 		// DROP COLUMN vs DROP CONSTRAINT
 		if cmd.CONSTRAINT() != nil {
-			// Skip constraint drops — not column-level DDL.
+			dropFlags := append(copyFlags(flags), "DROP_CONSTRAINT")
+			if cmd.IF_P() != nil && cmd.EXISTS() != nil {
+				dropFlags = append(dropFlags, "IF_EXISTS")
+			}
+			name := ""
+			if cmd.Name() != nil {
+				name = contextText(tokens, cmd.Name())
+			}
+			result.DDLActions = append(result.DDLActions, DDLAction{
+				Type:        DDLAlterTable,
+				ObjectName:  tableName,
+				Schema:      tableSchema,
+				Flags:       dropFlags,
+				Constraints: []DDLConstraint{{Name: name}},
+			})
 			return
 		}
 		colName := extractAlterCmdColumnName(cmd, tokens)
@@ -316,12 +415,24 @@ func populateAlterTableCmd(result *ParsedQuery, cmd gen.IAlter_table_cmdContext,
 		})
 
 	case cmd.ADD_P() != nil:
-		if cmd.CONSTRAINT() != nil || cmd.Tableconstraint() != nil {
-			// Skip ADD CONSTRAINT.
+		if tc := cmd.Tableconstraint(); tc != nil {
+			constraint, ok := extractTableConstraint(tc, tokens)
+			if !ok {
+				return
+			}
+			result.DDLActions = append(result.DDLActions, DDLAction{
+				Type:        DDLAlterTable,
+				ObjectName:  tableName,
+				Schema:      tableSchema,
+				Columns:     append([]string(nil), constraint.Columns...),
+				Flags:       append(copyFlags(flags), "ADD_CONSTRAINT"),
+				Constraints: []DDLConstraint{constraint},
+			})
 			return
 		}
 		colName := ""
-		if colDef := cmd.ColumnDef(); colDef != nil {
+		colDef := cmd.ColumnDef()
+		if colDef != nil {
 			if colDef.Colid() != nil {
 				if prc, ok := colDef.Colid().(antlr.ParserRuleContext); ok {
 					colName = strings.TrimSpace(ctxText(tokens, prc))
@@ -336,31 +447,46 @@ func populateAlterTableCmd(result *ParsedQuery, cmd gen.IAlter_table_cmdContext,
 		if cmd.IF_P() != nil && cmd.NOT() != nil && cmd.EXISTS() != nil {
 			addFlags = append(addFlags, "IF_NOT_EXISTS")
 		}
+		result.DDLActions = append(result.DDLActions, DDLAction{
+			Type:          DDLAlterTable,
+			ObjectName:    tableName,
+			Schema:        tableSchema,
+			Columns:       []string{colName},
+			ColumnDetails: []DDLColumn{extractCreateTableColumn(colDef, tokens, nil)},
+			Flags:         addFlags,
+			Constraints:   extractColumnDefConstraints(colDef, tokens),
+		})
+
+	case cmd.OWNER() != nil && cmd.Rolespec() != nil:
 		result.DDLActions = append(result.DDLActions, DDLAction{
 			Type:       DDLAlterTable,
 			ObjectName: tableName,
 			Schema:     tableSchema,
-			Columns:    []string{colName},
-			Flags:      addFlags,
+			Flags:      flags,
+			AlterOp:    &DDLAlterOp{Type: DDLAlterOpOwnerTo, Owner: contextText(tokens, cmd.Rolespec())},
 		})
 
-	case cmd.ALTER() != nil:
-		colName := extractAlterCmdColumnName(cmd, tokens)
-		if colName == "" {
-			return
-		}
-		alterFlags := copyFlags(flags)
-		alterFlags = append(alterFlags, "ALTER_COLUMN")
+	case cmd.VALIDATE() != nil && cmd.Name() != nil:
 		result.DDLActions = append(result.DDLActions, DDLAction{
 			Type:       DDLAlterTable,
 			ObjectName: tableName,
 			Schema:     tableSchema,
-			Columns:    []string{colName},
-			Flags:      alterFlags,
+			Flags:      flags,
+			AlterOp:    &DDLAlterOp{Type: DDLAlterOpValidateConstraint, Constraint: contextText(tokens, cmd.Name())},
+		})
+
+	case cmd.TRIGGER() != nil:
+		op, triggerFlags := extractAlterTriggerOp(cmd, tokens)
+		result.DDLActions = append(result.DDLActions, DDLAction{
+			Type:       DDLAlterTable,
+			ObjectName: tableName,
+			Schema:     tableSchema,
+			Flags:      append(copyFlags(flags), triggerFlags...),
+			AlterOp:    op,
 		})
 
 	default:
-		// Other sub-commands (OWNER TO, SET, etc.) — generic ALTER_TABLE action.
+		// Other sub-commands (SET, RESET, CLUSTER ON, etc.) — generic ALTER_TABLE action.
 		result.DDLActions = append(result.DDLActions, DDLAction{
 			Type:       DDLAlterTable,
 			ObjectName: tableName,
@@ -425,6 +551,7 @@ func populateCreateIndex(result *ParsedQuery, ctx gen.IIndexstmtContext, tokens
 			Name:   tableName,
 			Type:   TableTypeBase,
 			Raw:    tableRaw,
+			Span:   spanFor(tokens, rel),
 		})
 	}
 
@@ -509,12 +636,14 @@ func populateTruncate(result *ParsedQuery, ctx gen.ITruncatestmtContext, tokens
 				ObjectName: name,
 				Schema:     schema,
 				Flags:      copyFlags(flags),
+				Span:       spanFor(tokens, rel),
 			})
 			result.Tables = append(result.Tables, TableRef{
 				Schema: schema,
 				Name:   name,
 				Type:   TableTypeBase,
 				Raw:    raw,
+				Span:   spanFor(tokens, rel),
 			})
 		}
 	}
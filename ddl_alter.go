@@ -0,0 +1,202 @@
+// ddl_alter.go extracts detailed ALTER TABLE sub-commands: column type, default
+// and nullability changes, renames, SET SCHEMA, OWNER TO, trigger toggles, and
+// partition attach/detach.
+package postgresparser
+
+import (
+	"fmt"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// extractAlterColumnOp returns the detailed operation for ALTER COLUMN
+// sub-commands that change the column type, default, or nullability. Other
+// ALTER COLUMN forms (STATISTICS, STORAGE, IDENTITY, ...) return nil.
+func extractAlterColumnOp(cmd gen.IAlter_table_cmdContext, colName string, tokens antlr.TokenStream) *DDLAlterOp {
+	switch {
+	case cmd.TYPE_P() != nil && cmd.Typename() != nil:
+		op := &DDLAlterOp{
+			Type:     DDLAlterOpSetDataType,
+			Column:   colName,
+			DataType: normalizeSpace(contextText(tokens, cmd.Typename())),
+		}
+		if collate := cmd.Collate_clause_(); collate != nil && collate.Any_name() != nil {
+			op.Collation = contextText(tokens, collate.Any_name())
+		}
+		if using := cmd.Alter_using(); using != nil && using.A_expr() != nil {
+			op.Using = contextText(tokens, using.A_expr())
+		}
+		return op
+	case cmd.Alter_column_default() != nil:
+		def := cmd.Alter_column_default()
+		if def.SET() != nil && def.A_expr() != nil {
+			return &DDLAlterOp{
+				Type:    DDLAlterOpSetDefault,
+				Column:  colName,
+				Default: contextText(tokens, def.A_expr()),
+			}
+		}
+		return &DDLAlterOp{Type: DDLAlterOpDropDefault, Column: colName}
+	case cmd.NOT() != nil && cmd.NULL_P() != nil:
+		if cmd.SET() != nil {
+			return &DDLAlterOp{Type: DDLAlterOpSetNotNull, Column: colName}
+		}
+		return &DDLAlterOp{Type: DDLAlterOpDropNotNull, Column: colName}
+	}
+	return nil
+}
+
+// extractAlterTriggerOp returns the operation for ENABLE/DISABLE TRIGGER
+// sub-commands, or nil for any other sub-command. ENABLE ALWAYS and ENABLE
+// REPLICA are reported as ALWAYS / REPLICA flags.
+func extractAlterTriggerOp(cmd gen.IAlter_table_cmdContext, tokens antlr.TokenStream) (*DDLAlterOp, []string) {
+	if cmd.TRIGGER() == nil {
+		return nil, nil
+	}
+	op := &DDLAlterOp{Type: DDLAlterOpEnableTrigger}
+	if cmd.DISABLE_P() != nil {
+		op.Type = DDLAlterOpDisableTrigger
+	}
+	switch {
+	case cmd.Name() != nil:
+		op.Trigger = contextText(tokens, cmd.Name())
+	case cmd.ALL() != nil:
+		op.Trigger = "ALL"
+	case cmd.USER() != nil:
+		op.Trigger = "USER"
+	}
+
+	var flags []string
+	if cmd.ALWAYS() != nil {
+		flags = append(flags, "ALWAYS")
+	} else if cmd.REPLICA() != nil {
+		flags = append(flags, "REPLICA")
+	}
+	return op, flags
+}
+
+// populateAlterTablePartition handles ALTER TABLE ... ATTACH PARTITION name
+// bound and ALTER TABLE ... DETACH PARTITION name.
+func populateAlterTablePartition(result *ParsedQuery, cmd gen.IPartition_cmdContext, tokens antlr.TokenStream, tableName, tableSchema string) {
+	if cmd == nil {
+		return
+	}
+	op := &DDLAlterOp{Type: DDLAlterOpAttachPartition}
+	if cmd.DETACH() != nil {
+		op.Type = DDLAlterOpDetachPartition
+	}
+	if cmd.Qualified_name() != nil {
+		op.Partition = contextText(tokens, cmd.Qualified_name())
+	}
+	if bound := cmd.Partitionboundspec(); bound != nil {
+		op.PartitionBound = normalizeSpace(contextText(tokens, bound))
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLAlterTable,
+		ObjectName: tableName,
+		Schema:     tableSchema,
+		AlterOp:    op,
+	})
+}
+
+// markTableIfExists adds TABLE_IF_EXISTS to the actions of an ALTER TABLE
+// IF EXISTS statement. It is kept apart from IF_EXISTS, which belongs to
+// DROP COLUMN / DROP CONSTRAINT sub-commands.
+func markTableIfExists(actions []DDLAction, ifExists bool) {
+	if !ifExists {
+		return
+	}
+	for i := range actions {
+		actions[i].Flags = append(actions[i].Flags, "TABLE_IF_EXISTS")
+	}
+}
+
+// populateRenameTable handles ALTER TABLE ... RENAME TO, RENAME [COLUMN] ... TO,
+// and RENAME CONSTRAINT ... TO. Renames of other object kinds are ignored.
+func populateRenameTable(result *ParsedQuery, ctx gen.IRenamestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("rename statement: %w", ErrNilContext)
+	}
+	rel := ctx.Relation_expr()
+	if ctx.TABLE() == nil || rel == nil {
+		return nil
+	}
+	tableRaw, tableSchema, tableName := extractRelationExprNameParts(rel, tokens)
+	result.Tables = append(result.Tables, TableRef{
+		Schema: tableSchema,
+		Name:   tableName,
+		Type:   TableTypeBase,
+		Raw:    tableRaw,
+		Span:   spanFor(tokens, rel),
+	})
+
+	names := ctx.AllName()
+	action := DDLAction{
+		Type:       DDLAlterTable,
+		ObjectName: tableName,
+		Schema:     tableSchema,
+	}
+	switch {
+	case len(names) == 1:
+		action.AlterOp = &DDLAlterOp{
+			Type:    DDLAlterOpRenameTable,
+			OldName: tableName,
+			NewName: contextText(tokens, names[0]),
+		}
+	case len(names) == 2 && ctx.CONSTRAINT() != nil:
+		action.AlterOp = &DDLAlterOp{
+			Type:    DDLAlterOpRenameConstraint,
+			OldName: contextText(tokens, names[0]),
+			NewName: contextText(tokens, names[1]),
+		}
+	case len(names) == 2:
+		oldName := contextText(tokens, names[0])
+		action.Columns = []string{oldName}
+		action.AlterOp = &DDLAlterOp{
+			Type:    DDLAlterOpRenameColumn,
+			Column:  oldName,
+			OldName: oldName,
+			NewName: contextText(tokens, names[1]),
+		}
+	default:
+		return nil
+	}
+	result.DDLActions = append(result.DDLActions, action)
+	markTableIfExists(result.DDLActions[len(result.DDLActions)-1:], ctx.IF_P() != nil && ctx.EXISTS() != nil)
+	return nil
+}
+
+// populateAlterTableSetSchema handles ALTER TABLE ... SET SCHEMA name.
+// SET SCHEMA for other object kinds is ignored.
+func populateAlterTableSetSchema(result *ParsedQuery, ctx gen.IAlterobjectschemastmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("alter object schema statement: %w", ErrNilContext)
+	}
+	rel := ctx.Relation_expr()
+	if ctx.TABLE() == nil || rel == nil {
+		return nil
+	}
+	tableRaw, tableSchema, tableName := extractRelationExprNameParts(rel, tokens)
+	result.Tables = append(result.Tables, TableRef{
+		Schema: tableSchema,
+		Name:   tableName,
+		Type:   TableTypeBase,
+		Raw:    tableRaw,
+		Span:   spanFor(tokens, rel),
+	})
+
+	newSchema := ""
+	if names := ctx.AllName(); len(names) > 0 {
+		newSchema = contextText(tokens, names[len(names)-1])
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLAlterTable,
+		ObjectName: tableName,
+		Schema:     tableSchema,
+		AlterOp:    &DDLAlterOp{Type: DDLAlterOpSetSchema, NewSchema: newSchema},
+	})
+	markTableIfExists(result.DDLActions[len(result.DDLActions)-1:], ctx.IF_P() != nil && ctx.EXISTS() != nil)
+	return nil
+}
@@ -57,6 +57,7 @@ func populateCommentStmt(result *ParsedQuery, ctx gen.ICommentstmtContext, token
 				Name:   objectName,
 				Type:   TableTypeBase,
 				Raw:    rawName,
+				Span:   spanFor(tokens, ctx.Any_name()),
 			})
 		}
 
@@ -214,7 +215,7 @@ func extractCreateTableFieldCommentsByColumn(tableElems []gen.ITableelementConte
 	if len(tableElems) == 0 || tokens == nil {
 		return nil
 	}
-	tokenStream, ok := tokens.(*antlr.CommonTokenStream)
+	tokenStream, ok := commonTokenStream(tokens)
 	if !ok {
 		return nil
 	}
@@ -0,0 +1,221 @@
+// ddl_constraint.go extracts table and column constraints from CREATE TABLE and ALTER TABLE.
+package postgresparser
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// extractCreateTableConstraints collects column-level and table-level constraints
+// in declaration order from CREATE TABLE elements.
+func extractCreateTableConstraints(tableElems []gen.ITableelementContext, tokens antlr.TokenStream) []DDLConstraint {
+	var constraints []DDLConstraint
+	for _, tableElem := range tableElems {
+		if tableElem == nil {
+			continue
+		}
+		if colDef := tableElem.ColumnDef(); colDef != nil {
+			constraints = append(constraints, extractColumnDefConstraints(colDef, tokens)...)
+			continue
+		}
+		if tc := tableElem.Tableconstraint(); tc != nil {
+			if c, ok := extractTableConstraint(tc, tokens); ok {
+				constraints = append(constraints, c)
+			}
+		}
+	}
+	return constraints
+}
+
+// extractColumnDefConstraints converts inline column constraints (PRIMARY KEY,
+// UNIQUE, CHECK, REFERENCES) into DDLConstraint values scoped to that column.
+// NOT NULL, NULL, DEFAULT and GENERATED are reported through DDLColumn instead.
+func extractColumnDefConstraints(colDef gen.IColumnDefContext, tokens antlr.TokenStream) []DDLConstraint {
+	if colDef == nil || colDef.Colquallist() == nil {
+		return nil
+	}
+	colName := ""
+	if colid := colDef.Colid(); colid != nil {
+		colName = contextText(tokens, colid)
+	}
+
+	var constraints []DDLConstraint
+	for _, qual := range colDef.Colquallist().AllColconstraint() {
+		if qual == nil {
+			continue
+		}
+		// DEFERRABLE / INITIALLY ... attach to the constraint they follow.
+		if attr := qual.Constraintattr(); attr != nil {
+			if n := len(constraints); n > 0 {
+				constraints[n-1].Flags = append(constraints[n-1].Flags, constraintAttrFlag(attr))
+			}
+			continue
+		}
+		elem := qual.Colconstraintelem()
+		if elem == nil {
+			continue
+		}
+		c := DDLConstraint{Columns: []string{colName}}
+		if qual.Name() != nil {
+			c.Name = contextText(tokens, qual.Name())
+		}
+		switch {
+		case elem.PRIMARY() != nil && elem.KEY() != nil:
+			c.Type = DDLConstraintPrimaryKey
+		case elem.UNIQUE() != nil:
+			c.Type = DDLConstraintUnique
+		case elem.CHECK() != nil:
+			c.Type = DDLConstraintCheck
+			if elem.A_expr() != nil {
+				c.Expression = contextText(tokens, elem.A_expr())
+			}
+			if elem.No_inherit_() != nil {
+				c.Flags = append(c.Flags, "NO_INHERIT")
+			}
+		case elem.REFERENCES() != nil:
+			c.Type = DDLConstraintForeignKey
+			populateForeignKeyTarget(&c, elem.Qualified_name(), elem.Column_list_(), elem.Key_match(), elem.Key_actions(), tokens)
+		default:
+			continue
+		}
+		constraints = append(constraints, c)
+	}
+	return constraints
+}
+
+// extractTableConstraint converts a table-level constraint definition,
+// including an optional CONSTRAINT name, into a DDLConstraint.
+func extractTableConstraint(tc gen.ITableconstraintContext, tokens antlr.TokenStream) (DDLConstraint, bool) {
+	if tc == nil || tc.Constraintelem() == nil {
+		return DDLConstraint{}, false
+	}
+	elem := tc.Constraintelem()
+
+	var c DDLConstraint
+	if tc.Name() != nil {
+		c.Name = contextText(tokens, tc.Name())
+	}
+
+	switch {
+	case elem.CHECK() != nil:
+		c.Type = DDLConstraintCheck
+		if elem.A_expr() != nil {
+			c.Expression = contextText(tokens, elem.A_expr())
+		}
+	case elem.FOREIGN() != nil:
+		c.Type = DDLConstraintForeignKey
+		c.Columns = extractColumnlistNames(elem.Columnlist(), tokens)
+		populateForeignKeyTarget(&c, elem.Qualified_name(), elem.Column_list_(), elem.Key_match(), elem.Key_actions(), tokens)
+	case elem.PRIMARY() != nil || elem.UNIQUE() != nil:
+		c.Type = DDLConstraintUnique
+		if elem.PRIMARY() != nil {
+			c.Type = DDLConstraintPrimaryKey
+		}
+		c.Columns = extractColumnlistNames(elem.Columnlist(), tokens)
+		if existing := elem.Existingindex(); existing != nil && existing.Name() != nil {
+			c.UsingIndex = contextText(tokens, existing.Name())
+		}
+	case elem.EXCLUDE() != nil:
+		c.Type = DDLConstraintExclude
+		if amc := elem.Access_method_clause(); amc != nil && amc.Name() != nil {
+			c.IndexType = contextText(tokens, amc.Name())
+		}
+		if list := elem.Exclusionconstraintlist(); list != nil {
+			for _, ex := range list.AllExclusionconstraintelem() {
+				if ex == nil || ex.Index_elem() == nil {
+					continue
+				}
+				c.Columns = append(c.Columns, contextText(tokens, ex.Index_elem()))
+				op := ""
+				if ex.Any_operator() != nil {
+					op = contextText(tokens, ex.Any_operator())
+				}
+				c.Operators = append(c.Operators, op)
+			}
+		}
+		if where := elem.Exclusionwhereclause(); where != nil && where.A_expr() != nil {
+			c.Expression = contextText(tokens, where.A_expr())
+		}
+	default:
+		return DDLConstraint{}, false
+	}
+
+	c.Flags = append(c.Flags, constraintAttributeSpecFlags(elem.Constraintattributespec())...)
+	return c, true
+}
+
+// populateForeignKeyTarget fills the referenced table, columns, MATCH type and
+// ON DELETE / ON UPDATE actions of a foreign key constraint.
+func populateForeignKeyTarget(c *DDLConstraint, ref gen.IQualified_nameContext, refCols gen.IColumn_list_Context,
+	match gen.IKey_matchContext, actions gen.IKey_actionsContext, tokens antlr.TokenStream) {
+	if ref != nil {
+		c.ReferencedSchema, c.ReferencedTable = splitQualifiedName(contextText(tokens, ref))
+	}
+	if refCols != nil {
+		c.ReferencedColumns = extractColumnlistNames(refCols.Columnlist(), tokens)
+	}
+	if match != nil {
+		switch {
+		case match.FULL() != nil:
+			c.Match = "FULL"
+		case match.PARTIAL() != nil:
+			c.Match = "PARTIAL"
+		case match.SIMPLE() != nil:
+			c.Match = "SIMPLE"
+		}
+	}
+	if actions != nil {
+		if del := actions.Key_delete(); del != nil && del.Key_action() != nil {
+			c.OnDelete = strings.ToUpper(normalizeSpace(contextText(tokens, del.Key_action())))
+		}
+		if upd := actions.Key_update(); upd != nil && upd.Key_action() != nil {
+			c.OnUpdate = strings.ToUpper(normalizeSpace(contextText(tokens, upd.Key_action())))
+		}
+	}
+}
+
+// constraintAttributeSpecFlags maps DEFERRABLE / INITIALLY / NOT VALID / NO INHERIT
+// attributes of a table constraint to flag strings.
+func constraintAttributeSpecFlags(spec gen.IConstraintattributespecContext) []string {
+	if spec == nil {
+		return nil
+	}
+	var flags []string
+	for _, elem := range spec.AllConstraintattributeElem() {
+		if elem == nil {
+			continue
+		}
+		switch {
+		case elem.VALID() != nil:
+			flags = append(flags, "NOT_VALID")
+		case elem.INHERIT() != nil:
+			flags = append(flags, "NO_INHERIT")
+		case elem.NOT() != nil:
+			flags = append(flags, "NOT_DEFERRABLE")
+		case elem.DEFERRABLE() != nil:
+			flags = append(flags, "DEFERRABLE")
+		case elem.DEFERRED() != nil:
+			flags = append(flags, "INITIALLY_DEFERRED")
+		case elem.IMMEDIATE() != nil:
+			flags = append(flags, "INITIALLY_IMMEDIATE")
+		}
+	}
+	return flags
+}
+
+// constraintAttrFlag maps a column-level constraint attribute to a flag string.
+func constraintAttrFlag(attr gen.IConstraintattrContext) string {
+	switch {
+	case attr.NOT() != nil:
+		return "NOT_DEFERRABLE"
+	case attr.DEFERRABLE() != nil:
+		return "DEFERRABLE"
+	case attr.DEFERRED() != nil:
+		return "INITIALLY_DEFERRED"
+	default:
+		return "INITIALLY_IMMEDIATE"
+	}
+}
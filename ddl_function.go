@@ -0,0 +1,280 @@
+// ddl_function.go implements DDL population logic for CREATE FUNCTION,
+// CREATE PROCEDURE, DROP FUNCTION/PROCEDURE/ROUTINE, CALL, and DO.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateCreateFunction handles CREATE [OR REPLACE] FUNCTION/PROCEDURE metadata
+// extraction. The signature, options, and decoded body are stored in
+// DDLAction.Function; LANGUAGE sql and plpgsql bodies are parsed into
+// Function.Statements and their tables are added to result.Tables.
+func populateCreateFunction(result *ParsedQuery, ctx gen.ICreatefunctionstmtContext, tokens antlr.TokenStream, opts ParseOptions) error {
+	if ctx == nil {
+		return fmt.Errorf("create function statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateFunction,
+		ObjectType: "FUNCTION",
+		Function:   &DDLFunction{},
+	}
+	if ctx.PROCEDURE() != nil {
+		action.Type = DDLCreateProcedure
+		action.ObjectType = "PROCEDURE"
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(contextText(tokens, ctx.Func_name()))
+	if ctx.Or_replace_() != nil {
+		action.Flags = append(action.Flags, "OR_REPLACE")
+	}
+
+	fn := action.Function
+	if args := ctx.Func_args_with_defaults(); args != nil && args.Func_args_with_defaults_list() != nil {
+		for _, argCtx := range args.Func_args_with_defaults_list().AllFunc_arg_with_default() {
+			arg := extractFunctionArg(argCtx.Func_arg(), tokens)
+			if argCtx.A_expr() != nil {
+				arg.Default = contextText(tokens, argCtx.A_expr())
+			}
+			fn.Args = append(fn.Args, arg)
+		}
+	}
+
+	switch {
+	case ctx.Func_return() != nil:
+		fn.Returns = normalizeSpace(contextText(tokens, ctx.Func_return()))
+		fn.ReturnsSet = strings.HasPrefix(strings.ToUpper(fn.Returns), "SETOF ")
+	case ctx.Table_func_column_list() != nil:
+		var columns []string
+		for _, col := range ctx.Table_func_column_list().AllTable_func_column() {
+			columns = append(columns, contextText(tokens, col.Param_name())+" "+normalizeSpace(contextText(tokens, col.Func_type())))
+		}
+		fn.Returns = "TABLE (" + strings.Join(columns, ", ") + ")"
+		fn.ReturnsSet = true
+	}
+
+	options := ctx.Createfunc_opt_list()
+	if options == nil {
+		return fmt.Errorf("create function options: %w", ErrNilContext)
+	}
+	for _, item := range options.AllCreatefunc_opt_item() {
+		switch {
+		case item.AS() != nil && item.Func_as() != nil:
+			if bodies := item.Func_as().AllSconst(); len(bodies) > 0 {
+				fn.Body = decodeCommentStringLiteral(contextText(tokens, bodies[0]))
+			}
+		case item.LANGUAGE() != nil:
+			fn.Language = strings.ToLower(decodeCommentStringLiteral(contextText(tokens, item.Nonreservedword_or_sconst())))
+		case item.WINDOW() != nil:
+			action.Flags = append(action.Flags, "WINDOW")
+		case item.Common_func_opt_item() != nil:
+			applyFunctionOption(&action, item.Common_func_opt_item(), tokens)
+		}
+	}
+
+	parseRoutineBody(result, fn, opts)
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateDo handles DO [LANGUAGE lang] 'code'. The block is reported as a DO
+// action whose Function holds the language and body, parsed like a function
+// body.
+func populateDo(result *ParsedQuery, ctx gen.IDostmtContext, tokens antlr.TokenStream, opts ParseOptions) error {
+	if ctx == nil || ctx.Dostmt_opt_list() == nil {
+		return fmt.Errorf("do statement: %w", ErrNilContext)
+	}
+
+	fn := &DDLFunction{Language: "plpgsql"}
+	for _, item := range ctx.Dostmt_opt_list().AllDostmt_opt_item() {
+		if item.LANGUAGE() != nil {
+			fn.Language = strings.ToLower(decodeCommentStringLiteral(contextText(tokens, item.Nonreservedword_or_sconst())))
+		} else if item.Sconst() != nil {
+			fn.Body = decodeCommentStringLiteral(contextText(tokens, item.Sconst()))
+		}
+	}
+
+	parseRoutineBody(result, fn, opts)
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:     DDLDo,
+		Function: fn,
+	})
+	return nil
+}
+
+// parseRoutineBody parses a LANGUAGE sql or plpgsql body into fn.Statements
+// and adds their tables to result.Tables. Bodies that cannot be parsed, and
+// other languages, are left as text. A body is never a psql script, so
+// opts.PSQL is ignored.
+func parseRoutineBody(result *ParsedQuery, fn *DDLFunction, opts ParseOptions) {
+	opts.PSQL = nil
+	if strings.TrimSpace(fn.Body) == "" {
+		return
+	}
+	switch fn.Language {
+	case "sql":
+		batch, err := ParseSQLAllWithOptions(fn.Body, opts)
+		if err != nil {
+			return
+		}
+		for _, stmt := range batch.Statements {
+			if stmt.Query == nil || len(stmt.Warnings) > 0 {
+				continue
+			}
+			fn.Statements = append(fn.Statements, stmt.Query)
+			result.Tables = append(result.Tables, stmt.Query.Tables...)
+		}
+	case "plpgsql":
+		body, err := ParsePLpgSQLWithOptions(fn.Body, opts)
+		if err != nil {
+			return
+		}
+		fn.PLpgSQL = body
+		for _, stmt := range body.Statements {
+			if stmt.Query != nil {
+				fn.Statements = append(fn.Statements, stmt.Query)
+			}
+		}
+		result.Tables = append(result.Tables, body.Tables...)
+	}
+}
+
+// applyFunctionOption records one common routine option. Volatility and
+// PARALLEL are stored on the function; STRICT, SECURITY_DEFINER, and LEAKPROOF
+// become flags.
+func applyFunctionOption(action *DDLAction, item gen.ICommon_func_opt_itemContext, tokens antlr.TokenStream) {
+	switch {
+	case item.IMMUTABLE() != nil:
+		action.Function.Volatility = "IMMUTABLE"
+	case item.STABLE() != nil:
+		action.Function.Volatility = "STABLE"
+	case item.VOLATILE() != nil:
+		action.Function.Volatility = "VOLATILE"
+	case item.STRICT_P() != nil, item.RETURNS() != nil:
+		action.Flags = append(action.Flags, "STRICT")
+	case item.SECURITY() != nil && item.DEFINER() != nil:
+		action.Flags = append(action.Flags, "SECURITY_DEFINER")
+	case item.LEAKPROOF() != nil && item.NOT() == nil:
+		action.Flags = append(action.Flags, "LEAKPROOF")
+	case item.PARALLEL() != nil && item.Colid() != nil:
+		action.Function.Parallel = strings.ToUpper(contextText(tokens, item.Colid()))
+	}
+}
+
+// extractFunctionArg splits a routine argument into its mode, name, and type.
+func extractFunctionArg(ctx gen.IFunc_argContext, tokens antlr.TokenStream) DDLFunctionArg {
+	var arg DDLFunctionArg
+	if ctx == nil {
+		return arg
+	}
+	if class := ctx.Arg_class(); class != nil {
+		switch {
+		case class.INOUT() != nil, class.IN_P() != nil && class.OUT_P() != nil:
+			arg.Mode = "INOUT"
+		case class.OUT_P() != nil:
+			arg.Mode = "OUT"
+		case class.VARIADIC() != nil:
+			arg.Mode = "VARIADIC"
+		default:
+			arg.Mode = "IN"
+		}
+	}
+	if ctx.Param_name() != nil {
+		arg.Name = contextText(tokens, ctx.Param_name())
+	}
+	arg.Type = normalizeSpace(contextText(tokens, ctx.Func_type()))
+	return arg
+}
+
+// populateDropFunction handles DROP FUNCTION/PROCEDURE/ROUTINE [IF EXISTS],
+// emitting one action per dropped routine.
+func populateDropFunction(result *ParsedQuery, ctx gen.IRemovefuncstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop function statement: %w", ErrNilContext)
+	}
+
+	objectType := "FUNCTION"
+	switch {
+	case ctx.PROCEDURE() != nil:
+		objectType = "PROCEDURE"
+	case ctx.ROUTINE() != nil:
+		objectType = "ROUTINE"
+	}
+
+	var flags []string
+	if ctx.IF_P() != nil && ctx.EXISTS() != nil {
+		flags = append(flags, "IF_EXISTS")
+	}
+	if db := ctx.Drop_behavior_(); db != nil {
+		if db.CASCADE() != nil {
+			flags = append(flags, "CASCADE")
+		} else if db.RESTRICT() != nil {
+			flags = append(flags, "RESTRICT")
+		}
+	}
+
+	list := ctx.Function_with_argtypes_list()
+	if list == nil {
+		return fmt.Errorf("drop function list: %w", ErrNilContext)
+	}
+	for _, routine := range list.AllFunction_with_argtypes() {
+		action := DDLAction{
+			Type:       DDLDropFunction,
+			ObjectType: objectType,
+			Flags:      copyFlags(flags),
+			Function:   &DDLFunction{},
+		}
+		if routine.Func_name() == nil {
+			action.Schema, action.ObjectName = splitQualifiedName(contextText(tokens, routine))
+			result.DDLActions = append(result.DDLActions, action)
+			continue
+		}
+		action.Schema, action.ObjectName = splitQualifiedName(contextText(tokens, routine.Func_name()))
+		if args := routine.Func_args(); args != nil && args.Func_args_list() != nil {
+			for _, arg := range args.Func_args_list().AllFunc_arg() {
+				action.Function.Args = append(action.Function.Args, extractFunctionArg(arg, tokens))
+			}
+		}
+		result.DDLActions = append(result.DDLActions, action)
+	}
+	return nil
+}
+
+// populateCall handles CALL procedure(args). Each argument expression is
+// recorded in Function.Args, with its name for named notation.
+func populateCall(result *ParsedQuery, ctx gen.ICallstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil || ctx.Func_application() == nil {
+		return fmt.Errorf("call statement: %w", ErrNilContext)
+	}
+	app := ctx.Func_application()
+
+	action := DDLAction{
+		Type:       DDLCall,
+		ObjectType: "PROCEDURE",
+		Function:   &DDLFunction{},
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(contextText(tokens, app.Func_name()))
+
+	var exprs []gen.IFunc_arg_exprContext
+	if list := app.Func_arg_list(); list != nil {
+		exprs = append(exprs, list.AllFunc_arg_expr()...)
+	}
+	if app.Func_arg_expr() != nil {
+		exprs = append(exprs, app.Func_arg_expr())
+	}
+	for _, expr := range exprs {
+		arg := DDLFunctionArg{Value: contextText(tokens, expr.A_expr())}
+		if expr.Param_name() != nil {
+			arg.Name = contextText(tokens, expr.Param_name())
+		}
+		action.Function.Args = append(action.Function.Args, arg)
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
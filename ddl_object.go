@@ -0,0 +1,460 @@
+// ddl_object.go implements DDL population logic for sequences, types, domains,
+// triggers, extensions, and schemas.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateCreateSequence handles CREATE [TEMP | UNLOGGED] SEQUENCE [IF NOT EXISTS].
+func populateCreateSequence(result *ParsedQuery, ctx gen.ICreateseqstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create sequence statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateSequence,
+		ObjectType: "SEQUENCE",
+		Sequence:   &DDLSequence{},
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(contextText(tokens, ctx.Qualified_name()))
+	if temp := ctx.Opttemp(); temp != nil {
+		if temp.UNLOGGED() != nil {
+			action.Flags = append(action.Flags, "UNLOGGED")
+		} else {
+			action.Flags = append(action.Flags, "TEMPORARY")
+		}
+	}
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		action.Flags = append(action.Flags, "IF_NOT_EXISTS")
+	}
+	if opts := ctx.Optseqoptlist(); opts != nil && opts.Seqoptlist() != nil {
+		applySequenceOptions(&action, opts.Seqoptlist(), tokens)
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateAlterSequence handles ALTER SEQUENCE [IF EXISTS] name options. Other
+// ALTER SEQUENCE forms (RENAME, OWNER TO, SET SCHEMA) are not reported.
+func populateAlterSequence(result *ParsedQuery, ctx gen.IAlterseqstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("alter sequence statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLAlterSequence,
+		ObjectType: "SEQUENCE",
+		Sequence:   &DDLSequence{},
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(contextText(tokens, ctx.Qualified_name()))
+	if ctx.IF_P() != nil {
+		action.Flags = append(action.Flags, "IF_EXISTS")
+	}
+	if ctx.Seqoptlist() != nil {
+		applySequenceOptions(&action, ctx.Seqoptlist(), tokens)
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// applySequenceOptions records sequence options on action.Sequence. Negated
+// options and a bare RESTART become flags.
+func applySequenceOptions(action *DDLAction, list gen.ISeqoptlistContext, tokens antlr.TokenStream) {
+	seq := action.Sequence
+	for _, elem := range list.AllSeqoptelem() {
+		value := ""
+		if elem.Numericonly() != nil {
+			value = contextText(tokens, elem.Numericonly())
+		}
+		switch {
+		case elem.NO() != nil:
+			switch {
+			case elem.MINVALUE() != nil:
+				action.Flags = append(action.Flags, "NO_MINVALUE")
+			case elem.MAXVALUE() != nil:
+				action.Flags = append(action.Flags, "NO_MAXVALUE")
+			default:
+				action.Flags = append(action.Flags, "NO_CYCLE")
+			}
+		case elem.AS() != nil:
+			seq.DataType = normalizeSpace(contextText(tokens, elem.Simpletypename()))
+		case elem.CACHE() != nil:
+			seq.Cache = value
+		case elem.CYCLE() != nil:
+			action.Flags = append(action.Flags, "CYCLE")
+		case elem.INCREMENT() != nil:
+			seq.Increment = value
+		case elem.MINVALUE() != nil:
+			seq.MinValue = value
+		case elem.MAXVALUE() != nil:
+			seq.MaxValue = value
+		case elem.OWNED() != nil:
+			seq.OwnedBy = contextText(tokens, elem.Any_name())
+		case elem.START() != nil:
+			seq.Start = value
+		case elem.RESTART() != nil:
+			if value == "" {
+				action.Flags = append(action.Flags, "RESTART")
+			}
+			seq.Restart = value
+		}
+	}
+}
+
+// populateCreateType handles CREATE TYPE for enum, composite, range, base, and
+// shell types.
+func populateCreateType(result *ParsedQuery, ctx gen.IDefinestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil || ctx.TYPE_P() == nil {
+		return fmt.Errorf("create type statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateType,
+		ObjectType: "TYPE",
+		TypeDef:    &DDLType{},
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(contextText(tokens, ctx.Any_name(0)))
+
+	def := action.TypeDef
+	switch {
+	case ctx.ENUM_P() != nil:
+		def.Kind = "ENUM"
+		if labels := ctx.Enum_val_list_(); labels != nil && labels.Enum_val_list() != nil {
+			for _, label := range labels.Enum_val_list().AllSconst() {
+				def.Labels = append(def.Labels, decodeCommentStringLiteral(contextText(tokens, label)))
+			}
+		}
+	case ctx.AS() != nil && ctx.RANGE() != nil:
+		def.Kind = "RANGE"
+		def.Definition = normalizeSpace(contextText(tokens, ctx.Definition()))
+	case ctx.AS() != nil:
+		def.Kind = "COMPOSITE"
+		if elems := ctx.Opttablefuncelementlist(); elems != nil && elems.Tablefuncelementlist() != nil {
+			for _, elem := range elems.Tablefuncelementlist().AllTablefuncelement() {
+				def.Attributes = append(def.Attributes, DDLColumn{
+					Name:     contextText(tokens, elem.Colid()),
+					Type:     normalizeSpace(contextText(tokens, elem.Typename())),
+					Nullable: true,
+				})
+			}
+		}
+	case ctx.Definition() != nil:
+		def.Kind = "BASE"
+		def.Definition = normalizeSpace(contextText(tokens, ctx.Definition()))
+	default:
+		def.Kind = "SHELL"
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateAlterEnum handles ALTER TYPE ... ADD VALUE and ALTER TYPE ... RENAME
+// VALUE on enum types.
+func populateAlterEnum(result *ParsedQuery, ctx gen.IAlterenumstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("alter type statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLAlterType,
+		ObjectType: "TYPE",
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(contextText(tokens, ctx.Any_name()))
+
+	var labels []string
+	for _, label := range ctx.AllSconst() {
+		labels = append(labels, decodeCommentStringLiteral(contextText(tokens, label)))
+	}
+	if len(labels) == 0 {
+		return fmt.Errorf("alter type value: %w", ErrNilContext)
+	}
+
+	if ctx.RENAME() != nil && len(labels) == 2 {
+		action.AlterOp = &DDLAlterOp{
+			Type:    DDLAlterOpRenameValue,
+			OldName: labels[0],
+			NewName: labels[1],
+		}
+	} else {
+		if ctx.If_not_exists_() != nil {
+			action.Flags = append(action.Flags, "IF_NOT_EXISTS")
+		}
+		action.TypeDef = &DDLType{Kind: "ENUM", Labels: labels[:1]}
+		if len(labels) == 2 {
+			if ctx.BEFORE() != nil {
+				action.TypeDef.Before = labels[1]
+			} else {
+				action.TypeDef.After = labels[1]
+			}
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateCreateDomain handles CREATE DOMAIN. CHECK constraints are reported in
+// DDLAction.Constraints; NOT NULL, DEFAULT, and COLLATE are stored on TypeDef.
+func populateCreateDomain(result *ParsedQuery, ctx gen.ICreatedomainstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create domain statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateDomain,
+		ObjectType: "DOMAIN",
+		TypeDef: &DDLType{
+			Kind:     "DOMAIN",
+			BaseType: normalizeSpace(contextText(tokens, ctx.Typename())),
+		},
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(contextText(tokens, ctx.Any_name()))
+
+	if quals := ctx.Colquallist(); quals != nil {
+		for _, qual := range quals.AllColconstraint() {
+			if qual.COLLATE() != nil {
+				action.TypeDef.Collation = contextText(tokens, qual.Any_name())
+				continue
+			}
+			if attr := qual.Constraintattr(); attr != nil {
+				if n := len(action.Constraints); n > 0 {
+					action.Constraints[n-1].Flags = append(action.Constraints[n-1].Flags, constraintAttrFlag(attr))
+				}
+				continue
+			}
+			elem := qual.Colconstraintelem()
+			if elem == nil {
+				continue
+			}
+			switch {
+			case elem.NOT() != nil && elem.NULL_P() != nil:
+				action.TypeDef.NotNull = true
+			case elem.DEFAULT() != nil:
+				action.TypeDef.Default = contextText(tokens, elem.B_expr())
+			case elem.CHECK() != nil:
+				c := DDLConstraint{
+					Type:       DDLConstraintCheck,
+					Expression: contextText(tokens, elem.A_expr()),
+				}
+				if qual.Name() != nil {
+					c.Name = contextText(tokens, qual.Name())
+				}
+				if elem.No_inherit_() != nil {
+					c.Flags = append(c.Flags, "NO_INHERIT")
+				}
+				action.Constraints = append(action.Constraints, c)
+			}
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateCreateTrigger handles CREATE [CONSTRAINT] TRIGGER. The trigger is
+// reported under the schema of its table, which is also added to
+// result.Tables.
+func populateCreateTrigger(result *ParsedQuery, ctx gen.ICreatetrigstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil || ctx.Qualified_name() == nil {
+		return fmt.Errorf("create trigger statement: %w", ErrNilContext)
+	}
+
+	trigger := &DDLTrigger{ForEach: "STATEMENT"}
+	action := DDLAction{
+		Type:       DDLCreateTrigger,
+		ObjectType: "TRIGGER",
+		ObjectName: contextText(tokens, ctx.Name()),
+		Trigger:    trigger,
+	}
+	appendTriggerTable(result, &action, ctx.Qualified_name(), tokens)
+
+	if ctx.CONSTRAINT() != nil {
+		action.Flags = append(action.Flags, "CONSTRAINT")
+		action.Flags = append(action.Flags, constraintAttributeSpecFlags(ctx.Constraintattributespec())...)
+		trigger.Timing = "AFTER"
+		trigger.ForEach = "ROW"
+	}
+	if timing := ctx.Triggeractiontime(); timing != nil {
+		switch {
+		case timing.BEFORE() != nil:
+			trigger.Timing = "BEFORE"
+		case timing.AFTER() != nil:
+			trigger.Timing = "AFTER"
+		default:
+			trigger.Timing = "INSTEAD OF"
+		}
+	}
+	if events := ctx.Triggerevents(); events != nil {
+		for _, event := range events.AllTriggeroneevent() {
+			switch {
+			case event.INSERT() != nil:
+				trigger.Events = append(trigger.Events, "INSERT")
+			case event.UPDATE() != nil:
+				trigger.Events = append(trigger.Events, "UPDATE")
+				if event.Columnlist() != nil {
+					action.Columns = append(action.Columns, extractColumnlistNames(event.Columnlist(), tokens)...)
+				}
+			case event.DELETE_P() != nil:
+				trigger.Events = append(trigger.Events, "DELETE")
+			case event.TRUNCATE() != nil:
+				trigger.Events = append(trigger.Events, "TRUNCATE")
+			}
+		}
+	}
+	if spec := ctx.Triggerforspec(); spec != nil && spec.Triggerfortype() != nil && spec.Triggerfortype().ROW() != nil {
+		trigger.ForEach = "ROW"
+	}
+	if when := ctx.Triggerwhen(); when != nil && when.A_expr() != nil {
+		trigger.When = contextText(tokens, when.A_expr())
+	}
+
+	trigger.Function = contextText(tokens, ctx.Func_name())
+	if args := ctx.Triggerfuncargs(); args != nil {
+		for _, arg := range args.AllTriggerfuncarg() {
+			trigger.FunctionArgs = append(trigger.FunctionArgs, contextText(tokens, arg))
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// appendTriggerTable records the table a trigger is defined on, both on the
+// action and in result.Tables.
+func appendTriggerTable(result *ParsedQuery, action *DDLAction, table antlr.ParserRuleContext, tokens antlr.TokenStream) {
+	raw := strings.TrimSpace(ctxText(tokens, table))
+	schema, name := splitQualifiedName(raw)
+	action.Schema = schema
+	action.Trigger.Table = name
+	action.Trigger.TableSchema = schema
+	result.Tables = append(result.Tables, TableRef{
+		Schema: schema,
+		Name:   name,
+		Type:   TableTypeBase,
+		Raw:    raw,
+		Span:   spanFor(tokens, table),
+	})
+}
+
+// populateCreateExtension handles CREATE EXTENSION [IF NOT EXISTS]. The SCHEMA
+// option is reported in DDLAction.Schema.
+func populateCreateExtension(result *ParsedQuery, ctx gen.ICreateextensionstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create extension statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateExtension,
+		ObjectType: "EXTENSION",
+		ObjectName: contextText(tokens, ctx.Name()),
+	}
+	if ctx.IF_P() != nil {
+		action.Flags = append(action.Flags, "IF_NOT_EXISTS")
+	}
+	if opts := ctx.Create_extension_opt_list(); opts != nil {
+		for _, item := range opts.AllCreate_extension_opt_item() {
+			switch {
+			case item.SCHEMA() != nil:
+				action.Schema = contextText(tokens, item.Name())
+			case item.VERSION_P() != nil:
+				action.Version = decodeCommentStringLiteral(contextText(tokens, item.Nonreservedword_or_sconst()))
+			case item.CASCADE() != nil:
+				action.Flags = append(action.Flags, "CASCADE")
+			}
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateCreateSchema handles CREATE SCHEMA [IF NOT EXISTS]. Without an
+// explicit name the schema is named after the AUTHORIZATION role. Tables,
+// indexes, sequences, triggers, and views created inside the statement are
+// reported as their own actions in the new schema.
+func populateCreateSchema(result *ParsedQuery, ctx gen.ICreateschemastmtContext, tokens antlr.TokenStream, opts ParseOptions) error {
+	if ctx == nil {
+		return fmt.Errorf("create schema statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateSchema,
+		ObjectType: "SCHEMA",
+	}
+	if ctx.IF_P() != nil {
+		action.Flags = append(action.Flags, "IF_NOT_EXISTS")
+	}
+	if ctx.Rolespec() != nil {
+		action.Owner = contextText(tokens, ctx.Rolespec())
+		action.ObjectName = action.Owner
+	}
+	switch {
+	case ctx.Colid() != nil:
+		action.ObjectName = contextText(tokens, ctx.Colid())
+	case ctx.Optschemaname() != nil && ctx.Optschemaname().Colid() != nil:
+		action.ObjectName = contextText(tokens, ctx.Optschemaname().Colid())
+	}
+	result.DDLActions = append(result.DDLActions, action)
+
+	elems := ctx.Optschemaeltlist()
+	if elems == nil {
+		return nil
+	}
+	for _, elem := range elems.AllSchema_stmt() {
+		firstAction, firstTable := len(result.DDLActions), len(result.Tables)
+		var err error
+		switch {
+		case elem.Createstmt() != nil:
+			err = populateCreateTable(result, elem.Createstmt(), tokens, opts)
+		case elem.Indexstmt() != nil:
+			err = populateCreateIndex(result, elem.Indexstmt(), tokens)
+		case elem.Createseqstmt() != nil:
+			err = populateCreateSequence(result, elem.Createseqstmt(), tokens)
+		case elem.Createtrigstmt() != nil:
+			err = populateCreateTrigger(result, elem.Createtrigstmt(), tokens)
+		case elem.Viewstmt() != nil:
+			err = populateCreateView(result, elem.Viewstmt(), tokens)
+		}
+		if err != nil {
+			return err
+		}
+		for i := firstAction; i < len(result.DDLActions); i++ {
+			if result.DDLActions[i].Schema == "" {
+				result.DDLActions[i].Schema = action.ObjectName
+			}
+			if trigger := result.DDLActions[i].Trigger; trigger != nil && trigger.TableSchema == "" {
+				trigger.TableSchema = action.ObjectName
+			}
+		}
+		for i := firstTable; i < len(result.Tables); i++ {
+			if result.Tables[i].Schema == "" {
+				result.Tables[i].Schema = action.ObjectName
+			}
+		}
+		setActionSpans(result.DDLActions[firstAction:], spanFor(tokens, elem))
+	}
+	return nil
+}
+
+// appendDropActions emits one action per dropped object name.
+func appendDropActions(result *ParsedQuery, actionType DDLActionType, objectType string, names []string, flags []string) {
+	for _, name := range names {
+		schema, objectName := splitQualifiedName(strings.TrimSpace(name))
+		result.DDLActions = append(result.DDLActions, DDLAction{
+			Type:       actionType,
+			ObjectType: objectType,
+			ObjectName: objectName,
+			Schema:     schema,
+			Flags:      copyFlags(flags),
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// ddl_view.go implements DDL population logic for CREATE VIEW, CREATE MATERIALIZED VIEW,
+// REFRESH MATERIALIZED VIEW, and CREATE TABLE AS.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateCreateView handles CREATE [OR REPLACE] [TEMP] [RECURSIVE] VIEW metadata
+// extraction. The defining SELECT is parsed into DDLAction.Query.
+func populateCreateView(result *ParsedQuery, ctx gen.IViewstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create view statement: %w", ErrNilContext)
+	}
+
+	var flags []string
+	if ctx.OR() != nil && ctx.REPLACE() != nil {
+		flags = append(flags, "OR_REPLACE")
+	}
+	if temp := ctx.Opttemp(); temp != nil && (temp.TEMPORARY() != nil || temp.TEMP() != nil) {
+		flags = append(flags, "TEMPORARY")
+	}
+	if ctx.RECURSIVE() != nil {
+		flags = append(flags, "RECURSIVE")
+	}
+	if check := ctx.Check_option_(); check != nil {
+		if check.LOCAL() != nil {
+			flags = append(flags, "LOCAL_CHECK_OPTION")
+		} else {
+			flags = append(flags, "CASCADED_CHECK_OPTION")
+		}
+	}
+
+	var columns []string
+	if list := ctx.Column_list_(); list != nil {
+		columns = extractColumnlistNames(list.Columnlist(), tokens)
+	} else if ctx.Columnlist() != nil {
+		columns = extractColumnlistNames(ctx.Columnlist(), tokens)
+	}
+
+	return appendViewAction(result, DDLCreateView, "VIEW", ctx.Qualified_name(), columns, flags, ctx.Selectstmt(), tokens)
+}
+
+// populateCreateMaterializedView handles CREATE MATERIALIZED VIEW metadata extraction.
+// The defining SELECT is parsed into DDLAction.Query.
+func populateCreateMaterializedView(result *ParsedQuery, ctx gen.ICreatematviewstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create materialized view statement: %w", ErrNilContext)
+	}
+
+	var flags []string
+	if ctx.Optnolog() != nil {
+		flags = append(flags, "UNLOGGED")
+	}
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		flags = append(flags, "IF_NOT_EXISTS")
+	}
+	if withData := ctx.With_data_(); withData != nil && withData.NO() != nil {
+		flags = append(flags, "WITH_NO_DATA")
+	}
+
+	target := ctx.Create_mv_target()
+	if target == nil {
+		return fmt.Errorf("create materialized view target: %w", ErrNilContext)
+	}
+	var columns []string
+	if list := target.Column_list_(); list != nil {
+		columns = extractColumnlistNames(list.Columnlist(), tokens)
+	}
+
+	return appendViewAction(result, DDLCreateMaterializedView, "MATERIALIZED VIEW", target.Qualified_name(), columns, flags, ctx.Selectstmt(), tokens)
+}
+
+// populateRefreshMaterializedView handles REFRESH MATERIALIZED VIEW [CONCURRENTLY] name [WITH [NO] DATA].
+func populateRefreshMaterializedView(result *ParsedQuery, ctx gen.IRefreshmatviewstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("refresh materialized view statement: %w", ErrNilContext)
+	}
+
+	var flags []string
+	if ctx.Concurrently_() != nil {
+		flags = append(flags, "CONCURRENTLY")
+	}
+	if withData := ctx.With_data_(); withData != nil && withData.NO() != nil {
+		flags = append(flags, "WITH_NO_DATA")
+	}
+
+	return appendViewAction(result, DDLRefreshMaterializedView, "MATERIALIZED VIEW", ctx.Qualified_name(), nil, flags, nil, tokens)
+}
+
+// populateCreateTableAs handles CREATE TABLE ... AS SELECT metadata extraction.
+// The defining SELECT is parsed into DDLAction.Query.
+func populateCreateTableAs(result *ParsedQuery, ctx gen.ICreateasstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create table as statement: %w", ErrNilContext)
+	}
+
+	var flags []string
+	if temp := ctx.Opttemp(); temp != nil {
+		if temp.UNLOGGED() != nil {
+			flags = append(flags, "UNLOGGED")
+		} else {
+			flags = append(flags, "TEMPORARY")
+		}
+	}
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		flags = append(flags, "IF_NOT_EXISTS")
+	}
+	if withData := ctx.With_data_(); withData != nil && withData.NO() != nil {
+		flags = append(flags, "WITH_NO_DATA")
+	}
+
+	target := ctx.Create_as_target()
+	if target == nil {
+		return fmt.Errorf("create table as target: %w", ErrNilContext)
+	}
+	var columns []string
+	if list := target.Column_list_(); list != nil {
+		columns = extractColumnlistNames(list.Columnlist(), tokens)
+	}
+
+	return appendViewAction(result, DDLCreateTableAs, "TABLE", target.Qualified_name(), columns, flags, ctx.Selectstmt(), tokens)
+}
+
+// appendViewAction records the view (or CREATE TABLE AS table) relation and its DDL action. When selectCtx
+// is present, the defining query is parsed into a nested ParsedQuery.
+func appendViewAction(result *ParsedQuery, actionType DDLActionType, objectType string, name gen.IQualified_nameContext,
+	columns, flags []string, selectCtx gen.ISelectstmtContext, tokens antlr.TokenStream) error {
+	viewRaw := ""
+	if name != nil {
+		viewRaw = contextText(tokens, name)
+	}
+	schema, viewName := splitQualifiedName(viewRaw)
+	if viewRaw != "" {
+		result.Tables = append(result.Tables, TableRef{
+			Schema: schema,
+			Name:   viewName,
+			Type:   TableTypeBase,
+			Raw:    viewRaw,
+			Span:   spanFor(tokens, name),
+		})
+	}
+
+	action := DDLAction{
+		Type:       actionType,
+		ObjectName: viewName,
+		ObjectType: objectType,
+		Schema:     schema,
+		Columns:    columns,
+		Flags:      flags,
+	}
+	if selectCtx != nil {
+		query, err := buildViewQuery(selectCtx, tokens)
+		if err != nil {
+			return fmt.Errorf("%s %q defining query: %w", strings.ToLower(objectType), viewRaw, err)
+		}
+		action.Query = query
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// buildViewQuery parses a view's defining SELECT into a standalone ParsedQuery.
+func buildViewQuery(selectCtx gen.ISelectstmtContext, tokens antlr.TokenStream) (*ParsedQuery, error) {
+	rawSQL := ""
+	if prc, ok := selectCtx.(antlr.ParserRuleContext); ok {
+		rawSQL = strings.TrimSpace(ctxText(tokens, prc))
+	}
+	query := &ParsedQuery{
+		Command:        QueryCommandSelect,
+		RawSQL:         rawSQL,
+		DerivedColumns: make(map[string]string),
+	}
+	if err := populateSelect(query, selectCtx, tokens); err != nil {
+		return nil, err
+	}
+	query.Parameters = extractParameters(rawSQL)
+	setParameterSpans(query.Parameters, selectCtx, tokens)
+	return query, nil
+}
+
+// extractColumnlistNames returns the column names of a parenthesized column list.
+func extractColumnlistNames(list gen.IColumnlistContext, tokens antlr.TokenStream) []string {
+	if list == nil {
+		return nil
+	}
+	elems := list.AllColumnElem()
+	columns := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		if elem == nil {
+			continue
+		}
+		if name := contextText(tokens, elem); name != "" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
@@ -80,8 +80,10 @@ func recordSetTargetUsage(result *ParsedQuery, list gen.ISet_clause_listContext,
 			continue
 		}
 		expression := ""
+		var span *SourceSpan
 		if prc, ok := target.(antlr.ParserRuleContext); ok {
 			expression = strings.TrimSpace(ctxText(tokens, prc))
+			span = spanFor(tokens, prc)
 		}
 		result.ColumnUsage = append(result.ColumnUsage, ColumnUsage{
 			TableAlias: ref.TableAlias,
@@ -89,6 +91,7 @@ func recordSetTargetUsage(result *ParsedQuery, list gen.ISet_clause_listContext,
 			Expression: expression,
 			UsageType:  role,
 			Context:    strings.TrimSpace(ctxText(tokens, setCtx)),
+			Span:       span,
 		})
 	}
 }
@@ -104,10 +107,17 @@ func appendReturningClause(result *ParsedQuery, returning gen.IReturning_clauseC
 			result.Returning = append(result.Returning, text)
 		}
 	}
+	if list := returning.Target_list(); list != nil {
+		for _, item := range list.AllTarget_el() {
+			if sc, ok := targetColumn(item, tokens); ok {
+				result.ReturningItems = append(result.ReturningItems, sc)
+			}
+		}
+	}
 	findAndRecordUsage(result, returning, ColumnUsageTypeReturning, tokens)
 }
 
-// appendRelationOptAlias registers the main relation referenced by an UPDATE/DELETE.
+// appendRelationOptAlias registers the target relation of an UPDATE/DELETE.
 func appendRelationOptAlias(result *ParsedQuery, rel gen.IRelation_expr_opt_aliasContext, tokens antlr.TokenStream) {
 	if rel == nil {
 		return
@@ -132,6 +142,8 @@ func appendRelationOptAlias(result *ParsedQuery, rel gen.IRelation_expr_opt_alia
 		Alias:  alias,
 		Type:   TableTypeBase,
 		Raw:    nameText,
+		Span:   spanFor(tokens, rel.Relation_expr()),
+		Target: true,
 	})
 }
 
@@ -143,6 +155,14 @@ func appendWhereClause(result *ParsedQuery, whereCtx antlr.ParserRuleContext, to
 	text := strings.TrimSpace(ctxText(tokens, whereCtx))
 	if text != "" {
 		result.Where = append(result.Where, text)
+		if wantExprTrees(tokens) {
+			// WHERE CURRENT OF has no predicate and gets a nil tree.
+			var pred *Expr
+			if cond := firstChildOfType[*gen.A_exprContext](whereCtx); cond != nil {
+				pred = buildExpr(cond, tokens)
+			}
+			result.WhereExprs = append(result.WhereExprs, pred)
+		}
 	}
 	// Use the new comparison-aware extraction for DML WHERE clauses
 	findAndRecordComparisons(result, whereCtx, ColumnUsageTypeFilter, tokens)
@@ -43,6 +43,8 @@ func populateInsert(result *ParsedQuery, ctx gen.IInsertstmtContext, tokens antl
 				Alias:  alias,
 				Type:   TableTypeBase,
 				Raw:    nameText,
+				Span:   spanFor(tokens, qn),
+				Target: true,
 			}
 			result.Tables = append(result.Tables, tbl)
 		}
@@ -57,6 +59,7 @@ func populateInsert(result *ParsedQuery, ctx gen.IInsertstmtContext, tokens antl
 				result.Upsert = upsert
 				if len(upsert.SetClauses) > 0 {
 					result.SetClauses = append(result.SetClauses, upsert.SetClauses...)
+					result.SetClauseExprs = append(result.SetClauseExprs, buildSetClauseExprs(conflictSet.Set_clause_list(), tokens)...)
 				}
 			}
 		}
@@ -27,6 +27,7 @@ func populateUpdate(result *ParsedQuery, ctx gen.IUpdatestmtContext, tokens antl
 	appendRelationOptAlias(result, ctx.Relation_expr_opt_alias(), tokens)
 	if ctx.Set_clause_list() != nil {
 		result.SetClauses = append(result.SetClauses, extractSetClauses(ctx.Set_clause_list(), tokens)...)
+		result.SetClauseExprs = append(result.SetClauseExprs, buildSetClauseExprs(ctx.Set_clause_list(), tokens)...)
 		recordSetTargetUsage(result, ctx.Set_clause_list(), ColumnUsageTypeDMLSet, tokens)
 		findAndRecordUsage(result, ctx.Set_clause_list(), ColumnUsageTypeDMLSet, tokens)
 	}
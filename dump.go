@@ -0,0 +1,568 @@
+// dump.go reads plain-format pg_dump output as a stream of SQL statements,
+// psql meta-commands, and COPY data rows, without holding the whole dump in
+// memory.
+package postgresparser
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// DumpEntryKind identifies an entry read from a dump.
+type DumpEntryKind string
+
+const (
+	// DumpEntryStatement is a SQL statement terminated by a semicolon.
+	DumpEntryStatement DumpEntryKind = "STATEMENT"
+	// DumpEntryMetaCommand is a psql backslash command such as \connect.
+	DumpEntryMetaCommand DumpEntryKind = "META_COMMAND"
+)
+
+// MetaCommand is a psql backslash command.
+type MetaCommand struct {
+	Name string   // Command name without the backslash, e.g. "connect"
+	Args []string // Whitespace-separated arguments; quoted arguments are unquoted
+}
+
+// DumpEntry is one statement or meta-command of a dump.
+type DumpEntry struct {
+	Kind  DumpEntryKind
+	Index int // 1-based position of the entry in the dump
+	Line  int // 1-based line where the entry starts
+	// SQL is the statement text without its terminating semicolon, or the
+	// meta-command line as written.
+	SQL string
+	// Query and Warnings hold the parse result of a statement, as in
+	// StatementParseResult. Query is nil for meta-commands and for
+	// statements the parser does not support.
+	Query    *ParsedQuery
+	Warnings []ParseWarning
+	// MetaCommand is set for DumpEntryMetaCommand entries.
+	MetaCommand *MetaCommand
+	// CopyData is true for COPY ... FROM stdin statements. The data rows that
+	// follow can be read with DumpReader.ReadRow before the next call to
+	// DumpReader.Next, which otherwise skips them.
+	CopyData bool
+}
+
+// DumpReader splits a plain-format dump into entries. Statements end at a
+// semicolon outside quotes, comments, parentheses, and BEGIN ATOMIC bodies;
+// a line starting with a backslash between statements is a meta-command; and
+// the lines after COPY ... FROM stdin up to a "\." line are COPY data.
+//
+// Only the current statement and line are kept in memory.
+type DumpReader struct {
+	src    *bufio.Reader
+	opts   ParseOptions
+	engine *parseEngine
+
+	scan  statementScanner
+	rest  string // Unconsumed part of the current line
+	line  int    // Number of lines read
+	index int    // Number of entries returned
+	eof   bool
+	err   error
+
+	inCopy bool // Positioned inside a COPY data block
+}
+
+// NewDumpReader returns a DumpReader that reads a dump from r.
+func NewDumpReader(r io.Reader) *DumpReader {
+	return NewDumpReaderWithOptions(r, ParseOptions{})
+}
+
+// NewDumpReaderWithOptions returns a DumpReader that parses each statement
+// with opts. opts.PSQL is ignored: the reader returns meta-commands as entries
+// of their own and parses statements as plain SQL.
+func NewDumpReaderWithOptions(r io.Reader, opts ParseOptions) *DumpReader {
+	opts.PSQL = nil
+	return &DumpReader{
+		src:    bufio.NewReader(r),
+		opts:   opts,
+		engine: newParseEngine(),
+	}
+}
+
+// copyFromStdinPattern matches COPY statements whose data follows inline.
+var copyFromStdinPattern = regexp.MustCompile(`(?is)^COPY\b.*\bFROM\s+STDIN\b`)
+
+// Next returns the next entry, skipping any unread COPY data of the previous
+// entry. It returns io.EOF after the last entry.
+func (r *DumpReader) Next() (*DumpEntry, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for r.inCopy {
+		if _, err := r.ReadRow(); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	}
+
+	for {
+		if r.rest == "" {
+			line, err := r.readLine()
+			if errors.Is(err, io.EOF) {
+				if r.scan.started {
+					return r.statementEntry(r.scan.flush())
+				}
+				return nil, io.EOF
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !r.scan.started && r.scan.comment == 0 && strings.HasPrefix(strings.TrimLeft(line, " \t"), `\`) {
+				return r.metaEntry(line), nil
+			}
+			r.rest = line
+		}
+
+		stmt, rest, done := r.scan.feed(r.rest, r.line)
+		r.rest = rest
+		if done {
+			if entry, err := r.statementEntry(stmt); entry != nil || err != nil {
+				return entry, err
+			}
+		}
+	}
+}
+
+// ReadRow returns the next data row of the current COPY entry, decoded from
+// the COPY text format that pg_dump writes: tab-separated values with \N for
+// NULL and backslash escapes. It returns io.EOF at the end of the data block.
+func (r *DumpReader) ReadRow() ([]sql.NullString, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if !r.inCopy {
+		return nil, io.EOF
+	}
+	line, err := r.readLine()
+	if errors.Is(err, io.EOF) {
+		r.err = fmt.Errorf("line %d: COPY data is not terminated by \\.: %w", r.line, io.ErrUnexpectedEOF)
+		return nil, r.err
+	}
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if line == `\.` {
+		r.inCopy = false
+		return nil, io.EOF
+	}
+	return decodeCopyRow(line), nil
+}
+
+// readLine returns the next line including its newline. A final line without
+// a newline is returned as is; io.EOF follows it.
+func (r *DumpReader) readLine() (string, error) {
+	if r.eof {
+		return "", io.EOF
+	}
+	line, err := r.src.ReadString('\n')
+	if errors.Is(err, io.EOF) {
+		r.eof = true
+		if line == "" {
+			return "", io.EOF
+		}
+		err = nil
+	}
+	if err != nil {
+		r.err = err
+		return "", err
+	}
+	r.line++
+	return line, nil
+}
+
+// statementEntry parses a complete statement into an entry. It returns a nil
+// entry for statements that are empty, such as a lone semicolon.
+func (r *DumpReader) statementEntry(stmt scannedStatement) (*DumpEntry, error) {
+	text := strings.TrimSpace(stmt.text)
+	if text == "" {
+		return nil, nil
+	}
+	r.index++
+	entry := &DumpEntry{
+		Kind:     DumpEntryStatement,
+		Index:    r.index,
+		Line:     stmt.line,
+		SQL:      text,
+		CopyData: copyFromStdinPattern.MatchString(text),
+	}
+	if entry.CopyData {
+		// The data block starts on the line after the statement.
+		r.inCopy = true
+		r.rest = ""
+	}
+
+	batch, err := parseAllStatements(r.engine, text, r.opts)
+	switch {
+	case err != nil:
+		entry.Warnings = append(entry.Warnings, ParseWarning{Code: ParseWarningCodeSyntaxError, Message: err.Error()})
+	case len(batch.Statements) > 0:
+		entry.Query = batch.Statements[0].Query
+		entry.Warnings = batch.Statements[0].Warnings
+	}
+	return entry, nil
+}
+
+// metaEntry builds the entry for a meta-command line.
+func (r *DumpReader) metaEntry(line string) *DumpEntry {
+	r.index++
+	text := strings.TrimSpace(line)
+	return &DumpEntry{
+		Kind:        DumpEntryMetaCommand,
+		Index:       r.index,
+		Line:        r.line,
+		SQL:         text,
+		MetaCommand: parseMetaCommand(text),
+	}
+}
+
+// parseMetaCommand splits a backslash command line into its name and
+// arguments. Single- and double-quoted arguments are unquoted, with doubled
+// quotes collapsed.
+func parseMetaCommand(text string) *MetaCommand {
+	text = strings.TrimPrefix(text, `\`)
+	name := text
+	rest := ""
+	if i := strings.IndexAny(text, " \t"); i >= 0 {
+		name, rest = text[:i], text[i:]
+	}
+
+	cmd := &MetaCommand{Name: name}
+	var arg strings.Builder
+	inArg := false
+	var quote byte
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		switch {
+		case quote != 0 && c == quote:
+			if i+1 < len(rest) && rest[i+1] == quote {
+				arg.WriteByte(c)
+				i++
+				continue
+			}
+			quote = 0
+		case quote != 0:
+			arg.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				cmd.Args = append(cmd.Args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		cmd.Args = append(cmd.Args, arg.String())
+	}
+	return cmd
+}
+
+// decodeCopyRow splits a COPY text-format line into its values.
+func decodeCopyRow(line string) []sql.NullString {
+	fields := strings.Split(line, "\t")
+	row := make([]sql.NullString, len(fields))
+	for i, field := range fields {
+		if field == `\N` {
+			continue
+		}
+		row[i] = sql.NullString{String: decodeCopyField(field), Valid: true}
+	}
+	return row
+}
+
+// decodeCopyField resolves the backslash escapes of one COPY text value:
+// \b \f \n \r \t \v, octal \ooo, hex \xhh, and a backslash before any other
+// character, which stands for that character.
+func decodeCopyField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c != '\\' || i+1 == len(field) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = field[i]; c {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case 'x':
+			value, n := 0, 0
+			for ; n < 2 && i+1 < len(field) && isHexDigit(field[i+1]); n++ {
+				i++
+				value = value*16 + hexValue(field[i])
+			}
+			if n == 0 {
+				b.WriteByte('x')
+			} else {
+				b.WriteByte(byte(value))
+			}
+		default:
+			if c < '0' || c > '7' {
+				b.WriteByte(c)
+				continue
+			}
+			value := int(c - '0')
+			for n := 1; n < 3 && i+1 < len(field) && field[i+1] >= '0' && field[i+1] <= '7'; n++ {
+				i++
+				value = value*8 + int(field[i]-'0')
+			}
+			b.WriteByte(byte(value))
+		}
+	}
+	return b.String()
+}
+
+// isHexDigit reports whether c is a hexadecimal digit.
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// hexValue returns the value of the hexadecimal digit c.
+func hexValue(c byte) int {
+	switch {
+	case c >= 'a':
+		return int(c-'a') + 10
+	case c >= 'A':
+		return int(c-'A') + 10
+	default:
+		return int(c - '0')
+	}
+}
+
+// scannedStatement is a statement split off by statementScanner.
+type scannedStatement struct {
+	text string
+	line int // Line of the first non-comment character
+}
+
+// statementScanner splits SQL text fed line by line into statements, the way
+// psql does. It tracks quotes, dollar quotes, nested block comments,
+// parentheses, and the BEGIN ... END depth of CREATE FUNCTION/PROCEDURE
+// bodies so that only a top-level semicolon ends a statement. Comments and
+// whitespace before a statement are dropped.
+type statementScanner struct {
+	buf       strings.Builder
+	started   bool
+	startLine int
+
+	quote     byte // Open ' or " quote
+	escape    bool // The open ' quote is an E'...' string
+	dollarTag string
+	inDollar  bool
+	comment   int // Block comment nesting depth
+	parens    int
+
+	word       strings.Builder // Identifier being read
+	head       []string        // First words of the statement, upper-cased
+	beginDepth int
+
+	// vars, when non-nil, enables psql variable interpolation of :name,
+	// :'name', and :"name" outside quotes and comments.
+	vars map[string]string
+}
+
+// feed consumes line until the end of a statement. It returns the statement
+// and the unconsumed remainder of line when a terminating semicolon is found.
+func (s *statementScanner) feed(line string, lineNo int) (stmt scannedStatement, rest string, done bool) {
+	interpolated := 0 // End of the last interpolated value, which is not expanded again
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case s.inDollar:
+			if c == '$' && strings.HasPrefix(line[i+1:], s.dollarTag+"$") {
+				s.buf.WriteString(line[i : i+len(s.dollarTag)+2])
+				i += len(s.dollarTag) + 1
+				s.inDollar = false
+				continue
+			}
+		case s.quote != 0:
+			if s.escape && c == '\\' && i+1 < len(line) {
+				s.buf.WriteString(line[i : i+2])
+				i++
+				continue
+			}
+			if c == s.quote {
+				s.quote = 0
+			}
+		case s.comment > 0:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				s.comment--
+				s.write(line[i : i+2])
+				i++
+				continue
+			}
+			if c == '/' && i+1 < len(line) && line[i+1] == '*' {
+				s.comment++
+				s.write(line[i : i+2])
+				i++
+				continue
+			}
+			if !s.started {
+				continue
+			}
+		case c == '-' && i+1 < len(line) && line[i+1] == '-':
+			s.endWord()
+			end := strings.IndexByte(line[i:], '\n')
+			if end < 0 {
+				end = len(line) - i
+			}
+			s.write(line[i : i+end])
+			i += end - 1
+			continue
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			s.endWord()
+			s.comment++
+			s.write(line[i : i+2])
+			i++
+			continue
+		case isWordByte(c):
+			if c == '$' && s.word.Len() == 0 {
+				if tag, ok := parseDollarTag([]rune(line[i:]), 0); ok && (tag == "" || tag[0] < '0' || tag[0] > '9') {
+					s.start(lineNo)
+					s.inDollar = true
+					s.dollarTag = tag
+					s.buf.WriteString(line[i : i+len(tag)+2])
+					i += len(tag) + 1
+					continue
+				}
+			}
+			s.start(lineNo)
+			s.word.WriteByte(c)
+		default:
+			if c == ':' && s.vars != nil {
+				if strings.HasPrefix(line[i:], "::") {
+					s.endWord()
+					s.start(lineNo)
+					s.buf.WriteString("::")
+					i++
+					continue
+				}
+				if value, n, ok := expandSQLVariable(line[i:], s.vars); ok && i >= interpolated {
+					line = line[:i] + value + line[i+n:]
+					interpolated = i + len(value)
+					i--
+					continue
+				}
+			}
+			word := s.endWord()
+			switch c {
+			case '\'', '"':
+				s.quote = c
+				s.escape = c == '\'' && (word == "E" || word == "e")
+			case '(':
+				s.parens++
+			case ')':
+				if s.parens > 0 {
+					s.parens--
+				}
+			case ';':
+				if s.parens == 0 && s.beginDepth == 0 {
+					return s.flush(), line[i+1:], true
+				}
+			}
+			if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+				if s.started {
+					s.buf.WriteByte(c)
+				}
+				continue
+			}
+			s.start(lineNo)
+		}
+		s.buf.WriteByte(c)
+	}
+	return scannedStatement{}, "", false
+}
+
+// start marks the beginning of a statement at the first significant byte.
+func (s *statementScanner) start(lineNo int) {
+	if !s.started {
+		s.started = true
+		s.startLine = lineNo
+	}
+}
+
+// write appends comment text, which is kept only inside a statement.
+func (s *statementScanner) write(text string) {
+	if s.started {
+		s.buf.WriteString(text)
+	}
+}
+
+// endWord finishes the identifier being read, updates the BEGIN ... END depth
+// of routine bodies, and returns the word.
+func (s *statementScanner) endWord() string {
+	if s.word.Len() == 0 {
+		return ""
+	}
+	word := s.word.String()
+	s.word.Reset()
+	upper := strings.ToUpper(word)
+	if len(s.head) < 4 {
+		s.head = append(s.head, upper)
+	}
+	if s.isRoutine() {
+		switch {
+		case upper == "BEGIN":
+			s.beginDepth++
+		case upper == "CASE" && s.beginDepth > 0:
+			s.beginDepth++
+		case upper == "END" && s.beginDepth > 0:
+			s.beginDepth--
+		}
+	}
+	return word
+}
+
+// isRoutine reports whether the statement is CREATE [OR REPLACE]
+// FUNCTION/PROCEDURE, whose BEGIN ATOMIC body may contain semicolons.
+func (s *statementScanner) isRoutine() bool {
+	head := s.head
+	if len(head) < 2 || head[0] != "CREATE" {
+		return false
+	}
+	head = head[1:]
+	if len(head) >= 3 && head[0] == "OR" && head[1] == "REPLACE" {
+		head = head[2:]
+	}
+	return head[0] == "FUNCTION" || head[0] == "PROCEDURE"
+}
+
+// flush returns the statement read so far and resets the scanner.
+func (s *statementScanner) flush() scannedStatement {
+	s.endWord()
+	stmt := scannedStatement{text: s.buf.String(), line: s.startLine}
+	*s = statementScanner{vars: s.vars}
+	return stmt
+}
+
+// isWordByte reports whether c can be part of an identifier or keyword.
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,183 @@
+package postgresparser
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDump = `--
+-- PostgreSQL database dump
+--
+
+\restrict abc123
+
+SET statement_timeout = 0;
+SET client_encoding = 'UTF8'; SET standard_conforming_strings = on;
+
+/* leading comment; dropped */
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $_$
+BEGIN
+  NEW.updated_at := now(); -- keep;
+  RETURN NEW;
+END;
+$_$;
+
+CREATE FUNCTION public.sign(a integer) RETURNS integer
+    LANGUAGE sql
+    BEGIN ATOMIC
+ SELECT CASE WHEN a > 0 THEN 1 ELSE 0 END;
+END;
+
+CREATE TABLE public.users (
+    id bigint NOT NULL,
+    name text,
+    bio text
+);
+
+COPY public.users (id, name, bio) FROM stdin;
+1	alice	line\none\ttab
+2	o'brien	\N
+\.
+
+COPY public.audit (id) FROM stdin;
+1
+2
+\.
+
+SELECT E'it\'s; fine', 'a''b;c' FROM public.users;
+
+\connect -reuse-previous=on "dbname='other db'"
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id)`
+
+// readDump returns every entry of a dump, reading the rows of the COPY entry
+// at index copyIndex.
+func readDump(t *testing.T, dump string, copyIndex int) ([]*DumpEntry, [][]sql.NullString) {
+	t.Helper()
+	r := NewDumpReader(strings.NewReader(dump))
+	var entries []*DumpEntry
+	var rows [][]sql.NullString
+	for {
+		entry, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, rows
+		}
+		require.NoError(t, err)
+		entries = append(entries, entry)
+		if entry.Index != copyIndex {
+			continue
+		}
+		for {
+			row, err := r.ReadRow()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			rows = append(rows, row)
+		}
+	}
+}
+
+func TestDumpReader(t *testing.T) {
+	entries, rows := readDump(t, testDump, 8)
+
+	type want struct {
+		kind DumpEntryKind
+		line int
+		sql  string
+	}
+	wants := []want{
+		{DumpEntryMetaCommand, 5, `\restrict abc123`},
+		{DumpEntryStatement, 7, "SET statement_timeout = 0"},
+		{DumpEntryStatement, 8, "SET client_encoding = 'UTF8'"},
+		{DumpEntryStatement, 8, "SET standard_conforming_strings = on"},
+		{DumpEntryStatement, 11, "CREATE FUNCTION public.touch() RETURNS trigger\n    LANGUAGE plpgsql\n    AS $_$\nBEGIN\n  NEW.updated_at := now(); -- keep;\n  RETURN NEW;\nEND;\n$_$"},
+		{DumpEntryStatement, 20, "CREATE FUNCTION public.sign(a integer) RETURNS integer\n    LANGUAGE sql\n    BEGIN ATOMIC\n SELECT CASE WHEN a > 0 THEN 1 ELSE 0 END;\nEND"},
+		{DumpEntryStatement, 26, "CREATE TABLE public.users (\n    id bigint NOT NULL,\n    name text,\n    bio text\n)"},
+		{DumpEntryStatement, 32, "COPY public.users (id, name, bio) FROM stdin"},
+		{DumpEntryStatement, 37, "COPY public.audit (id) FROM stdin"},
+		{DumpEntryStatement, 42, `SELECT E'it\'s; fine', 'a''b;c' FROM public.users`},
+		{DumpEntryMetaCommand, 44, `\connect -reuse-previous=on "dbname='other db'"`},
+		{DumpEntryStatement, 46, "ALTER TABLE ONLY public.users\n    ADD CONSTRAINT users_pkey PRIMARY KEY (id)"},
+	}
+	require.Len(t, entries, len(wants))
+	for i, w := range wants {
+		assert.Equal(t, i+1, entries[i].Index, "entry %d", i)
+		assert.Equal(t, w.kind, entries[i].Kind, "entry %d", i)
+		assert.Equal(t, w.line, entries[i].Line, "entry %d", i)
+		assert.Equal(t, w.sql, entries[i].SQL, "entry %d", i)
+	}
+
+	assert.Equal(t, &MetaCommand{Name: "restrict", Args: []string{"abc123"}}, entries[0].MetaCommand)
+	assert.Equal(t, &MetaCommand{Name: "connect", Args: []string{"-reuse-previous=on", "dbname='other db'"}}, entries[10].MetaCommand)
+	assert.Nil(t, entries[0].Query)
+
+	require.NotNil(t, entries[6].Query)
+	assert.Equal(t, QueryCommandDDL, entries[6].Query.Command)
+	require.NotNil(t, entries[11].Query)
+	assert.Equal(t, DDLAlterTable, entries[11].Query.DDLActions[0].Type)
+
+	assert.True(t, entries[7].CopyData)
+	assert.True(t, entries[8].CopyData)
+	assert.False(t, entries[9].CopyData)
+	assert.Equal(t, [][]sql.NullString{
+		{{String: "1", Valid: true}, {String: "alice", Valid: true}, {String: "line\none\ttab", Valid: true}},
+		{{String: "2", Valid: true}, {String: "o'brien", Valid: true}, {}},
+	}, rows, "rows of the second COPY block are skipped")
+}
+
+func TestDumpReader_UnterminatedCopy(t *testing.T) {
+	r := NewDumpReader(strings.NewReader("COPY t (id) FROM stdin;\n1\n2\n"))
+	entry, err := r.Next()
+	require.NoError(t, err)
+	assert.True(t, entry.CopyData)
+
+	row, err := r.ReadRow()
+	require.NoError(t, err)
+	assert.Equal(t, []sql.NullString{{String: "1", Valid: true}}, row)
+
+	_, err = r.Next()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Contains(t, err.Error(), `line 3: COPY data is not terminated by \.`)
+}
+
+func TestDumpReader_IgnoresPSQLOptions(t *testing.T) {
+	const dump = "SELECT id FROM :tbl;\n"
+	next := func(r *DumpReader) *DumpEntry {
+		entry, err := r.Next()
+		require.NoError(t, err)
+		return entry
+	}
+	plain := next(NewDumpReader(strings.NewReader(dump)))
+	opts := ParseOptions{PSQL: &PSQLOptions{Variables: map[string]string{"tbl": "users"}}}
+	entry := next(NewDumpReaderWithOptions(strings.NewReader(dump), opts))
+	assert.Equal(t, plain, entry)
+	assert.Equal(t, "SELECT id FROM :tbl", entry.SQL)
+	assert.NotEmpty(t, entry.Warnings, "variables are not interpolated")
+}
+
+func TestDecodeCopyField(t *testing.T) {
+	tests := map[string]string{
+		`plain`:         "plain",
+		`a\\b`:          `a\b`,
+		`tab\there`:     "tab\there",
+		`\x41\x4a\xZ`:   "AJxZ",
+		`\101\0612`:     "A12",
+		`\r\n\b\f\v\q`:  "\r\n\b\f\vq",
+		`trailing\`:     `trailing\`,
+		`caf\303\251`:   "café",
+		`\N is literal`: "N is literal",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, decodeCopyField(in), in)
+	}
+}
@@ -21,7 +21,12 @@ func ParseSQL(sql string) (*ParsedQuery, error) {
 // ParseSQLWithOptions parses only the first SQL statement in the input string
 // and enables optional metadata extraction flags.
 func ParseSQLWithOptions(sql string, opts ParseOptions) (*ParsedQuery, error) {
-	state, err := prepareParseState(sql, false)
+	return parseFirstStatement(newParseEngine(), sql, opts)
+}
+
+// parseFirstStatement implements ParseSQLWithOptions on the given engine.
+func parseFirstStatement(engine *parseEngine, sql string, opts ParseOptions) (*ParsedQuery, error) {
+	state, err := prepareParseState(engine, sql, false, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +44,15 @@ func ParseSQLAll(sql string) (*ParseBatchResult, error) {
 // ParseSQLAllWithOptions parses all SQL statements and enables optional
 // metadata extraction flags.
 func ParseSQLAllWithOptions(sql string, opts ParseOptions) (*ParseBatchResult, error) {
-	state, err := prepareParseState(sql, true)
+	return parseAllStatements(newParseEngine(), sql, opts)
+}
+
+// parseAllStatements implements ParseSQLAllWithOptions on the given engine.
+func parseAllStatements(engine *parseEngine, sql string, opts ParseOptions) (*ParseBatchResult, error) {
+	if opts.PSQL != nil {
+		return parsePSQLScript(engine, sql, opts)
+	}
+	state, err := prepareParseState(engine, sql, true, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +113,12 @@ func ParseSQLStrict(sql string) (*ParsedQuery, error) {
 // ParseSQLStrictWithOptions parses input only when it contains exactly one SQL
 // statement and enables optional metadata extraction flags.
 func ParseSQLStrictWithOptions(sql string, opts ParseOptions) (*ParsedQuery, error) {
-	state, err := prepareParseState(sql, false)
+	return parseSingleStatement(newParseEngine(), sql, opts)
+}
+
+// parseSingleStatement implements ParseSQLStrictWithOptions on the given engine.
+func parseSingleStatement(engine *parseEngine, sql string, opts ParseOptions) (*ParsedQuery, error) {
+	state, err := prepareParseState(engine, sql, false, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -119,55 +137,49 @@ type parseState struct {
 	syntaxErrors []SyntaxError
 }
 
-// prepareParseState preprocesses SQL, runs ANTLR parsing once, and returns the
+// prepareParseState preprocesses SQL, runs ANTLR parsing on engine, and returns the
 // parsed statement list plus shared token stream used for IR extraction.
 // When tolerateSyntaxErrors is false, any syntax error fails immediately.
 // When true, syntax errors are collected into state.syntaxErrors as long as
 // statement contexts can still be recovered.
-func prepareParseState(sql string, tolerateSyntaxErrors bool) (*parseState, error) {
+// When opts.UseSLLPrediction is set, parsing is attempted in SLL mode first
+// and repeated in LL mode only if the SLL attempt reports a syntax error.
+// When opts.IncludeSourcePositions or opts.IncludeExpressionTrees is set, the
+// returned stream carries those options; positions are mapped back to sql.
+func prepareParseState(engine *parseEngine, sql string, tolerateSyntaxErrors bool, opts ParseOptions) (*parseState, error) {
 	cleanSQL := preprocessSQLInput(sql)
-	input := antlr.NewInputStream(cleanSQL)
-	lexer := gen.NewPostgreSQLLexer(input)
-	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
-	parser := gen.NewPostgreSQLParser(stream)
-	parser.BuildParseTrees = true
-
-	errListener := &parseErrorListener{}
-	parser.RemoveErrorListeners()
-	parser.AddErrorListener(errListener)
-
-	root := parser.Root()
-	if !tolerateSyntaxErrors && len(errListener.errs) > 0 {
-		return nil, &ParseErrors{SQL: cleanSQL, Errors: errListener.errs}
+	root, stream, syntaxErrs := engine.parse(cleanSQL, opts.UseSLLPrediction)
+	if !tolerateSyntaxErrors && len(syntaxErrs) > 0 {
+		return nil, &ParseErrors{SQL: cleanSQL, Errors: syntaxErrs}
 	}
 	if root == nil || root.Stmtblock() == nil {
-		if len(errListener.errs) > 0 {
-			return nil, &ParseErrors{SQL: cleanSQL, Errors: errListener.errs}
+		if len(syntaxErrs) > 0 {
+			return nil, &ParseErrors{SQL: cleanSQL, Errors: syntaxErrs}
 		}
 		return nil, ErrNoStatements
 	}
 	stmtMulti := root.Stmtblock().Stmtmulti()
 	if stmtMulti == nil {
-		if len(errListener.errs) > 0 {
-			return nil, &ParseErrors{SQL: cleanSQL, Errors: errListener.errs}
+		if len(syntaxErrs) > 0 {
+			return nil, &ParseErrors{SQL: cleanSQL, Errors: syntaxErrs}
 		}
 		return nil, ErrNoStatements
 	}
 	stmts := stmtMulti.AllStmt()
 	if len(stmts) == 0 {
-		if len(errListener.errs) > 0 {
-			return nil, &ParseErrors{SQL: cleanSQL, Errors: errListener.errs}
+		if len(syntaxErrs) > 0 {
+			return nil, &ParseErrors{SQL: cleanSQL, Errors: syntaxErrs}
 		}
 		return nil, ErrNoStatements
 	}
 
 	state := &parseState{
 		cleanSQL: cleanSQL,
-		stream:   stream,
+		stream:   newOptionTokenStream(stream, sql, cleanSQL, opts),
 		stmts:    stmts,
 	}
 	if tolerateSyntaxErrors {
-		state.syntaxErrors = errListener.errs
+		state.syntaxErrors = syntaxErrs
 	}
 	return state, nil
 }
@@ -221,6 +233,16 @@ func parseStatementToIR(stmt gen.IStmtContext, stream antlr.TokenStream, rawSQL
 		if err := populateAlterTable(res, stmt.Altertablestmt(), stream); err != nil {
 			return nil, err
 		}
+	case stmt.Renamestmt() != nil && stmt.Renamestmt().TABLE() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateRenameTable(res, stmt.Renamestmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Alterobjectschemastmt() != nil && stmt.Alterobjectschemastmt().TABLE() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateAlterTableSetSchema(res, stmt.Alterobjectschemastmt(), stream); err != nil {
+			return nil, err
+		}
 	case stmt.Indexstmt() != nil:
 		res.Command = QueryCommandDDL
 		if err := populateCreateIndex(res, stmt.Indexstmt(), stream); err != nil {
@@ -231,16 +253,132 @@ func parseStatementToIR(stmt gen.IStmtContext, stream antlr.TokenStream, rawSQL
 		if err := populateTruncate(res, stmt.Truncatestmt(), stream); err != nil {
 			return nil, err
 		}
+	case stmt.Createasstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateTableAs(res, stmt.Createasstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Viewstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateView(res, stmt.Viewstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Creatematviewstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateMaterializedView(res, stmt.Creatematviewstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Refreshmatviewstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateRefreshMaterializedView(res, stmt.Refreshmatviewstmt(), stream); err != nil {
+			return nil, err
+		}
 	case stmt.Commentstmt() != nil:
 		res.Command = QueryCommandDDL
 		if err := populateCommentStmt(res, stmt.Commentstmt(), stream); err != nil {
 			return nil, err
 		}
+	case stmt.Createfunctionstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateFunction(res, stmt.Createfunctionstmt(), stream, opts); err != nil {
+			return nil, err
+		}
+	case stmt.Removefuncstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropFunction(res, stmt.Removefuncstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Callstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCall(res, stmt.Callstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Dostmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDo(res, stmt.Dostmt(), stream, opts); err != nil {
+			return nil, err
+		}
+	case stmt.Createseqstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateSequence(res, stmt.Createseqstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Alterseqstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateAlterSequence(res, stmt.Alterseqstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Definestmt() != nil && stmt.Definestmt().TYPE_P() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateType(res, stmt.Definestmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Alterenumstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateAlterEnum(res, stmt.Alterenumstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Createdomainstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateDomain(res, stmt.Createdomainstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Createtrigstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateTrigger(res, stmt.Createtrigstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Createextensionstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateExtension(res, stmt.Createextensionstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Createschemastmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateSchema(res, stmt.Createschemastmt(), stream, opts); err != nil {
+			return nil, err
+		}
+	case stmt.Grantstmt() != nil:
+		res.Command = QueryCommandDCL
+		if err := populateGrant(res, stmt.Grantstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Revokestmt() != nil:
+		res.Command = QueryCommandDCL
+		if err := populateRevoke(res, stmt.Revokestmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Grantrolestmt() != nil:
+		res.Command = QueryCommandDCL
+		if err := populateGrantRole(res, stmt.Grantrolestmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Revokerolestmt() != nil:
+		res.Command = QueryCommandDCL
+		if err := populateRevokeRole(res, stmt.Revokerolestmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Alterdefaultprivilegesstmt() != nil:
+		res.Command = QueryCommandDCL
+		if err := populateAlterDefaultPrivileges(res, stmt.Alterdefaultprivilegesstmt(), stream); err != nil {
+			return nil, err
+		}
+	case stmt.Transactionstmt() != nil:
+		res.Command = QueryCommandTransaction
+		if err := populateTransaction(res, stmt.Transactionstmt(), stream); err != nil {
+			return nil, err
+		}
 	default:
 		return res, nil
 	}
 
+	res.HasSubqueries = hasSubquery(stmt)
 	res.Parameters = extractParameters(rawSQL)
+	setParameterSpans(res.Parameters, stmt, stream)
+	setActionSpans(res.DDLActions, spanFor(stream, stmt))
+	if opts.IncludeLineage {
+		res.Lineage = buildLineage(res, stmt, stream)
+	}
 	return res, nil
 }
 
@@ -88,3 +88,35 @@ func (l *parseErrorListener) SyntaxError(recognizer antlr.Recognizer, offendingS
 		TokenIndex: tokenIndex,
 	})
 }
+
+// bailErrorStrategy aborts an SLL parse attempt at the first syntax error so the
+// caller can fall back to LL mode. antlr.BailErrorStrategy is not used because
+// the Go runtime routes its cancellation error back through ReportError, which
+// panics on that error type.
+type bailErrorStrategy struct {
+	*antlr.DefaultErrorStrategy
+	failed bool
+}
+
+// ReportError records the failure without notifying error listeners.
+func (b *bailErrorStrategy) ReportError(_ antlr.Parser, _ antlr.RecognitionException) {
+	b.failed = true
+}
+
+// Recover skips the remaining input so the rule stack unwinds quickly.
+func (b *bailErrorStrategy) Recover(recognizer antlr.Parser, _ antlr.RecognitionException) {
+	b.failed = true
+	for recognizer.GetTokenStream().LA(1) != antlr.TokenEOF {
+		recognizer.Consume()
+	}
+}
+
+// RecoverInline flags a mismatch instead of attempting single-token repair.
+func (b *bailErrorStrategy) RecoverInline(recognizer antlr.Parser) antlr.Token {
+	b.failed = true
+	recognizer.SetError(antlr.NewInputMisMatchException(recognizer))
+	return nil
+}
+
+// Sync is a no-op; sub-rule resynchronization is wasted work before a retry.
+func (b *bailErrorStrategy) Sync(_ antlr.Parser) {}
@@ -12,6 +12,10 @@
 //
 // In practice, the PostgreSQL ANTLR grammar is SLL-compatible for all common
 // SQL patterns. The LL fallback is a safety net, not the common path.
+//
+// postgresparser implements this pattern internally; set
+// ParseOptions.UseSLLPrediction to get it from ParseSQLWithOptions and friends.
+// This example shows the equivalent hand-rolled setup against the gen package.
 package main
 
 import (
@@ -0,0 +1,755 @@
+// expr.go builds typed Expr trees from a_expr parse trees. Trees are only built
+// when ParseOptions.IncludeExpressionTrees is set; the raw clause strings in the
+// IR are always populated.
+package postgresparser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// Walk calls fn for e and then each of its descendants in depth-first order,
+// skipping the children of nodes for which fn returns false. Nested subquery
+// trees are not visited.
+func (e *Expr) Walk(fn func(*Expr) bool) {
+	if e == nil || !fn(e) {
+		return
+	}
+	for _, arg := range e.Args {
+		arg.Walk(fn)
+	}
+	e.Filter.Walk(fn)
+	e.CaseOperand.Walk(fn)
+	for _, w := range e.Whens {
+		w.Condition.Walk(fn)
+		w.Result.Walk(fn)
+	}
+	e.Else.Walk(fn)
+}
+
+// buildExprTree returns the Expr for an expression context, or nil when
+// expression trees are disabled for tokens.
+func buildExprTree(ctx antlr.Tree, tokens antlr.TokenStream) *Expr {
+	if ctx == nil || !wantExprTrees(tokens) {
+		return nil
+	}
+	return buildExpr(ctx, tokens)
+}
+
+// buildSetClauseExprs returns the assigned value of each SET clause, in the
+// order extractSetClauses reports the clauses.
+func buildSetClauseExprs(list gen.ISet_clause_listContext, tokens antlr.TokenStream) []*Expr {
+	if list == nil || !wantExprTrees(tokens) {
+		return nil
+	}
+	var exprs []*Expr
+	for _, clause := range list.AllSet_clause() {
+		prc, ok := clause.(antlr.ParserRuleContext)
+		if !ok || strings.TrimSpace(ctxText(tokens, prc)) == "" {
+			continue
+		}
+		var value *Expr
+		for _, child := range prc.GetChildren() {
+			if expr, ok := child.(*gen.A_exprContext); ok {
+				value = buildExpr(expr, tokens)
+			}
+		}
+		exprs = append(exprs, value)
+	}
+	return exprs
+}
+
+// buildExpr converts one expression node. Rule contexts that only wrap a
+// higher-precedence level are unwrapped; forms without a typed node become
+// ExprKindRaw.
+func buildExpr(node antlr.Tree, tokens antlr.TokenStream) *Expr {
+	ctx, ok := node.(antlr.ParserRuleContext)
+	if !ok {
+		return nil
+	}
+	switch n := ctx.(type) {
+	case *gen.A_exprContext, *gen.C_expr_caseContext, *gen.Case_argContext, *gen.Case_defaultContext:
+		return buildExpr(firstRuleChild(n), tokens)
+	case *gen.A_expr_qualContext:
+		return buildPostfixExpr(n, tokens)
+	case *gen.A_expr_orContext:
+		return buildBoolExpr(n, "OR", tokens)
+	case *gen.A_expr_andContext:
+		return buildBoolExpr(n, "AND", tokens)
+	case *gen.A_expr_lesslessContext, *gen.A_expr_qual_opContext, *gen.A_expr_addContext,
+		*gen.A_expr_mulContext, *gen.A_expr_caretContext:
+		return buildBinaryChain(n, tokens)
+	case *gen.A_expr_betweenContext:
+		return buildBetweenExpr(n, tokens)
+	case *gen.A_expr_inContext:
+		return buildInExpr(n, tokens)
+	case *gen.A_expr_unary_notContext:
+		return buildNotExpr(n, tokens)
+	case *gen.A_expr_isnullContext:
+		return buildIsNullExpr(n, tokens)
+	case *gen.A_expr_is_notContext:
+		return buildIsExpr(n, tokens)
+	case *gen.A_expr_compareContext:
+		return buildCompareExpr(n, tokens)
+	case *gen.A_expr_likeContext:
+		return buildLikeExpr(n, tokens)
+	case *gen.A_expr_unary_qualopContext, *gen.A_expr_unary_signContext:
+		return buildPrefixExpr(n, tokens)
+	case *gen.A_expr_at_time_zoneContext:
+		return buildAtTimeZoneExpr(n, tokens)
+	case *gen.A_expr_collateContext:
+		return buildCollateExpr(n, tokens)
+	case *gen.A_expr_typecastContext:
+		return buildTypecastExpr(n, tokens)
+	case *gen.B_exprContext:
+		return buildBExpr(n, tokens)
+	case *gen.C_expr_existsContext:
+		return buildSubqueryExpr(n, "EXISTS", firstChildOfType[*gen.Select_with_parensContext](n), tokens)
+	case *gen.C_expr_exprContext:
+		return buildCExpr(n, tokens)
+	case *gen.Case_exprContext:
+		return buildCaseExpr(n, tokens)
+	case *gen.Func_exprContext:
+		return buildFuncExpr(n, tokens)
+	case *gen.Func_expr_common_subexprContext:
+		return buildCommonFuncExpr(n, tokens)
+	case *gen.ColumnrefContext:
+		ref := parseColRefFromContext(n)
+		return &Expr{Kind: ExprKindColumn, Text: exprText(tokens, n), Table: ref.TableAlias, Column: ref.Name}
+	case *gen.AexprconstContext:
+		return buildLiteralExpr(n, tokens)
+	case *gen.Select_with_parensContext:
+		return buildSubqueryExpr(n, "", n, tokens)
+	case *gen.Explicit_rowContext, *gen.Implicit_rowContext, *gen.RowContext:
+		return &Expr{Kind: ExprKindRow, Text: exprText(tokens, n), Args: collectArgExprs(n, tokens)}
+	case *gen.Array_exprContext:
+		return &Expr{Kind: ExprKindArray, Text: exprText(tokens, n), Args: collectArrayElements(n, tokens)}
+	}
+	return rawExpr(ctx, tokens)
+}
+
+// buildPostfixExpr handles a trailing qual_op, e.g. "n !".
+func buildPostfixExpr(ctx *gen.A_expr_qualContext, tokens antlr.TokenStream) *Expr {
+	operand := firstRuleChild(ctx)
+	op := firstChildOfType[*gen.Qual_opContext](ctx)
+	if op == nil {
+		return buildExpr(operand, tokens)
+	}
+	return &Expr{Kind: ExprKindUnary, Text: exprText(tokens, ctx), Op: exprText(tokens, op), Args: []*Expr{buildExpr(operand, tokens)}}
+}
+
+// buildBoolExpr builds an n-ary AND/OR node, or unwraps a single operand.
+func buildBoolExpr(ctx antlr.ParserRuleContext, op string, tokens antlr.TokenStream) *Expr {
+	operands := ruleChildren(ctx)
+	if len(operands) == 1 {
+		return buildExpr(operands[0], tokens)
+	}
+	expr := &Expr{Kind: ExprKindBool, Text: exprText(tokens, ctx), Op: op}
+	for _, operand := range operands {
+		expr.Args = append(expr.Args, buildExpr(operand, tokens))
+	}
+	return expr
+}
+
+// buildBinaryChain folds "a op b op c" into left-associative BINARY nodes.
+func buildBinaryChain(ctx antlr.ParserRuleContext, tokens antlr.TokenStream) *Expr {
+	var left *Expr
+	var first antlr.ParserRuleContext
+	op := ""
+	for _, child := range ctx.GetChildren() {
+		switch c := child.(type) {
+		case antlr.TerminalNode:
+			op = c.GetText()
+		case *gen.Qual_opContext:
+			op = exprText(tokens, c)
+		case antlr.ParserRuleContext:
+			operand := buildExpr(c, tokens)
+			if left == nil {
+				left, first = operand, c
+				continue
+			}
+			left = &Expr{
+				Kind: ExprKindBinary,
+				Text: textBetween(tokens, first, c),
+				Op:   strings.ToUpper(op),
+				Args: []*Expr{left, operand},
+			}
+		}
+	}
+	if left == nil {
+		return rawExpr(ctx, tokens)
+	}
+	return left
+}
+
+// buildBetweenExpr handles "x [NOT] BETWEEN [SYMMETRIC] low AND high".
+func buildBetweenExpr(ctx *gen.A_expr_betweenContext, tokens antlr.TokenStream) *Expr {
+	operands := ruleChildren(ctx)
+	if len(operands) == 1 {
+		return buildExpr(operands[0], tokens)
+	}
+	expr := &Expr{Kind: ExprKindBetween, Text: exprText(tokens, ctx), Op: "BETWEEN"}
+	for _, kw := range terminalWords(ctx) {
+		switch kw {
+		case "NOT":
+			expr.Not = true
+		case "SYMMETRIC":
+			expr.Symmetric = true
+		}
+	}
+	for _, operand := range operands {
+		expr.Args = append(expr.Args, buildExpr(operand, tokens))
+	}
+	return expr
+}
+
+// buildInExpr handles "x [NOT] IN (list)" and "x [NOT] IN (subquery)".
+func buildInExpr(ctx *gen.A_expr_inContext, tokens antlr.TokenStream) *Expr {
+	operands := ruleChildren(ctx)
+	if len(operands) == 1 {
+		return buildExpr(operands[0], tokens)
+	}
+	expr := &Expr{Kind: ExprKindIn, Text: exprText(tokens, ctx), Op: "IN", Not: hasTerminal(ctx, "NOT")}
+	expr.Args = append(expr.Args, buildExpr(operands[0], tokens))
+	switch in := operands[len(operands)-1].(type) {
+	case *gen.In_expr_selectContext:
+		if sub := buildSubqueryExpr(in, "", firstChildOfType[*gen.Select_with_parensContext](in), tokens); sub != nil {
+			expr.Query = sub.Query
+		}
+	case *gen.In_expr_listContext:
+		expr.Args = append(expr.Args, collectArgExprs(in, tokens)...)
+	}
+	return expr
+}
+
+// buildNotExpr handles a leading NOT.
+func buildNotExpr(ctx *gen.A_expr_unary_notContext, tokens antlr.TokenStream) *Expr {
+	operand := firstRuleChild(ctx)
+	if !hasTerminal(ctx, "NOT") {
+		return buildExpr(operand, tokens)
+	}
+	return &Expr{Kind: ExprKindBool, Text: exprText(tokens, ctx), Op: "NOT", Args: []*Expr{buildExpr(operand, tokens)}}
+}
+
+// buildIsNullExpr handles the ISNULL and NOTNULL postfix forms.
+func buildIsNullExpr(ctx *gen.A_expr_isnullContext, tokens antlr.TokenStream) *Expr {
+	operand := firstRuleChild(ctx)
+	words := terminalWords(ctx)
+	if len(words) == 0 {
+		return buildExpr(operand, tokens)
+	}
+	return &Expr{
+		Kind: ExprKindIs,
+		Text: exprText(tokens, ctx),
+		Op:   "NULL",
+		Not:  words[0] == "NOTNULL",
+		Args: []*Expr{buildExpr(operand, tokens)},
+	}
+}
+
+// buildIsExpr handles "x IS [NOT] NULL/TRUE/FALSE/UNKNOWN/DISTINCT FROM y/OF (...)/...".
+func buildIsExpr(ctx *gen.A_expr_is_notContext, tokens antlr.TokenStream) *Expr {
+	children := ruleChildren(ctx)
+	if !hasTerminal(ctx, "IS") {
+		return buildExpr(children[0], tokens)
+	}
+	expr := &Expr{Kind: ExprKindIs, Text: exprText(tokens, ctx), Args: []*Expr{buildExpr(children[0], tokens)}}
+	var words []string
+	for _, kw := range terminalWords(ctx) {
+		switch kw {
+		case "IS", "(", ")":
+		case "NOT":
+			expr.Not = true
+		default:
+			words = append(words, kw)
+		}
+	}
+	for _, child := range children[1:] {
+		switch c := child.(type) {
+		case *gen.A_exprContext:
+			expr.Args = append(expr.Args, buildExpr(c, tokens))
+		case *gen.Type_listContext:
+			expr.TypeName = exprText(tokens, c)
+		}
+	}
+	expr.Op = strings.Join(words, " ")
+	return expr
+}
+
+// buildCompareExpr handles comparison operators and quantified comparisons
+// such as "x = ANY (subquery)".
+func buildCompareExpr(ctx *gen.A_expr_compareContext, tokens antlr.TokenStream) *Expr {
+	children := ruleChildren(ctx)
+	subOp := firstChildOfType[*gen.Subquery_OpContext](ctx)
+	if subOp == nil {
+		return buildBinaryChain(ctx, tokens)
+	}
+	expr := &Expr{
+		Kind: ExprKindBinary,
+		Text: exprText(tokens, ctx),
+		Op:   strings.ToUpper(strings.Join(strings.Fields(exprText(tokens, subOp)), " ")),
+		Args: []*Expr{buildExpr(children[0], tokens)},
+	}
+	if q := firstChildOfType[*gen.Sub_typeContext](ctx); q != nil {
+		expr.Quantifier = strings.ToUpper(exprText(tokens, q))
+	}
+	if sub := firstChildOfType[*gen.Select_with_parensContext](ctx); sub != nil {
+		expr.Args = append(expr.Args, buildSubqueryExpr(sub, "", sub, tokens))
+	} else if rhs := firstChildOfType[*gen.A_exprContext](ctx); rhs != nil {
+		expr.Args = append(expr.Args, buildExpr(rhs, tokens))
+	}
+	return expr
+}
+
+// buildLikeExpr handles "x [NOT] LIKE|ILIKE|SIMILAR TO pattern [ESCAPE e]".
+func buildLikeExpr(ctx *gen.A_expr_likeContext, tokens antlr.TokenStream) *Expr {
+	children := ruleChildren(ctx)
+	if len(children) == 1 {
+		return buildExpr(children[0], tokens)
+	}
+	expr := &Expr{Kind: ExprKindLike, Text: exprText(tokens, ctx)}
+	var words []string
+	for _, kw := range terminalWords(ctx) {
+		if kw == "NOT" {
+			expr.Not = true
+			continue
+		}
+		words = append(words, kw)
+	}
+	expr.Op = strings.Join(words, " ")
+	for _, child := range children {
+		if esc, ok := child.(*gen.Escape_Context); ok {
+			expr.Args = append(expr.Args, buildExpr(firstRuleChild(esc), tokens))
+			continue
+		}
+		expr.Args = append(expr.Args, buildExpr(child, tokens))
+	}
+	return expr
+}
+
+// buildPrefixExpr handles a leading operator (unary minus/plus or a qual_op).
+// A sign applied to a numeric literal is folded into the literal.
+func buildPrefixExpr(ctx antlr.ParserRuleContext, tokens antlr.TokenStream) *Expr {
+	children := ctx.GetChildren()
+	if len(children) < 2 {
+		return buildExpr(firstRuleChild(ctx), tokens)
+	}
+	operand := buildExpr(children[len(children)-1], tokens)
+	op := ""
+	switch c := children[0].(type) {
+	case antlr.TerminalNode:
+		op = c.GetText()
+	case antlr.ParserRuleContext:
+		op = exprText(tokens, c)
+	}
+	if (op == "-" || op == "+") && operand != nil && operand.Kind == ExprKindLiteral &&
+		(operand.LiteralType == LiteralInteger || operand.LiteralType == LiteralNumeric) {
+		operand.Text = exprText(tokens, ctx)
+		if op == "-" {
+			operand.Value = "-" + operand.Value
+		}
+		return operand
+	}
+	return &Expr{Kind: ExprKindUnary, Text: exprText(tokens, ctx), Op: op, Args: []*Expr{operand}}
+}
+
+// buildAtTimeZoneExpr handles "x AT TIME ZONE zone".
+func buildAtTimeZoneExpr(ctx *gen.A_expr_at_time_zoneContext, tokens antlr.TokenStream) *Expr {
+	children := ruleChildren(ctx)
+	if len(children) == 1 {
+		return buildExpr(children[0], tokens)
+	}
+	return &Expr{
+		Kind: ExprKindBinary,
+		Text: exprText(tokens, ctx),
+		Op:   "AT TIME ZONE",
+		Args: []*Expr{buildExpr(children[0], tokens), buildExpr(children[1], tokens)},
+	}
+}
+
+// buildCollateExpr handles "x COLLATE name".
+func buildCollateExpr(ctx *gen.A_expr_collateContext, tokens antlr.TokenStream) *Expr {
+	children := ruleChildren(ctx)
+	if len(children) == 1 {
+		return buildExpr(children[0], tokens)
+	}
+	return &Expr{
+		Kind:      ExprKindCollate,
+		Text:      exprText(tokens, ctx),
+		Collation: exprText(tokens, children[1]),
+		Args:      []*Expr{buildExpr(children[0], tokens)},
+	}
+}
+
+// buildTypecastExpr folds "x::a::b" into nested CAST nodes.
+func buildTypecastExpr(ctx *gen.A_expr_typecastContext, tokens antlr.TokenStream) *Expr {
+	children := ruleChildren(ctx)
+	expr := buildExpr(children[0], tokens)
+	for _, child := range children[1:] {
+		expr = &Expr{
+			Kind:     ExprKindCast,
+			Text:     textBetween(tokens, children[0], child),
+			TypeName: exprText(tokens, child),
+			Args:     []*Expr{expr},
+		}
+	}
+	return expr
+}
+
+// buildBExpr handles the restricted b_expr grammar used in a few positions.
+func buildBExpr(ctx *gen.B_exprContext, tokens antlr.TokenStream) *Expr {
+	children := ruleChildren(ctx)
+	switch {
+	case len(children) == 1 && ctx.GetChildCount() == 1:
+		return buildExpr(children[0], tokens)
+	case len(children) == 1 && ctx.GetChildCount() == 2:
+		return buildPrefixExpr(ctx, tokens)
+	case hasTerminal(ctx, "::") && len(children) == 2:
+		return &Expr{
+			Kind:     ExprKindCast,
+			Text:     exprText(tokens, ctx),
+			TypeName: exprText(tokens, children[1]),
+			Args:     []*Expr{buildExpr(children[0], tokens)},
+		}
+	case len(children) == 2 && ctx.GetChildCount() == 3 && !hasTerminal(ctx, "IS"):
+		return buildBinaryChain(ctx, tokens)
+	}
+	return rawExpr(ctx, tokens)
+}
+
+// buildCExpr dispatches the unlabeled c_expr alternatives.
+func buildCExpr(ctx *gen.C_expr_exprContext, tokens antlr.TokenStream) *Expr {
+	children := ctx.GetChildren()
+	if len(children) == 0 {
+		return rawExpr(ctx, tokens)
+	}
+	if term, ok := children[0].(antlr.TerminalNode); ok {
+		switch strings.ToUpper(term.GetText()) {
+		case "ARRAY":
+			if sub := firstChildOfType[*gen.Select_with_parensContext](ctx); sub != nil {
+				return buildSubqueryExpr(ctx, "ARRAY", sub, tokens)
+			}
+			if arr := firstChildOfType[*gen.Array_exprContext](ctx); arr != nil {
+				expr := buildExpr(arr, tokens)
+				expr.Text = exprText(tokens, ctx)
+				return expr
+			}
+		case "UNIQUE":
+			return buildSubqueryExpr(ctx, "UNIQUE", firstChildOfType[*gen.Select_with_parensContext](ctx), tokens)
+		case "GROUPING":
+			return &Expr{Kind: ExprKindFunc, Text: exprText(tokens, ctx), FuncName: "GROUPING", Args: collectArgExprs(ctx, tokens)}
+		case "(":
+			inner := firstChildOfType[*gen.A_exprContext](ctx)
+			if inner != nil && !hasIndirection(ctx) {
+				return buildExpr(inner, tokens)
+			}
+		default:
+			if term.GetSymbol().GetTokenType() == gen.PostgreSQLLexerPARAM && !hasIndirection(ctx) {
+				return buildParamExpr(term)
+			}
+		}
+		return rawExpr(ctx, tokens)
+	}
+	if row := firstChildOfType[*gen.RowContext](ctx); row != nil && hasTerminal(ctx, "OVERLAPS") {
+		rows := ruleChildren(ctx)
+		return &Expr{
+			Kind: ExprKindBinary,
+			Text: exprText(tokens, ctx),
+			Op:   "OVERLAPS",
+			Args: []*Expr{buildExpr(rows[0], tokens), buildExpr(rows[len(rows)-1], tokens)},
+		}
+	}
+	if sub, ok := children[0].(*gen.Select_with_parensContext); ok {
+		if hasIndirection(ctx) {
+			return rawExpr(ctx, tokens)
+		}
+		return buildSubqueryExpr(ctx, "", sub, tokens)
+	}
+	return buildExpr(children[0], tokens)
+}
+
+// buildParamExpr converts a PARAM token into a PARAM node.
+func buildParamExpr(term antlr.TerminalNode) *Expr {
+	text := term.GetText()
+	param := &Parameter{Raw: text, Marker: "?"}
+	if strings.HasPrefix(text, "$") {
+		param.Marker = "$"
+		if idx, err := strconv.Atoi(text[1:]); err == nil {
+			param.Position = idx
+		}
+	}
+	return &Expr{Kind: ExprKindParam, Text: text, Param: param}
+}
+
+// buildSubqueryExpr parses sub as a nested query. op is EXISTS, ARRAY, UNIQUE,
+// or empty for a plain subquery operand.
+func buildSubqueryExpr(ctx antlr.ParserRuleContext, op string, sub *gen.Select_with_parensContext, tokens antlr.TokenStream) *Expr {
+	expr := &Expr{Kind: ExprKindSubquery, Text: exprText(tokens, ctx), Op: op}
+	if sub == nil {
+		return expr
+	}
+	if ref, err := buildSubqueryRefWithResult("", sub, tokens, nil); err == nil && ref != nil {
+		expr.Query = ref.Query
+	}
+	return expr
+}
+
+// buildCaseExpr handles simple and searched CASE expressions.
+func buildCaseExpr(ctx *gen.Case_exprContext, tokens antlr.TokenStream) *Expr {
+	expr := &Expr{Kind: ExprKindCase, Text: exprText(tokens, ctx)}
+	for _, child := range ctx.GetChildren() {
+		switch c := child.(type) {
+		case *gen.Case_argContext:
+			expr.CaseOperand = buildExpr(c, tokens)
+		case *gen.When_clause_listContext:
+			for _, w := range ruleChildren(c) {
+				parts := ruleChildren(w)
+				if len(parts) != 2 {
+					continue
+				}
+				expr.Whens = append(expr.Whens, ExprWhen{
+					Condition: buildExpr(parts[0], tokens),
+					Result:    buildExpr(parts[1], tokens),
+				})
+			}
+		case *gen.Case_defaultContext:
+			expr.Else = buildExpr(c, tokens)
+		}
+	}
+	return expr
+}
+
+// buildFuncExpr handles generic function calls with optional FILTER and OVER.
+func buildFuncExpr(ctx *gen.Func_exprContext, tokens antlr.TokenStream) *Expr {
+	if common := firstChildOfType[*gen.Func_expr_common_subexprContext](ctx); common != nil {
+		return buildCommonFuncExpr(common, tokens)
+	}
+	app := firstChildOfType[*gen.Func_applicationContext](ctx)
+	if app == nil {
+		return rawExpr(ctx, tokens)
+	}
+	expr := &Expr{Kind: ExprKindFunc, Text: exprText(tokens, ctx)}
+	for _, child := range app.GetChildren() {
+		switch c := child.(type) {
+		case *gen.Func_nameContext:
+			expr.FuncName = exprText(tokens, c)
+		case antlr.TerminalNode:
+			switch strings.ToUpper(c.GetText()) {
+			case "*":
+				expr.Star = true
+			case "DISTINCT":
+				expr.Distinct = true
+			}
+		}
+	}
+	expr.Args = collectArgExprs(app, tokens)
+	if filter := firstChildOfType[*gen.Filter_clauseContext](ctx); filter != nil {
+		if cond := firstChildOfType[*gen.A_exprContext](filter); cond != nil {
+			expr.Filter = buildExpr(cond, tokens)
+		}
+	}
+	if over := firstChildOfType[*gen.Over_clauseContext](ctx); over != nil {
+		if spec := ruleChildren(over); len(spec) > 0 {
+			expr.Over = exprText(tokens, spec[0])
+		}
+	}
+	return expr
+}
+
+// buildCommonFuncExpr handles the keyword-introduced function forms such as
+// CAST, COALESCE, CURRENT_DATE, and EXTRACT.
+func buildCommonFuncExpr(ctx *gen.Func_expr_common_subexprContext, tokens antlr.TokenStream) *Expr {
+	words := terminalWords(ctx)
+	if len(words) == 0 {
+		return rawExpr(ctx, tokens)
+	}
+	if words[0] == "CAST" || words[0] == "TREAT" {
+		typ := firstChildOfType[*gen.TypenameContext](ctx)
+		operand := firstChildOfType[*gen.A_exprContext](ctx)
+		if typ != nil && operand != nil {
+			return &Expr{
+				Kind:     ExprKindCast,
+				Text:     exprText(tokens, ctx),
+				Op:       words[0],
+				TypeName: exprText(tokens, typ),
+				Args:     []*Expr{buildExpr(operand, tokens)},
+			}
+		}
+	}
+	return &Expr{Kind: ExprKindFunc, Text: exprText(tokens, ctx), FuncName: words[0], Args: collectArgExprs(ctx, tokens)}
+}
+
+// buildLiteralExpr classifies an aexprconst.
+func buildLiteralExpr(ctx *gen.AexprconstContext, tokens antlr.TokenStream) *Expr {
+	text := exprText(tokens, ctx)
+	expr := &Expr{Kind: ExprKindLiteral, Text: text, Value: text}
+	children := ctx.GetChildren()
+	if len(children) == 0 {
+		return expr
+	}
+	switch c := children[0].(type) {
+	case *gen.IconstContext:
+		expr.LiteralType = LiteralInteger
+	case *gen.FconstContext:
+		expr.LiteralType = LiteralNumeric
+	case *gen.SconstContext:
+		expr.LiteralType = LiteralString
+		expr.Value = decodeCommentStringLiteral(text)
+	case *gen.BconstContext, *gen.XconstContext:
+		expr.LiteralType = LiteralBitString
+	case antlr.TerminalNode:
+		switch strings.ToUpper(c.GetText()) {
+		case "TRUE":
+			expr.LiteralType, expr.Value = LiteralBoolean, "true"
+		case "FALSE":
+			expr.LiteralType, expr.Value = LiteralBoolean, "false"
+		case "NULL":
+			expr.LiteralType, expr.Value = LiteralNull, ""
+		}
+	default:
+		expr.LiteralType = LiteralTyped
+		expr.TypeName = exprText(tokens, children[0])
+		if s := firstChildOfType[*gen.SconstContext](ctx); s != nil {
+			expr.Value = decodeCommentStringLiteral(exprText(tokens, s))
+		}
+	}
+	return expr
+}
+
+// collectArgExprs builds every a_expr/b_expr reachable from ctx through list
+// and argument wrapper rules, in source order. Type names and nested queries
+// are not descended into.
+func collectArgExprs(ctx antlr.ParserRuleContext, tokens antlr.TokenStream) []*Expr {
+	var args []*Expr
+	for _, child := range ctx.GetChildren() {
+		switch c := child.(type) {
+		case *gen.A_exprContext, *gen.B_exprContext:
+			args = append(args, buildExpr(c, tokens))
+		case *gen.TypenameContext, *gen.Func_nameContext, *gen.Select_with_parensContext, *gen.Sort_clause_Context:
+		case antlr.ParserRuleContext:
+			args = append(args, collectArgExprs(c, tokens)...)
+		}
+	}
+	return args
+}
+
+// collectArrayElements returns the elements of an ARRAY[...] literal; nested
+// bracketed sub-arrays become ARRAY nodes.
+func collectArrayElements(ctx *gen.Array_exprContext, tokens antlr.TokenStream) []*Expr {
+	var elems []*Expr
+	for _, child := range ruleChildren(ctx) {
+		switch c := child.(type) {
+		case *gen.Expr_listContext:
+			elems = append(elems, collectArgExprs(c, tokens)...)
+		case *gen.Array_expr_listContext:
+			for _, sub := range ruleChildren(c) {
+				elems = append(elems, buildExpr(sub, tokens))
+			}
+		}
+	}
+	return elems
+}
+
+// hasIndirection reports whether a c_expr carries a non-empty indirection,
+// e.g. (row).field or $1[2].
+func hasIndirection(ctx antlr.ParserRuleContext) bool {
+	for _, child := range ctx.GetChildren() {
+		switch c := child.(type) {
+		case *gen.IndirectionContext:
+			return true
+		case *gen.Opt_indirectionContext:
+			if c.GetChildCount() > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rawExpr wraps a node that has no typed form.
+func rawExpr(ctx antlr.ParserRuleContext, tokens antlr.TokenStream) *Expr {
+	return &Expr{Kind: ExprKindRaw, Text: exprText(tokens, ctx)}
+}
+
+// exprText returns the trimmed source text of ctx.
+func exprText(tokens antlr.TokenStream, ctx antlr.Tree) string {
+	prc, ok := ctx.(antlr.ParserRuleContext)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(ctxText(tokens, prc))
+}
+
+// textBetween returns the source text from the start of from to the end of to.
+func textBetween(tokens antlr.TokenStream, from, to antlr.Tree) string {
+	start, ok1 := from.(antlr.ParserRuleContext)
+	stop, ok2 := to.(antlr.ParserRuleContext)
+	if !ok1 || !ok2 || start.GetStart() == nil || stop.GetStop() == nil {
+		return ""
+	}
+	return strings.TrimSpace(tokens.GetTextFromInterval(antlr.Interval{
+		Start: start.GetStart().GetTokenIndex(),
+		Stop:  stop.GetStop().GetTokenIndex(),
+	}))
+}
+
+// ruleChildren returns the rule-context children of ctx, skipping tokens.
+func ruleChildren(ctx antlr.Tree) []antlr.ParserRuleContext {
+	var out []antlr.ParserRuleContext
+	for _, child := range ctx.GetChildren() {
+		if prc, ok := child.(antlr.ParserRuleContext); ok {
+			out = append(out, prc)
+		}
+	}
+	return out
+}
+
+// firstRuleChild returns the first rule-context child of ctx, or nil.
+func firstRuleChild(ctx antlr.Tree) antlr.ParserRuleContext {
+	for _, child := range ctx.GetChildren() {
+		if prc, ok := child.(antlr.ParserRuleContext); ok {
+			return prc
+		}
+	}
+	return nil
+}
+
+// firstChildOfType returns the first direct child of ctx with type T.
+func firstChildOfType[T antlr.Tree](ctx antlr.Tree) T {
+	var zero T
+	if ctx == nil {
+		return zero
+	}
+	for _, child := range ctx.GetChildren() {
+		if c, ok := child.(T); ok {
+			return c
+		}
+	}
+	return zero
+}
+
+// terminalWords returns the upper-cased text of the direct token children of ctx.
+func terminalWords(ctx antlr.Tree) []string {
+	var words []string
+	for _, child := range ctx.GetChildren() {
+		if term, ok := child.(antlr.TerminalNode); ok {
+			words = append(words, strings.ToUpper(term.GetText()))
+		}
+	}
+	return words
+}
+
+// hasTerminal reports whether ctx has a direct token child with text word
+// (case-insensitive).
+func hasTerminal(ctx antlr.Tree, word string) bool {
+	for _, w := range terminalWords(ctx) {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
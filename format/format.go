@@ -0,0 +1,248 @@
+// Package format renders SQL as canonical, indented PostgreSQL text.
+//
+// Formatting works on the token stream from postgresparser.Tokenize, so the
+// output contains exactly the input tokens: only whitespace, line breaks, and
+// keyword case change. Comments are kept next to the tokens they annotate,
+// and formatting already formatted text returns it unchanged.
+//
+// This file defines the options and entry points and splits the input into
+// statements.
+package format
+
+import (
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// KeywordCase selects how keywords are written.
+type KeywordCase string
+
+const (
+	// KeywordUpper writes keywords in upper case.
+	KeywordUpper KeywordCase = "upper"
+	// KeywordLower writes keywords in lower case.
+	KeywordLower KeywordCase = "lower"
+	// KeywordPreserve keeps keywords as written.
+	KeywordPreserve KeywordCase = "preserve"
+)
+
+// CommaStyle selects where list separators go when a list is broken over
+// several lines.
+type CommaStyle string
+
+const (
+	// CommaTrailing ends every line but the last with a comma.
+	CommaTrailing CommaStyle = "trailing"
+	// CommaLeading starts every line but the first with a comma.
+	CommaLeading CommaStyle = "leading"
+)
+
+// Options controls the layout. The zero value formats with upper-case
+// keywords, four-space indentation, trailing commas, and 80-column lines.
+type Options struct {
+	// KeywordCase defaults to KeywordUpper.
+	KeywordCase KeywordCase
+	// IndentWidth is the number of spaces per nesting level; zero means 4.
+	IndentWidth int
+	// CommaStyle defaults to CommaTrailing.
+	CommaStyle CommaStyle
+	// LineWidth is the column limit. Clauses whose items fit stay on one
+	// line, and longer runs of tokens are wrapped. Zero means 80; a negative
+	// value disables the limit.
+	LineWidth int
+}
+
+// withDefaults fills in zero-valued options.
+func (o Options) withDefaults() Options {
+	if o.KeywordCase == "" {
+		o.KeywordCase = KeywordUpper
+	}
+	if o.IndentWidth <= 0 {
+		o.IndentWidth = 4
+	}
+	if o.CommaStyle == "" {
+		o.CommaStyle = CommaTrailing
+	}
+	if o.LineWidth == 0 {
+		o.LineWidth = 80
+	}
+	return o
+}
+
+// SQL formats every statement in sql. Statements are separated by a blank
+// line and keep their terminating semicolon if they had one. The input must
+// parse; syntax errors are returned as *postgresparser.ParseErrors.
+func SQL(sql string, opts Options) (string, error) {
+	tokens, err := postgresparser.Tokenize(sql)
+	if err != nil {
+		return "", err
+	}
+	opts = opts.withDefaults()
+	p := newPrinter(opts)
+	for i, stmt := range splitStatements(prepareTokens(tokens, opts.KeywordCase)) {
+		if i > 0 {
+			p.newline(0)
+			p.newline(0)
+		}
+		p.statement(stmt)
+	}
+	return p.String(), nil
+}
+
+// Query formats the statement text q was parsed from.
+func Query(q *postgresparser.ParsedQuery, opts Options) (string, error) {
+	if q == nil {
+		return "", postgresparser.ErrNoStatements
+	}
+	return SQL(q.RawSQL, opts)
+}
+
+// token is one significant token or comment prepared for layout.
+type token struct {
+	kind postgresparser.TokenKind
+	text string // Output text, with keyword case applied
+	word string // Upper-cased text of keywords, for clause matching
+	// ownLine marks a comment that starts a line in the source rather than
+	// following another token on the same line.
+	ownLine bool
+	// tight marks an opening parenthesis written without a space before it,
+	// as in a function call.
+	tight bool
+}
+
+// isComment reports whether t is a comment.
+func (t *token) isComment() bool {
+	return t.kind == postgresparser.TokenComment
+}
+
+// isLineComment reports whether t is a -- comment, which must end its line.
+func (t *token) isLineComment() bool {
+	return t.isComment() && strings.HasPrefix(t.text, "--")
+}
+
+// is reports whether t is the punctuation text.
+func (t *token) is(text string) bool {
+	return t.kind == postgresparser.TokenPunctuation && t.text == text
+}
+
+// prepareTokens drops whitespace, applies keyword case, and records which
+// comments start their own line.
+func prepareTokens(tokens []postgresparser.Token, kc KeywordCase) []*token {
+	var out []*token
+	newline := true
+	for _, tok := range tokens {
+		if tok.Kind == postgresparser.TokenWhitespace {
+			if strings.ContainsAny(tok.Text, "\r\n") {
+				newline = true
+			}
+			continue
+		}
+		t := &token{kind: tok.Kind, text: tok.Text}
+		switch tok.Kind {
+		case postgresparser.TokenKeyword:
+			t.word = strings.ToUpper(tok.Text)
+			switch kc {
+			case KeywordUpper:
+				t.text = t.word
+			case KeywordLower:
+				t.text = strings.ToLower(tok.Text)
+			}
+		case postgresparser.TokenComment:
+			t.ownLine = newline
+		}
+		out = append(out, t)
+		newline = false
+	}
+	return out
+}
+
+// statement is one semicolon-terminated statement with its comments.
+type statement struct {
+	lead  []*token // Comments before the statement
+	nodes []node   // Statement body
+	semi  *token   // Terminating semicolon, if any
+	tail  []*token // Comments after the body, written after the semicolon
+}
+
+// splitStatements groups tokens into statements. Comments after a semicolon
+// on the same line stay with the statement it ends; comments on later lines
+// introduce the next statement.
+func splitStatements(tokens []*token) []*statement {
+	var stmts []*statement
+	var cur []*token
+	depth := 0
+	flush := func(semi *token) {
+		stmt := &statement{semi: semi}
+		start, end := 0, len(cur)
+		for start < end && cur[start].isComment() {
+			start++
+		}
+		for end > start && cur[end-1].isComment() {
+			end--
+		}
+		stmt.lead = cur[:start]
+		stmt.tail = cur[end:]
+		stmt.nodes = buildNodes(cur[start:end])
+		stmts = append(stmts, stmt)
+		cur = nil
+	}
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.is("(") || tok.is("["):
+			depth++
+		case tok.is(")") || tok.is("]"):
+			depth--
+		case tok.is(";") && depth == 0:
+			flush(tok)
+			for i+1 < len(tokens) && tokens[i+1].isComment() && !tokens[i+1].ownLine {
+				i++
+				stmts[len(stmts)-1].tail = append(stmts[len(stmts)-1].tail, tokens[i])
+			}
+			continue
+		}
+		cur = append(cur, tok)
+	}
+
+	hasBody := false
+	for _, tok := range cur {
+		if !tok.isComment() {
+			hasBody = true
+		}
+	}
+	switch {
+	case hasBody:
+		flush(nil)
+	case len(stmts) > 0:
+		last := stmts[len(stmts)-1]
+		last.tail = append(last.tail, cur...)
+	}
+
+	// Comments on their own lines before a semicolon are written after it,
+	// where a second pass would read them as leading the next statement.
+	for i := 0; i+1 < len(stmts); i++ {
+		stmt := stmts[i]
+		if stmt.semi == nil {
+			continue
+		}
+		own := firstOwnLine(stmt.tail)
+		if own < len(stmt.tail) {
+			next := stmts[i+1]
+			next.lead = append(append([]*token{}, stmt.tail[own:]...), next.lead...)
+			stmt.tail = stmt.tail[:own]
+		}
+	}
+	return stmts
+}
+
+// firstOwnLine returns the index of the first comment in comments that starts
+// its own line, or len(comments).
+func firstOwnLine(comments []*token) int {
+	for i, c := range comments {
+		if c.ownLine {
+			return i
+		}
+	}
+	return len(comments)
+}
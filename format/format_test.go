@@ -0,0 +1,213 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+func TestSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		opts Options
+		want string
+	}{
+		{
+			name: "short clauses stay on one line",
+			sql:  "select id, name from users where id = $1",
+			want: "SELECT id, name\nFROM users\nWHERE id = $1",
+		},
+		{
+			name: "long lists and conditions break",
+			sql: "SELECT u.id, u.email, count(o.id) AS orders, sum(o.total) FILTER (WHERE o.paid) AS paid_total " +
+				"FROM users u LEFT JOIN orders o ON o.user_id = u.id " +
+				"WHERE u.active AND u.created_at > now() - interval '30 days' AND u.id BETWEEN 1 AND 100 " +
+				"GROUP BY u.id, u.email ORDER BY orders DESC LIMIT 10",
+			want: `SELECT
+    u.id,
+    u.email,
+    count(o.id) AS orders,
+    sum(o.total) FILTER (WHERE o.paid) AS paid_total
+FROM users u
+LEFT JOIN orders o ON o.user_id = u.id
+WHERE u.active
+    AND u.created_at > now() - INTERVAL '30 days'
+    AND u.id BETWEEN 1 AND 100
+GROUP BY u.id, u.email
+ORDER BY orders DESC
+LIMIT 10`,
+		},
+		{
+			name: "subqueries and CTEs indent",
+			sql:  "with a as (select 1 as x) select * from a where x in (select y from b) union all select -1",
+			want: `WITH a AS (
+    SELECT 1 AS x
+)
+SELECT *
+FROM a
+WHERE x IN (
+    SELECT y
+    FROM b
+)
+UNION ALL
+SELECT -1`,
+		},
+		{
+			name: "insert with upsert",
+			sql:  "INSERT INTO t(a, b) VALUES (1, 2), (3, 4) ON CONFLICT (a) DO UPDATE SET b = excluded.b RETURNING a",
+			want: `INSERT INTO t (a, b)
+VALUES (1, 2), (3, 4)
+ON CONFLICT (a)
+DO UPDATE SET b = excluded.b
+RETURNING a`,
+		},
+		{
+			name: "create table elements",
+			sql:  "create table if not exists public.users (id bigint primary key, email varchar(255) not null, created_at timestamp default now())",
+			want: `CREATE TABLE IF NOT EXISTS public.users (
+    id BIGINT PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT now()
+)`,
+		},
+		{
+			name: "create view query",
+			sql:  "create view v as select a from t",
+			want: "CREATE VIEW v AS\nSELECT a\nFROM t",
+		},
+		{
+			name: "operators and casts",
+			sql:  "select -a, a - -1, t.*, arr[1], '{}'::int[], cast(x as numeric(10,2)) from t",
+			want: "SELECT -a, a - -1, t.*, arr[1], '{}'::INT[], CAST(x AS NUMERIC(10, 2))\nFROM t",
+		},
+		{
+			name: "lower case, two spaces, leading commas",
+			sql:  "SELECT a, b, c FROM t WHERE a = 1 AND b = 2",
+			opts: Options{KeywordCase: KeywordLower, IndentWidth: 2, CommaStyle: CommaLeading, LineWidth: 12},
+			want: `select
+  a
+, b
+, c
+from t
+where a = 1
+  and b = 2`,
+		},
+		{
+			name: "preserved keyword case",
+			sql:  "Select a From t",
+			opts: Options{KeywordCase: KeywordPreserve},
+			want: "Select a\nFrom t",
+		},
+		{
+			name: "multiple statements",
+			sql:  "select 1; select 2",
+			want: "SELECT 1;\n\nSELECT 2",
+		},
+		{
+			name: "other statements stay in line",
+			sql:  "alter table t add column c int default 0",
+			want: "ALTER TABLE t ADD COLUMN c INT DEFAULT 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SQL(tt.sql, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSQL_Comments(t *testing.T) {
+	sql := `-- header
+SELECT a, -- first
+  b /* second */
+  -- about c
+  , c
+FROM t -- table
+-- filter
+WHERE a = 1 -- one
+  AND b = 2;
+-- next
+select 2; -- trailing`
+	want := `-- header
+SELECT
+    a, -- first
+    b, /* second */
+    -- about c
+    c
+FROM t -- table
+-- filter
+WHERE a = 1 -- one
+    AND b = 2;
+
+-- next
+SELECT 2; -- trailing`
+	got, err := SQL(sql, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestSQL_Idempotent(t *testing.T) {
+	inputs := []string{
+		"select a from (select a from t) sub join lateral (select 1) l on true",
+		"select 1 -- a\n-- b\n; select 2",
+		"select distinct on (a) a, b from t where x is distinct from y for update",
+		"(select 1) union (select 2) order by 1",
+		"select count(*) over (partition by a order by b) from t where a not between 1 and 2 and b or c",
+		"WITH RECURSIVE t(n) AS (VALUES (1) UNION ALL SELECT n + 1 FROM t WHERE n < 100) SELECT sum(n) FROM t",
+		"update accounts set balance = balance - $1, updated_at = now() where id = $2 returning id, balance",
+		"delete from t using u where t.id = u.id",
+		"select a /* x */\n/* y */ /* z */ , b from t where\n-- lead\nb = 1",
+		"select ( -- after paren\n select 1)",
+	}
+	optionSets := []Options{
+		{},
+		{KeywordCase: KeywordLower, IndentWidth: 2, CommaStyle: CommaLeading, LineWidth: 30},
+		{LineWidth: 20},
+	}
+	for _, opts := range optionSets {
+		for _, sql := range inputs {
+			once, err := SQL(sql, opts)
+			require.NoError(t, err, sql)
+			twice, err := SQL(once, opts)
+			require.NoError(t, err, once)
+			assert.Equal(t, once, twice, "options %+v", opts)
+		}
+	}
+}
+
+func TestSQL_PreservesTokens(t *testing.T) {
+	sql := "SELECT name, type FROM events WHERE payload ->> 'kind' = $1 AND note = $$it's;$$"
+	got, err := SQL(sql, Options{KeywordCase: KeywordLower})
+	require.NoError(t, err)
+
+	in, err := postgresparser.Normalize(sql)
+	require.NoError(t, err)
+	out, err := postgresparser.Normalize(got)
+	require.NoError(t, err)
+	assert.Equal(t, in.Fingerprint, out.Fingerprint)
+	// Keywords used as column names are identifiers and keep their case.
+	assert.Contains(t, got, "select name, type")
+}
+
+func TestSQL_Errors(t *testing.T) {
+	_, err := SQL("SELECT FROM WHERE (", Options{})
+	var parseErrs *postgresparser.ParseErrors
+	assert.ErrorAs(t, err, &parseErrs)
+
+	_, err = SQL("", Options{})
+	assert.ErrorIs(t, err, postgresparser.ErrNoStatements)
+}
+
+func TestQuery(t *testing.T) {
+	q, err := postgresparser.ParseSQL("select a from t where b = 1")
+	require.NoError(t, err)
+	got, err := Query(q, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT a\nFROM t\nWHERE b = 1", got)
+}
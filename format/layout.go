@@ -0,0 +1,579 @@
+// layout.go builds the layout tree: tokens nest into parenthesized groups,
+// query text splits into clauses, and clause bodies split into items with
+// their comments attached.
+package format
+
+import (
+	"github.com/valkdb/postgresparser"
+)
+
+// node is a token, a comment, or a bracketed group.
+type node struct {
+	tok   *token
+	group *group
+}
+
+// significant reports whether n is not a comment.
+func (n node) significant() bool {
+	return n.group != nil || !n.tok.isComment()
+}
+
+// keyword returns the upper-cased keyword of n, or "" if n is not a keyword.
+func (n node) keyword() string {
+	if n.tok == nil {
+		return ""
+	}
+	return n.tok.word
+}
+
+// groupKind selects how a bracketed group is laid out.
+type groupKind int
+
+const (
+	groupInline   groupKind = iota // Written in line with the surrounding tokens
+	groupQuery                     // Parenthesized query, written as an indented block
+	groupElements                  // CREATE TABLE element list, one element per line
+)
+
+// group is a bracketed token sequence.
+type group struct {
+	open, close *token
+	nodes       []node
+	kind        groupKind
+	query       *block   // Set for groupQuery
+	openTrail   []*token // Comments following the opening bracket on its line
+}
+
+// buildNodes nests tokens into groups and classifies each group.
+func buildNodes(tokens []*token) []node {
+	pos := 0
+	nodes := nestTokens(tokens, &pos)
+	annotateGroups(nodes, startsCreate(nodes))
+	return nodes
+}
+
+// nestTokens consumes tokens from *pos up to an unmatched closing bracket.
+func nestTokens(tokens []*token, pos *int) []node {
+	var nodes []node
+	for *pos < len(tokens) {
+		tok := tokens[*pos]
+		if tok.is(")") || tok.is("]") {
+			return nodes
+		}
+		*pos++
+		if !tok.is("(") && !tok.is("[") {
+			nodes = append(nodes, node{tok: tok})
+			continue
+		}
+		g := &group{open: tok}
+		g.nodes = nestTokens(tokens, pos)
+		if *pos < len(tokens) {
+			g.close = tokens[*pos]
+			*pos++
+		}
+		nodes = append(nodes, node{group: g})
+	}
+	return nodes
+}
+
+// startsCreate reports whether nodes begin with CREATE.
+func startsCreate(nodes []node) bool {
+	for _, n := range nodes {
+		if n.significant() {
+			return n.keyword() == "CREATE"
+		}
+	}
+	return false
+}
+
+// queryStarts are the keywords that open a query inside parentheses.
+var queryStarts = map[string]bool{"SELECT": true, "WITH": true, "VALUES": true}
+
+// tightParenKeywords are keywords written directly before their argument list,
+// like function calls and type modifiers.
+var tightParenKeywords = map[string]bool{
+	"ARRAY": true, "BIT": true, "CAST": true, "CHAR": true, "CHARACTER": true,
+	"COALESCE": true, "CURRENT_TIME": true, "CURRENT_TIMESTAMP": true, "DEC": true,
+	"DECIMAL": true, "EXTRACT": true, "FLOAT": true, "GREATEST": true, "GROUPING": true,
+	"INTERVAL": true, "JSON": true, "JSON_ARRAY": true, "JSON_ARRAYAGG": true,
+	"JSON_EXISTS": true, "JSON_OBJECT": true, "JSON_OBJECTAGG": true, "JSON_QUERY": true,
+	"JSON_SCALAR": true, "JSON_SERIALIZE": true, "JSON_TABLE": true, "JSON_VALUE": true,
+	"LEAST": true, "LOCALTIME": true, "LOCALTIMESTAMP": true, "NCHAR": true,
+	"NORMALIZE": true, "NULLIF": true, "NUMERIC": true, "OVERLAY": true, "POSITION": true,
+	"ROW": true, "SUBSTRING": true, "TIME": true, "TIMESTAMP": true, "TREAT": true,
+	"TRIM": true, "VARCHAR": true, "VARYING": true, "XMLATTRIBUTES": true,
+	"XMLCONCAT": true, "XMLELEMENT": true, "XMLEXISTS": true, "XMLFOREST": true,
+	"XMLPARSE": true, "XMLPI": true, "XMLROOT": true, "XMLSERIALIZE": true,
+}
+
+// nameContextKeywords introduce a relation or type name whose following
+// parenthesis is a column or element list rather than call arguments.
+var nameContextKeywords = map[string]bool{
+	"AS": true, "EXISTS": true, "INTO": true, "ONLY": true, "RECURSIVE": true,
+	"REFERENCES": true, "TABLE": true, "VIEW": true, "WITH": true,
+}
+
+// annotateGroups decides the spacing and layout of every group in nodes. In a
+// CREATE ... TABLE statement, a first top-level parenthesis that follows the
+// table name is the element list.
+func annotateGroups(nodes []node, create bool) {
+	sawTable, sawParen, sawAS := false, false, false
+	for i, n := range nodes {
+		switch n.keyword() {
+		case "TABLE":
+			sawTable = true
+		case "AS":
+			sawAS = true
+		}
+		g := n.group
+		if g == nil {
+			continue
+		}
+		if g.open.is("(") {
+			g.open.tight = tightParen(nodes, i, create)
+		}
+		annotateGroups(g.nodes, create)
+		switch {
+		case g.open.is("(") && startsQuery(g.nodes):
+			g.kind = groupQuery
+			g.openTrail, g.nodes = splitOpenTrail(g.nodes)
+			g.query = parseBlock(g.nodes)
+		case g.open.is("(") && create && sawTable && !sawParen && !sawAS && followsName(nodes, i):
+			g.kind = groupElements
+			g.openTrail, g.nodes = splitOpenTrail(g.nodes)
+		}
+		if g.open.is("(") {
+			sawParen = true
+		}
+	}
+}
+
+// followsName reports whether the node before nodes[i] is an identifier.
+func followsName(nodes []node, i int) bool {
+	p := prevSignificant(nodes, i)
+	return p >= 0 && nodes[p].tok != nil && nodes[p].tok.kind == postgresparser.TokenIdentifier
+}
+
+// startsQuery reports whether the first significant node is a query keyword.
+func startsQuery(nodes []node) bool {
+	for _, n := range nodes {
+		if n.significant() {
+			return queryStarts[n.keyword()]
+		}
+	}
+	return false
+}
+
+// splitOpenTrail separates the comments that follow an opening bracket on
+// the same line from the group contents.
+func splitOpenTrail(nodes []node) ([]*token, []node) {
+	var trail []*token
+	for len(nodes) > 0 && !nodes[0].significant() && !nodes[0].tok.ownLine {
+		trail = append(trail, nodes[0].tok)
+		nodes = nodes[1:]
+	}
+	return trail, nodes
+}
+
+// tightParen reports whether the group at nodes[i] is written without a
+// space before it: after a function name or a type keyword, but not after a
+// relation name whose column list follows.
+func tightParen(nodes []node, i int, create bool) bool {
+	p := prevSignificant(nodes, i)
+	if p < 0 || nodes[p].tok == nil {
+		return false
+	}
+	switch nodes[p].tok.kind {
+	case postgresparser.TokenKeyword:
+		return tightParenKeywords[nodes[p].tok.word]
+	case postgresparser.TokenIdentifier:
+	default:
+		return false
+	}
+	// Walk back over a qualified name.
+	for {
+		dot := prevSignificant(nodes, p)
+		if dot < 0 || nodes[dot].tok == nil || !nodes[dot].tok.is(".") {
+			break
+		}
+		name := prevSignificant(nodes, dot)
+		if name < 0 || nodes[name].tok == nil || nodes[name].tok.kind != postgresparser.TokenIdentifier {
+			break
+		}
+		p = name
+	}
+	before := prevSignificant(nodes, p)
+	if before < 0 {
+		return true
+	}
+	kw := nodes[before].keyword()
+	if nameContextKeywords[kw] || (create && (kw == "ON" || kw == "USING")) {
+		return false
+	}
+	return true
+}
+
+// prevSignificant returns the index of the last significant node before i,
+// or -1.
+func prevSignificant(nodes []node, i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if nodes[j].significant() {
+			return j
+		}
+	}
+	return -1
+}
+
+// clauseKind selects how a clause body is broken when it does not fit.
+type clauseKind int
+
+const (
+	clausePlain clauseKind = iota // Tokens in line, wrapped at the line width
+	clauseList                    // Comma-separated items, one per line
+	clauseCond                    // AND/OR-separated conditions, one per line
+	clauseCTE                     // WITH list, items aligned with the clause
+)
+
+// clause is one clause of a query, such as "WHERE a = 1".
+type clause struct {
+	lead []*token // Comments on the lines before the head
+	head []node
+	kind clauseKind
+	body []node
+}
+
+// block is a query: a sequence of clauses.
+type block struct {
+	clauses []*clause
+	verb    string // Leading statement keyword: SELECT, INSERT, UPDATE, ...
+}
+
+// parseBlock splits query nodes into clauses at the clause keywords found
+// outside brackets and CASE expressions. Comments on their own lines before
+// a clause keyword lead that clause.
+func parseBlock(nodes []node) *block {
+	b := &block{}
+	cur := &clause{}
+	var prev string // Keyword of the previous significant node, if any
+	seen, caseDepth := false, 0
+	for i := 0; i < len(nodes); {
+		n := nodes[i]
+		if n.keyword() != "" && caseDepth == 0 {
+			leading := b.leading() && (len(cur.head) == 0 || cur.head[0].keyword() == "WITH")
+			if size, kind, ok := b.matchHead(nodes, i, prev, !seen, leading); ok {
+				next := &clause{head: nodes[i : i+size], kind: kind}
+				cur.body, next.lead = splitLeadComments(cur.body)
+				b.add(cur)
+				cur = next
+				prev = nodes[i+size-1].keyword()
+				seen = true
+				i += size
+				continue
+			}
+		}
+		switch n.keyword() {
+		case "CASE":
+			caseDepth++
+		case "END":
+			if caseDepth > 0 {
+				caseDepth--
+			}
+		}
+		cur.body = append(cur.body, n)
+		if n.significant() {
+			prev = n.keyword()
+			seen = true
+		}
+		i++
+	}
+	b.add(cur)
+	return b
+}
+
+// add appends c unless it is an empty leading clause.
+func (b *block) add(c *clause) {
+	if len(c.head) == 0 && len(c.body) == 0 && len(c.lead) == 0 {
+		return
+	}
+	b.clauses = append(b.clauses, c)
+}
+
+// leading reports whether no clause other than WITH has been added yet.
+func (b *block) leading() bool {
+	for _, c := range b.clauses {
+		if len(c.head) > 0 && c.head[0].keyword() != "WITH" {
+			return false
+		}
+	}
+	return true
+}
+
+// matchHead reports whether a clause keyword sequence starts at nodes[i],
+// returning its length and kind. prev is the keyword before it, first
+// reports whether nodes[i] is the first significant node of the query, and
+// leading reports whether only a WITH clause precedes it, so that a statement
+// keyword such as UPDATE opens the statement rather than, e.g., ending
+// FOR UPDATE.
+func (b *block) matchHead(nodes []node, i int, prev string, first, leading bool) (int, clauseKind, bool) {
+	word := func(j int) string {
+		if j < len(nodes) {
+			return nodes[j].keyword()
+		}
+		return ""
+	}
+	switch w := word(i); w {
+	case "WITH":
+		if !first {
+			return 0, 0, false
+		}
+		if word(i+1) == "RECURSIVE" {
+			return 2, clauseCTE, true
+		}
+		return 1, clauseCTE, true
+	case "SELECT":
+		if leading {
+			b.verb = w
+		}
+		switch word(i + 1) {
+		case "ALL":
+			return 2, clauseList, true
+		case "DISTINCT":
+			if word(i+2) == "ON" && i+3 < len(nodes) && nodes[i+3].group != nil {
+				return 4, clauseList, true
+			}
+			return 2, clauseList, true
+		}
+		return 1, clauseList, true
+	case "FROM":
+		if prev == "DISTINCT" {
+			return 0, 0, false
+		}
+		return 1, clauseList, true
+	case "WHERE", "HAVING":
+		return 1, clauseCond, true
+	case "GROUP", "ORDER":
+		if word(i+1) == "BY" {
+			return 2, clauseList, true
+		}
+	case "WINDOW", "RETURNING":
+		return 1, clauseList, true
+	case "LIMIT", "OFFSET", "FETCH", "FOR":
+		return 1, clausePlain, true
+	case "UNION", "INTERSECT", "EXCEPT":
+		if next := word(i + 1); next == "ALL" || next == "DISTINCT" {
+			return 2, clausePlain, true
+		}
+		return 1, clausePlain, true
+	case "VALUES":
+		if prev == "DEFAULT" || b.verb == "MERGE" {
+			return 0, 0, false
+		}
+		if leading {
+			b.verb = w
+		}
+		return 1, clauseList, true
+	case "INSERT", "MERGE":
+		if leading && word(i+1) == "INTO" {
+			b.verb = w
+			return 2, clausePlain, true
+		}
+	case "DELETE":
+		if leading && word(i+1) == "FROM" {
+			b.verb = w
+			return 2, clausePlain, true
+		}
+	case "UPDATE":
+		if leading {
+			b.verb = w
+			return 1, clausePlain, true
+		}
+	case "SET":
+		if b.verb == "UPDATE" {
+			return 1, clauseList, true
+		}
+	case "ON":
+		if b.verb == "INSERT" && word(i+1) == "CONFLICT" {
+			return 2, clausePlain, true
+		}
+	case "DO":
+		if b.verb != "INSERT" {
+			return 0, 0, false
+		}
+		if word(i+1) == "UPDATE" && word(i+2) == "SET" {
+			return 3, clauseList, true
+		}
+		if word(i+1) == "NOTHING" {
+			return 2, clausePlain, true
+		}
+	case "USING":
+		switch b.verb {
+		case "DELETE":
+			return 1, clauseList, true
+		case "MERGE":
+			return 1, clauseCond, true
+		}
+	case "WHEN":
+		if b.verb == "MERGE" {
+			return 1, clausePlain, true
+		}
+	case "NATURAL", "INNER", "CROSS", "LEFT", "RIGHT", "FULL", "JOIN":
+		j := i
+		if word(j) == "NATURAL" {
+			j++
+		}
+		switch word(j) {
+		case "LEFT", "RIGHT", "FULL":
+			j++
+			if word(j) == "OUTER" {
+				j++
+			}
+		case "INNER", "CROSS":
+			j++
+		}
+		if word(j) == "JOIN" {
+			return j - i + 1, clauseCond, true
+		}
+	}
+	return 0, 0, false
+}
+
+// splitLeadComments moves the comments at the end of body that start on
+// their own line, and any comments after them, out of body.
+func splitLeadComments(body []node) ([]node, []*token) {
+	end := len(body)
+	for end > 0 && !body[end-1].significant() {
+		end--
+	}
+	trail := make([]*token, 0, len(body)-end)
+	for _, n := range body[end:] {
+		trail = append(trail, n.tok)
+	}
+	own := firstOwnLine(trail)
+	return body[:end+own], trail[own:]
+}
+
+// item is one list element or condition of a clause body.
+type item struct {
+	sep   *token   // Separator before the item: a comma, AND, or OR
+	lead  []*token // Comments on the lines before the item
+	nodes []node
+	trail []*token // Comments after the item on its last line
+	after []*token // Comments on lines after the last item
+}
+
+// splitItems splits nodes at the separators chosen by isSep. Comments that
+// follow a token on the same line attach to the item ending there; comments
+// that start a line lead the next item. The comments after the clause head
+// on its line are returned separately.
+func splitItems(nodes []node, isSep func(nodes []node, i int) bool) ([]*item, []*token) {
+	items := []*item{{}}
+	for i, n := range nodes {
+		if isSep(nodes, i) {
+			items = append(items, &item{sep: n.tok})
+			continue
+		}
+		cur := items[len(items)-1]
+		cur.nodes = append(cur.nodes, n)
+	}
+
+	var headTrail []*token
+	for k, it := range items {
+		var front []*token
+		for len(it.nodes) > 0 && !it.nodes[0].significant() {
+			c := it.nodes[0].tok
+			if c.ownLine || len(it.lead) > 0 {
+				it.lead = append(it.lead, c)
+			} else {
+				front = append(front, c)
+			}
+			it.nodes = it.nodes[1:]
+		}
+		if k == 0 {
+			headTrail = front
+		} else {
+			prev := items[k-1]
+			prev.trail = append(prev.trail, front...)
+		}
+
+		var rest []node
+		rest, it.after = splitLeadComments(it.nodes)
+		for len(rest) > 0 && !rest[len(rest)-1].significant() {
+			it.trail = append([]*token{rest[len(rest)-1].tok}, it.trail...)
+			rest = rest[:len(rest)-1]
+		}
+		it.nodes = rest
+		if k > 0 && len(items[k-1].after) > 0 {
+			it.lead = append(append([]*token{}, items[k-1].after...), it.lead...)
+			items[k-1].after = nil
+		}
+	}
+	return items, headTrail
+}
+
+// commaSep separates list items.
+func commaSep(nodes []node, i int) bool {
+	return nodes[i].tok != nil && nodes[i].tok.is(",")
+}
+
+// condSep returns a separator test for AND and OR outside CASE expressions,
+// skipping the AND of BETWEEN.
+func condSep() func(nodes []node, i int) bool {
+	between, caseDepth := false, 0
+	return func(nodes []node, i int) bool {
+		switch nodes[i].keyword() {
+		case "CASE":
+			caseDepth++
+		case "END":
+			if caseDepth > 0 {
+				caseDepth--
+			}
+		case "BETWEEN":
+			between = true
+		case "AND":
+			if between {
+				between = false
+				return false
+			}
+			return caseDepth == 0
+		case "OR":
+			return caseDepth == 0
+		}
+		return false
+	}
+}
+
+// statementQuery splits a statement into a prefix written in line and the
+// query that follows it: the whole statement for DML and queries, the query
+// after AS for CREATE ... AS, and the explained statement for EXPLAIN.
+func statementQuery(nodes []node) ([]node, []node) {
+	first := -1
+	for i, n := range nodes {
+		if n.significant() {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return nodes, nil
+	}
+	switch kw := nodes[first].keyword(); {
+	case kw == "SELECT", kw == "WITH", kw == "VALUES", kw == "INSERT", kw == "UPDATE",
+		kw == "DELETE", kw == "MERGE":
+		return nil, nodes
+	case nodes[first].group != nil && nodes[first].group.kind == groupQuery:
+		return nil, nodes
+	case kw == "CREATE", kw == "EXPLAIN":
+		for i := first + 1; i < len(nodes); i++ {
+			w := nodes[i].keyword()
+			if kw == "CREATE" && queryStarts[w] {
+				if p := prevSignificant(nodes, i); p >= 0 && nodes[p].keyword() == "AS" {
+					return nodes[:i], nodes[i:]
+				}
+			}
+			if kw == "EXPLAIN" && (queryStarts[w] || w == "INSERT" || w == "UPDATE" || w == "DELETE" || w == "MERGE") {
+				return nodes[:i], nodes[i:]
+			}
+		}
+	}
+	return nodes, nil
+}
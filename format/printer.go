@@ -0,0 +1,381 @@
+// printer.go writes the layout tree: spacing between tokens, line breaks,
+// indentation, comment placement, and wrapping at the line width.
+package format
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// operandKeywords are keywords that end an operand, so a following + or - is
+// a binary operator.
+var operandKeywords = map[string]bool{
+	"CURRENT_CATALOG": true, "CURRENT_DATE": true, "CURRENT_ROLE": true,
+	"CURRENT_SCHEMA": true, "CURRENT_TIME": true, "CURRENT_TIMESTAMP": true,
+	"CURRENT_USER": true, "END": true, "FALSE": true, "LOCALTIME": true,
+	"LOCALTIMESTAMP": true, "NULL": true, "SESSION_USER": true, "TRUE": true,
+	"USER": true,
+}
+
+// printer accumulates formatted output.
+type printer struct {
+	opts Options
+	b    strings.Builder
+
+	col     int  // Column of the next character on the current line
+	indent  int  // Indentation of the current line, written before its first token
+	fresh   bool // Nothing but indentation belongs to the current line yet
+	breakNL bool // A line comment was written; the next token starts a line
+	cont    int  // Indentation for continuation lines
+
+	prev  *token // Last token or comment on the current line
+	last  *token // Last significant token written
+	unary bool   // last was a prefix operator
+}
+
+// newPrinter returns a printer at the start of the output.
+func newPrinter(opts Options) *printer {
+	return &printer{opts: opts, fresh: true}
+}
+
+// String returns the output written so far.
+func (p *printer) String() string {
+	return p.b.String()
+}
+
+// newline ends the current line; the next line is indented by indent.
+func (p *printer) newline(indent int) {
+	p.b.WriteByte('\n')
+	p.col, p.indent = 0, indent
+	p.fresh, p.breakNL = true, false
+	p.prev = nil
+}
+
+// put writes text, indenting first if it starts the line.
+func (p *printer) put(text string) {
+	if p.fresh {
+		p.b.WriteString(strings.Repeat(" ", p.indent))
+		p.col = p.indent
+		p.fresh = false
+	}
+	p.b.WriteString(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		p.col = utf8.RuneCountInString(text[i+1:])
+	} else {
+		p.col += utf8.RuneCountInString(text)
+	}
+}
+
+// token writes a significant token, preceded by a space where needed and
+// wrapped onto a continuation line if it would pass the line width.
+func (p *printer) token(t *token) {
+	p.write(t, true)
+}
+
+// write writes a significant token; wrap allows a line break before it.
+func (p *printer) write(t *token, wrap bool) {
+	if t == nil {
+		return
+	}
+	if p.breakNL {
+		p.newline(p.cont)
+	}
+	space := !p.fresh && p.needSpace(t)
+	if space && wrap && p.opts.LineWidth > 0 && p.col+1+utf8.RuneCountInString(t.text) > p.opts.LineWidth {
+		p.newline(p.cont)
+		space = false
+	}
+	if space {
+		p.put(" ")
+	}
+	p.put(t.text)
+	p.unary = (t.is("-") || t.is("+")) && p.prefixPosition()
+	p.prev, p.last = t, t
+}
+
+// prefixPosition reports whether an operator written after p.last is a
+// prefix operator.
+func (p *printer) prefixPosition() bool {
+	switch last := p.last; {
+	case last == nil:
+		return true
+	case last.kind == postgresparser.TokenPunctuation:
+		return !last.is(")") && !last.is("]")
+	case last.kind == postgresparser.TokenKeyword:
+		return !operandKeywords[last.word]
+	}
+	return false
+}
+
+// needSpace reports whether a space separates p.prev and t.
+func (p *printer) needSpace(t *token) bool {
+	prev := p.prev
+	switch {
+	case prev == nil:
+		return false
+	case prev.isComment():
+		return true
+	case isOperator(prev) && isOperator(t):
+		// Adjacent operator characters would lex as one operator or a comment.
+		return true
+	case t.kind == postgresparser.TokenPunctuation &&
+		(t.text == "," || t.text == ";" || t.text == ")" || t.text == "]" || t.text == "." ||
+			t.text == "::" || t.text == ":" || t.text == "["):
+		return false
+	case prev.kind == postgresparser.TokenPunctuation &&
+		(prev.text == "(" || prev.text == "[" || prev.text == "." || prev.text == "::" || prev.text == ":"):
+		return false
+	case p.unary:
+		return false
+	case t.is("("):
+		return !t.tight
+	}
+	return true
+}
+
+// isOperator reports whether t is an operator rather than other punctuation.
+func isOperator(t *token) bool {
+	if t.kind != postgresparser.TokenPunctuation {
+		return false
+	}
+	switch t.text {
+	case "(", ")", "[", "]", ",", ";", ".", "::", ":":
+		return false
+	}
+	return true
+}
+
+// comment writes a comment. Comments that started a line in the source start
+// a line here too; others follow the previous token. A line comment ends its
+// line.
+func (p *printer) comment(c *token) {
+	if c.ownLine {
+		if p.breakNL || !p.fresh {
+			p.newline(p.cont)
+		}
+	} else if !p.fresh {
+		p.put(" ")
+	}
+	p.put(c.text)
+	p.prev = c
+	p.breakNL = c.isLineComment()
+}
+
+// comments writes each comment with continuation indentation indent.
+func (p *printer) comments(list []*token, indent int) {
+	saved := p.cont
+	p.cont = indent
+	for _, c := range list {
+		p.comment(c)
+	}
+	p.cont = saved
+}
+
+// statement writes one statement.
+func (p *printer) statement(stmt *statement) {
+	p.last, p.unary = nil, false
+	if len(stmt.lead) > 0 {
+		p.comments(stmt.lead, 0)
+		p.newline(0)
+	}
+	prefix, query := statementQuery(stmt.nodes)
+	if len(prefix) > 0 {
+		p.cont = p.opts.IndentWidth
+		p.nodes(prefix, 0)
+		if query != nil {
+			p.newline(0)
+		}
+	}
+	if query != nil {
+		p.block(parseBlock(query), 0)
+	}
+	if stmt.semi != nil {
+		p.breakNL = false
+		p.prev = nil
+		p.put(stmt.semi.text)
+		p.prev = stmt.semi
+	}
+	p.comments(stmt.tail, 0)
+}
+
+// nodes writes nodes in line; nested blocks are indented from base.
+func (p *printer) nodes(nodes []node, base int) {
+	for _, n := range nodes {
+		switch {
+		case n.group != nil:
+			p.group(n.group, base)
+		case n.tok.isComment():
+			p.comment(n.tok)
+		default:
+			p.token(n.tok)
+		}
+	}
+}
+
+// group writes a bracketed group. Queries and element lists open an
+// indented block that closes on its own line at base.
+func (p *printer) group(g *group, base int) {
+	// The opening bracket of a block stays with the text it follows.
+	p.write(g.open, g.kind == groupInline)
+	switch g.kind {
+	case groupQuery:
+		saved := p.cont
+		p.comments(g.openTrail, base+p.opts.IndentWidth)
+		p.newline(base + p.opts.IndentWidth)
+		p.block(g.query, base+p.opts.IndentWidth)
+		p.newline(base)
+		p.cont = saved
+	case groupElements:
+		items, _ := splitItems(g.nodes, commaSep)
+		if len(items) < 2 && len(g.openTrail) == 0 && !hasComments(g.nodes) {
+			p.nodes(g.nodes, base)
+			break
+		}
+		saved := p.cont
+		p.comments(g.openTrail, base+p.opts.IndentWidth)
+		p.items(items, base+p.opts.IndentWidth, false)
+		p.newline(base)
+		p.cont = saved
+	default:
+		p.nodes(g.nodes, base)
+	}
+	p.token(g.close)
+}
+
+// block writes the clauses of a query, each starting a line at indent. The
+// caller has already started the first line.
+func (p *printer) block(b *block, indent int) {
+	for i, c := range b.clauses {
+		if i > 0 {
+			p.newline(indent)
+		}
+		if len(c.lead) > 0 {
+			p.comments(c.lead, indent)
+			p.newline(indent)
+		}
+		p.clause(c, indent)
+	}
+}
+
+// clause writes one clause whose head starts the current line.
+func (p *printer) clause(c *clause, indent int) {
+	step := p.opts.IndentWidth
+	p.cont = indent + step
+	p.nodes(c.head, indent)
+	if len(c.body) == 0 {
+		return
+	}
+	if c.kind != clausePlain && p.fits(c, indent) {
+		p.nodes(c.body, indent)
+		return
+	}
+
+	switch c.kind {
+	case clausePlain:
+		p.nodes(c.body, indent)
+	case clauseCTE:
+		items, headTrail := splitItems(c.body, commaSep)
+		p.comments(headTrail, indent+step)
+		p.items(items, indent, true)
+	case clauseList:
+		items, headTrail := splitItems(c.body, commaSep)
+		p.comments(headTrail, indent+step)
+		p.items(items, indent+step, len(items) == 1 && len(items[0].lead) == 0)
+	case clauseCond:
+		items, headTrail := splitItems(c.body, condSep())
+		p.comments(headTrail, indent+step)
+		for k, it := range items {
+			if k == 0 && len(it.lead) == 0 {
+				p.cont = indent + step
+				p.nodes(it.nodes, indent)
+			} else {
+				p.comments(it.lead, indent+step)
+				p.newline(indent + step)
+				p.cont = indent + 2*step
+				p.token(it.sep)
+				p.nodes(it.nodes, indent+step)
+			}
+			p.comments(it.trail, indent+step)
+			p.comments(it.after, indent+step)
+		}
+	}
+}
+
+// items writes list items one per line at indent, separated in the
+// configured comma style. With inlineFirst the first item continues the
+// current line.
+func (p *printer) items(items []*item, indent int, inlineFirst bool) {
+	step := p.opts.IndentWidth
+	leading := p.opts.CommaStyle == CommaLeading
+	for k, it := range items {
+		p.comments(it.lead, indent)
+		if k > 0 || !inlineFirst || len(it.lead) > 0 {
+			p.newline(indent)
+		}
+		base := indent
+		if k == 0 && inlineFirst && len(it.lead) == 0 {
+			base = p.indent
+		}
+		p.cont = base + step
+		if leading && k > 0 {
+			if indent >= 2 && !inlineFirst {
+				p.indent = indent - 2
+			}
+			p.token(it.sep)
+		}
+		p.nodes(it.nodes, base)
+		if !leading && k+1 < len(items) {
+			p.token(items[k+1].sep)
+		}
+		p.comments(it.trail, indent)
+		p.comments(it.after, indent)
+	}
+}
+
+// fits reports whether a clause can be written on one line: it holds no
+// comments or nested blocks, and its head and body fit the line width.
+func (p *printer) fits(c *clause, indent int) bool {
+	if hasComments(c.body) || hasBlocks(c.body) {
+		return false
+	}
+	if p.opts.LineWidth < 0 {
+		return true
+	}
+	flat := newPrinter(Options{LineWidth: -1, IndentWidth: p.opts.IndentWidth})
+	flat.col, flat.indent = indent, indent
+	flat.nodes(c.head, indent)
+	flat.nodes(c.body, indent)
+	return !strings.Contains(flat.String(), "\n") && flat.col <= p.opts.LineWidth
+}
+
+// hasComments reports whether nodes contain a comment at any depth.
+func hasComments(nodes []node) bool {
+	for _, n := range nodes {
+		if n.group != nil {
+			if len(n.group.openTrail) > 0 || hasComments(n.group.nodes) {
+				return true
+			}
+			continue
+		}
+		if n.tok.isComment() {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBlocks reports whether nodes contain a query or element-list group at
+// any depth.
+func hasBlocks(nodes []node) bool {
+	for _, n := range nodes {
+		if n.group == nil {
+			continue
+		}
+		if n.group.kind != groupInline || hasBlocks(n.group.nodes) {
+			return true
+		}
+	}
+	return false
+}
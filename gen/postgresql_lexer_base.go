@@ -70,6 +70,13 @@ func NewPostgreSQLLexerBase(input antlr.CharStream) *PostgreSQLLexerBase {
 	}
 }
 
+// SetInputStream clears the dollar-quote tag stack along with the base lexer
+// state so a lexer instance can be reused for new input.
+func (l *PostgreSQLLexerBase) SetInputStream(input antlr.CharStream) {
+	l.stack = StringStack{}
+	l.BaseLexer.SetInputStream(input)
+}
+
 func (l *PostgreSQLLexerBase) PushTag() {
 	l.stack.Push(l.GetText())
 }
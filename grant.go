@@ -0,0 +1,352 @@
+// grant.go implements privilege extraction for GRANT, REVOKE (including role
+// membership), and ALTER DEFAULT PRIVILEGES.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateGrant handles GRANT privileges ON objects TO grantees.
+func populateGrant(result *ParsedQuery, ctx gen.IGrantstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("grant statement: %w", ErrNilContext)
+	}
+	clause := &PrivilegeClause{
+		Action:     PrivilegeActionGrant,
+		Privileges: extractPrivileges(ctx.Privileges(), tokens),
+		Grantees:   extractGrantees(ctx.Grantee_list(), tokens),
+	}
+	extractPrivilegeTarget(result, clause, ctx.Privilege_target(), tokens)
+	if ctx.Grant_grant_option_() != nil {
+		clause.Flags = append(clause.Flags, "WITH_GRANT_OPTION")
+	}
+	result.Privileges = clause
+	return nil
+}
+
+// populateRevoke handles REVOKE [GRANT OPTION FOR] privileges ON objects FROM grantees.
+func populateRevoke(result *ParsedQuery, ctx gen.IRevokestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("revoke statement: %w", ErrNilContext)
+	}
+	clause := &PrivilegeClause{
+		Action:     PrivilegeActionRevoke,
+		Privileges: extractPrivileges(ctx.Privileges(), tokens),
+		Grantees:   extractGrantees(ctx.Grantee_list(), tokens),
+	}
+	extractPrivilegeTarget(result, clause, ctx.Privilege_target(), tokens)
+	if ctx.GRANT() != nil && ctx.OPTION() != nil {
+		clause.Flags = append(clause.Flags, "GRANT_OPTION_FOR")
+	}
+	clause.Flags = appendDropBehaviorFlag(clause.Flags, ctx.Drop_behavior_())
+	result.Privileges = clause
+	return nil
+}
+
+// populateGrantRole handles GRANT role TO roles [WITH ADMIN OPTION] [GRANTED BY role].
+func populateGrantRole(result *ParsedQuery, ctx gen.IGrantrolestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("grant role statement: %w", ErrNilContext)
+	}
+	clause := &PrivilegeClause{
+		Action:     PrivilegeActionGrant,
+		ObjectType: "ROLE",
+		Objects:    extractGrantedRoles(ctx.Privilege_list(), tokens),
+		Grantees:   extractRoleList(ctx.Role_list(), tokens),
+	}
+	if ctx.Grant_admin_option_() != nil {
+		clause.Flags = append(clause.Flags, "WITH_ADMIN_OPTION")
+	}
+	if by := ctx.Granted_by_(); by != nil {
+		clause.GrantedBy = contextText(tokens, by.Rolespec())
+	}
+	result.Privileges = clause
+	return nil
+}
+
+// populateRevokeRole handles REVOKE [ADMIN OPTION FOR] role FROM roles.
+func populateRevokeRole(result *ParsedQuery, ctx gen.IRevokerolestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("revoke role statement: %w", ErrNilContext)
+	}
+	clause := &PrivilegeClause{
+		Action:     PrivilegeActionRevoke,
+		ObjectType: "ROLE",
+		Objects:    extractGrantedRoles(ctx.Privilege_list(), tokens),
+		Grantees:   extractRoleList(ctx.Role_list(), tokens),
+	}
+	if ctx.ADMIN() != nil {
+		clause.Flags = append(clause.Flags, "ADMIN_OPTION_FOR")
+	}
+	clause.Flags = appendDropBehaviorFlag(clause.Flags, ctx.Drop_behavior_())
+	if by := ctx.Granted_by_(); by != nil {
+		clause.GrantedBy = contextText(tokens, by.Rolespec())
+	}
+	result.Privileges = clause
+	return nil
+}
+
+// populateAlterDefaultPrivileges handles ALTER DEFAULT PRIVILEGES
+// [FOR ROLE ...] [IN SCHEMA ...] GRANT/REVOKE ... ON TABLES|SEQUENCES|... .
+func populateAlterDefaultPrivileges(result *ParsedQuery, ctx gen.IAlterdefaultprivilegesstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("alter default privileges statement: %w", ErrNilContext)
+	}
+	action := ctx.Defaclaction()
+	if action == nil {
+		return fmt.Errorf("alter default privileges action: %w", ErrNilContext)
+	}
+
+	clause := &PrivilegeClause{
+		Action:            PrivilegeActionGrant,
+		Privileges:        extractPrivileges(action.Privileges(), tokens),
+		Grantees:          extractGrantees(action.Grantee_list(), tokens),
+		DefaultPrivileges: true,
+	}
+	if action.REVOKE() != nil {
+		clause.Action = PrivilegeActionRevoke
+		if action.GRANT() != nil && action.OPTION() != nil {
+			clause.Flags = append(clause.Flags, "GRANT_OPTION_FOR")
+		}
+		clause.Flags = appendDropBehaviorFlag(clause.Flags, action.Drop_behavior_())
+	} else if action.Grant_grant_option_() != nil {
+		clause.Flags = append(clause.Flags, "WITH_GRANT_OPTION")
+	}
+
+	if target := action.Defacl_privilege_target(); target != nil {
+		switch {
+		case target.TABLES() != nil:
+			clause.ObjectType = "TABLE"
+		case target.SEQUENCES() != nil:
+			clause.ObjectType = "SEQUENCE"
+		case target.FUNCTIONS() != nil:
+			clause.ObjectType = "FUNCTION"
+		case target.ROUTINES() != nil:
+			clause.ObjectType = "ROUTINE"
+		case target.TYPES_P() != nil:
+			clause.ObjectType = "TYPE"
+		case target.SCHEMAS() != nil:
+			clause.ObjectType = "SCHEMA"
+		}
+	}
+
+	if options := ctx.Defacloptionlist(); options != nil {
+		for _, opt := range options.AllDefacloption() {
+			if opt.IN_P() != nil {
+				clause.InSchemas = append(clause.InSchemas, extractNameList(opt.Name_list(), tokens)...)
+			} else {
+				clause.ForRoles = append(clause.ForRoles, extractRoleList(opt.Role_list(), tokens)...)
+			}
+		}
+	}
+	result.Privileges = clause
+	return nil
+}
+
+// extractPrivileges returns the privileges of a GRANT/REVOKE list. ALL and
+// ALL PRIVILEGES both yield a single ALL entry.
+func extractPrivileges(ctx gen.IPrivilegesContext, tokens antlr.TokenStream) []Privilege {
+	if ctx == nil {
+		return nil
+	}
+	if ctx.ALL() != nil {
+		priv := Privilege{Name: "ALL"}
+		if ctx.Columnlist() != nil {
+			priv.Columns = extractColumnlistNames(ctx.Columnlist(), tokens)
+		}
+		return []Privilege{priv}
+	}
+	list := ctx.Privilege_list()
+	if list == nil {
+		return nil
+	}
+	privileges := make([]Privilege, 0, len(list.AllPrivilege()))
+	for _, p := range list.AllPrivilege() {
+		priv := Privilege{}
+		switch {
+		case p.SELECT() != nil:
+			priv.Name = "SELECT"
+		case p.REFERENCES() != nil:
+			priv.Name = "REFERENCES"
+		case p.CREATE() != nil:
+			priv.Name = "CREATE"
+		default:
+			priv.Name = strings.ToUpper(contextText(tokens, p.Colid()))
+		}
+		if cols := p.Column_list_(); cols != nil {
+			priv.Columns = extractColumnlistNames(cols.Columnlist(), tokens)
+		}
+		privileges = append(privileges, priv)
+	}
+	return privileges
+}
+
+// extractPrivilegeTarget fills ObjectType, Objects, and InSchemas from the ON
+// clause. Tables are also recorded in result.Tables.
+func extractPrivilegeTarget(result *ParsedQuery, clause *PrivilegeClause, ctx gen.IPrivilege_targetContext, tokens antlr.TokenStream) {
+	if ctx == nil {
+		return
+	}
+	switch {
+	case ctx.ALL() != nil:
+		switch {
+		case ctx.TABLES() != nil:
+			clause.ObjectType = "TABLE"
+		case ctx.SEQUENCES() != nil:
+			clause.ObjectType = "SEQUENCE"
+		case ctx.FUNCTIONS() != nil:
+			clause.ObjectType = "FUNCTION"
+		case ctx.PROCEDURES() != nil:
+			clause.ObjectType = "PROCEDURE"
+		case ctx.ROUTINES() != nil:
+			clause.ObjectType = "ROUTINE"
+		}
+		clause.InSchemas = extractNameList(ctx.Name_list(), tokens)
+	case ctx.Qualified_name_list() != nil:
+		clause.ObjectType = "TABLE"
+		if ctx.SEQUENCE() != nil {
+			clause.ObjectType = "SEQUENCE"
+		}
+		for _, qn := range ctx.Qualified_name_list().AllQualified_name() {
+			raw := contextText(tokens, qn)
+			schema, name := splitQualifiedName(raw)
+			clause.Objects = append(clause.Objects, PrivilegeObject{Schema: schema, Name: name})
+			if clause.ObjectType == "TABLE" {
+				result.Tables = append(result.Tables, TableRef{
+					Schema: schema,
+					Name:   name,
+					Type:   TableTypeBase,
+					Raw:    raw,
+					Span:   spanFor(tokens, qn),
+				})
+			}
+		}
+	case ctx.Function_with_argtypes_list() != nil:
+		switch {
+		case ctx.PROCEDURE() != nil:
+			clause.ObjectType = "PROCEDURE"
+		case ctx.ROUTINE() != nil:
+			clause.ObjectType = "ROUTINE"
+		default:
+			clause.ObjectType = "FUNCTION"
+		}
+		for _, fn := range ctx.Function_with_argtypes_list().AllFunction_with_argtypes() {
+			clause.Objects = append(clause.Objects, extractFunctionObject(fn, tokens))
+		}
+	case ctx.Any_name_list_() != nil:
+		clause.ObjectType = "TYPE"
+		if ctx.DOMAIN_P() != nil {
+			clause.ObjectType = "DOMAIN"
+		}
+		for _, name := range ctx.Any_name_list_().AllAny_name() {
+			schema, objectName := splitQualifiedName(contextText(tokens, name))
+			clause.Objects = append(clause.Objects, PrivilegeObject{Schema: schema, Name: objectName})
+		}
+	case ctx.Numericonly_list() != nil:
+		clause.ObjectType = "LARGE OBJECT"
+		for _, oid := range ctx.Numericonly_list().AllNumericonly() {
+			clause.Objects = append(clause.Objects, PrivilegeObject{Name: contextText(tokens, oid)})
+		}
+	case ctx.Name_list() != nil:
+		switch {
+		case ctx.DATA_P() != nil:
+			clause.ObjectType = "FOREIGN DATA WRAPPER"
+		case ctx.SERVER() != nil:
+			clause.ObjectType = "FOREIGN SERVER"
+		case ctx.DATABASE() != nil:
+			clause.ObjectType = "DATABASE"
+		case ctx.LANGUAGE() != nil:
+			clause.ObjectType = "LANGUAGE"
+		case ctx.SCHEMA() != nil:
+			clause.ObjectType = "SCHEMA"
+		case ctx.TABLESPACE() != nil:
+			clause.ObjectType = "TABLESPACE"
+		}
+		for _, name := range extractNameList(ctx.Name_list(), tokens) {
+			clause.Objects = append(clause.Objects, PrivilegeObject{Name: name})
+		}
+	}
+}
+
+// extractFunctionObject splits a routine signature into schema, name, and
+// argument list.
+func extractFunctionObject(fn gen.IFunction_with_argtypesContext, tokens antlr.TokenStream) PrivilegeObject {
+	if fn.Func_name() == nil {
+		schema, name := splitQualifiedName(contextText(tokens, fn))
+		return PrivilegeObject{Schema: schema, Name: name}
+	}
+	schema, name := splitQualifiedName(contextText(tokens, fn.Func_name()))
+	return PrivilegeObject{
+		Schema: schema,
+		Name:   name,
+		Args:   normalizeSpace(contextText(tokens, fn.Func_args())),
+	}
+}
+
+// extractGrantedRoles returns the roles granted or revoked by a role
+// membership statement, which the grammar parses as a privilege list.
+func extractGrantedRoles(list gen.IPrivilege_listContext, tokens antlr.TokenStream) []PrivilegeObject {
+	if list == nil {
+		return nil
+	}
+	roles := make([]PrivilegeObject, 0, len(list.AllPrivilege()))
+	for _, p := range list.AllPrivilege() {
+		roles = append(roles, PrivilegeObject{Name: contextText(tokens, p)})
+	}
+	return roles
+}
+
+// extractGrantees returns grantee role names as written, dropping the
+// optional GROUP keyword.
+func extractGrantees(list gen.IGrantee_listContext, tokens antlr.TokenStream) []string {
+	if list == nil {
+		return nil
+	}
+	grantees := make([]string, 0, len(list.AllGrantee()))
+	for _, g := range list.AllGrantee() {
+		grantees = append(grantees, contextText(tokens, g.Rolespec()))
+	}
+	return grantees
+}
+
+// extractRoleList returns role names from a role list.
+func extractRoleList(list gen.IRole_listContext, tokens antlr.TokenStream) []string {
+	if list == nil {
+		return nil
+	}
+	roles := make([]string, 0, len(list.AllRolespec()))
+	for _, r := range list.AllRolespec() {
+		roles = append(roles, contextText(tokens, r))
+	}
+	return roles
+}
+
+// extractNameList returns the names of a comma-separated name list.
+func extractNameList(list gen.IName_listContext, tokens antlr.TokenStream) []string {
+	if list == nil {
+		return nil
+	}
+	names := make([]string, 0, len(list.AllName()))
+	for _, n := range list.AllName() {
+		names = append(names, contextText(tokens, n))
+	}
+	return names
+}
+
+// appendDropBehaviorFlag appends CASCADE or RESTRICT when present.
+func appendDropBehaviorFlag(flags []string, db gen.IDrop_behavior_Context) []string {
+	switch {
+	case db == nil:
+		return flags
+	case db.CASCADE() != nil:
+		return append(flags, "CASCADE")
+	case db.RESTRICT() != nil:
+		return append(flags, "RESTRICT")
+	}
+	return flags
+}
@@ -16,6 +16,15 @@ type columnRef struct {
 	TableAlias string
 	Name       string
 	Expr       string
+	node       *gen.ColumnrefContext // Source node, when parsed from the tree
+}
+
+// span returns the source span of the reference's node, if any.
+func (r columnRef) span(tokens antlr.TokenStream) *SourceSpan {
+	if r.node == nil {
+		return nil
+	}
+	return spanFor(tokens, r.node)
 }
 
 // --- Column Usage Analysis Helpers ---
@@ -72,6 +81,7 @@ func findAndRecordUsage(result *ParsedQuery, ctx antlr.RuleContext, role ColumnU
 			Expression: colCtx.GetText(),
 			UsageType:  role,
 			Context:    ctx.GetText(), // Context is the text of the rule context that triggered the find
+			Span:       ref.span(tokens),
 		}
 
 		// Assign operator only to the first column to avoid duplicates
@@ -144,6 +154,7 @@ func parseColRefFromContext(ctx *gen.ColumnrefContext) columnRef {
 		ref.Name = strings.TrimSpace(ctx.GetText())
 	}
 	ref.Expr = ctx.GetText()
+	ref.node = ctx
 	return ref
 }
 
@@ -273,6 +284,27 @@ func aliasFromFuncAlias(alias gen.IFunc_alias_clauseContext, tokens antlr.TokenS
 	return ""
 }
 
+// hasSubquery reports whether a parenthesized SELECT or VALUES appears within
+// tree. Parentheses around a whole SELECT statement do not count.
+func hasSubquery(tree antlr.Tree) bool {
+	if tree == nil {
+		return false
+	}
+	_, wrapper := tree.(*gen.SelectstmtContext)
+	if !wrapper {
+		_, wrapper = tree.(*gen.Select_with_parensContext)
+	}
+	for _, child := range tree.GetChildren() {
+		if _, ok := child.(*gen.Select_with_parensContext); ok && !wrapper {
+			return true
+		}
+		if hasSubquery(child) {
+			return true
+		}
+	}
+	return false
+}
+
 // ctxText returns the exact input substring covered by the supplied parse context.
 func ctxText(tokens antlr.TokenStream, ctx antlr.RuleContext) string {
 	if ctx == nil {
@@ -17,8 +17,13 @@ const (
 	QueryCommandDelete QueryCommand = "DELETE"
 	// QueryCommandMerge is returned for MERGE statements.
 	QueryCommandMerge QueryCommand = "MERGE"
-	// QueryCommandDDL is returned for DDL statements (CREATE, ALTER, DROP, TRUNCATE).
+	// QueryCommandDDL is returned for DDL statements (CREATE, ALTER, DROP, TRUNCATE), CALL, and DO.
 	QueryCommandDDL QueryCommand = "DDL"
+	// QueryCommandDCL is returned for GRANT, REVOKE, and ALTER DEFAULT PRIVILEGES.
+	QueryCommandDCL QueryCommand = "DCL"
+	// QueryCommandTransaction is returned for transaction control statements
+	// (BEGIN, COMMIT, ROLLBACK, SAVEPOINT, ...).
+	QueryCommandTransaction QueryCommand = "TRANSACTION"
 	// QueryCommandUnknown is used when the command could not be determined.
 	QueryCommandUnknown QueryCommand = "UNKNOWN"
 )
@@ -52,6 +57,18 @@ const (
 	TableTypeSubquery TableType = "subquery"
 )
 
+// SourceSpan locates an IR element in the SQL text passed to the parser.
+// Start and End are byte offsets (End exclusive); lines and columns are
+// 1-based, with columns counted in runes and EndColumn just past the last rune.
+type SourceSpan struct {
+	Start       int
+	End         int
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+}
+
 // TableRef captures a table-like source referenced in a query.
 type TableRef struct {
 	Schema string
@@ -59,12 +76,22 @@ type TableRef struct {
 	Alias  string
 	Type   TableType
 	Raw    string
+	Span   *SourceSpan // Location of Raw; set only with ParseOptions.IncludeSourcePositions
+	Target bool        // The table written by INSERT, UPDATE, DELETE, or MERGE
 }
 
 // SelectColumn captures the projection list of a SELECT query.
 type SelectColumn struct {
 	Expression string
 	Alias      string
+	Span       *SourceSpan // Location of the target entry; set only with ParseOptions.IncludeSourcePositions
+	Expr       *Expr       // Typed form of Expression; set only with ParseOptions.IncludeExpressionTrees
+	// InferredType is the PostgreSQL type of the output column; set only by
+	// analysis.InferResultColumns.
+	InferredType string
+	// Nullable reports whether the output column can be NULL; set only by
+	// analysis.InferResultColumns.
+	Nullable bool
 }
 
 // SetOperation describes a UNION/INTERSECT/EXCEPT block chained to the main SELECT.
@@ -113,6 +140,79 @@ type MergeClause struct {
 	Actions   []MergeAction
 }
 
+// TransactionActionType identifies a transaction control statement.
+type TransactionActionType string
+
+const (
+	TransactionBegin            TransactionActionType = "BEGIN"
+	TransactionStart            TransactionActionType = "START_TRANSACTION"
+	TransactionCommit           TransactionActionType = "COMMIT"   // COMMIT and END
+	TransactionRollback         TransactionActionType = "ROLLBACK" // ROLLBACK and ABORT
+	TransactionSavepoint        TransactionActionType = "SAVEPOINT"
+	TransactionRelease          TransactionActionType = "RELEASE"
+	TransactionRollbackTo       TransactionActionType = "ROLLBACK_TO"
+	TransactionPrepare          TransactionActionType = "PREPARE_TRANSACTION"
+	TransactionCommitPrepared   TransactionActionType = "COMMIT_PREPARED"
+	TransactionRollbackPrepared TransactionActionType = "ROLLBACK_PREPARED"
+)
+
+// TransactionClause stores the metadata extracted from a transaction control statement.
+type TransactionClause struct {
+	Action         TransactionActionType
+	IsolationLevel string // "", "READ UNCOMMITTED", "READ COMMITTED", "REPEATABLE READ", or "SERIALIZABLE"
+	AccessMode     string // "", "READ ONLY", or "READ WRITE"
+	Deferrable     string // "", "DEFERRABLE", or "NOT DEFERRABLE"
+	Chain          bool   // COMMIT/ROLLBACK AND CHAIN
+	Savepoint      string // SAVEPOINT, RELEASE, and ROLLBACK TO target
+	GID            string // Decoded transaction identifier for PREPARE TRANSACTION / COMMIT PREPARED / ROLLBACK PREPARED
+}
+
+// PrivilegeActionType identifies whether privileges are granted or revoked.
+type PrivilegeActionType string
+
+const (
+	PrivilegeActionGrant  PrivilegeActionType = "GRANT"
+	PrivilegeActionRevoke PrivilegeActionType = "REVOKE"
+)
+
+// Privilege is one privilege from a GRANT/REVOKE list.
+type Privilege struct {
+	Name    string   // Upper-cased keyword: SELECT, INSERT, ..., or ALL
+	Columns []string // Column-level privileges, e.g. UPDATE (email)
+}
+
+// PrivilegeObject names one object that privileges apply to. For role
+// membership the object is the granted role.
+type PrivilegeObject struct {
+	Schema string
+	Name   string
+	Args   string // Argument types for FUNCTION/PROCEDURE/ROUTINE, e.g. "(integer, text)"
+}
+
+// PrivilegeClause stores the metadata extracted from GRANT, REVOKE, and
+// ALTER DEFAULT PRIVILEGES statements.
+type PrivilegeClause struct {
+	Action     PrivilegeActionType
+	Privileges []Privilege // Empty for role membership
+	// ObjectType is TABLE, SEQUENCE, FUNCTION, PROCEDURE, ROUTINE, SCHEMA,
+	// DATABASE, DOMAIN, TYPE, LANGUAGE, TABLESPACE, LARGE OBJECT, FOREIGN SERVER,
+	// FOREIGN DATA WRAPPER, or ROLE for role membership.
+	ObjectType string
+	Objects    []PrivilegeObject
+	// InSchemas lists the schemas of ALL <objects> IN SCHEMA, or the IN SCHEMA
+	// scope of ALTER DEFAULT PRIVILEGES.
+	InSchemas []string
+	Grantees  []string // Role names as written, including PUBLIC; GROUP is dropped
+	GrantedBy string
+	// Flags holds WITH_GRANT_OPTION, GRANT_OPTION_FOR, WITH_ADMIN_OPTION,
+	// ADMIN_OPTION_FOR, CASCADE, and RESTRICT.
+	Flags []string
+	// DefaultPrivileges is true for ALTER DEFAULT PRIVILEGES, which applies to
+	// objects created later rather than to existing ones.
+	DefaultPrivileges bool
+	ForRoles          []string // ALTER DEFAULT PRIVILEGES FOR ROLE/USER
+}
+
 // DDLActionType identifies the specific DDL operation.
 type DDLActionType string
 
@@ -125,6 +225,32 @@ const (
 	DDLDropIndex   DDLActionType = "DROP_INDEX"
 	DDLTruncate    DDLActionType = "TRUNCATE"
 	DDLComment     DDLActionType = "COMMENT"
+
+	DDLCreateTableAs           DDLActionType = "CREATE_TABLE_AS"
+	DDLCreateView              DDLActionType = "CREATE_VIEW"
+	DDLCreateMaterializedView  DDLActionType = "CREATE_MATERIALIZED_VIEW"
+	DDLRefreshMaterializedView DDLActionType = "REFRESH_MATERIALIZED_VIEW"
+
+	DDLCreateFunction  DDLActionType = "CREATE_FUNCTION"
+	DDLCreateProcedure DDLActionType = "CREATE_PROCEDURE"
+	DDLDropFunction    DDLActionType = "DROP_FUNCTION" // DROP FUNCTION, PROCEDURE, and ROUTINE; see ObjectType
+	DDLCall            DDLActionType = "CALL"
+	DDLDo              DDLActionType = "DO"
+
+	DDLCreateSequence  DDLActionType = "CREATE_SEQUENCE"
+	DDLAlterSequence   DDLActionType = "ALTER_SEQUENCE"
+	DDLDropSequence    DDLActionType = "DROP_SEQUENCE"
+	DDLCreateType      DDLActionType = "CREATE_TYPE"
+	DDLAlterType       DDLActionType = "ALTER_TYPE"
+	DDLDropType        DDLActionType = "DROP_TYPE"
+	DDLCreateDomain    DDLActionType = "CREATE_DOMAIN"
+	DDLDropDomain      DDLActionType = "DROP_DOMAIN"
+	DDLCreateTrigger   DDLActionType = "CREATE_TRIGGER"
+	DDLDropTrigger     DDLActionType = "DROP_TRIGGER"
+	DDLCreateExtension DDLActionType = "CREATE_EXTENSION"
+	DDLDropExtension   DDLActionType = "DROP_EXTENSION"
+	DDLCreateSchema    DDLActionType = "CREATE_SCHEMA"
+	DDLDropSchema      DDLActionType = "DROP_SCHEMA"
 )
 
 // DDLColumn describes column-level metadata extracted from CREATE TABLE statements.
@@ -136,18 +262,177 @@ type DDLColumn struct {
 	Comment  []string // Optional line comments immediately preceding column definition.
 }
 
+// DDLConstraintType identifies the kind of table or column constraint.
+type DDLConstraintType string
+
+const (
+	DDLConstraintPrimaryKey DDLConstraintType = "PRIMARY_KEY"
+	DDLConstraintUnique     DDLConstraintType = "UNIQUE"
+	DDLConstraintForeignKey DDLConstraintType = "FOREIGN_KEY"
+	DDLConstraintCheck      DDLConstraintType = "CHECK"
+	DDLConstraintExclude    DDLConstraintType = "EXCLUDE"
+)
+
+// DDLConstraint describes a constraint declared in CREATE TABLE or added/dropped
+// by ALTER TABLE. Column-level constraints list their column in Columns.
+type DDLConstraint struct {
+	Type              DDLConstraintType // Empty for ALTER TABLE ... DROP CONSTRAINT
+	Name              string            // Explicit CONSTRAINT name; empty when unnamed
+	Columns           []string          // Constrained columns (EXCLUDE: element expressions)
+	ReferencedSchema  string            // FOREIGN KEY target schema
+	ReferencedTable   string            // FOREIGN KEY target table
+	ReferencedColumns []string          // FOREIGN KEY target columns; empty means the target's primary key
+	OnDelete          string            // NO ACTION, RESTRICT, CASCADE, SET NULL, SET DEFAULT
+	OnUpdate          string            // NO ACTION, RESTRICT, CASCADE, SET NULL, SET DEFAULT
+	Match             string            // FULL, PARTIAL, SIMPLE
+	Expression        string            // CHECK expression or EXCLUDE WHERE predicate
+	Operators         []string          // EXCLUDE operators, paired with Columns
+	IndexType         string            // EXCLUDE access method (gist, btree, ...)
+	UsingIndex        string            // PRIMARY KEY / UNIQUE ... USING INDEX name
+	Flags             []string          // DEFERRABLE, INITIALLY_DEFERRED, NOT_VALID, NO_INHERIT, etc.
+}
+
+// DDLAlterOpType identifies a detailed ALTER TABLE sub-command.
+type DDLAlterOpType string
+
+const (
+	DDLAlterOpSetDataType        DDLAlterOpType = "SET_DATA_TYPE"
+	DDLAlterOpSetDefault         DDLAlterOpType = "SET_DEFAULT"
+	DDLAlterOpDropDefault        DDLAlterOpType = "DROP_DEFAULT"
+	DDLAlterOpSetNotNull         DDLAlterOpType = "SET_NOT_NULL"
+	DDLAlterOpDropNotNull        DDLAlterOpType = "DROP_NOT_NULL"
+	DDLAlterOpRenameColumn       DDLAlterOpType = "RENAME_COLUMN"
+	DDLAlterOpRenameTable        DDLAlterOpType = "RENAME_TABLE"
+	DDLAlterOpRenameConstraint   DDLAlterOpType = "RENAME_CONSTRAINT"
+	DDLAlterOpValidateConstraint DDLAlterOpType = "VALIDATE_CONSTRAINT"
+	DDLAlterOpSetSchema          DDLAlterOpType = "SET_SCHEMA"
+	DDLAlterOpOwnerTo            DDLAlterOpType = "OWNER_TO"
+	DDLAlterOpEnableTrigger      DDLAlterOpType = "ENABLE_TRIGGER"
+	DDLAlterOpDisableTrigger     DDLAlterOpType = "DISABLE_TRIGGER"
+	DDLAlterOpAttachPartition    DDLAlterOpType = "ATTACH_PARTITION"
+	DDLAlterOpDetachPartition    DDLAlterOpType = "DETACH_PARTITION"
+	DDLAlterOpRenameValue        DDLAlterOpType = "RENAME_VALUE" // ALTER TYPE ... RENAME VALUE
+)
+
+// DDLAlterOp carries the details of one ALTER TABLE sub-command, or of ALTER
+// TYPE ... RENAME VALUE. Only the fields relevant to Type are populated.
+type DDLAlterOp struct {
+	Type           DDLAlterOpType
+	Column         string // Target column for column-level sub-commands
+	DataType       string // SET DATA TYPE new type
+	Collation      string // SET DATA TYPE ... COLLATE name
+	Using          string // SET DATA TYPE ... USING expression
+	Default        string // SET DEFAULT expression
+	OldName        string // RENAME COLUMN/TABLE/CONSTRAINT/VALUE previous name
+	NewName        string // RENAME COLUMN/TABLE/CONSTRAINT/VALUE new name
+	Constraint     string // VALIDATE CONSTRAINT name
+	NewSchema      string // SET SCHEMA target schema
+	Owner          string // OWNER TO role
+	Trigger        string // ENABLE/DISABLE TRIGGER name, or ALL / USER
+	Partition      string // ATTACH/DETACH PARTITION name as written
+	PartitionBound string // ATTACH PARTITION bound spec (FOR VALUES ... / DEFAULT)
+}
+
+// DDLFunctionArg is one argument of a routine signature, or one argument
+// expression of a CALL.
+type DDLFunctionArg struct {
+	Name    string // Empty for unnamed arguments
+	Mode    string // IN, OUT, INOUT, or VARIADIC; empty when not written and for CALL
+	Type    string // Argument type as written; empty for CALL
+	Default string // DEFAULT expression
+	Value   string // CALL argument expression
+}
+
+// DDLFunction stores the routine metadata extracted from CREATE FUNCTION,
+// CREATE PROCEDURE, DROP FUNCTION, CALL, and DO. Only Args is set for DROP and
+// CALL; DO sets Language, Body, and the parsed body.
+type DDLFunction struct {
+	Args []DDLFunctionArg
+	// Returns is the return type as written, e.g. "integer", "SETOF orders",
+	// or "TABLE (id integer, total numeric)"; empty for procedures.
+	Returns    string
+	ReturnsSet bool   // RETURNS SETOF or RETURNS TABLE
+	Language   string // Lower-cased LANGUAGE name
+	Volatility string // IMMUTABLE, STABLE, or VOLATILE; empty when not written
+	Parallel   string // SAFE, RESTRICTED, or UNSAFE; empty when not written
+	// Body is the decoded AS string. For AS 'obj_file', 'link_symbol' it is
+	// the object file.
+	Body string
+	// Statements holds the parsed statements of a LANGUAGE sql or plpgsql
+	// body; statements that fail to parse are omitted. Source spans are
+	// relative to Body for sql and to each statement's RawSQL for plpgsql.
+	Statements []*ParsedQuery
+	// PLpgSQL is the parsed LANGUAGE plpgsql body, including dynamic EXECUTE
+	// statements; nil for other languages or when the block structure is
+	// malformed.
+	PLpgSQL *PLpgSQLBody
+}
+
+// DDLSequence stores the options of CREATE SEQUENCE and ALTER SEQUENCE as
+// written. NO MINVALUE, NO MAXVALUE, CYCLE, NO CYCLE, and a RESTART without a
+// value are reported as action flags.
+type DDLSequence struct {
+	DataType  string // AS type
+	Start     string // START [WITH] value
+	Restart   string // ALTER SEQUENCE ... RESTART [WITH] value
+	Increment string // INCREMENT [BY] value
+	MinValue  string
+	MaxValue  string
+	Cache     string
+	OwnedBy   string // OWNED BY table.column, or NONE
+}
+
+// DDLType stores the definition of a type created by CREATE TYPE or CREATE
+// DOMAIN, or the enum label added by ALTER TYPE ... ADD VALUE. Only the
+// fields relevant to Kind are populated.
+type DDLType struct {
+	Kind       string      // ENUM, COMPOSITE, RANGE, BASE, SHELL, or DOMAIN
+	Labels     []string    // ENUM labels in order; the added label for ADD VALUE
+	Before     string      // ADD VALUE ... BEFORE label
+	After      string      // ADD VALUE ... AFTER label
+	Attributes []DDLColumn // COMPOSITE attributes
+	Definition string      // RANGE and BASE option list as written, e.g. "(subtype = float8)"
+	BaseType   string      // DOMAIN underlying type
+	Default    string      // DOMAIN DEFAULT expression
+	NotNull    bool        // DOMAIN NOT NULL
+	Collation  string      // DOMAIN COLLATE name
+}
+
+// DDLTrigger stores the definition of CREATE TRIGGER. DROP TRIGGER sets only
+// the table.
+type DDLTrigger struct {
+	Table        string   // Unqualified table name
+	TableSchema  string   // Table schema qualifier
+	Timing       string   // BEFORE, AFTER, or INSTEAD OF
+	Events       []string // INSERT, UPDATE, DELETE, TRUNCATE; UPDATE OF columns are in DDLAction.Columns
+	ForEach      string   // ROW or STATEMENT
+	When         string   // WHEN condition
+	Function     string   // Trigger function as written, possibly schema-qualified
+	FunctionArgs []string // Function arguments as written
+}
+
 // DDLAction describes a single DDL operation extracted from a statement.
 type DDLAction struct {
 	Type          DDLActionType
-	ObjectName    string      // Unqualified table/index/object name
-	ObjectType    string      // TABLE, COLUMN, INDEX, ...
-	Schema        string      // Optional schema qualifier
-	Columns       []string    // Affected columns
-	ColumnDetails []DDLColumn // Column metadata (CREATE TABLE)
-	Flags         []string    // IF_EXISTS, CONCURRENTLY, CASCADE, etc.
-	IndexType     string      // btree, gin, gist, hash (CREATE INDEX only)
-	Target        string      // Generic fully-qualified target path for comment-like actions.
-	Comment       string      // Comment text for COMMENT ON statements.
+	ObjectName    string          // Unqualified table/index/object name
+	ObjectType    string          // TABLE, COLUMN, INDEX, ...
+	Schema        string          // Optional schema qualifier
+	Columns       []string        // Affected columns
+	ColumnDetails []DDLColumn     // Column metadata (CREATE TABLE)
+	Flags         []string        // IF_EXISTS, CONCURRENTLY, CASCADE, etc.
+	IndexType     string          // btree, gin, gist, hash (CREATE INDEX only)
+	Target        string          // Generic fully-qualified target path for comment-like actions.
+	Comment       string          // Comment text for COMMENT ON statements.
+	Query         *ParsedQuery    // Defining SELECT (CREATE TABLE AS / CREATE VIEW / CREATE MATERIALIZED VIEW)
+	Constraints   []DDLConstraint // Constraints (CREATE TABLE, ALTER TABLE ADD/DROP CONSTRAINT)
+	AlterOp       *DDLAlterOp     // Detailed ALTER TABLE sub-command, when recognized
+	Function      *DDLFunction    // Routine signature and body (CREATE/DROP FUNCTION, CREATE PROCEDURE, CALL, DO)
+	Sequence      *DDLSequence    // Sequence options (CREATE/ALTER SEQUENCE)
+	TypeDef       *DDLType        // Type definition (CREATE TYPE, CREATE DOMAIN, ALTER TYPE ... ADD VALUE)
+	Trigger       *DDLTrigger     // Trigger definition (CREATE/DROP TRIGGER)
+	Version       string          // CREATE EXTENSION ... VERSION
+	Owner         string          // CREATE SCHEMA ... AUTHORIZATION role
+	Span          *SourceSpan     // Sub-command or statement location; set only with ParseOptions.IncludeSourcePositions
 }
 
 // SubqueryRef records metadata for subqueries discovered in FROM or set operations.
@@ -173,8 +458,110 @@ type LimitClause struct {
 // Parameter describes a positional or anonymous parameter placeholder.
 type Parameter struct {
 	Raw      string
-	Marker   string // "$", "?"
-	Position int    // Parsed index for $n, or sequential order for '?'
+	Marker   string      // "$", "?"
+	Position int         // Parsed index for $n, or sequential order for '?'
+	Span     *SourceSpan // Placeholder location; set only with ParseOptions.IncludeSourcePositions
+	// InferredType is the PostgreSQL type expected at the placeholder, as
+	// written in a cast or the schema; set only by analysis.InferParameterTypes.
+	InferredType string
+	// Nullable reports whether the placeholder is bound to a nullable column
+	// (an INSERT value or SET target); set only by analysis.InferParameterTypes.
+	Nullable bool
+	// Column is the unqualified column the placeholder is compared with,
+	// assigned to, or inserted into; set only by analysis.InferParameterTypes.
+	Column string
+	// Clause is the clause keyword the placeholder appears in, such as WHERE,
+	// SET, VALUES, or LIMIT; set only by analysis.InferParameterTypes.
+	Clause string
+}
+
+// ExprKind identifies the node type of an Expr.
+type ExprKind string
+
+const (
+	ExprKindColumn   ExprKind = "COLUMN"   // Column reference
+	ExprKindLiteral  ExprKind = "LITERAL"  // Constant; see LiteralType
+	ExprKindParam    ExprKind = "PARAM"    // $n or ? placeholder
+	ExprKindBinary   ExprKind = "BINARY"   // Arithmetic, comparison, and other binary operators
+	ExprKindUnary    ExprKind = "UNARY"    // Prefix or postfix operator
+	ExprKindBool     ExprKind = "BOOL"     // AND, OR, NOT
+	ExprKindFunc     ExprKind = "FUNC"     // Function call, including aggregates and window calls
+	ExprKindCase     ExprKind = "CASE"     // CASE expression
+	ExprKindCast     ExprKind = "CAST"     // CAST(x AS t), TREAT(x AS t), and x::t
+	ExprKindIn       ExprKind = "IN"       // [NOT] IN (list) and [NOT] IN (subquery)
+	ExprKindBetween  ExprKind = "BETWEEN"  // [NOT] BETWEEN [SYMMETRIC]
+	ExprKindLike     ExprKind = "LIKE"     // [NOT] LIKE, ILIKE, SIMILAR TO
+	ExprKindIs       ExprKind = "IS"       // IS [NOT] NULL/TRUE/FALSE/UNKNOWN/DISTINCT FROM/..., ISNULL, NOTNULL
+	ExprKindSubquery ExprKind = "SUBQUERY" // Scalar, EXISTS, ARRAY, UNIQUE, and quantified subqueries
+	ExprKindRow      ExprKind = "ROW"      // ROW(...) and (a, b, ...)
+	ExprKindArray    ExprKind = "ARRAY"    // ARRAY[...]
+	ExprKindCollate  ExprKind = "COLLATE"  // x COLLATE name
+	ExprKindRaw      ExprKind = "RAW"      // Any other form; only Text is set
+)
+
+// LiteralType classifies the constant held by an ExprKindLiteral node.
+type LiteralType string
+
+const (
+	LiteralInteger   LiteralType = "integer"
+	LiteralNumeric   LiteralType = "numeric"
+	LiteralString    LiteralType = "string"
+	LiteralBitString LiteralType = "bitstring" // B'...' and X'...'
+	LiteralBoolean   LiteralType = "boolean"
+	LiteralNull      LiteralType = "null"
+	LiteralTyped     LiteralType = "typed" // type 'string', e.g. DATE '2024-01-01'; see TypeName
+)
+
+// Expr is a node of the typed expression tree built for predicates and
+// projections when ParseOptions.IncludeExpressionTrees is set. Only the
+// fields relevant to Kind are populated. Args holds the operands:
+//
+//   - BINARY: left and right operand
+//   - UNARY, CAST, COLLATE, IS: the operand (IS DISTINCT FROM adds the right side)
+//   - BOOL: the AND/OR operands, or the single NOT operand
+//   - FUNC, ROW, ARRAY: the arguments or elements
+//   - IN: the tested expression followed by the list items (none for a subquery)
+//   - BETWEEN: the tested expression, lower bound, and upper bound
+//   - LIKE: the tested expression, the pattern, and the optional ESCAPE
+//
+// Parentheses are not represented; a parenthesized expression is its inner node.
+type Expr struct {
+	Kind ExprKind
+	Text string  // Source text of the node
+	Op   string  // Operator or keyword: "=", "+", "AND", "NOT", "LIKE", "NULL" (IS), "EXISTS", ...
+	Not  bool    // NOT IN, NOT BETWEEN, NOT LIKE, IS NOT, NOTNULL
+	Args []*Expr // Operands; see above
+
+	Table  string // COLUMN qualifier (table alias or name)
+	Column string // COLUMN name; "*" for t.*
+
+	LiteralType LiteralType // LITERAL
+	Value       string      // LITERAL value, with string quoting removed
+
+	Param *Parameter // PARAM; Position is 0 for ? placeholders
+
+	FuncName string // FUNC name as written (schema-qualified when given), or the keyword for CURRENT_DATE, COALESCE, ...
+	Distinct bool   // FUNC with DISTINCT arguments
+	Star     bool   // FUNC called with *, e.g. count(*)
+	Filter   *Expr  // FUNC ... FILTER (WHERE ...) predicate
+	Over     string // FUNC ... OVER window name or specification, as written
+
+	TypeName  string // CAST target type; LITERAL type for LiteralTyped; IS OF type list
+	Collation string // COLLATE name
+
+	CaseOperand *Expr      // CASE operand WHEN ...
+	Whens       []ExprWhen // CASE WHEN branches
+	Else        *Expr      // CASE ELSE result
+
+	Symmetric  bool         // BETWEEN SYMMETRIC
+	Quantifier string       // ANY, SOME, or ALL for BINARY ... op ANY (...)
+	Query      *ParsedQuery // SUBQUERY and IN (subquery)
+}
+
+// ExprWhen is one WHEN ... THEN ... branch of a CASE expression.
+type ExprWhen struct {
+	Condition *Expr
+	Result    *Expr
 }
 
 // CTE describes a common table expression defined in a WITH clause.
@@ -226,6 +613,37 @@ type ColumnUsage struct {
 	Operator   string
 	Side       string
 	Functions  []string
+	Span       *SourceSpan // Location of the column reference; set only with ParseOptions.IncludeSourcePositions
+}
+
+// LineageKind classifies how an output column is computed from its sources.
+type LineageKind string
+
+const (
+	LineageDirect     LineageKind = "direct"     // A source column passed through unchanged
+	LineageExpression LineageKind = "expression" // Computed from its sources by an expression
+	LineageAggregate  LineageKind = "aggregate"  // Computed by an aggregate function
+	LineageWindow     LineageKind = "window"     // Computed by a window function
+)
+
+// ColumnSource is a base table column an output column is computed from.
+// Names are as written in the query, without quotes.
+type ColumnSource struct {
+	Schema string
+	Table  string // Empty for an unqualified column when several tables could hold it
+	Column string // "*" when a star over a base table could not be expanded
+}
+
+// ColumnLineage links one output column of a query, or one target column of
+// INSERT ... SELECT, CREATE TABLE AS, or a view, to its source columns.
+type ColumnLineage struct {
+	Column     string // Output name, or target column; empty for INSERT without a column list
+	Position   int    // 1-based position in the output
+	Expression string // Projected expression as written
+	// Kind is the most derived transformation applied to any source: a
+	// direct reference to an aggregated CTE column is an aggregate.
+	Kind    LineageKind
+	Sources []ColumnSource // Base table columns, deduplicated; empty for constants
 }
 
 // StatementParseResult contains the parse outcome for one input statement at the
@@ -235,13 +653,20 @@ type StatementParseResult struct {
 	RawSQL   string
 	Query    *ParsedQuery
 	Warnings []ParseWarning
+	// MetaCommand is set, and Query is nil, for backslash commands read in
+	// psql script mode (ParseOptions.PSQL).
+	MetaCommand *MetaCommand
+	// File is the path of the \i or \ir include the statement was read from
+	// in psql script mode; empty for the input SQL itself.
+	File string
 }
 
 // ParseBatchResult is returned by ParseSQLAll and includes one parse result per
 // input statement plus a failure flag.
 type ParseBatchResult struct {
 	Statements []StatementParseResult
-	// HasFailures is true when at least one statement has a nil Query or any Warnings.
+	// HasFailures is true when at least one statement has any Warnings or a nil
+	// Query, not counting psql meta-commands.
 	HasFailures bool
 }
 
@@ -254,20 +679,29 @@ type ParsedQuery struct {
 	ColumnUsage    []ColumnUsage
 	SetOperations  []SetOperation
 	Subqueries     []SubqueryRef
+	HasSubqueries  bool // A parenthesized SELECT or VALUES appears anywhere, including in expressions, which Subqueries does not list
 	CTEs           []CTE
 	Where          []string
+	WhereExprs     []*Expr // Parallel to Where; set only with ParseOptions.IncludeExpressionTrees
 	Having         []string
+	HavingExprs    []*Expr // Parallel to Having; set only with ParseOptions.IncludeExpressionTrees
 	GroupBy        []string
 	OrderBy        []OrderExpression
 	Limit          *LimitClause
 	JoinConditions []string
+	JoinExprs      []*Expr // Parallel to JoinConditions (ON predicate, nil for USING); set only with ParseOptions.IncludeExpressionTrees
 	Parameters     []Parameter
 	InsertColumns  []string
 	SetClauses     []string
+	SetClauseExprs []*Expr // Parallel to SetClauses (assigned value); set only with ParseOptions.IncludeExpressionTrees
 	Returning      []string
+	ReturningItems []SelectColumn // One entry per RETURNING target, shaped like Columns
 	Upsert         *UpsertClause
 	Merge          *MergeClause
+	Privileges     *PrivilegeClause   // GRANT, REVOKE, ALTER DEFAULT PRIVILEGES
+	Transaction    *TransactionClause // BEGIN, COMMIT, ROLLBACK, SAVEPOINT, ...
 	DDLActions     []DDLAction
 	Correlations   []JoinCorrelation // Join correlations for LATERAL and correlated subqueries
 	DerivedColumns map[string]string // Alias -> expression mappings (e.g., "order_count" -> "COUNT(*)")
+	Lineage        []ColumnLineage   // Output column sources; set only with ParseOptions.IncludeLineage
 }
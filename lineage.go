@@ -0,0 +1,609 @@
+// lineage.go traces the output columns of SELECT, INSERT ... SELECT, CREATE
+// TABLE AS, and view statements back to the base table columns they are
+// computed from, following aliases through CTEs, subqueries, and set operations.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+	"github.com/valkdb/postgresparser/internal/ident"
+)
+
+// aggregateFunctions lists the built-in aggregate functions by lower-cased name.
+var aggregateFunctions = map[string]struct{}{
+	"any_value": {}, "array_agg": {}, "avg": {}, "bit_and": {}, "bit_or": {}, "bit_xor": {},
+	"bool_and": {}, "bool_or": {}, "corr": {}, "count": {}, "covar_pop": {}, "covar_samp": {},
+	"cume_dist": {}, "dense_rank": {}, "every": {}, "json_agg": {}, "json_object_agg": {},
+	"jsonb_agg": {}, "jsonb_object_agg": {}, "max": {}, "min": {}, "mode": {},
+	"percent_rank": {}, "percentile_cont": {}, "percentile_disc": {}, "range_agg": {},
+	"range_intersect_agg": {}, "rank": {}, "regr_avgx": {}, "regr_avgy": {}, "regr_count": {},
+	"regr_intercept": {}, "regr_r2": {}, "regr_slope": {}, "regr_sxx": {}, "regr_sxy": {},
+	"regr_syy": {}, "stddev": {}, "stddev_pop": {}, "stddev_samp": {}, "string_agg": {},
+	"sum": {}, "var_pop": {}, "var_samp": {}, "variance": {}, "xmlagg": {},
+}
+
+// lineageRank orders the kinds from least to most derived; a column computed
+// from several inputs takes the highest kind among them.
+var lineageRank = map[LineageKind]int{
+	LineageDirect:     0,
+	LineageExpression: 1,
+	LineageAggregate:  2,
+	LineageWindow:     3,
+}
+
+// lineageRelation is a relation visible to a query block.
+type lineageRelation struct {
+	name    string          // Alias, or the unqualified relation name
+	schema  string          // Base tables only
+	table   string          // Base tables only
+	derived bool            // CTE, subquery, or function
+	columns []ColumnLineage // Output columns of a derived relation, when known
+}
+
+// lineageScope holds the relations of one query block and the CTEs it defines.
+// Names not found in a scope are looked up in its outer scopes.
+type lineageScope struct {
+	outer     *lineageScope
+	relations []lineageRelation
+	ctes      map[string][]ColumnLineage // Keyed by lower-cased name
+}
+
+// cte returns the output columns of the CTE visible under name.
+func (s *lineageScope) cte(name string) ([]ColumnLineage, bool) {
+	key := strings.ToLower(ident.TrimQuotes(name))
+	for ; s != nil; s = s.outer {
+		if cols, ok := s.ctes[key]; ok {
+			return cols, true
+		}
+	}
+	return nil, false
+}
+
+// column returns the sources and kind of column name in rel.
+func (rel lineageRelation) column(name string) ([]ColumnSource, LineageKind) {
+	if !rel.derived {
+		return []ColumnSource{{Schema: rel.schema, Table: rel.table, Column: name}}, LineageDirect
+	}
+	for _, col := range rel.columns {
+		if strings.EqualFold(col.Column, name) {
+			return append([]ColumnSource(nil), col.Sources...), col.Kind
+		}
+	}
+	return nil, LineageDirect
+}
+
+// hasColumn reports whether rel is derived and exposes column name.
+func (rel lineageRelation) hasColumn(name string) bool {
+	if !rel.derived {
+		return false
+	}
+	for _, col := range rel.columns {
+		if strings.EqualFold(col.Column, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lineageAcc accumulates the sources and kind of one output column.
+type lineageAcc struct {
+	kind    LineageKind
+	sources []ColumnSource
+}
+
+// add merges sources computed with kind into the accumulator.
+func (a *lineageAcc) add(sources []ColumnSource, kind LineageKind) {
+	a.raise(kind)
+	for _, src := range sources {
+		seen := false
+		for _, have := range a.sources {
+			if have == src {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			a.sources = append(a.sources, src)
+		}
+	}
+}
+
+// raise sets the accumulated kind to kind if it ranks higher.
+func (a *lineageAcc) raise(kind LineageKind) {
+	if lineageRank[kind] > lineageRank[a.kind] {
+		a.kind = kind
+	}
+}
+
+// buildLineage returns the column lineage of stmt. result must already be
+// populated; its InsertColumns and DDL action Columns name the target columns.
+func buildLineage(result *ParsedQuery, stmt gen.IStmtContext, tokens antlr.TokenStream) []ColumnLineage {
+	b := &lineageBuilder{tokens: tokens}
+	var (
+		cols    []ColumnLineage
+		targets []string
+	)
+	switch {
+	case stmt.Selectstmt() != nil:
+		cols = b.query(stmt.Selectstmt(), nil)
+	case stmt.Insertstmt() != nil:
+		insert := stmt.Insertstmt()
+		rest := insert.Insert_rest()
+		if rest == nil || rest.Selectstmt() == nil {
+			return nil
+		}
+		var scope *lineageScope
+		if with := insert.With_clause_(); with != nil && with.With_clause() != nil {
+			scope = b.with(with.With_clause(), nil)
+		}
+		cols = b.query(rest.Selectstmt(), scope)
+		// Without a column list the targets are the table's columns in order,
+		// which are unknown here.
+		for i := range cols {
+			if i < len(result.InsertColumns) {
+				cols[i].Column = result.InsertColumns[i]
+			} else {
+				cols[i].Column = ""
+			}
+		}
+	case stmt.Viewstmt() != nil:
+		cols = b.query(stmt.Viewstmt().Selectstmt(), nil)
+	case stmt.Creatematviewstmt() != nil:
+		cols = b.query(stmt.Creatematviewstmt().Selectstmt(), nil)
+	case stmt.Createasstmt() != nil:
+		cols = b.query(stmt.Createasstmt().Selectstmt(), nil)
+	default:
+		return nil
+	}
+	if len(result.DDLActions) > 0 {
+		targets = result.DDLActions[0].Columns
+	}
+	cols = renameLineage(cols, targets)
+	for i := range cols {
+		cols[i].Position = i + 1
+	}
+	return cols
+}
+
+// lineageBuilder walks SELECT parse trees to compute column lineage.
+type lineageBuilder struct {
+	tokens antlr.TokenStream
+}
+
+// query returns the output columns of a SELECT statement.
+func (b *lineageBuilder) query(selectCtx gen.ISelectstmtContext, outer *lineageScope) []ColumnLineage {
+	if selectCtx == nil {
+		return nil
+	}
+	if snp := selectCtx.Select_no_parens(); snp != nil {
+		return b.selectNoParens(snp, outer)
+	}
+	return b.selectWithParens(selectCtx.Select_with_parens(), outer)
+}
+
+// selectWithParens unwraps a parenthesized SELECT.
+func (b *lineageBuilder) selectWithParens(swp gen.ISelect_with_parensContext, outer *lineageScope) []ColumnLineage {
+	for swp != nil {
+		if snp := swp.Select_no_parens(); snp != nil {
+			return b.selectNoParens(snp, outer)
+		}
+		swp = swp.Select_with_parens()
+	}
+	return nil
+}
+
+// selectNoParens returns the output columns of a SELECT with its WITH clause
+// and set operations. The arms of a set operation are merged by position and
+// take their names from the first arm.
+func (b *lineageBuilder) selectNoParens(snp gen.ISelect_no_parensContext, outer *lineageScope) []ColumnLineage {
+	scope := outer
+	if with := snp.With_clause(); with != nil {
+		scope = b.with(with, outer)
+	}
+	clause := snp.Select_clause()
+	if clause == nil {
+		return nil
+	}
+	var cols []ColumnLineage
+	first := true
+	for _, intersect := range clause.AllSimple_select_intersect() {
+		for _, primary := range intersect.AllSimple_select_pramary() {
+			arm := b.primary(primary, scope)
+			if first {
+				cols, first = arm, false
+				continue
+			}
+			cols = mergeLineage(cols, arm)
+		}
+	}
+	return cols
+}
+
+// with returns a scope holding the CTEs of a WITH clause. Each CTE sees the
+// ones defined before it; a recursive CTE also sees itself, so its recursive
+// arm is traced through the columns of the non-recursive one.
+func (b *lineageBuilder) with(withCtx gen.IWith_clauseContext, outer *lineageScope) *lineageScope {
+	scope := &lineageScope{outer: outer, ctes: make(map[string][]ColumnLineage)}
+	list := withCtx.Cte_list()
+	if list == nil {
+		return scope
+	}
+	recursive := withCtx.RECURSIVE() != nil
+	for _, cte := range list.AllCommon_table_expr() {
+		if cte == nil || cte.Name() == nil {
+			continue
+		}
+		key := strings.ToLower(ident.TrimQuotes(contextText(b.tokens, cte.Name())))
+		var names []string
+		if list := cte.Name_list_(); list != nil {
+			names = b.names(list.Name_list())
+		}
+		if !recursive {
+			scope.ctes[key] = renameLineage(b.preparable(cte.Preparablestmt(), scope), names)
+			continue
+		}
+		// Deliberate two-pass approximation of the fixed point: the first
+		// pass sees the CTE as empty and yields the columns of the
+		// non-recursive arm; the second traces the recursive arm through
+		// them. Sources that would only arrive after further iterations,
+		// such as through columns that swap positions, are not followed.
+		scope.ctes[key] = renameLineage(nil, names)
+		for pass := 0; pass < 2; pass++ {
+			scope.ctes[key] = renameLineage(b.preparable(cte.Preparablestmt(), scope), names)
+		}
+	}
+	return scope
+}
+
+// preparable returns the output columns of a CTE body. Data-modifying bodies
+// are not traced.
+func (b *lineageBuilder) preparable(stmt gen.IPreparablestmtContext, scope *lineageScope) []ColumnLineage {
+	if stmt == nil || stmt.Selectstmt() == nil {
+		return nil
+	}
+	return b.query(stmt.Selectstmt(), scope)
+}
+
+// primary returns the output columns of one SELECT, VALUES, or TABLE arm.
+func (b *lineageBuilder) primary(primary gen.ISimple_select_pramaryContext, outer *lineageScope) []ColumnLineage {
+	if primary == nil {
+		return nil
+	}
+	if swp := primary.Select_with_parens(); swp != nil {
+		return b.selectWithParens(swp, outer)
+	}
+	if values := primary.Values_clause(); values != nil {
+		return b.values(values, outer)
+	}
+
+	scope := &lineageScope{outer: outer}
+	if rel := primary.Relation_expr(); rel != nil {
+		b.addRelation(scope, rel, "", nil)
+		return b.star(scope, "", contextText(b.tokens, primary))
+	}
+	if from := primary.From_clause(); from != nil && from.From_list() != nil {
+		for _, ref := range from.From_list().AllTable_ref() {
+			b.tableRef(scope, ref)
+		}
+	}
+
+	targets := primary.Target_list()
+	if targets == nil && primary.Target_list_() != nil {
+		targets = primary.Target_list_().Target_list()
+	}
+	if targets == nil {
+		return nil
+	}
+	var cols []ColumnLineage
+	for _, item := range targets.AllTarget_el() {
+		switch target := item.(type) {
+		case *gen.Target_labelContext:
+			alias := ""
+			switch {
+			case target.ColLabel() != nil:
+				alias = ident.TrimQuotes(contextText(b.tokens, target.ColLabel()))
+			case target.BareColLabel() != nil:
+				alias = ident.TrimQuotes(contextText(b.tokens, target.BareColLabel()))
+			}
+			cols = append(cols, b.target(scope, target.A_expr(), alias)...)
+		case *gen.Target_starContext:
+			cols = append(cols, b.star(scope, "", contextText(b.tokens, target))...)
+		}
+	}
+	return cols
+}
+
+// values returns the output columns of a VALUES list, merging its rows by
+// position.
+func (b *lineageBuilder) values(values gen.IValues_clauseContext, scope *lineageScope) []ColumnLineage {
+	var cols []ColumnLineage
+	for i, row := range values.AllExpr_list() {
+		var arm []ColumnLineage
+		for j, expr := range row.AllA_expr() {
+			arm = append(arm, b.target(scope, expr, fmt.Sprintf("column%d", j+1))...)
+		}
+		if i == 0 {
+			cols = arm
+			continue
+		}
+		cols = mergeLineage(cols, arm)
+	}
+	return cols
+}
+
+// tableRef adds the relations of a FROM item, including joined ones, to scope.
+func (b *lineageBuilder) tableRef(scope *lineageScope, ref gen.ITable_refContext) {
+	if ref == nil {
+		return
+	}
+	switch {
+	case ref.Relation_expr() != nil:
+		b.addRelation(scope, ref.Relation_expr(), aliasFromAliasClause(ref.Alias_clause(), b.tokens), ref.Alias_clause())
+	case ref.Select_with_parens() != nil:
+		rel := lineageRelation{
+			name:    ident.TrimQuotes(aliasFromAliasClause(ref.Alias_clause(), b.tokens)),
+			derived: true,
+			columns: b.selectWithParens(ref.Select_with_parens(), scope),
+		}
+		rel.columns = renameLineage(rel.columns, b.aliasColumns(ref.Alias_clause()))
+		scope.relations = append(scope.relations, rel)
+	case ref.Func_table() != nil:
+		// The columns of a set-returning function are not table columns.
+		scope.relations = append(scope.relations, lineageRelation{
+			name:    ident.TrimQuotes(aliasFromFuncAlias(ref.Func_alias_clause(), b.tokens)),
+			derived: true,
+		})
+	}
+	for _, nested := range ref.AllTable_ref() {
+		b.tableRef(scope, nested)
+	}
+}
+
+// addRelation adds a named relation to scope: the CTE it refers to when one
+// is visible, otherwise a base table.
+func (b *lineageBuilder) addRelation(scope *lineageScope, rel gen.IRelation_exprContext, alias string, aliasClause gen.IAlias_clauseContext) {
+	if rel.Qualified_name() == nil {
+		return
+	}
+	schema, table := splitQualifiedName(contextText(b.tokens, rel.Qualified_name()))
+	entry := lineageRelation{name: ident.TrimQuotes(table)}
+	if alias != "" {
+		entry.name = ident.TrimQuotes(alias)
+	}
+	if cols, ok := scope.cte(table); ok && schema == "" {
+		entry.derived = true
+		entry.columns = renameLineage(cols, b.aliasColumns(aliasClause))
+	} else {
+		entry.schema = ident.TrimQuotes(schema)
+		entry.table = ident.TrimQuotes(table)
+	}
+	scope.relations = append(scope.relations, entry)
+}
+
+// target returns the lineage of one projected expression; a qualified star
+// expands to several columns. alias is the output name, or empty to derive
+// it the way PostgreSQL does.
+func (b *lineageBuilder) target(scope *lineageScope, exprCtx gen.IA_exprContext, alias string) []ColumnLineage {
+	if exprCtx == nil {
+		return nil
+	}
+	text := contextText(b.tokens, exprCtx)
+	expr := buildExpr(exprCtx, b.tokens)
+	if expr != nil && expr.Kind == ExprKindColumn {
+		if expr.Column == "*" {
+			return b.star(scope, expr.Table, text)
+		}
+		sources, kind := b.resolve(scope, columnRef{TableAlias: expr.Table, Name: ident.TrimQuotes(expr.Column)})
+		if alias == "" {
+			alias = ident.TrimQuotes(expr.Column)
+		}
+		return []ColumnLineage{{Column: alias, Expression: text, Kind: kind, Sources: sources}}
+	}
+
+	acc := &lineageAcc{kind: LineageExpression}
+	b.collect(scope, exprCtx, acc)
+	if alias == "" {
+		alias = OutputColumnName(expr)
+	}
+	return []ColumnLineage{{Column: alias, Expression: text, Kind: acc.kind, Sources: acc.sources}}
+}
+
+// star expands * or qualifier.* over the relations of the current block. A
+// base table's columns are unknown, so it yields a single "*" column.
+func (b *lineageBuilder) star(scope *lineageScope, qualifier, text string) []ColumnLineage {
+	var cols []ColumnLineage
+	for _, rel := range scope.relations {
+		if qualifier != "" && !strings.EqualFold(rel.name, ident.TrimQuotes(qualifier)) {
+			continue
+		}
+		if !rel.derived {
+			cols = append(cols, ColumnLineage{
+				Column:     "*",
+				Expression: text,
+				Kind:       LineageDirect,
+				Sources:    []ColumnSource{{Schema: rel.schema, Table: rel.table, Column: "*"}},
+			})
+			continue
+		}
+		for _, col := range rel.columns {
+			col.Expression = text
+			col.Sources = append([]ColumnSource(nil), col.Sources...)
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// collect adds the sources of every column reference in node to acc, and
+// raises acc to aggregate or window for the function calls it contains.
+// Subqueries contribute the sources of all their output columns.
+func (b *lineageBuilder) collect(scope *lineageScope, node antlr.Tree, acc *lineageAcc) {
+	switch n := node.(type) {
+	case *gen.ColumnrefContext:
+		sources, kind := b.resolve(scope, parseColRefFromContext(n))
+		acc.add(sources, kind)
+		return
+	case *gen.Select_with_parensContext:
+		for _, col := range b.selectWithParens(n, scope) {
+			acc.add(col.Sources, col.Kind)
+		}
+		return
+	case *gen.Func_exprContext:
+		if firstChildOfType[*gen.Over_clauseContext](n) != nil {
+			acc.raise(LineageWindow)
+		} else if isAggregateCall(n) {
+			acc.raise(LineageAggregate)
+		}
+	}
+	for _, child := range node.GetChildren() {
+		b.collect(scope, child, acc)
+	}
+}
+
+// resolve finds the sources of a column reference, searching outer scopes for
+// correlated references. An unqualified column is taken from the derived
+// relation that exposes it, or else from the block's only base table; with
+// several base tables its table is left empty.
+func (b *lineageBuilder) resolve(scope *lineageScope, ref columnRef) ([]ColumnSource, LineageKind) {
+	if ref.TableAlias != "" {
+		for s := scope; s != nil; s = s.outer {
+			for _, rel := range s.relations {
+				if strings.EqualFold(rel.name, ref.TableAlias) {
+					return rel.column(ref.Name)
+				}
+			}
+		}
+		return []ColumnSource{{Table: ref.TableAlias, Column: ref.Name}}, LineageDirect
+	}
+
+	for s := scope; s != nil; s = s.outer {
+		var bases []lineageRelation
+		for _, rel := range s.relations {
+			if rel.hasColumn(ref.Name) {
+				return rel.column(ref.Name)
+			}
+			if !rel.derived {
+				bases = append(bases, rel)
+			}
+		}
+		switch len(bases) {
+		case 0:
+			continue
+		case 1:
+			return bases[0].column(ref.Name)
+		default:
+			return []ColumnSource{{Column: ref.Name}}, LineageDirect
+		}
+	}
+	return []ColumnSource{{Column: ref.Name}}, LineageDirect
+}
+
+// names returns the identifiers of a name_list.
+func (b *lineageBuilder) names(list gen.IName_listContext) []string {
+	if list == nil {
+		return nil
+	}
+	var names []string
+	for _, name := range list.AllName() {
+		names = append(names, ident.TrimQuotes(contextText(b.tokens, name)))
+	}
+	return names
+}
+
+// aliasColumns returns the column names of an alias clause, e.g. s(a, b).
+func (b *lineageBuilder) aliasColumns(alias gen.IAlias_clauseContext) []string {
+	if alias == nil {
+		return nil
+	}
+	return b.names(alias.Name_list())
+}
+
+// isAggregateCall reports whether a function call is an aggregate: a known
+// aggregate function, or any call with WITHIN GROUP or FILTER.
+func isAggregateCall(ctx *gen.Func_exprContext) bool {
+	if firstChildOfType[*gen.Within_group_clauseContext](ctx) != nil || firstChildOfType[*gen.Filter_clauseContext](ctx) != nil {
+		return true
+	}
+	app := firstChildOfType[*gen.Func_applicationContext](ctx)
+	if app == nil || app.Func_name() == nil {
+		return false
+	}
+	return IsAggregateFunction(app.Func_name().GetText())
+}
+
+// IsAggregateFunction reports whether name, optionally schema-qualified, is a
+// built-in aggregate function.
+func IsAggregateFunction(name string) bool {
+	parts := splitQuotedDot(strings.TrimSpace(name))
+	_, ok := aggregateFunctions[strings.ToLower(ident.TrimQuotes(parts[len(parts)-1]))]
+	return ok
+}
+
+// OutputColumnName returns the name PostgreSQL gives an unaliased output
+// expression: the column or function name, "case", "array", "row", "exists",
+// or "?column?" when PostgreSQL has no better name.
+func OutputColumnName(expr *Expr) string {
+	if expr == nil {
+		return "?column?"
+	}
+	switch expr.Kind {
+	case ExprKindColumn:
+		return ident.TrimQuotes(expr.Column)
+	case ExprKindFunc:
+		parts := splitQuotedDot(expr.FuncName)
+		name := strings.TrimSpace(parts[len(parts)-1])
+		if strings.HasPrefix(name, `"`) {
+			return ident.TrimQuotes(name)
+		}
+		return strings.ToLower(name)
+	case ExprKindCast:
+		if len(expr.Args) > 0 {
+			return OutputColumnName(expr.Args[0])
+		}
+	case ExprKindCase:
+		return "case"
+	case ExprKindArray:
+		return "array"
+	case ExprKindRow:
+		return "row"
+	case ExprKindSubquery:
+		switch expr.Op {
+		case "EXISTS":
+			return "exists"
+		case "ARRAY":
+			return "array"
+		}
+	}
+	return "?column?"
+}
+
+// mergeLineage merges a set operation arm into cols by position.
+func mergeLineage(cols, arm []ColumnLineage) []ColumnLineage {
+	for i := range cols {
+		if i >= len(arm) {
+			break
+		}
+		acc := &lineageAcc{kind: cols[i].Kind, sources: cols[i].Sources}
+		acc.add(arm[i].Sources, arm[i].Kind)
+		cols[i].Kind, cols[i].Sources = acc.kind, acc.sources
+	}
+	return cols
+}
+
+// renameLineage returns a copy of cols with the leading columns renamed to
+// names; names beyond the end of cols add columns without sources.
+func renameLineage(cols []ColumnLineage, names []string) []ColumnLineage {
+	out := append([]ColumnLineage(nil), cols...)
+	for i, name := range names {
+		if i < len(out) {
+			out[i].Column = name
+		} else {
+			out = append(out, ColumnLineage{Column: name, Kind: LineageDirect})
+		}
+	}
+	return out
+}
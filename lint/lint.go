@@ -0,0 +1,205 @@
+// Package lint reports migration-safety hazards found in parsed DDL, such as
+// statements that take long-held locks, rewrite tables, or destroy data.
+// This file defines findings, configuration, and the batch driver.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/ident"
+)
+
+// Severity ranks how likely a finding is to cause an incident.
+type Severity string
+
+const (
+	// SeverityError marks operations that block traffic or lose data.
+	SeverityError Severity = "ERROR"
+	// SeverityWarning marks operations that are risky on large or busy tables.
+	SeverityWarning Severity = "WARNING"
+)
+
+// RuleID is the stable identifier of a lint rule.
+type RuleID string
+
+const (
+	RuleCreateIndexNotConcurrent RuleID = "CREATE_INDEX_NOT_CONCURRENTLY"
+	RuleAddColumnVolatileDefault RuleID = "ADD_COLUMN_VOLATILE_DEFAULT"
+	RuleSetNotNull               RuleID = "SET_NOT_NULL"
+	RuleDropColumn               RuleID = "DROP_COLUMN"
+	RuleDropTable                RuleID = "DROP_TABLE"
+	RuleAlterColumnType          RuleID = "ALTER_COLUMN_TYPE"
+	RuleTruncate                 RuleID = "TRUNCATE"
+)
+
+// Rule describes one lint rule.
+type Rule struct {
+	ID          RuleID
+	Severity    Severity
+	Description string
+}
+
+// Finding is one hazard reported for a statement.
+type Finding struct {
+	Rule     RuleID
+	Severity Severity
+	// Statement is the 1-based statement index within the linted batch.
+	Statement int
+	// Object is the qualified table (and column, where relevant) the finding is about.
+	Object  string
+	Message string
+}
+
+// String formats the finding as "statement N: SEVERITY RULE object: message".
+func (f Finding) String() string {
+	return fmt.Sprintf("statement %d: %s %s %s: %s", f.Statement, f.Severity, f.Rule, f.Object, f.Message)
+}
+
+// Config selects which rules run. The zero value runs every rule.
+type Config struct {
+	// Disabled lists rules to skip.
+	Disabled []RuleID
+}
+
+// enabled reports whether id is not disabled by cfg.
+func (cfg Config) enabled(id RuleID) bool {
+	for _, disabled := range cfg.Disabled {
+		if disabled == id {
+			return false
+		}
+	}
+	return true
+}
+
+// Rules returns every available rule sorted by ID.
+func Rules() []Rule {
+	out := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, r.Rule)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// LintSQL parses sql with ParseSQLAll and lints the resulting batch.
+func LintSQL(sql string, cfg Config) ([]Finding, error) {
+	batch, err := postgresparser.ParseSQLAll(sql)
+	if err != nil {
+		return nil, err
+	}
+	return Lint(batch, cfg), nil
+}
+
+// Lint checks every statement of batch in order and returns findings ordered
+// by statement index. Statements that failed to parse are skipped.
+//
+// Tables created earlier in the same batch are new and therefore empty, so
+// operations on them are not reported.
+func Lint(batch *postgresparser.ParseBatchResult, cfg Config) []Finding {
+	if batch == nil {
+		return nil
+	}
+	st := newState()
+	var findings []Finding
+	for _, stmt := range batch.Statements {
+		if stmt.Query == nil {
+			continue
+		}
+		for _, action := range stmt.Query.DDLActions {
+			for _, r := range rules {
+				if !cfg.enabled(r.ID) {
+					continue
+				}
+				for _, f := range r.check(st, stmt.Query, action) {
+					f.Rule = r.ID
+					f.Severity = r.Severity
+					f.Statement = stmt.Index
+					findings = append(findings, f)
+				}
+			}
+			st.record(action)
+		}
+	}
+	return findings
+}
+
+// state tracks what earlier statements in the batch did, so rules can tell
+// new tables from existing ones and find CHECK constraints added beforehand.
+type state struct {
+	created    map[string]bool
+	notNullChk map[string]bool   // Validated CHECK (col IS NOT NULL), by table.col
+	notValid   map[string]string // Column of each NOT VALID CHECK (col IS NOT NULL), by table.constraint
+}
+
+// newState returns empty batch state.
+func newState() *state {
+	return &state{
+		created:    make(map[string]bool),
+		notNullChk: make(map[string]bool),
+		notValid:   make(map[string]string),
+	}
+}
+
+// record updates batch state after an action has been checked.
+func (st *state) record(action postgresparser.DDLAction) {
+	table := tableKey(action.Schema, action.ObjectName)
+	switch action.Type {
+	case postgresparser.DDLCreateTable:
+		st.created[table] = true
+	case postgresparser.DDLDropTable:
+		delete(st.created, table)
+	case postgresparser.DDLAlterTable:
+		if op := action.AlterOp; op != nil && op.Type == postgresparser.DDLAlterOpValidateConstraint {
+			key := table + "." + strings.ToLower(ident.TrimQuotes(op.Constraint))
+			if col, ok := st.notValid[key]; ok {
+				st.notNullChk[table+"."+col] = true
+				delete(st.notValid, key)
+			}
+		}
+		for _, con := range action.Constraints {
+			if con.Type != postgresparser.DDLConstraintCheck {
+				continue
+			}
+			col, ok := isNotNullCheck(con.Expression)
+			if !ok {
+				continue
+			}
+			if !hasFlag(con.Flags, "NOT_VALID") {
+				st.notNullChk[table+"."+col] = true
+				continue
+			}
+			// An unvalidated check does not let SET NOT NULL skip the table
+			// scan until VALIDATE CONSTRAINT; unnamed checks get PostgreSQL's
+			// default name.
+			name := con.Name
+			if name == "" {
+				name = ident.TrimQuotes(action.ObjectName) + "_" + col + "_check"
+			}
+			st.notValid[table+"."+strings.ToLower(ident.TrimQuotes(name))] = col
+		}
+	}
+}
+
+// isNew reports whether the table was created earlier in the batch.
+func (st *state) isNew(schema, name string) bool {
+	return st.created[tableKey(schema, name)]
+}
+
+// tableKey builds a lowercased, schema-qualified key with "public" as default schema.
+func tableKey(schema, name string) string {
+	if schema == "" {
+		schema = "public"
+	}
+	return strings.ToLower(ident.TrimQuotes(schema) + "." + ident.TrimQuotes(name))
+}
+
+// qualified formats schema.name for messages, omitting an empty schema.
+func qualified(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
@@ -0,0 +1,222 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+func TestLintSQL_Rules(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		wantRule   RuleID
+		wantSev    Severity
+		wantObject string
+	}{
+		{
+			name:       "create index without concurrently",
+			sql:        "CREATE INDEX users_email_idx ON users (email)",
+			wantRule:   RuleCreateIndexNotConcurrent,
+			wantSev:    SeverityError,
+			wantObject: "users",
+		},
+		{
+			name:       "add column with volatile default",
+			sql:        "ALTER TABLE users ADD COLUMN token uuid DEFAULT gen_random_uuid()",
+			wantRule:   RuleAddColumnVolatileDefault,
+			wantSev:    SeverityError,
+			wantObject: "users.token",
+		},
+		{
+			name:       "add column with schema-qualified volatile default",
+			sql:        "ALTER TABLE app.users ADD COLUMN r float8 NOT NULL DEFAULT pg_catalog.random()",
+			wantRule:   RuleAddColumnVolatileDefault,
+			wantSev:    SeverityError,
+			wantObject: "app.users.r",
+		},
+		{
+			name:       "add serial column",
+			sql:        "ALTER TABLE users ADD COLUMN seq bigserial",
+			wantRule:   RuleAddColumnVolatileDefault,
+			wantSev:    SeverityError,
+			wantObject: "users.seq",
+		},
+		{
+			name:       "set not null",
+			sql:        "ALTER TABLE users ALTER COLUMN email SET NOT NULL",
+			wantRule:   RuleSetNotNull,
+			wantSev:    SeverityWarning,
+			wantObject: "users.email",
+		},
+		{
+			name:       "drop column",
+			sql:        "ALTER TABLE users DROP COLUMN legacy",
+			wantRule:   RuleDropColumn,
+			wantSev:    SeverityWarning,
+			wantObject: "users.legacy",
+		},
+		{
+			name:       "drop table",
+			sql:        "DROP TABLE IF EXISTS public.sessions",
+			wantRule:   RuleDropTable,
+			wantSev:    SeverityError,
+			wantObject: "public.sessions",
+		},
+		{
+			name:       "column type change",
+			sql:        "ALTER TABLE users ALTER COLUMN id TYPE bigint",
+			wantRule:   RuleAlterColumnType,
+			wantSev:    SeverityError,
+			wantObject: "users.id",
+		},
+		{
+			name:       "truncate",
+			sql:        "TRUNCATE events",
+			wantRule:   RuleTruncate,
+			wantSev:    SeverityError,
+			wantObject: "events",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, err := LintSQL(tc.sql, Config{})
+			require.NoError(t, err)
+			require.Len(t, findings, 1)
+			f := findings[0]
+			assert.Equal(t, tc.wantRule, f.Rule)
+			assert.Equal(t, tc.wantSev, f.Severity)
+			assert.Equal(t, 1, f.Statement)
+			assert.Equal(t, tc.wantObject, f.Object)
+			assert.NotEmpty(t, f.Message)
+		})
+	}
+}
+
+func TestLintSQL_SafePatterns(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{
+			name: "concurrent index",
+			sql:  "CREATE INDEX CONCURRENTLY users_email_idx ON users (email)",
+		},
+		{
+			name: "stable default",
+			sql:  "ALTER TABLE users ADD COLUMN created_at timestamptz NOT NULL DEFAULT now()",
+		},
+		{
+			name: "constant default",
+			sql:  "ALTER TABLE users ADD COLUMN status text DEFAULT 'active'",
+		},
+		{
+			name: "set not null after check",
+			sql: `ALTER TABLE users ADD CONSTRAINT users_email_not_null CHECK (email IS NOT NULL);
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;`,
+		},
+		{
+			name: "set not null after validated check",
+			sql: `ALTER TABLE users ADD CONSTRAINT users_email_not_null CHECK (email IS NOT NULL) NOT VALID;
+ALTER TABLE users VALIDATE CONSTRAINT users_email_not_null;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;`,
+		},
+		{
+			name: "set not null after validated unnamed check",
+			sql: `ALTER TABLE users ADD CHECK (email IS NOT NULL) NOT VALID;
+ALTER TABLE users VALIDATE CONSTRAINT users_email_check;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;`,
+		},
+		{
+			name: "operations on table created in the same batch",
+			sql: `CREATE TABLE audit (id int, note text);
+CREATE INDEX audit_id_idx ON audit (id);
+ALTER TABLE audit ADD COLUMN token uuid DEFAULT gen_random_uuid();
+ALTER TABLE audit ALTER COLUMN note SET NOT NULL;
+ALTER TABLE audit ALTER COLUMN id TYPE bigint;
+ALTER TABLE audit DROP COLUMN note;
+TRUNCATE audit;
+DROP TABLE audit;`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, err := LintSQL(tc.sql, Config{})
+			require.NoError(t, err)
+			assert.Empty(t, findings)
+		})
+	}
+}
+
+func TestLintSQL_SetNotNullAfterNotValidCheck(t *testing.T) {
+	findings, err := LintSQL(`
+ALTER TABLE users ADD CONSTRAINT users_email_not_null CHECK (email IS NOT NULL) NOT VALID;
+ALTER TABLE users VALIDATE CONSTRAINT users_other_check;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
+`, Config{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleSetNotNull, findings[0].Rule)
+	assert.Equal(t, 3, findings[0].Statement)
+	assert.Equal(t, "users.email", findings[0].Object)
+}
+
+func TestLintSQL_StatementIndexAndOrder(t *testing.T) {
+	findings, err := LintSQL(`
+CREATE TABLE audit (id int);
+CREATE INDEX users_email_idx ON users (email);
+SELECT 1;
+ALTER TABLE users DROP COLUMN legacy, ALTER COLUMN id TYPE bigint;
+`, Config{})
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+
+	assert.Equal(t, RuleCreateIndexNotConcurrent, findings[0].Rule)
+	assert.Equal(t, 2, findings[0].Statement)
+	assert.Equal(t, RuleDropColumn, findings[1].Rule)
+	assert.Equal(t, 4, findings[1].Statement)
+	assert.Equal(t, RuleAlterColumnType, findings[2].Rule)
+	assert.Equal(t, 4, findings[2].Statement)
+	assert.Equal(t, "statement 2: ERROR CREATE_INDEX_NOT_CONCURRENTLY users: use CREATE INDEX CONCURRENTLY to avoid blocking writes", findings[0].String())
+}
+
+func TestLintSQL_DisabledRules(t *testing.T) {
+	sql := "DROP TABLE sessions; TRUNCATE events;"
+
+	findings, err := LintSQL(sql, Config{Disabled: []RuleID{RuleDropTable}})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleTruncate, findings[0].Rule)
+
+	findings, err = LintSQL(sql, Config{Disabled: []RuleID{RuleDropTable, RuleTruncate}})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestLint_SkipsUnparsedStatements(t *testing.T) {
+	batch := &postgresparser.ParseBatchResult{
+		Statements: []postgresparser.StatementParseResult{{Index: 1, RawSQL: "DROP TABLE (", Query: nil}},
+	}
+	assert.Empty(t, Lint(batch, Config{}))
+	assert.Empty(t, Lint(nil, Config{}))
+}
+
+func TestRules(t *testing.T) {
+	all := Rules()
+	require.Len(t, all, 7)
+	seen := make(map[RuleID]bool)
+	for i, r := range all {
+		assert.NotEmpty(t, r.Description, r.ID)
+		assert.NotEmpty(t, r.Severity, r.ID)
+		assert.False(t, seen[r.ID], "duplicate rule %s", r.ID)
+		seen[r.ID] = true
+		if i > 0 {
+			assert.Less(t, all[i-1].ID, r.ID)
+		}
+	}
+}
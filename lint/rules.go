@@ -0,0 +1,255 @@
+// rules.go implements the individual migration-safety rules.
+package lint
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/ident"
+)
+
+// rule pairs rule metadata with its check. A check inspects one DDL action and
+// returns findings without Rule, Severity, or Statement set; Lint fills those.
+type rule struct {
+	Rule
+	check func(st *state, query *postgresparser.ParsedQuery, action postgresparser.DDLAction) []Finding
+}
+
+// rules lists every rule in evaluation order.
+var rules = []rule{
+	{
+		Rule: Rule{
+			ID:          RuleCreateIndexNotConcurrent,
+			Severity:    SeverityError,
+			Description: "CREATE INDEX without CONCURRENTLY blocks writes to an existing table while the index builds.",
+		},
+		check: checkCreateIndexNotConcurrent,
+	},
+	{
+		Rule: Rule{
+			ID:          RuleAddColumnVolatileDefault,
+			Severity:    SeverityError,
+			Description: "ADD COLUMN with a volatile DEFAULT (or a serial type) rewrites the whole table under an ACCESS EXCLUSIVE lock.",
+		},
+		check: checkAddColumnVolatileDefault,
+	},
+	{
+		Rule: Rule{
+			ID:          RuleSetNotNull,
+			Severity:    SeverityWarning,
+			Description: "SET NOT NULL scans the whole table under an ACCESS EXCLUSIVE lock unless a validated CHECK (col IS NOT NULL) already exists.",
+		},
+		check: checkSetNotNull,
+	},
+	{
+		Rule: Rule{
+			ID:          RuleDropColumn,
+			Severity:    SeverityWarning,
+			Description: "DROP COLUMN breaks application code that still reads or writes the column.",
+		},
+		check: checkDropColumn,
+	},
+	{
+		Rule: Rule{
+			ID:          RuleDropTable,
+			Severity:    SeverityError,
+			Description: "DROP TABLE permanently deletes data.",
+		},
+		check: checkDropTable,
+	},
+	{
+		Rule: Rule{
+			ID:          RuleAlterColumnType,
+			Severity:    SeverityError,
+			Description: "Changing a column type usually rewrites the table and its indexes under an ACCESS EXCLUSIVE lock.",
+		},
+		check: checkAlterColumnType,
+	},
+	{
+		Rule: Rule{
+			ID:          RuleTruncate,
+			Severity:    SeverityError,
+			Description: "TRUNCATE permanently deletes all rows.",
+		},
+		check: checkTruncate,
+	},
+}
+
+// checkCreateIndexNotConcurrent reports non-concurrent index builds on
+// tables that existed before the batch.
+func checkCreateIndexNotConcurrent(st *state, query *postgresparser.ParsedQuery, action postgresparser.DDLAction) []Finding {
+	if action.Type != postgresparser.DDLCreateIndex || hasFlag(action.Flags, "CONCURRENTLY") || len(query.Tables) == 0 {
+		return nil
+	}
+	table := query.Tables[0]
+	if st.isNew(table.Schema, table.Name) {
+		return nil
+	}
+	return []Finding{{
+		Object:  qualified(table.Schema, table.Name),
+		Message: "use CREATE INDEX CONCURRENTLY to avoid blocking writes",
+	}}
+}
+
+// checkAddColumnVolatileDefault reports ADD COLUMN with a default that must be
+// evaluated per row.
+func checkAddColumnVolatileDefault(st *state, _ *postgresparser.ParsedQuery, action postgresparser.DDLAction) []Finding {
+	if action.Type != postgresparser.DDLAlterTable || !hasFlag(action.Flags, "ADD_COLUMN") || len(action.ColumnDetails) == 0 {
+		return nil
+	}
+	if st.isNew(action.Schema, action.ObjectName) {
+		return nil
+	}
+	col := action.ColumnDetails[0]
+	object := qualified(action.Schema, action.ObjectName) + "." + col.Name
+	if isSerialType(col.Type) {
+		return []Finding{{
+			Object:  object,
+			Message: "serial column " + col.Type + " fills every existing row from a sequence; add a plain column and backfill instead",
+		}}
+	}
+	if fn, ok := volatileCall(col.Default); ok {
+		return []Finding{{
+			Object:  object,
+			Message: "DEFAULT calls volatile function " + fn + "(); add the column without a default, then set it and backfill",
+		}}
+	}
+	return nil
+}
+
+// checkSetNotNull reports SET NOT NULL unless an earlier statement in the
+// batch added a validated CHECK (col IS NOT NULL) to the same table, either
+// directly or as NOT VALID followed by VALIDATE CONSTRAINT.
+func checkSetNotNull(st *state, _ *postgresparser.ParsedQuery, action postgresparser.DDLAction) []Finding {
+	op := action.AlterOp
+	if action.Type != postgresparser.DDLAlterTable || op == nil || op.Type != postgresparser.DDLAlterOpSetNotNull {
+		return nil
+	}
+	if st.isNew(action.Schema, action.ObjectName) {
+		return nil
+	}
+	if st.notNullChk[tableKey(action.Schema, action.ObjectName)+"."+strings.ToLower(ident.TrimQuotes(op.Column))] {
+		return nil
+	}
+	return []Finding{{
+		Object:  qualified(action.Schema, action.ObjectName) + "." + op.Column,
+		Message: "add CHECK (" + op.Column + " IS NOT NULL) NOT VALID and VALIDATE it first so SET NOT NULL can skip the table scan",
+	}}
+}
+
+// checkDropColumn reports DROP COLUMN on existing tables.
+func checkDropColumn(st *state, _ *postgresparser.ParsedQuery, action postgresparser.DDLAction) []Finding {
+	if action.Type != postgresparser.DDLDropColumn || st.isNew(action.Schema, action.ObjectName) {
+		return nil
+	}
+	findings := make([]Finding, 0, len(action.Columns))
+	for _, col := range action.Columns {
+		findings = append(findings, Finding{
+			Object:  qualified(action.Schema, action.ObjectName) + "." + col,
+			Message: "make sure no deployed code references the column before dropping it",
+		})
+	}
+	return findings
+}
+
+// checkDropTable reports DROP TABLE on existing tables.
+func checkDropTable(st *state, _ *postgresparser.ParsedQuery, action postgresparser.DDLAction) []Finding {
+	if action.Type != postgresparser.DDLDropTable || st.isNew(action.Schema, action.ObjectName) {
+		return nil
+	}
+	return []Finding{{
+		Object:  qualified(action.Schema, action.ObjectName),
+		Message: "dropping a table deletes its data permanently",
+	}}
+}
+
+// checkAlterColumnType reports SET DATA TYPE on existing tables.
+func checkAlterColumnType(st *state, _ *postgresparser.ParsedQuery, action postgresparser.DDLAction) []Finding {
+	op := action.AlterOp
+	if action.Type != postgresparser.DDLAlterTable || op == nil || op.Type != postgresparser.DDLAlterOpSetDataType {
+		return nil
+	}
+	if st.isNew(action.Schema, action.ObjectName) {
+		return nil
+	}
+	return []Finding{{
+		Object:  qualified(action.Schema, action.ObjectName) + "." + op.Column,
+		Message: "changing the type to " + op.DataType + " may rewrite the table; prefer adding a new column and backfilling",
+	}}
+}
+
+// checkTruncate reports TRUNCATE on existing tables.
+func checkTruncate(st *state, _ *postgresparser.ParsedQuery, action postgresparser.DDLAction) []Finding {
+	if action.Type != postgresparser.DDLTruncate || st.isNew(action.Schema, action.ObjectName) {
+		return nil
+	}
+	return []Finding{{
+		Object:  qualified(action.Schema, action.ObjectName),
+		Message: "TRUNCATE deletes every row and takes an ACCESS EXCLUSIVE lock",
+	}}
+}
+
+// volatileFunctions lists common built-in and extension functions that
+// PostgreSQL evaluates per row when used as a column default.
+var volatileFunctions = map[string]bool{
+	"random":             true,
+	"gen_random_uuid":    true,
+	"uuid_generate_v1":   true,
+	"uuid_generate_v1mc": true,
+	"uuid_generate_v4":   true,
+	"clock_timestamp":    true,
+	"timeofday":          true,
+	"nextval":            true,
+	"txid_current":       true,
+	"setseed":            true,
+}
+
+// funcCallPattern matches identifiers followed by an opening parenthesis.
+var funcCallPattern = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_$.]*)\s*\(`)
+
+// volatileCall returns the first volatile function called in expr.
+func volatileCall(expr string) (string, bool) {
+	for _, m := range funcCallPattern.FindAllStringSubmatch(expr, -1) {
+		name := strings.ToLower(m[1])
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if volatileFunctions[name] {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// isSerialType reports whether typ is one of the serial pseudo-types.
+func isSerialType(typ string) bool {
+	switch strings.ToLower(strings.TrimSpace(typ)) {
+	case "serial", "serial2", "serial4", "serial8", "smallserial", "bigserial":
+		return true
+	}
+	return false
+}
+
+// notNullCheckPattern matches "col IS NOT NULL", optionally parenthesized.
+var notNullCheckPattern = regexp.MustCompile(`(?i)^\(*\s*("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*)\s+IS\s+NOT\s+NULL\s*\)*$`)
+
+// isNotNullCheck reports whether a CHECK expression is "col IS NOT NULL" and
+// returns the folded column name.
+func isNotNullCheck(expr string) (string, bool) {
+	m := notNullCheckPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return "", false
+	}
+	return strings.ToLower(ident.TrimQuotes(m[1])), true
+}
+
+// hasFlag reports whether flags contains flag.
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
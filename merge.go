@@ -42,6 +42,8 @@ func populateMerge(result *ParsedQuery, ctx gen.IMergestmtContext, tokens antlr.
 			Alias:  targetAlias,
 			Type:   TableTypeBase,
 			Raw:    targetName,
+			Span:   spanFor(tokens, qualifiedNames[0]),
+			Target: true,
 		}
 		appendSetOpTables(result, nil, []TableRef{merge.Target})
 		if rc, ok := qualifiedNames[0].(antlr.RuleContext); ok {
@@ -64,6 +66,7 @@ func populateMerge(result *ParsedQuery, ctx gen.IMergestmtContext, tokens antlr.
 			Alias: sourceAlias,
 			Type:  TableTypeSubquery,
 			Raw:   raw,
+			Span:  spanFor(tokens, swp),
 		}
 		appendSetOpTables(result, nil, []TableRef{merge.Source.Table})
 		// Use buildSubqueryRefWithResult to propagate column usage from nested subqueries
@@ -84,6 +87,7 @@ func populateMerge(result *ParsedQuery, ctx gen.IMergestmtContext, tokens antlr.
 			Alias:  sourceAlias,
 			Type:   TableTypeBase,
 			Raw:    sourceName,
+			Span:   spanFor(tokens, qualifiedNames[1]),
 		}
 		appendSetOpTables(result, nil, []TableRef{merge.Source.Table})
 		if rc, ok := qualifiedNames[1].(antlr.RuleContext); ok {
@@ -111,6 +115,7 @@ func populateMerge(result *ParsedQuery, ctx gen.IMergestmtContext, tokens antlr.
 				merge.Actions = append(merge.Actions, action)
 				if len(action.SetClauses) > 0 {
 					result.SetClauses = append(result.SetClauses, action.SetClauses...)
+					result.SetClauseExprs = append(result.SetClauseExprs, buildSetClauseExprs(node.Set_clause_list(), tokens)...)
 				}
 			}
 		case *gen.Merge_delete_clauseContext:
@@ -0,0 +1,379 @@
+// normalize.go implements query normalization and fingerprinting in the style
+// of pg_stat_statements: literals become positional placeholders and the
+// remaining tokens are rendered in a canonical form.
+package postgresparser
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// NormalizedQuery is the result of Normalize.
+type NormalizedQuery struct {
+	// SQL is the canonical query text: literals are replaced by $n
+	// placeholders, comments are dropped, keywords are upper-cased, unquoted
+	// identifiers are lower-cased, and tokens are separated by single spaces.
+	SQL string
+	// Fingerprint is a stable 64-bit hash of SQL. Queries that differ only in
+	// constants, IN-list length, whitespace, comments, or keyword case share
+	// a fingerprint.
+	Fingerprint uint64
+	// Constants lists the replaced source text in placeholder order.
+	Constants []NormalizedConstant
+}
+
+// NormalizedConstant records the source text replaced by one placeholder.
+type NormalizedConstant struct {
+	Placeholder string // e.g. "$2"
+	// Text is the literal as written. For a collapsed IN-list or ARRAY
+	// literal it is the comma-separated element list.
+	Text string
+}
+
+// normToken is one significant lexer token prepared for normalization.
+type normToken struct {
+	kind normTokenKind
+	typ  int
+	text string
+}
+
+// normTokenKind classifies tokens for rendering and literal replacement.
+type normTokenKind int
+
+const (
+	normOther normTokenKind = iota
+	normKeyword
+	normIdent
+	normLiteral
+	normParam
+)
+
+// Normalize lexes sql and returns its canonical, literal-free form and
+// fingerprint. Numeric, string, bit-string, and dollar-quoted literals
+// (including a leading sign) become $n placeholders numbered after any
+// parameters already present. IN lists and ARRAY[...] literals made only of
+// constants and parameters, with at least one literal, collapse to a single
+// placeholder so that their length does not change the fingerprint; lists of
+// only parameters are kept as written. Input is not parsed, so Normalize
+// also accepts statements the parser has no IR for.
+func Normalize(sql string) (*NormalizedQuery, error) {
+	tokens, err := lexForNormalize(preprocessSQLInput(sql))
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, ErrNoStatements
+	}
+
+	n := &normalizer{tokens: tokens, next: firstFreePlaceholder(tokens)}
+	n.run()
+
+	out := &NormalizedQuery{SQL: n.b.String(), Constants: n.constants}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(out.SQL))
+	out.Fingerprint = h.Sum64()
+	return out, nil
+}
+
+// lexForNormalize returns the default-channel tokens of sql with
+// dollar-quoted strings merged into single literal tokens and trailing
+// semicolons dropped.
+func lexForNormalize(sql string) ([]normToken, error) {
+	lexer := gen.NewPostgreSQLLexer(antlr.NewInputStream(sql))
+	errListener := &parseErrorListener{}
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errListener)
+
+	var tokens []normToken
+	var dollar *strings.Builder
+	for {
+		tok := lexer.NextToken()
+		if tok == nil || tok.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		if tok.GetChannel() != antlr.TokenDefaultChannel {
+			continue
+		}
+		typ := tok.GetTokenType()
+		if isMalformedToken(lexer, typ) {
+			errListener.errs = append(errListener.errs, SyntaxError{
+				Line:       tok.GetLine(),
+				Column:     tok.GetColumn(),
+				Message:    fmt.Sprintf("malformed token %q", tok.GetText()),
+				TokenIndex: tok.GetTokenIndex(),
+			})
+			continue
+		}
+		switch {
+		case typ == gen.PostgreSQLLexerBeginDollarStringConstant:
+			dollar = &strings.Builder{}
+			dollar.WriteString(tok.GetText())
+			continue
+		case dollar != nil:
+			dollar.WriteString(tok.GetText())
+			if typ == gen.PostgreSQLLexerEndDollarStringConstant {
+				tokens = append(tokens, normToken{kind: normLiteral, typ: typ, text: dollar.String()})
+				dollar = nil
+			}
+			continue
+		}
+		tokens = append(tokens, normToken{kind: classifyNormToken(typ), typ: typ, text: tok.GetText()})
+	}
+	if len(errListener.errs) > 0 {
+		return nil, &ParseErrors{SQL: sql, Errors: errListener.errs}
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].typ == gen.PostgreSQLLexerSEMI {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens, nil
+}
+
+// isMalformedToken reports whether typ is one of the lexer's error tokens for
+// unterminated or invalid literals and identifiers, which the parser rejects.
+func isMalformedToken(lexer *gen.PostgreSQLLexer, typ int) bool {
+	names := lexer.GetSymbolicNames()
+	if typ < 0 || typ >= len(names) {
+		return false
+	}
+	name := names[typ]
+	return strings.HasPrefix(name, "Unterminated") || strings.HasPrefix(name, "Invalid") || name == "ErrorCharacter"
+}
+
+// classifyNormToken maps a lexer token type to its normalization kind.
+func classifyNormToken(typ int) normTokenKind {
+	switch typ {
+	case gen.PostgreSQLLexerIntegral,
+		gen.PostgreSQLLexerBinaryIntegral,
+		gen.PostgreSQLLexerOctalIntegral,
+		gen.PostgreSQLLexerHexadecimalIntegral,
+		gen.PostgreSQLLexerNumeric,
+		gen.PostgreSQLLexerStringConstant,
+		gen.PostgreSQLLexerEscapeStringConstant,
+		gen.PostgreSQLLexerUnicodeEscapeStringConstant,
+		gen.PostgreSQLLexerBinaryStringConstant,
+		gen.PostgreSQLLexerHexadecimalStringConstant:
+		return normLiteral
+	case gen.PostgreSQLLexerPARAM:
+		return normParam
+	case gen.PostgreSQLLexerIdentifier:
+		return normIdent
+	case gen.PostgreSQLLexerQuotedIdentifier, gen.PostgreSQLLexerUnicodeQuotedIdentifier:
+		return normIdent
+	}
+	if typ >= gen.PostgreSQLLexerJSON && typ <= gen.PostgreSQLLexerFORMAT {
+		return normKeyword
+	}
+	return normOther
+}
+
+// firstFreePlaceholder returns the first placeholder number not used by a
+// parameter already present in the input.
+func firstFreePlaceholder(tokens []normToken) int {
+	highest, anonymous := 0, 0
+	for _, tok := range tokens {
+		if tok.kind != normParam {
+			continue
+		}
+		if tok.text == "?" {
+			anonymous++
+			continue
+		}
+		if idx, err := strconv.Atoi(tok.text[1:]); err == nil && idx > highest {
+			highest = idx
+		}
+	}
+	return max(highest, anonymous) + 1
+}
+
+// normalizer renders tokens into canonical text.
+type normalizer struct {
+	tokens    []normToken
+	next      int
+	b         strings.Builder
+	prev      *normToken
+	constants []NormalizedConstant
+}
+
+// run walks the token list, replacing constants and collapsing lists.
+func (n *normalizer) run() {
+	for i := 0; i < len(n.tokens); i++ {
+		tok := n.tokens[i]
+
+		if end, ok := n.constantList(i); ok {
+			n.emit(tok)
+			n.emitPlaceholder(n.listText(i+1, end))
+			n.emit(n.tokens[end])
+			i = end
+			continue
+		}
+		if width := n.constantWidth(i); width > 0 && tok.kind != normParam {
+			n.emitPlaceholder(n.joinText(i, i+width))
+			i += width - 1
+			continue
+		}
+		n.emit(tok)
+	}
+}
+
+// constantWidth reports how many tokens starting at i form one constant: a
+// literal, a parameter, or a sign followed by a numeric literal in operand
+// position. It returns 0 when tokens[i] does not start a constant.
+func (n *normalizer) constantWidth(i int) int {
+	tok := n.tokens[i]
+	if tok.kind == normLiteral || tok.kind == normParam {
+		return 1
+	}
+	if (tok.typ == gen.PostgreSQLLexerMINUS || tok.typ == gen.PostgreSQLLexerPLUS) &&
+		i+1 < len(n.tokens) && isNumericLiteral(n.tokens[i+1].typ) && !n.prevIsOperand(i) {
+		return 2
+	}
+	return 0
+}
+
+// prevIsOperand reports whether the token before i ends an operand, in which
+// case a following sign is a binary operator.
+func (n *normalizer) prevIsOperand(i int) bool {
+	if i == 0 {
+		return false
+	}
+	prev := n.tokens[i-1]
+	switch prev.kind {
+	case normLiteral, normParam, normIdent:
+		return true
+	}
+	switch prev.typ {
+	case gen.PostgreSQLLexerCLOSE_PAREN, gen.PostgreSQLLexerCLOSE_BRACKET,
+		gen.PostgreSQLLexerEND_P, gen.PostgreSQLLexerNULL_P,
+		gen.PostgreSQLLexerTRUE_P, gen.PostgreSQLLexerFALSE_P:
+		return true
+	}
+	return false
+}
+
+// constantList reports whether tokens[i] opens an IN (...) list or the
+// bracket of ARRAY[...] whose elements are all constants, at least one of
+// them a literal, returning the index of the closing token. Lists of only
+// parameters are left as written, since collapsing them would number the new
+// placeholder after the list's own parameters.
+func (n *normalizer) constantList(i int) (int, bool) {
+	if i == 0 {
+		return 0, false
+	}
+	var closer int
+	switch {
+	case n.tokens[i].typ == gen.PostgreSQLLexerOPEN_PAREN && n.tokens[i-1].typ == gen.PostgreSQLLexerIN_P:
+		closer = gen.PostgreSQLLexerCLOSE_PAREN
+	case n.tokens[i].typ == gen.PostgreSQLLexerOPEN_BRACKET && n.tokens[i-1].typ == gen.PostgreSQLLexerARRAY:
+		closer = gen.PostgreSQLLexerCLOSE_BRACKET
+	default:
+		return 0, false
+	}
+
+	j := i + 1
+	literal := false
+	for {
+		if j >= len(n.tokens) {
+			return 0, false
+		}
+		width := n.constantWidth(j)
+		if width == 0 {
+			return 0, false
+		}
+		literal = literal || n.tokens[j].kind != normParam
+		j += width
+		if j >= len(n.tokens) {
+			return 0, false
+		}
+		switch n.tokens[j].typ {
+		case gen.PostgreSQLLexerCOMMA:
+			j++
+		case closer:
+			return j, literal
+		default:
+			return 0, false
+		}
+	}
+}
+
+// isNumericLiteral reports whether typ is a numeric literal token.
+func isNumericLiteral(typ int) bool {
+	switch typ {
+	case gen.PostgreSQLLexerIntegral, gen.PostgreSQLLexerBinaryIntegral,
+		gen.PostgreSQLLexerOctalIntegral, gen.PostgreSQLLexerHexadecimalIntegral,
+		gen.PostgreSQLLexerNumeric:
+		return true
+	}
+	return false
+}
+
+// emitPlaceholder writes the next $n placeholder in place of source text.
+func (n *normalizer) emitPlaceholder(source string) {
+	placeholder := fmt.Sprintf("$%d", n.next)
+	n.next++
+	n.constants = append(n.constants, NormalizedConstant{Placeholder: placeholder, Text: source})
+	n.emit(normToken{kind: normParam, typ: gen.PostgreSQLLexerPARAM, text: placeholder})
+}
+
+// emit writes tok in canonical case with canonical spacing before it.
+func (n *normalizer) emit(tok normToken) {
+	if n.prev != nil && needsSpace(*n.prev, tok) {
+		n.b.WriteByte(' ')
+	}
+	switch {
+	case tok.kind == normKeyword:
+		n.b.WriteString(strings.ToUpper(tok.text))
+	case tok.typ == gen.PostgreSQLLexerIdentifier:
+		n.b.WriteString(strings.ToLower(tok.text))
+	default:
+		n.b.WriteString(tok.text)
+	}
+	n.prev = &tok
+}
+
+// needsSpace decides whether a space separates prev and cur in canonical text.
+func needsSpace(prev, cur normToken) bool {
+	switch cur.typ {
+	case gen.PostgreSQLLexerCLOSE_PAREN, gen.PostgreSQLLexerCLOSE_BRACKET,
+		gen.PostgreSQLLexerOPEN_BRACKET, gen.PostgreSQLLexerCOMMA,
+		gen.PostgreSQLLexerSEMI, gen.PostgreSQLLexerDOT, gen.PostgreSQLLexerTYPECAST:
+		return false
+	case gen.PostgreSQLLexerOPEN_PAREN:
+		if prev.kind == normIdent {
+			return false
+		}
+	}
+	switch prev.typ {
+	case gen.PostgreSQLLexerOPEN_PAREN, gen.PostgreSQLLexerOPEN_BRACKET,
+		gen.PostgreSQLLexerDOT, gen.PostgreSQLLexerTYPECAST:
+		return false
+	}
+	return true
+}
+
+// joinText concatenates the source text of tokens[from:to].
+func (n *normalizer) joinText(from, to int) string {
+	var b strings.Builder
+	for _, tok := range n.tokens[from:to] {
+		b.WriteString(tok.text)
+	}
+	return b.String()
+}
+
+// listText renders the comma-separated elements of tokens[from:to].
+func (n *normalizer) listText(from, to int) string {
+	var parts []string
+	start := from
+	for i := from; i <= to; i++ {
+		if i == to || n.tokens[i].typ == gen.PostgreSQLLexerCOMMA {
+			parts = append(parts, n.joinText(start, i))
+			start = i + 1
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,147 @@
+package postgresparser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeReplacesLiterals(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		want      string
+		constants []NormalizedConstant
+	}{
+		{
+			name: "numbers strings and signs",
+			sql:  "select a from Users where b = -1.5 and c = 'x''y' and d = x - 1 limit 10",
+			want: "SELECT a FROM users WHERE b = $1 AND c = $2 AND d = x - $3 LIMIT $4",
+			constants: []NormalizedConstant{
+				{Placeholder: "$1", Text: "-1.5"},
+				{Placeholder: "$2", Text: "'x''y'"},
+				{Placeholder: "$3", Text: "1"},
+				{Placeholder: "$4", Text: "10"},
+			},
+		},
+		{
+			name: "in list collapses",
+			sql:  "SELECT * FROM t WHERE id IN (1, 2, 3) AND kind NOT IN ('a', 'b')",
+			want: "SELECT * FROM t WHERE id IN ($1) AND kind NOT IN ($2)",
+			constants: []NormalizedConstant{
+				{Placeholder: "$1", Text: "1, 2, 3"},
+				{Placeholder: "$2", Text: "'a', 'b'"},
+			},
+		},
+		{
+			name: "array literal collapses",
+			sql:  "SELECT ARRAY[1, 2, 3], ARRAY[a, 4] FROM t",
+			want: "SELECT ARRAY[$1], ARRAY[a, $2] FROM t",
+			constants: []NormalizedConstant{
+				{Placeholder: "$1", Text: "1, 2, 3"},
+				{Placeholder: "$2", Text: "4"},
+			},
+		},
+		{
+			name: "existing parameters are kept and numbering continues",
+			sql:  "UPDATE t SET a = $1, b = 'v'::jsonb WHERE id = $2",
+			want: "UPDATE t SET a = $1, b = $3::jsonb WHERE id = $2",
+			constants: []NormalizedConstant{
+				{Placeholder: "$3", Text: "'v'"},
+			},
+		},
+		{
+			name: "special string forms",
+			sql:  "SELECT $tag$ it's; -- not a comment $tag$, E'a\\n', B'101', X'ff', U&'d\\0061t'",
+			want: "SELECT $1, $2, $3, $4, $5",
+			constants: []NormalizedConstant{
+				{Placeholder: "$1", Text: "$tag$ it's; -- not a comment $tag$"},
+				{Placeholder: "$2", Text: "E'a\\n'"},
+				{Placeholder: "$3", Text: "B'101'"},
+				{Placeholder: "$4", Text: "X'ff'"},
+				{Placeholder: "$5", Text: "U&'d\\0061t'"},
+			},
+		},
+		{
+			name: "comments and trailing semicolon are dropped",
+			sql:  "/* report */ SELECT id -- trailing\nFROM t // app metadata\n;",
+			want: "SELECT id FROM t",
+		},
+		{
+			name: "quoted identifiers keep their case",
+			sql:  `select "UserId", t.* from "Tbl" t`,
+			want: `SELECT "UserId", t.* FROM "Tbl" t`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Normalize(tc.sql)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got.SQL)
+			assert.Equal(t, tc.constants, got.Constants)
+		})
+	}
+}
+
+func TestNormalizeFingerprintStability(t *testing.T) {
+	base, err := Normalize("SELECT id, count(*) FROM orders WHERE status IN ('new', 'paid') AND total > 10 GROUP BY id")
+	require.NoError(t, err)
+
+	same := []string{
+		"select id,count(*) from orders where status in ('open') and total > 99.5 group by id",
+		"SELECT  id ,\n  COUNT(*)\nFROM Orders -- hot path\nWHERE status IN ('a','b','c','d') AND total > -1 GROUP BY id;",
+	}
+	for _, sql := range same {
+		got, err := Normalize(sql)
+		require.NoError(t, err)
+		assert.Equal(t, base.SQL, got.SQL)
+		assert.Equal(t, base.Fingerprint, got.Fingerprint, "sql: %s", sql)
+	}
+
+	different := []string{
+		"SELECT id, count(*) FROM orders WHERE status IN ('new') AND total >= 10 GROUP BY id",
+		"SELECT id, count(*) FROM orders WHERE status IN ('new') AND total > 10 GROUP BY status",
+	}
+	for _, sql := range different {
+		got, err := Normalize(sql)
+		require.NoError(t, err)
+		assert.NotEqual(t, base.Fingerprint, got.Fingerprint, "sql: %s", sql)
+	}
+}
+
+func TestNormalizeFingerprintStabilityWithParameters(t *testing.T) {
+	base, err := Normalize("SELECT * FROM orders WHERE user_id = $1 AND status IN ('new', 'paid')")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM orders WHERE user_id = $1 AND status IN ($2)", base.SQL)
+	got, err := Normalize("SELECT * FROM orders WHERE user_id = $1 AND status IN ('new', 'paid', 'void')")
+	require.NoError(t, err)
+	assert.Equal(t, base.Fingerprint, got.Fingerprint)
+
+	for _, sql := range []string{
+		"SELECT ARRAY[$1, $2] FROM orders WHERE id IN ($3, $4)",
+		"SELECT ARRAY[$1] FROM orders WHERE id IN ($2, $3, $4)",
+	} {
+		got, err := Normalize(sql)
+		require.NoError(t, err)
+		assert.Equal(t, sql, got.SQL, "lists of only parameters are left as written")
+		assert.Empty(t, got.Constants, sql)
+	}
+
+	got, err = Normalize("SELECT * FROM orders WHERE id IN ($1, 2)")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM orders WHERE id IN ($2)", got.SQL)
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	_, err := Normalize("  -- only a comment\n")
+	assert.ErrorIs(t, err, ErrNoStatements)
+
+	_, err = Normalize("SELECT 'unterminated")
+	var parseErrs *ParseErrors
+	require.True(t, errors.As(err, &parseErrs))
+	require.Len(t, parseErrs.Errors, 1)
+	assert.Equal(t, 7, parseErrs.Errors[0].Column)
+}
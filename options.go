@@ -5,4 +5,31 @@ type ParseOptions struct {
 	// IncludeCreateTableFieldComments enables extraction of line comments (`-- ...`)
 	// that immediately precede CREATE TABLE column definitions.
 	IncludeCreateTableFieldComments bool
+
+	// UseSLLPrediction parses with ANTLR's faster SLL prediction mode first and
+	// reparses in the default LL mode only when the SLL attempt fails. Results
+	// and syntax errors are identical to LL-only parsing; typical queries parse
+	// 4-8x faster with far fewer allocations.
+	UseSLLPrediction bool
+
+	// IncludeSourcePositions records a SourceSpan on tables, projected columns,
+	// column usages, DDL actions, and parameters. Offsets refer to the SQL text
+	// passed to the parser, before preprocessing.
+	IncludeSourcePositions bool
+
+	// IncludeExpressionTrees builds a typed Expr tree alongside the raw text of
+	// WHERE and HAVING predicates, JOIN ON conditions, UPDATE SET values, and
+	// projected columns.
+	IncludeExpressionTrees bool
+
+	// IncludeLineage traces each output column of SELECT, INSERT ... SELECT,
+	// CREATE TABLE AS, and view statements to its source table columns in
+	// ParsedQuery.Lineage.
+	IncludeLineage bool
+
+	// PSQL, when set, parses the input of ParseSQLAllWithOptions as a psql
+	// script: backslash meta-commands become separate results and variables,
+	// includes, and \if blocks are evaluated. Routine bodies, DumpReader, and
+	// other entry points ignore it.
+	PSQL *PSQLOptions
 }
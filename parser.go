@@ -0,0 +1,132 @@
+// parser.go contains the reusable Parser type and the pooled ANTLR parse engine.
+package postgresparser
+
+import (
+	"sync"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// Parser parses SQL with a fixed set of ParseOptions, reusing lexer, token
+// stream and parser instances across calls instead of building new ones for
+// every statement. It is intended for high-throughput callers such as query-log
+// ingestion.
+//
+// A Parser is safe for concurrent use by multiple goroutines: each call borrows
+// a parse engine from an internal pool and returns it when IR extraction is
+// done. The zero value is ready to use with default options.
+type Parser struct {
+	opts    ParseOptions
+	engines sync.Pool
+}
+
+// NewParser returns a Parser that applies opts to every call.
+func NewParser(opts ParseOptions) *Parser {
+	return &Parser{opts: opts}
+}
+
+// Options returns the ParseOptions applied by p.
+func (p *Parser) Options() ParseOptions {
+	return p.opts
+}
+
+// Parse parses only the first SQL statement in the input string.
+// It behaves like ParseSQLWithOptions with the Parser's options.
+func (p *Parser) Parse(sql string) (*ParsedQuery, error) {
+	engine := p.acquire()
+	defer p.release(engine)
+	return parseFirstStatement(engine, sql, p.opts)
+}
+
+// ParseAll parses all SQL statements in the input string.
+// It behaves like ParseSQLAllWithOptions with the Parser's options.
+func (p *Parser) ParseAll(sql string) (*ParseBatchResult, error) {
+	engine := p.acquire()
+	defer p.release(engine)
+	return parseAllStatements(engine, sql, p.opts)
+}
+
+// ParseStrict parses input only when it contains exactly one SQL statement.
+// It behaves like ParseSQLStrictWithOptions with the Parser's options.
+func (p *Parser) ParseStrict(sql string) (*ParsedQuery, error) {
+	engine := p.acquire()
+	defer p.release(engine)
+	return parseSingleStatement(engine, sql, p.opts)
+}
+
+// acquire takes an idle engine from the pool or builds a new one.
+func (p *Parser) acquire() *parseEngine {
+	if engine, ok := p.engines.Get().(*parseEngine); ok {
+		return engine
+	}
+	return newParseEngine()
+}
+
+// release drops references to the last input and returns engine to the pool.
+func (p *Parser) release(engine *parseEngine) {
+	engine.reset()
+	p.engines.Put(engine)
+}
+
+// parseEngine bundles the lexer, token stream and parser used for one parse.
+// An engine is not safe for concurrent use; Parser pools engines so that each
+// goroutine works on its own instance.
+type parseEngine struct {
+	lexer  *gen.PostgreSQLLexer
+	stream *antlr.CommonTokenStream
+	parser *gen.PostgreSQLParser
+}
+
+// newParseEngine wires up a lexer, token stream and parser with no input.
+func newParseEngine() *parseEngine {
+	lexer := gen.NewPostgreSQLLexer(antlr.NewInputStream(""))
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	parser := gen.NewPostgreSQLParser(stream)
+	parser.BuildParseTrees = true
+	return &parseEngine{lexer: lexer, stream: stream, parser: parser}
+}
+
+// parse lexes and parses cleanSQL into a root context. With sllFirst set,
+// the first attempt runs in SLL prediction mode with a bail-out error strategy;
+// if it reports any syntax error, the token stream is rewound and the input is
+// reparsed in LL mode with default error recovery so that results and error
+// messages match a plain LL parse.
+func (e *parseEngine) parse(cleanSQL string, sllFirst bool) (gen.IRootContext, antlr.TokenStream, []SyntaxError) {
+	e.lexer.SetInputStream(antlr.NewInputStream(cleanSQL))
+	e.stream.SetTokenSource(e.lexer)
+	e.parser.RemoveErrorListeners()
+
+	if sllFirst {
+		bail := &bailErrorStrategy{DefaultErrorStrategy: antlr.NewDefaultErrorStrategy()}
+		e.parser.SetErrorHandler(bail)
+		e.parser.SetInputStream(e.stream)
+		e.parser.GetInterpreter().SetPredictionMode(antlr.PredictionModeSLL)
+
+		root := e.parser.Root()
+		if !bail.failed {
+			return root, e.stream, nil
+		}
+		// SLL could not parse the input; it may be a genuine syntax error or an
+		// ambiguity only full-context LL prediction resolves. Rewind and retry.
+		e.stream.Seek(0)
+	}
+
+	e.parser.SetErrorHandler(antlr.NewDefaultErrorStrategy())
+	e.parser.SetInputStream(e.stream)
+	e.parser.GetInterpreter().SetPredictionMode(antlr.PredictionModeLL)
+
+	errListener := &parseErrorListener{}
+	e.parser.AddErrorListener(errListener)
+	root := e.parser.Root()
+	return root, e.stream, errListener.errs
+}
+
+// reset releases the tokens and input held from the last parse so pooled
+// engines do not pin large SQL strings or parse trees in memory.
+func (e *parseEngine) reset() {
+	e.lexer.SetInputStream(antlr.NewInputStream(""))
+	e.stream.SetTokenSource(e.lexer)
+	e.parser.RemoveErrorListeners()
+}
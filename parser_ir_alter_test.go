@@ -0,0 +1,224 @@
+// parser_ir_alter_test.go exercises detailed ALTER TABLE sub-command extraction
+// (AlterOp) at the IR level, including RENAME and SET SCHEMA statements.
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIR_DDL_AlterTableAlterOps(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantObject  string
+		wantSchema  string
+		wantColumns []string
+		wantFlags   []string
+		want        *DDLAlterOp
+	}{
+		{
+			name:        "set data type with using",
+			sql:         "ALTER TABLE users ALTER COLUMN age SET DATA TYPE bigint USING age::bigint",
+			wantObject:  "users",
+			wantColumns: []string{"age"},
+			wantFlags:   []string{"ALTER_COLUMN"},
+			want:        &DDLAlterOp{Type: DDLAlterOpSetDataType, Column: "age", DataType: "bigint", Using: "age::bigint"},
+		},
+		{
+			name:        "type shorthand with collation",
+			sql:         `ALTER TABLE public.users ALTER name TYPE varchar(200) COLLATE "C"`,
+			wantObject:  "users",
+			wantSchema:  "public",
+			wantColumns: []string{"name"},
+			wantFlags:   []string{"ALTER_COLUMN"},
+			want:        &DDLAlterOp{Type: DDLAlterOpSetDataType, Column: "name", DataType: "varchar(200)", Collation: `"C"`},
+		},
+		{
+			name:        "set default",
+			sql:         "ALTER TABLE users ALTER COLUMN status SET DEFAULT 'active'",
+			wantObject:  "users",
+			wantColumns: []string{"status"},
+			wantFlags:   []string{"ALTER_COLUMN"},
+			want:        &DDLAlterOp{Type: DDLAlterOpSetDefault, Column: "status", Default: "'active'"},
+		},
+		{
+			name:        "drop default",
+			sql:         "ALTER TABLE users ALTER COLUMN status DROP DEFAULT",
+			wantObject:  "users",
+			wantColumns: []string{"status"},
+			wantFlags:   []string{"ALTER_COLUMN"},
+			want:        &DDLAlterOp{Type: DDLAlterOpDropDefault, Column: "status"},
+		},
+		{
+			name:        "set not null",
+			sql:         "ALTER TABLE users ALTER COLUMN email SET NOT NULL",
+			wantObject:  "users",
+			wantColumns: []string{"email"},
+			wantFlags:   []string{"ALTER_COLUMN"},
+			want:        &DDLAlterOp{Type: DDLAlterOpSetNotNull, Column: "email"},
+		},
+		{
+			name:        "drop not null is not a drop column",
+			sql:         "ALTER TABLE users ALTER COLUMN email DROP NOT NULL",
+			wantObject:  "users",
+			wantColumns: []string{"email"},
+			wantFlags:   []string{"ALTER_COLUMN"},
+			want:        &DDLAlterOp{Type: DDLAlterOpDropNotNull, Column: "email"},
+		},
+		{
+			name:        "other alter column forms keep generic action",
+			sql:         "ALTER TABLE users ALTER COLUMN email SET STATISTICS 500",
+			wantObject:  "users",
+			wantColumns: []string{"email"},
+			wantFlags:   []string{"ALTER_COLUMN"},
+		},
+		{
+			name:       "owner to",
+			sql:        "ALTER TABLE users OWNER TO app_owner",
+			wantObject: "users",
+			want:       &DDLAlterOp{Type: DDLAlterOpOwnerTo, Owner: "app_owner"},
+		},
+		{
+			name:       "enable trigger",
+			sql:        "ALTER TABLE users ENABLE TRIGGER users_audit",
+			wantObject: "users",
+			want:       &DDLAlterOp{Type: DDLAlterOpEnableTrigger, Trigger: "users_audit"},
+		},
+		{
+			name:       "enable always trigger",
+			sql:        "ALTER TABLE users ENABLE ALWAYS TRIGGER users_audit",
+			wantObject: "users",
+			wantFlags:  []string{"ALWAYS"},
+			want:       &DDLAlterOp{Type: DDLAlterOpEnableTrigger, Trigger: "users_audit"},
+		},
+		{
+			name:       "disable all triggers",
+			sql:        "ALTER TABLE users DISABLE TRIGGER ALL",
+			wantObject: "users",
+			want:       &DDLAlterOp{Type: DDLAlterOpDisableTrigger, Trigger: "ALL"},
+		},
+		{
+			name:       "attach partition",
+			sql:        "ALTER TABLE measurements ATTACH PARTITION measurements_2024 FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+			wantObject: "measurements",
+			want: &DDLAlterOp{
+				Type:           DDLAlterOpAttachPartition,
+				Partition:      "measurements_2024",
+				PartitionBound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+			},
+		},
+		{
+			name:       "attach default partition",
+			sql:        "ALTER TABLE measurements ATTACH PARTITION archive.measurements_other DEFAULT",
+			wantObject: "measurements",
+			want: &DDLAlterOp{
+				Type:           DDLAlterOpAttachPartition,
+				Partition:      "archive.measurements_other",
+				PartitionBound: "DEFAULT",
+			},
+		},
+		{
+			name:       "detach partition",
+			sql:        "ALTER TABLE measurements DETACH PARTITION measurements_2023",
+			wantObject: "measurements",
+			want:       &DDLAlterOp{Type: DDLAlterOpDetachPartition, Partition: "measurements_2023"},
+		},
+		{
+			name:       "rename table",
+			sql:        "ALTER TABLE public.users RENAME TO accounts",
+			wantObject: "users",
+			wantSchema: "public",
+			want:       &DDLAlterOp{Type: DDLAlterOpRenameTable, OldName: "users", NewName: "accounts"},
+		},
+		{
+			name:        "rename column",
+			sql:         "ALTER TABLE IF EXISTS users RENAME COLUMN email TO email_address",
+			wantObject:  "users",
+			wantColumns: []string{"email"},
+			wantFlags:   []string{"TABLE_IF_EXISTS"},
+			want:        &DDLAlterOp{Type: DDLAlterOpRenameColumn, Column: "email", OldName: "email", NewName: "email_address"},
+		},
+		{
+			name:        "rename column without COLUMN keyword",
+			sql:         "ALTER TABLE users RENAME email TO email_address",
+			wantObject:  "users",
+			wantColumns: []string{"email"},
+			want:        &DDLAlterOp{Type: DDLAlterOpRenameColumn, Column: "email", OldName: "email", NewName: "email_address"},
+		},
+		{
+			name:       "rename constraint",
+			sql:        "ALTER TABLE users RENAME CONSTRAINT users_email_key TO users_email_uniq",
+			wantObject: "users",
+			want:       &DDLAlterOp{Type: DDLAlterOpRenameConstraint, OldName: "users_email_key", NewName: "users_email_uniq"},
+		},
+		{
+			name:       "validate constraint",
+			sql:        "ALTER TABLE users VALIDATE CONSTRAINT users_email_not_null",
+			wantObject: "users",
+			want:       &DDLAlterOp{Type: DDLAlterOpValidateConstraint, Constraint: "users_email_not_null"},
+		},
+		{
+			name:       "set schema",
+			sql:        "ALTER TABLE public.users SET SCHEMA archive",
+			wantObject: "users",
+			wantSchema: "public",
+			want:       &DDLAlterOp{Type: DDLAlterOpSetSchema, NewSchema: "archive"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command)
+			require.Len(t, ir.DDLActions, 1)
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLAlterTable, act.Type)
+			assert.Equal(t, tc.wantObject, act.ObjectName)
+			assert.Equal(t, tc.wantSchema, act.Schema)
+			assert.Equal(t, tc.wantColumns, act.Columns)
+			assert.Equal(t, tc.wantFlags, act.Flags)
+			assert.Equal(t, tc.want, act.AlterOp)
+
+			require.Len(t, ir.Tables, 1)
+			assert.Equal(t, tc.wantObject, ir.Tables[0].Name)
+			assert.Equal(t, tc.wantSchema, ir.Tables[0].Schema)
+		})
+	}
+}
+
+func TestIR_DDL_AlterTableMultipleAlterOps(t *testing.T) {
+	ir := parseAssertNoError(t, `ALTER TABLE orders
+    ALTER COLUMN total TYPE numeric(12,2),
+    ALTER COLUMN total SET DEFAULT 0,
+    ALTER COLUMN note DROP NOT NULL,
+    DROP COLUMN legacy`)
+	require.Len(t, ir.DDLActions, 4)
+
+	assert.Equal(t, DDLAlterOpSetDataType, ir.DDLActions[0].AlterOp.Type)
+	assert.Equal(t, "numeric(12,2)", ir.DDLActions[0].AlterOp.DataType)
+	assert.Equal(t, DDLAlterOpSetDefault, ir.DDLActions[1].AlterOp.Type)
+	assert.Equal(t, "0", ir.DDLActions[1].AlterOp.Default)
+	assert.Equal(t, DDLAlterOpDropNotNull, ir.DDLActions[2].AlterOp.Type)
+	assert.Equal(t, "note", ir.DDLActions[2].AlterOp.Column)
+
+	assert.Equal(t, DDLDropColumn, ir.DDLActions[3].Type)
+	assert.Equal(t, []string{"legacy"}, ir.DDLActions[3].Columns)
+	assert.Nil(t, ir.DDLActions[3].AlterOp)
+}
+
+func TestIR_DDL_RenameNonTableIgnored(t *testing.T) {
+	for _, sql := range []string{
+		"ALTER INDEX users_email_idx RENAME TO users_email_key",
+		"ALTER SEQUENCE users_id_seq SET SCHEMA archive",
+	} {
+		t.Run(sql, func(t *testing.T) {
+			ir := parseAssertNoError(t, sql)
+			assert.Equal(t, QueryCommandUnknown, ir.Command)
+			assert.Empty(t, ir.DDLActions)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// parser_ir_constraint_test.go exercises constraint extraction for CREATE TABLE
+// and ALTER TABLE ADD/DROP CONSTRAINT at the IR level.
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIR_DDL_CreateTableConstraints(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []DDLConstraint
+	}{
+		{
+			name: "column-level primary key, unique and check",
+			sql: `CREATE TABLE users (
+    id bigint PRIMARY KEY,
+    email text CONSTRAINT users_email_key UNIQUE NOT NULL,
+    age int CHECK (age >= 0)
+)`,
+			want: []DDLConstraint{
+				{Type: DDLConstraintPrimaryKey, Columns: []string{"id"}},
+				{Type: DDLConstraintUnique, Name: "users_email_key", Columns: []string{"email"}},
+				{Type: DDLConstraintCheck, Columns: []string{"age"}, Expression: "age >= 0"},
+			},
+		},
+		{
+			name: "column-level references with actions",
+			sql: `CREATE TABLE orders (
+    id bigint,
+    user_id bigint REFERENCES public.users (id) ON DELETE CASCADE ON UPDATE SET NULL DEFERRABLE INITIALLY DEFERRED
+)`,
+			want: []DDLConstraint{
+				{
+					Type:              DDLConstraintForeignKey,
+					Columns:           []string{"user_id"},
+					ReferencedSchema:  "public",
+					ReferencedTable:   "users",
+					ReferencedColumns: []string{"id"},
+					OnDelete:          "CASCADE",
+					OnUpdate:          "SET NULL",
+					Flags:             []string{"DEFERRABLE", "INITIALLY_DEFERRED"},
+				},
+			},
+		},
+		{
+			name: "column-level references without column list",
+			sql:  `CREATE TABLE orders (user_id bigint REFERENCES users)`,
+			want: []DDLConstraint{
+				{Type: DDLConstraintForeignKey, Columns: []string{"user_id"}, ReferencedTable: "users"},
+			},
+		},
+		{
+			name: "table-level constraints",
+			sql: `CREATE TABLE order_items (
+    order_id bigint,
+    product_id bigint,
+    qty int,
+    CONSTRAINT order_items_pkey PRIMARY KEY (order_id, product_id),
+    UNIQUE (product_id, order_id),
+    CONSTRAINT qty_positive CHECK (qty > 0) NOT VALID,
+    CONSTRAINT order_items_order_fk FOREIGN KEY (order_id) REFERENCES orders (id) MATCH FULL ON DELETE RESTRICT
+)`,
+			want: []DDLConstraint{
+				{Type: DDLConstraintPrimaryKey, Name: "order_items_pkey", Columns: []string{"order_id", "product_id"}},
+				{Type: DDLConstraintUnique, Columns: []string{"product_id", "order_id"}},
+				{Type: DDLConstraintCheck, Name: "qty_positive", Expression: "qty > 0", Flags: []string{"NOT_VALID"}},
+				{
+					Type:              DDLConstraintForeignKey,
+					Name:              "order_items_order_fk",
+					Columns:           []string{"order_id"},
+					ReferencedTable:   "orders",
+					ReferencedColumns: []string{"id"},
+					Match:             "FULL",
+					OnDelete:          "RESTRICT",
+				},
+			},
+		},
+		{
+			name: "exclude constraint",
+			sql: `CREATE TABLE bookings (
+    room_id int,
+    during tstzrange,
+    CONSTRAINT no_overlap EXCLUDE USING gist (room_id WITH =, during WITH &&) WHERE (room_id > 0)
+)`,
+			want: []DDLConstraint{
+				{
+					Type:       DDLConstraintExclude,
+					Name:       "no_overlap",
+					Columns:    []string{"room_id", "during"},
+					Operators:  []string{"=", "&&"},
+					IndexType:  "gist",
+					Expression: "room_id > 0",
+				},
+			},
+		},
+		{
+			name: "no constraints",
+			sql:  `CREATE TABLE plain (id int NOT NULL DEFAULT 0)`,
+			want: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			require.Len(t, ir.DDLActions, 1)
+			assert.Equal(t, DDLCreateTable, ir.DDLActions[0].Type)
+			assert.Equal(t, tc.want, ir.DDLActions[0].Constraints)
+		})
+	}
+}
+
+func TestIR_DDL_AlterTableConstraints(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		wantCols  []string
+		wantFlags []string
+		want      []DDLConstraint
+	}{
+		{
+			name:      "add foreign key",
+			sql:       "ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL NOT VALID",
+			wantCols:  []string{"user_id"},
+			wantFlags: []string{"ADD_CONSTRAINT"},
+			want: []DDLConstraint{{
+				Type:              DDLConstraintForeignKey,
+				Name:              "orders_user_fk",
+				Columns:           []string{"user_id"},
+				ReferencedTable:   "users",
+				ReferencedColumns: []string{"id"},
+				OnDelete:          "SET NULL",
+				Flags:             []string{"NOT_VALID"},
+			}},
+		},
+		{
+			name:      "add unique using index",
+			sql:       "ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE USING INDEX users_email_idx",
+			wantFlags: []string{"ADD_CONSTRAINT"},
+			want: []DDLConstraint{{
+				Type:       DDLConstraintUnique,
+				Name:       "users_email_key",
+				UsingIndex: "users_email_idx",
+			}},
+		},
+		{
+			name:      "add unnamed check",
+			sql:       "ALTER TABLE users ADD CHECK (char_length(email) > 3)",
+			wantFlags: []string{"ADD_CONSTRAINT"},
+			want: []DDLConstraint{{
+				Type:       DDLConstraintCheck,
+				Expression: "char_length(email) > 3",
+			}},
+		},
+		{
+			name:      "drop constraint",
+			sql:       "ALTER TABLE users DROP CONSTRAINT users_email_key",
+			wantFlags: []string{"DROP_CONSTRAINT"},
+			want:      []DDLConstraint{{Name: "users_email_key"}},
+		},
+		{
+			name:      "drop constraint if exists cascade",
+			sql:       "ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key CASCADE",
+			wantFlags: []string{"CASCADE", "DROP_CONSTRAINT", "IF_EXISTS"},
+			want:      []DDLConstraint{{Name: "users_email_key"}},
+		},
+		{
+			name:      "add column with inline references",
+			sql:       "ALTER TABLE orders ADD COLUMN account_id bigint REFERENCES accounts (id)",
+			wantCols:  []string{"account_id"},
+			wantFlags: []string{"ADD_COLUMN"},
+			want: []DDLConstraint{{
+				Type:              DDLConstraintForeignKey,
+				Columns:           []string{"account_id"},
+				ReferencedTable:   "accounts",
+				ReferencedColumns: []string{"id"},
+			}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			require.Len(t, ir.DDLActions, 1)
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLAlterTable, act.Type)
+			assert.Equal(t, tc.wantCols, act.Columns)
+			assert.Equal(t, tc.wantFlags, act.Flags)
+			assert.Equal(t, tc.want, act.Constraints)
+		})
+	}
+}
@@ -957,7 +957,7 @@ func TestExtractCreateTableFieldCommentsByColumn_Table(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			state, err := prepareParseState(tc.sql, false)
+			state, err := prepareParseState(newParseEngine(), tc.sql, false, ParseOptions{})
 			require.NoError(t, err)
 			require.Len(t, state.stmts, 1)
 			createStmt := state.stmts[0].Createstmt()
@@ -1024,6 +1024,13 @@ func TestIR_DDL_AlterTableAddColumn(t *testing.T) {
 	require.Len(t, act.Columns, 1, "column count mismatch")
 	assert.Equal(t, "status", act.Columns[0], "column mismatch")
 	assert.Contains(t, act.Flags, "ADD_COLUMN", "expected flag ADD_COLUMN")
+	assert.Equal(t, []DDLColumn{{Name: "status", Type: "text", Nullable: true}}, act.ColumnDetails)
+}
+
+func TestIR_DDL_AlterTableAddColumnDetails(t *testing.T) {
+	ir := parseAssertNoError(t, "ALTER TABLE users ADD COLUMN created_at timestamptz NOT NULL DEFAULT now()")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	assert.Equal(t, []DDLColumn{{Name: "created_at", Type: "timestamptz", Nullable: false, Default: "now()"}}, ir.DDLActions[0].ColumnDetails)
 }
 
 func TestIR_DDL_AlterTableSchemaQualified(t *testing.T) {
@@ -1048,8 +1055,17 @@ func TestIR_DDL_AlterTableOnlySchemaQualifiedTableRef(t *testing.T) {
 	assert.Equal(t, "schema_migrations", ir.Tables[0].Name, "table name mismatch")
 	assert.Equal(t, "ONLY public.schema_migrations", ir.Tables[0].Raw, "table raw mismatch")
 
-	// ADD CONSTRAINT is currently skipped in DDL action extraction.
-	assert.Empty(t, ir.DDLActions, "expected no DDL actions for ADD CONSTRAINT")
+	require.Len(t, ir.DDLActions, 1, "expected one ADD CONSTRAINT action")
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLAlterTable, act.Type)
+	assert.Equal(t, "schema_migrations", act.ObjectName)
+	assert.Equal(t, "public", act.Schema)
+	assert.Equal(t, []string{"ADD_CONSTRAINT"}, act.Flags)
+	assert.Equal(t, []DDLConstraint{{
+		Type:    DDLConstraintPrimaryKey,
+		Name:    "schema_migrations_pkey",
+		Columns: []string{"version"},
+	}}, act.Constraints)
 }
 
 func TestIR_DDL_AlterTableOnlyUnqualifiedTableRef(t *testing.T) {
@@ -1063,8 +1079,17 @@ func TestIR_DDL_AlterTableOnlyUnqualifiedTableRef(t *testing.T) {
 	assert.Equal(t, "schema_migrations", ir.Tables[0].Name, "table name mismatch")
 	assert.Equal(t, "ONLY schema_migrations", ir.Tables[0].Raw, "table raw mismatch")
 
-	// ADD CONSTRAINT is currently skipped in DDL action extraction.
-	assert.Empty(t, ir.DDLActions, "expected no DDL actions for ADD CONSTRAINT")
+	require.Len(t, ir.DDLActions, 1, "expected one ADD CONSTRAINT action")
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLAlterTable, act.Type)
+	assert.Equal(t, "schema_migrations", act.ObjectName)
+	assert.Equal(t, "", act.Schema)
+	assert.Equal(t, []string{"ADD_CONSTRAINT"}, act.Flags)
+	assert.Equal(t, []DDLConstraint{{
+		Type:    DDLConstraintPrimaryKey,
+		Name:    "schema_migrations_pkey",
+		Columns: []string{"version"},
+	}}, act.Constraints)
 }
 
 func TestIR_DDL_AlterTableMultiAction(t *testing.T) {
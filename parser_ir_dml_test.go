@@ -267,6 +267,24 @@ RETURNING id, balance`
 	assert.Contains(t, ir.Returning[0], "RETURNING id, balance", "expected RETURNING id, balance clause")
 }
 
+// TestIR_ReturningItems verifies RETURNING targets are split like a SELECT list.
+func TestIR_ReturningItems(t *testing.T) {
+	ir := parseAssertNoError(t, "UPDATE accounts SET balance = balance - $1 WHERE id = $2 RETURNING id, balance AS remaining, *")
+
+	require.Len(t, ir.ReturningItems, 3, "unexpected RETURNING item count")
+	assert.Equal(t, "id", ir.ReturningItems[0].Expression)
+	assert.Equal(t, "balance", ir.ReturningItems[1].Expression)
+	assert.Equal(t, "remaining", ir.ReturningItems[1].Alias)
+	assert.Equal(t, "*", ir.ReturningItems[2].Expression)
+	assert.Nil(t, ir.ReturningItems[0].Expr, "expression trees are opt-in")
+
+	ir, err := ParseSQLWithOptions("DELETE FROM accounts RETURNING lower(email)", ParseOptions{IncludeExpressionTrees: true})
+	require.NoError(t, err)
+	require.Len(t, ir.ReturningItems, 1)
+	require.NotNil(t, ir.ReturningItems[0].Expr)
+	assert.Equal(t, ExprKindFunc, ir.ReturningItems[0].Expr.Kind)
+}
+
 // TestIR_InsertOnConflictDoNothingMetadata verifies DO NOTHING targets/filters.
 func TestIR_InsertOnConflictDoNothingMetadata(t *testing.T) {
 	sql := `
@@ -284,3 +302,65 @@ ON CONFLICT (id) WHERE is_active = TRUE DO NOTHING`
 	assert.Contains(t, ir.Upsert.TargetWhere, "is_active", "expected target WHERE clause to capture predicate")
 	assert.Empty(t, ir.Upsert.SetClauses, "expected no set clauses for DO NOTHING")
 }
+
+// TestIR_DMLTargetAndSubqueries verifies the written table is marked in Tables
+// and that subqueries in expressions set HasSubqueries.
+func TestIR_DMLTargetAndSubqueries(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		target        string
+		hasSubqueries bool
+	}{
+		{
+			name:   "INSERT after CTE tables",
+			sql:    "WITH recent AS (SELECT user_id FROM archive) INSERT INTO archive SELECT user_id FROM recent",
+			target: "archive",
+		},
+		{
+			name:   "UPDATE with FROM",
+			sql:    "UPDATE users u SET name = $1 FROM teams t WHERE u.team_id = t.id",
+			target: "users",
+		},
+		{
+			name:          "DELETE with IN subquery",
+			sql:           "DELETE FROM sessions WHERE user_id IN (SELECT id FROM users WHERE banned)",
+			target:        "sessions",
+			hasSubqueries: true,
+		},
+		{
+			name:   "MERGE",
+			sql:    "MERGE INTO accounts a USING staging s ON a.id = s.id WHEN MATCHED THEN DELETE",
+			target: "accounts",
+		},
+		{
+			name: "parenthesized SELECT",
+			sql:  "(SELECT id FROM users)",
+		},
+		{
+			name:          "SELECT with EXISTS",
+			sql:           "SELECT id FROM users u WHERE EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id)",
+			hasSubqueries: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+
+			var targets []string
+			for i, tbl := range ir.Tables {
+				if tbl.Target {
+					targets = append(targets, tbl.Name)
+					assert.Equal(t, tc.target, tbl.Name, "table %d", i)
+				}
+			}
+			if tc.target == "" {
+				assert.Empty(t, targets)
+			} else {
+				assert.Len(t, targets, 1, "the target is marked once")
+			}
+			assert.Equal(t, tc.hasSubqueries, ir.HasSubqueries)
+		})
+	}
+}
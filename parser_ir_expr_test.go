@@ -0,0 +1,248 @@
+// parser_ir_expr_test.go exercises typed expression trees
+// (ParseOptions.IncludeExpressionTrees).
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exprOpts = ParseOptions{IncludeExpressionTrees: true}
+
+// parseExprs parses sql with expression trees enabled.
+func parseExprs(t *testing.T, sql string) *ParsedQuery {
+	t.Helper()
+	q, err := ParseSQLWithOptions(sql, exprOpts)
+	require.NoError(t, err, "ParseSQLWithOptions(%q) returned error", sql)
+	return q
+}
+
+// whereExpr returns the single WHERE tree of sql.
+func whereExpr(t *testing.T, sql string) *Expr {
+	t.Helper()
+	q := parseExprs(t, sql)
+	require.Len(t, q.WhereExprs, len(q.Where))
+	require.Len(t, q.WhereExprs, 1)
+	require.NotNil(t, q.WhereExprs[0])
+	return q.WhereExprs[0]
+}
+
+func TestIR_ExprTrees_Disabled(t *testing.T) {
+	q := parseAssertNoError(t, "SELECT id FROM users u JOIN orders o ON o.user_id = u.id WHERE u.id = 1 GROUP BY id HAVING count(*) > 1")
+	assert.Nil(t, q.WhereExprs)
+	assert.Nil(t, q.HavingExprs)
+	assert.Nil(t, q.JoinExprs)
+	require.Len(t, q.Columns, 1)
+	assert.Nil(t, q.Columns[0].Expr)
+}
+
+func TestIR_ExprTrees_BooleanComparison(t *testing.T) {
+	e := whereExpr(t, "SELECT * FROM users u WHERE u.status = 'active' AND (u.age >= 18 OR u.vip) AND NOT u.banned")
+
+	require.Equal(t, ExprKindBool, e.Kind)
+	assert.Equal(t, "AND", e.Op)
+	require.Len(t, e.Args, 3)
+
+	eq := e.Args[0]
+	assert.Equal(t, ExprKindBinary, eq.Kind)
+	assert.Equal(t, "=", eq.Op)
+	assert.Equal(t, "u.status = 'active'", eq.Text)
+	require.Len(t, eq.Args, 2)
+	assert.Equal(t, &Expr{Kind: ExprKindColumn, Text: "u.status", Table: "u", Column: "status"}, eq.Args[0])
+	assert.Equal(t, &Expr{Kind: ExprKindLiteral, Text: "'active'", LiteralType: LiteralString, Value: "active"}, eq.Args[1])
+
+	or := e.Args[1]
+	assert.Equal(t, ExprKindBool, or.Kind)
+	assert.Equal(t, "OR", or.Op)
+	require.Len(t, or.Args, 2)
+	assert.Equal(t, ">=", or.Args[0].Op)
+
+	not := e.Args[2]
+	assert.Equal(t, ExprKindBool, not.Kind)
+	assert.Equal(t, "NOT", not.Op)
+	require.Len(t, not.Args, 1)
+	assert.Equal(t, "banned", not.Args[0].Column)
+}
+
+func TestIR_ExprTrees_Predicates(t *testing.T) {
+	t.Run("in list", func(t *testing.T) {
+		e := whereExpr(t, "SELECT 1 FROM t WHERE status NOT IN ('a', 'b', $1)")
+		require.Equal(t, ExprKindIn, e.Kind)
+		assert.True(t, e.Not)
+		require.Len(t, e.Args, 4)
+		assert.Equal(t, "status", e.Args[0].Column)
+		assert.Equal(t, "b", e.Args[2].Value)
+		assert.Equal(t, ExprKindParam, e.Args[3].Kind)
+		assert.Equal(t, &Parameter{Raw: "$1", Marker: "$", Position: 1}, e.Args[3].Param)
+	})
+	t.Run("in subquery", func(t *testing.T) {
+		e := whereExpr(t, "SELECT 1 FROM users WHERE id IN (SELECT user_id FROM orders)")
+		require.Equal(t, ExprKindIn, e.Kind)
+		require.Len(t, e.Args, 1)
+		require.NotNil(t, e.Query)
+		assert.True(t, containsTable(e.Query.Tables, "orders"))
+	})
+	t.Run("between", func(t *testing.T) {
+		e := whereExpr(t, "SELECT 1 FROM t WHERE total BETWEEN 10 AND -2.5")
+		require.Equal(t, ExprKindBetween, e.Kind)
+		assert.False(t, e.Not)
+		require.Len(t, e.Args, 3)
+		assert.Equal(t, LiteralInteger, e.Args[1].LiteralType)
+		assert.Equal(t, "10", e.Args[1].Value)
+		assert.Equal(t, LiteralNumeric, e.Args[2].LiteralType)
+		assert.Equal(t, "-2.5", e.Args[2].Value)
+	})
+	t.Run("like with escape", func(t *testing.T) {
+		e := whereExpr(t, `SELECT 1 FROM t WHERE name NOT ILIKE 'a!%%' ESCAPE '!'`)
+		require.Equal(t, ExprKindLike, e.Kind)
+		assert.Equal(t, "ILIKE", e.Op)
+		assert.True(t, e.Not)
+		require.Len(t, e.Args, 3)
+		assert.Equal(t, "!", e.Args[2].Value)
+	})
+	t.Run("is null", func(t *testing.T) {
+		e := whereExpr(t, "SELECT 1 FROM t WHERE deleted_at IS NOT NULL")
+		require.Equal(t, ExprKindIs, e.Kind)
+		assert.Equal(t, "NULL", e.Op)
+		assert.True(t, e.Not)
+	})
+	t.Run("is distinct from", func(t *testing.T) {
+		e := whereExpr(t, "SELECT 1 FROM t WHERE a IS DISTINCT FROM b")
+		require.Equal(t, ExprKindIs, e.Kind)
+		assert.Equal(t, "DISTINCT FROM", e.Op)
+		require.Len(t, e.Args, 2)
+		assert.Equal(t, "b", e.Args[1].Column)
+	})
+	t.Run("exists", func(t *testing.T) {
+		e := whereExpr(t, "SELECT 1 FROM users u WHERE EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id)")
+		require.Equal(t, ExprKindSubquery, e.Kind)
+		assert.Equal(t, "EXISTS", e.Op)
+		require.NotNil(t, e.Query)
+		require.Len(t, e.Query.WhereExprs, 1)
+		assert.Equal(t, ExprKindBinary, e.Query.WhereExprs[0].Kind)
+	})
+	t.Run("quantified comparison", func(t *testing.T) {
+		e := whereExpr(t, "SELECT 1 FROM t WHERE id = ANY($1::int[])")
+		require.Equal(t, ExprKindBinary, e.Kind)
+		assert.Equal(t, "=", e.Op)
+		assert.Equal(t, "ANY", e.Quantifier)
+		require.Len(t, e.Args, 2)
+		assert.Equal(t, ExprKindCast, e.Args[1].Kind)
+		assert.Equal(t, "int[]", e.Args[1].TypeName)
+		assert.Equal(t, ExprKindParam, e.Args[1].Args[0].Kind)
+	})
+}
+
+func TestIR_ExprTrees_Projection(t *testing.T) {
+	q := parseExprs(t, `SELECT u.*, count(DISTINCT o.id) FILTER (WHERE o.paid) AS paid_orders,
+	CASE WHEN u.age < 18 THEN 'minor' ELSE 'adult' END AS bracket,
+	CAST(u.score AS numeric) + 1,
+	coalesce(u.nick, u.name),
+	row_number() OVER (PARTITION BY u.team ORDER BY u.id),
+	DATE '2024-01-01',
+	NULL
+FROM users u JOIN orders o ON o.user_id = u.id`)
+	require.Len(t, q.Columns, 8)
+
+	assert.Equal(t, &Expr{Kind: ExprKindColumn, Text: "u.*", Table: "u", Column: "*"}, q.Columns[0].Expr)
+
+	count := q.Columns[1].Expr
+	require.NotNil(t, count)
+	assert.Equal(t, ExprKindFunc, count.Kind)
+	assert.Equal(t, "count", count.FuncName)
+	assert.True(t, count.Distinct)
+	require.Len(t, count.Args, 1)
+	assert.Equal(t, "id", count.Args[0].Column)
+	require.NotNil(t, count.Filter)
+	assert.Equal(t, "paid", count.Filter.Column)
+
+	cs := q.Columns[2].Expr
+	require.NotNil(t, cs)
+	assert.Equal(t, ExprKindCase, cs.Kind)
+	assert.Nil(t, cs.CaseOperand)
+	require.Len(t, cs.Whens, 1)
+	assert.Equal(t, "<", cs.Whens[0].Condition.Op)
+	assert.Equal(t, "minor", cs.Whens[0].Result.Value)
+	require.NotNil(t, cs.Else)
+	assert.Equal(t, "adult", cs.Else.Value)
+
+	add := q.Columns[3].Expr
+	require.NotNil(t, add)
+	assert.Equal(t, "+", add.Op)
+	assert.Equal(t, ExprKindCast, add.Args[0].Kind)
+	assert.Equal(t, "numeric", add.Args[0].TypeName)
+
+	co := q.Columns[4].Expr
+	require.NotNil(t, co)
+	assert.Equal(t, ExprKindFunc, co.Kind)
+	assert.Equal(t, "COALESCE", co.FuncName)
+	assert.Len(t, co.Args, 2)
+
+	win := q.Columns[5].Expr
+	require.NotNil(t, win)
+	assert.Equal(t, "row_number", win.FuncName)
+	assert.Equal(t, "(PARTITION BY u.team ORDER BY u.id)", win.Over)
+
+	date := q.Columns[6].Expr
+	require.NotNil(t, date)
+	assert.Equal(t, LiteralTyped, date.LiteralType)
+	assert.Equal(t, "DATE", date.TypeName)
+	assert.Equal(t, "2024-01-01", date.Value)
+
+	assert.Equal(t, LiteralNull, q.Columns[7].Expr.LiteralType)
+
+	require.Len(t, q.JoinExprs, 1)
+	assert.Equal(t, "o.user_id = u.id", q.JoinExprs[0].Text)
+}
+
+func TestIR_ExprTrees_ArithmeticAssociativity(t *testing.T) {
+	q := parseExprs(t, "SELECT a - b - c * d FROM t")
+	require.Len(t, q.Columns, 1)
+	e := q.Columns[0].Expr
+	require.NotNil(t, e)
+	// (a - b) - (c * d)
+	assert.Equal(t, "-", e.Op)
+	assert.Equal(t, "a - b", e.Args[0].Text)
+	assert.Equal(t, "*", e.Args[1].Op)
+}
+
+func TestIR_ExprTrees_DML(t *testing.T) {
+	q := parseExprs(t, "UPDATE accounts SET balance = balance - $1, updated_at = now() WHERE id = $2")
+	require.Len(t, q.SetClauseExprs, len(q.SetClauses))
+	require.Len(t, q.SetClauseExprs, 2)
+	assert.Equal(t, "-", q.SetClauseExprs[0].Op)
+	assert.Equal(t, "now", q.SetClauseExprs[1].FuncName)
+	require.Len(t, q.WhereExprs, 1)
+	assert.Equal(t, "=", q.WhereExprs[0].Op)
+
+	q = parseExprs(t, "INSERT INTO t (id, n) VALUES (1, 2) ON CONFLICT (id) DO UPDATE SET n = t.n + excluded.n")
+	require.Len(t, q.SetClauseExprs, 1)
+	assert.Equal(t, "+", q.SetClauseExprs[0].Op)
+
+	q = parseExprs(t, "SELECT 1 FROM a JOIN b USING (id) WHERE a.x > 0 GROUP BY a.y HAVING sum(a.z) > 10")
+	require.Len(t, q.JoinExprs, 1)
+	assert.Nil(t, q.JoinExprs[0])
+	require.Len(t, q.HavingExprs, 1)
+	assert.Equal(t, "sum", q.HavingExprs[0].Args[0].FuncName)
+}
+
+func TestExprWalk(t *testing.T) {
+	e := whereExpr(t, "SELECT 1 FROM t WHERE a = 1 AND CASE WHEN b THEN c ELSE d END")
+	var cols []string
+	e.Walk(func(n *Expr) bool {
+		if n.Kind == ExprKindColumn {
+			cols = append(cols, n.Column)
+		}
+		return true
+	})
+	assert.Equal(t, []string{"a", "b", "c", "d"}, cols)
+
+	visited := 0
+	e.Walk(func(n *Expr) bool {
+		visited++
+		return n.Kind != ExprKindBool
+	})
+	assert.Equal(t, 1, visited)
+}
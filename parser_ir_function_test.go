@@ -0,0 +1,183 @@
+// parser_ir_function_test.go exercises CREATE FUNCTION, CREATE PROCEDURE,
+// DROP FUNCTION, and CALL parsing at the IR level.
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIR_DDL_CreateFunction(t *testing.T) {
+	sql := `CREATE OR REPLACE FUNCTION billing.order_total(p_order_id bigint, INOUT p_rate numeric DEFAULT 1.0, VARIADIC tags text[])
+RETURNS numeric
+LANGUAGE plpgsql
+STABLE
+SECURITY DEFINER
+PARALLEL SAFE
+AS $fn$
+BEGIN
+  RETURN (SELECT sum(amount) * p_rate FROM order_lines WHERE order_id = p_order_id);
+END;
+$fn$`
+	ir := parseAssertNoError(t, sql)
+	assert.Equal(t, QueryCommandDDL, ir.Command)
+	require.Len(t, ir.DDLActions, 1)
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateFunction, act.Type)
+	assert.Equal(t, "FUNCTION", act.ObjectType)
+	assert.Equal(t, "billing", act.Schema)
+	assert.Equal(t, "order_total", act.ObjectName)
+	assert.Equal(t, []string{"OR_REPLACE", "SECURITY_DEFINER"}, act.Flags)
+
+	fn := act.Function
+	require.NotNil(t, fn)
+	assert.Equal(t, []DDLFunctionArg{
+		{Name: "p_order_id", Type: "bigint"},
+		{Name: "p_rate", Mode: "INOUT", Type: "numeric", Default: "1.0"},
+		{Name: "tags", Mode: "VARIADIC", Type: "text[]"},
+	}, fn.Args)
+	assert.Equal(t, "numeric", fn.Returns)
+	assert.False(t, fn.ReturnsSet)
+	assert.Equal(t, "plpgsql", fn.Language)
+	assert.Equal(t, "STABLE", fn.Volatility)
+	assert.Equal(t, "SAFE", fn.Parallel)
+	assert.Equal(t, "\nBEGIN\n  RETURN (SELECT sum(amount) * p_rate FROM order_lines WHERE order_id = p_order_id);\nEND;\n", fn.Body)
+	require.NotNil(t, fn.PLpgSQL)
+	require.Len(t, fn.Statements, 1, "the RETURN subquery is extracted from the plpgsql body")
+	require.Len(t, ir.Tables, 1)
+	assert.Equal(t, "order_lines", ir.Tables[0].Name)
+	assert.Empty(t, ir.Parameters)
+}
+
+func TestIR_DDL_CreateFunction_SQLBody(t *testing.T) {
+	tests := []struct {
+		name           string
+		sql            string
+		wantReturns    string
+		wantFlags      []string
+		wantVolatility string
+		wantTables     []string
+		wantStatements int
+	}{
+		{
+			name: "dollar quoted",
+			sql: `CREATE FUNCTION active_orders(customer bigint) RETURNS SETOF orders AS $$
+  SELECT o.* FROM orders o JOIN customers c ON c.id = o.customer_id WHERE c.id = $1 AND o.status = 'open';
+$$ LANGUAGE sql IMMUTABLE STRICT`,
+			wantReturns:    "SETOF orders",
+			wantFlags:      []string{"STRICT"},
+			wantVolatility: "IMMUTABLE",
+			wantTables:     []string{"orders", "customers"},
+			wantStatements: 1,
+		},
+		{
+			name: "quoted body with several statements",
+			sql: `CREATE FUNCTION archive(days int) RETURNS TABLE (id bigint, archived_at timestamptz) LANGUAGE 'SQL' RETURNS NULL ON NULL INPUT
+AS 'INSERT INTO archive SELECT * FROM events WHERE created_at < now() - make_interval(days => $1);
+DELETE FROM events WHERE created_at < now() - make_interval(days => $1) RETURNING id, now()'`,
+			wantReturns:    "TABLE (id bigint, archived_at timestamptz)",
+			wantFlags:      []string{"STRICT"},
+			wantTables:     []string{"archive", "events", "events"},
+			wantStatements: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			require.Len(t, ir.DDLActions, 1)
+			act := ir.DDLActions[0]
+			assert.Equal(t, tc.wantFlags, act.Flags)
+
+			fn := act.Function
+			require.NotNil(t, fn)
+			assert.Equal(t, "sql", fn.Language)
+			assert.Equal(t, tc.wantReturns, fn.Returns)
+			assert.True(t, fn.ReturnsSet)
+			assert.Equal(t, tc.wantVolatility, fn.Volatility)
+			require.Len(t, fn.Statements, tc.wantStatements)
+
+			var tables []string
+			for _, tbl := range ir.Tables {
+				tables = append(tables, tbl.Name)
+			}
+			assert.ElementsMatch(t, tc.wantTables, tables)
+			assert.Empty(t, ir.Parameters, "body placeholders belong to the nested statements")
+			assert.NotEmpty(t, fn.Statements[0].Parameters)
+		})
+	}
+}
+
+func TestIR_DDL_CreateProcedure(t *testing.T) {
+	ir := parseAssertNoError(t, `CREATE PROCEDURE transfer(IN src bigint, IN dst bigint, amount numeric, OUT ok boolean)
+LANGUAGE sql
+AS $$
+UPDATE accounts SET balance = balance - amount WHERE id = src;
+UPDATE accounts SET balance = balance + amount WHERE id = dst;
+$$`)
+	require.Len(t, ir.DDLActions, 1)
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateProcedure, act.Type)
+	assert.Equal(t, "PROCEDURE", act.ObjectType)
+	assert.Equal(t, "transfer", act.ObjectName)
+
+	fn := act.Function
+	require.NotNil(t, fn)
+	assert.Equal(t, []DDLFunctionArg{
+		{Name: "src", Mode: "IN", Type: "bigint"},
+		{Name: "dst", Mode: "IN", Type: "bigint"},
+		{Name: "amount", Type: "numeric"},
+		{Name: "ok", Mode: "OUT", Type: "boolean"},
+	}, fn.Args)
+	assert.Empty(t, fn.Returns)
+	require.Len(t, fn.Statements, 2)
+	assert.Equal(t, QueryCommandUpdate, fn.Statements[0].Command)
+	require.Len(t, ir.Tables, 2)
+	assert.Equal(t, "accounts", ir.Tables[0].Name)
+}
+
+func TestIR_DDL_DropFunction(t *testing.T) {
+	ir := parseAssertNoError(t, "DROP FUNCTION IF EXISTS billing.order_total(bigint, numeric), cleanup CASCADE")
+	assert.Equal(t, QueryCommandDDL, ir.Command)
+	require.Len(t, ir.DDLActions, 2)
+
+	first := ir.DDLActions[0]
+	assert.Equal(t, DDLDropFunction, first.Type)
+	assert.Equal(t, "FUNCTION", first.ObjectType)
+	assert.Equal(t, "billing", first.Schema)
+	assert.Equal(t, "order_total", first.ObjectName)
+	assert.Equal(t, []string{"IF_EXISTS", "CASCADE"}, first.Flags)
+	require.NotNil(t, first.Function)
+	assert.Equal(t, []DDLFunctionArg{{Type: "bigint"}, {Type: "numeric"}}, first.Function.Args)
+
+	second := ir.DDLActions[1]
+	assert.Equal(t, "cleanup", second.ObjectName)
+	assert.Empty(t, second.Function.Args)
+
+	ir = parseAssertNoError(t, "DROP PROCEDURE transfer(bigint, bigint, numeric)")
+	require.Len(t, ir.DDLActions, 1)
+	assert.Equal(t, "PROCEDURE", ir.DDLActions[0].ObjectType)
+}
+
+func TestIR_DDL_Call(t *testing.T) {
+	ir := parseAssertNoError(t, "CALL billing.transfer(1, $1, amount => 10.5)")
+	assert.Equal(t, QueryCommandDDL, ir.Command)
+	require.Len(t, ir.DDLActions, 1)
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCall, act.Type)
+	assert.Equal(t, "PROCEDURE", act.ObjectType)
+	assert.Equal(t, "billing", act.Schema)
+	assert.Equal(t, "transfer", act.ObjectName)
+	require.NotNil(t, act.Function)
+	assert.Equal(t, []DDLFunctionArg{
+		{Value: "1"},
+		{Value: "$1"},
+		{Name: "amount", Value: "10.5"},
+	}, act.Function.Args)
+	require.Len(t, ir.Parameters, 1)
+	assert.Equal(t, 1, ir.Parameters[0].Position)
+}
@@ -0,0 +1,210 @@
+// parser_ir_grant_test.go exercises GRANT, REVOKE, role membership, and
+// ALTER DEFAULT PRIVILEGES extraction at the IR level.
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIR_DCL_Privileges(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		want       *PrivilegeClause
+		wantTables []string
+	}{
+		{
+			name: "grant table privileges with column list and grant option",
+			sql:  "GRANT SELECT, UPDATE (email, name) ON public.users, orders TO app_rw, GROUP staff WITH GRANT OPTION",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionGrant,
+				Privileges: []Privilege{{Name: "SELECT"}, {Name: "UPDATE", Columns: []string{"email", "name"}}},
+				ObjectType: "TABLE",
+				Objects:    []PrivilegeObject{{Schema: "public", Name: "users"}, {Name: "orders"}},
+				Grantees:   []string{"app_rw", "staff"},
+				Flags:      []string{"WITH_GRANT_OPTION"},
+			},
+			wantTables: []string{"users", "orders"},
+		},
+		{
+			name: "grant all on all tables in schema",
+			sql:  "GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA app, audit TO admin",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionGrant,
+				Privileges: []Privilege{{Name: "ALL"}},
+				ObjectType: "TABLE",
+				InSchemas:  []string{"app", "audit"},
+				Grantees:   []string{"admin"},
+			},
+		},
+		{
+			name: "revoke grant option with cascade",
+			sql:  "REVOKE GRANT OPTION FOR INSERT ON TABLE users FROM PUBLIC CASCADE",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionRevoke,
+				Privileges: []Privilege{{Name: "INSERT"}},
+				ObjectType: "TABLE",
+				Objects:    []PrivilegeObject{{Name: "users"}},
+				Grantees:   []string{"PUBLIC"},
+				Flags:      []string{"GRANT_OPTION_FOR", "CASCADE"},
+			},
+			wantTables: []string{"users"},
+		},
+		{
+			name: "revoke restrict",
+			sql:  "REVOKE delete ON sessions FROM app RESTRICT",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionRevoke,
+				Privileges: []Privilege{{Name: "DELETE"}},
+				ObjectType: "TABLE",
+				Objects:    []PrivilegeObject{{Name: "sessions"}},
+				Grantees:   []string{"app"},
+				Flags:      []string{"RESTRICT"},
+			},
+			wantTables: []string{"sessions"},
+		},
+		{
+			name: "grant execute on functions",
+			sql:  "GRANT EXECUTE ON FUNCTION app.add(integer, text), now() TO api",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionGrant,
+				Privileges: []Privilege{{Name: "EXECUTE"}},
+				ObjectType: "FUNCTION",
+				Objects:    []PrivilegeObject{{Schema: "app", Name: "add", Args: "(integer, text)"}, {Name: "now", Args: "()"}},
+				Grantees:   []string{"api"},
+			},
+		},
+		{
+			name: "grant on sequence",
+			sql:  "GRANT USAGE, SELECT ON SEQUENCE users_id_seq TO api",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionGrant,
+				Privileges: []Privilege{{Name: "USAGE"}, {Name: "SELECT"}},
+				ObjectType: "SEQUENCE",
+				Objects:    []PrivilegeObject{{Name: "users_id_seq"}},
+				Grantees:   []string{"api"},
+			},
+		},
+		{
+			name: "grant on schema",
+			sql:  "GRANT USAGE, CREATE ON SCHEMA app TO api",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionGrant,
+				Privileges: []Privilege{{Name: "USAGE"}, {Name: "CREATE"}},
+				ObjectType: "SCHEMA",
+				Objects:    []PrivilegeObject{{Name: "app"}},
+				Grantees:   []string{"api"},
+			},
+		},
+		{
+			name: "grant on database",
+			sql:  "GRANT CONNECT, TEMP ON DATABASE prod TO api",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionGrant,
+				Privileges: []Privilege{{Name: "CONNECT"}, {Name: "TEMP"}},
+				ObjectType: "DATABASE",
+				Objects:    []PrivilegeObject{{Name: "prod"}},
+				Grantees:   []string{"api"},
+			},
+		},
+		{
+			name: "grant on type",
+			sql:  "GRANT USAGE ON TYPE app.mood TO api",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionGrant,
+				Privileges: []Privilege{{Name: "USAGE"}},
+				ObjectType: "TYPE",
+				Objects:    []PrivilegeObject{{Schema: "app", Name: "mood"}},
+				Grantees:   []string{"api"},
+			},
+		},
+		{
+			name: "grant role membership",
+			sql:  "GRANT admin, ops TO alice, bob WITH ADMIN OPTION GRANTED BY postgres",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionGrant,
+				ObjectType: "ROLE",
+				Objects:    []PrivilegeObject{{Name: "admin"}, {Name: "ops"}},
+				Grantees:   []string{"alice", "bob"},
+				GrantedBy:  "postgres",
+				Flags:      []string{"WITH_ADMIN_OPTION"},
+			},
+		},
+		{
+			name: "revoke role membership",
+			sql:  "REVOKE ADMIN OPTION FOR admin FROM alice CASCADE",
+			want: &PrivilegeClause{
+				Action:     PrivilegeActionRevoke,
+				ObjectType: "ROLE",
+				Objects:    []PrivilegeObject{{Name: "admin"}},
+				Grantees:   []string{"alice"},
+				Flags:      []string{"ADMIN_OPTION_FOR", "CASCADE"},
+			},
+		},
+		{
+			name: "alter default privileges grant",
+			sql:  "ALTER DEFAULT PRIVILEGES FOR ROLE owner IN SCHEMA app GRANT SELECT ON TABLES TO readonly",
+			want: &PrivilegeClause{
+				Action:            PrivilegeActionGrant,
+				Privileges:        []Privilege{{Name: "SELECT"}},
+				ObjectType:        "TABLE",
+				InSchemas:         []string{"app"},
+				Grantees:          []string{"readonly"},
+				DefaultPrivileges: true,
+				ForRoles:          []string{"owner"},
+			},
+		},
+		{
+			name: "alter default privileges revoke",
+			sql:  "ALTER DEFAULT PRIVILEGES REVOKE EXECUTE ON FUNCTIONS FROM PUBLIC",
+			want: &PrivilegeClause{
+				Action:            PrivilegeActionRevoke,
+				Privileges:        []Privilege{{Name: "EXECUTE"}},
+				ObjectType:        "FUNCTION",
+				Grantees:          []string{"PUBLIC"},
+				DefaultPrivileges: true,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDCL, ir.Command)
+			require.NotNil(t, ir.Privileges)
+
+			got := *ir.Privileges
+			assert.Equal(t, tc.want.Action, got.Action)
+			assert.Equal(t, tc.want.ObjectType, got.ObjectType)
+			assert.Equal(t, tc.want.GrantedBy, got.GrantedBy)
+			assert.Equal(t, tc.want.DefaultPrivileges, got.DefaultPrivileges)
+			assert.ElementsMatch(t, tc.want.Privileges, got.Privileges)
+			assert.ElementsMatch(t, tc.want.Objects, got.Objects)
+			assert.ElementsMatch(t, tc.want.InSchemas, got.InSchemas)
+			assert.ElementsMatch(t, tc.want.Grantees, got.Grantees)
+			assert.ElementsMatch(t, tc.want.Flags, got.Flags)
+			assert.ElementsMatch(t, tc.want.ForRoles, got.ForRoles)
+
+			var tables []string
+			for _, tbl := range ir.Tables {
+				tables = append(tables, tbl.Name)
+			}
+			assert.Equal(t, tc.wantTables, tables)
+			assert.Empty(t, ir.DDLActions)
+		})
+	}
+}
+
+func TestIR_DCL_BatchDiff(t *testing.T) {
+	batch, err := ParseSQLAll(`GRANT SELECT ON users TO reporting;
+REVOKE SELECT ON users FROM reporting;`)
+	require.NoError(t, err)
+	require.Len(t, batch.Statements, 2)
+	require.NotNil(t, batch.Statements[0].Query.Privileges)
+	require.NotNil(t, batch.Statements[1].Query.Privileges)
+	assert.Equal(t, PrivilegeActionGrant, batch.Statements[0].Query.Privileges.Action)
+	assert.Equal(t, PrivilegeActionRevoke, batch.Statements[1].Query.Privileges.Action)
+}
@@ -32,6 +32,18 @@ func containsTable(tables []TableRef, name string) bool {
 	return false
 }
 
+// assertContainsColumnUsage fails the test unless usage includes a reference to
+// alias.column with the given role.
+func assertContainsColumnUsage(t *testing.T, usage []ColumnUsage, alias, column string, role ColumnUsageType) {
+	t.Helper()
+	for _, u := range usage {
+		if u.TableAlias == alias && u.Column == column && u.UsageType == role {
+			return
+		}
+	}
+	assert.Failf(t, "missing column usage", "expected %s usage of %s.%s in %+v", role, alias, column, usage)
+}
+
 // TestSplitQualifiedName verifies schema/name splitting including quoted identifiers.
 func TestSplitQualifiedName(t *testing.T) {
 	tests := []struct {
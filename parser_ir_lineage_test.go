@@ -0,0 +1,262 @@
+// parser_ir_lineage_test.go exercises column lineage (ParseOptions.IncludeLineage).
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineageOf parses sql with lineage enabled and returns its lineage.
+func lineageOf(t *testing.T, sql string) []ColumnLineage {
+	t.Helper()
+	q, err := ParseSQLWithOptions(sql, ParseOptions{IncludeLineage: true})
+	require.NoError(t, err, "ParseSQLWithOptions(%q) returned error", sql)
+	return q.Lineage
+}
+
+// lineageCol is the comparable part of a ColumnLineage.
+type lineageCol struct {
+	Column  string
+	Kind    LineageKind
+	Sources []ColumnSource
+}
+
+// summarize drops expressions and checks positions.
+func summarize(t *testing.T, lineage []ColumnLineage) []lineageCol {
+	t.Helper()
+	out := make([]lineageCol, 0, len(lineage))
+	for i, col := range lineage {
+		assert.Equal(t, i+1, col.Position)
+		out = append(out, lineageCol{col.Column, col.Kind, col.Sources})
+	}
+	return out
+}
+
+func src(table, column string) ColumnSource {
+	return ColumnSource{Table: table, Column: column}
+}
+
+func TestIR_Lineage_Disabled(t *testing.T) {
+	q := parseAssertNoError(t, "SELECT id FROM users")
+	assert.Nil(t, q.Lineage)
+}
+
+func TestIR_Lineage_Select(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []lineageCol
+	}{
+		{
+			name: "transformation kinds",
+			sql: "SELECT u.id, upper(u.email) AS email, count(o.id) AS order_count, " +
+				"row_number() OVER (ORDER BY u.created_at) AS rn " +
+				"FROM users u JOIN orders o ON o.user_id = u.id GROUP BY u.id, u.email, u.created_at",
+			want: []lineageCol{
+				{"id", LineageDirect, []ColumnSource{src("users", "id")}},
+				{"email", LineageExpression, []ColumnSource{src("users", "email")}},
+				{"order_count", LineageAggregate, []ColumnSource{src("orders", "id")}},
+				{"rn", LineageWindow, []ColumnSource{src("users", "created_at")}},
+			},
+		},
+		{
+			name: "unqualified columns of a single table",
+			sql:  "SELECT id, total * 2, count(*) FILTER (WHERE paid) FROM sales.orders GROUP BY id, total",
+			want: []lineageCol{
+				{"id", LineageDirect, []ColumnSource{{Schema: "sales", Table: "orders", Column: "id"}}},
+				{"?column?", LineageExpression, []ColumnSource{{Schema: "sales", Table: "orders", Column: "total"}}},
+				{"count", LineageAggregate, []ColumnSource{{Schema: "sales", Table: "orders", Column: "paid"}}},
+			},
+		},
+		{
+			name: "through a CTE",
+			sql: "WITH totals AS (SELECT user_id, sum(amount) AS spent FROM payments GROUP BY user_id) " +
+				"SELECT u.name, t.spent FROM users u JOIN totals t ON t.user_id = u.id",
+			want: []lineageCol{
+				{"name", LineageDirect, []ColumnSource{src("users", "name")}},
+				{"spent", LineageAggregate, []ColumnSource{src("payments", "amount")}},
+			},
+		},
+		{
+			name: "through a subquery with column aliases",
+			sql:  "SELECT s.a, b FROM (SELECT id, email FROM users) AS s(a, b)",
+			want: []lineageCol{
+				{"a", LineageDirect, []ColumnSource{src("users", "id")}},
+				{"b", LineageDirect, []ColumnSource{src("users", "email")}},
+			},
+		},
+		{
+			name: "set operation arms merge by position",
+			sql:  "SELECT id, email FROM users UNION SELECT customer_id, lower(contact) FROM leads",
+			want: []lineageCol{
+				{"id", LineageDirect, []ColumnSource{src("users", "id"), src("leads", "customer_id")}},
+				{"email", LineageExpression, []ColumnSource{src("users", "email"), src("leads", "contact")}},
+			},
+		},
+		{
+			name: "star over a CTE and a table",
+			sql:  "WITH x AS (SELECT id, amount + tax AS gross FROM invoices) SELECT * FROM x, users",
+			want: []lineageCol{
+				{"id", LineageDirect, []ColumnSource{src("invoices", "id")}},
+				{"gross", LineageExpression, []ColumnSource{src("invoices", "amount"), src("invoices", "tax")}},
+				{"*", LineageDirect, []ColumnSource{src("users", "*")}},
+			},
+		},
+		{
+			name: "correlated scalar subquery",
+			sql:  "SELECT o.id, (SELECT max(p.amount) FROM payments p WHERE p.order_id = o.id) AS paid FROM orders o",
+			want: []lineageCol{
+				{"id", LineageDirect, []ColumnSource{src("orders", "id")}},
+				{"paid", LineageAggregate, []ColumnSource{src("payments", "amount")}},
+			},
+		},
+		{
+			name: "recursive CTE",
+			sql: "WITH RECURSIVE tree AS (SELECT id, parent_id FROM nodes WHERE parent_id IS NULL " +
+				"UNION ALL SELECT n.id, n.parent_id FROM nodes n JOIN tree t ON n.parent_id = t.id) SELECT id FROM tree",
+			want: []lineageCol{
+				{"id", LineageDirect, []ColumnSource{src("nodes", "id")}},
+			},
+		},
+		{
+			name: "ambiguous unqualified column and constant",
+			sql:  "SELECT code, 1 AS one FROM a, b",
+			want: []lineageCol{
+				{"code", LineageDirect, []ColumnSource{{Column: "code"}}},
+				{"one", LineageExpression, nil},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, summarize(t, lineageOf(t, tt.sql)))
+		})
+	}
+}
+
+func TestIR_Lineage_Expression(t *testing.T) {
+	lineage := lineageOf(t, "SELECT u.email AS contact, * FROM users u")
+	require.Len(t, lineage, 2)
+	assert.Equal(t, "u.email", lineage[0].Expression)
+	assert.Equal(t, "*", lineage[1].Expression)
+}
+
+func TestIsAggregateFunction(t *testing.T) {
+	for _, name := range []string{"count", "SUM", "pg_catalog.any_value", `"corr"`, "regr_slope", "covar_samp"} {
+		assert.True(t, IsAggregateFunction(name), name)
+	}
+	for _, name := range []string{"", "lower", "coalesce", "app.counter"} {
+		assert.False(t, IsAggregateFunction(name), name)
+	}
+}
+
+func TestOutputColumnName(t *testing.T) {
+	tests := []struct {
+		expr *Expr
+		want string
+	}{
+		{nil, "?column?"},
+		{&Expr{Kind: ExprKindColumn, Column: `"UserID"`}, "UserID"},
+		{&Expr{Kind: ExprKindFunc, FuncName: "pg_catalog.LOWER"}, "lower"},
+		{&Expr{Kind: ExprKindFunc, FuncName: `app."Score"`}, "Score"},
+		{&Expr{Kind: ExprKindCast, Args: []*Expr{{Kind: ExprKindColumn, Column: "id"}}}, "id"},
+		{&Expr{Kind: ExprKindCase}, "case"},
+		{&Expr{Kind: ExprKindSubquery, Op: "EXISTS"}, "exists"},
+		{&Expr{Kind: ExprKindBinary, Op: "+"}, "?column?"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, OutputColumnName(tt.expr))
+	}
+}
+
+func TestIR_Lineage_Targets(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []lineageCol
+	}{
+		{
+			name: "INSERT with a column list",
+			sql:  "INSERT INTO archive (id, total) SELECT id, amount FROM orders",
+			want: []lineageCol{
+				{"id", LineageDirect, []ColumnSource{src("orders", "id")}},
+				{"total", LineageDirect, []ColumnSource{src("orders", "amount")}},
+			},
+		},
+		{
+			name: "INSERT without a column list",
+			sql:  "INSERT INTO archive SELECT id FROM orders",
+			want: []lineageCol{
+				{"", LineageDirect, []ColumnSource{src("orders", "id")}},
+			},
+		},
+		{
+			name: "INSERT VALUES",
+			sql:  "INSERT INTO archive (id) VALUES ($1)",
+			want: []lineageCol{
+				{"id", LineageExpression, nil},
+			},
+		},
+		{
+			name: "INSERT with a CTE",
+			sql:  "WITH recent AS (SELECT id FROM orders) INSERT INTO archive (id) SELECT id FROM recent",
+			want: []lineageCol{
+				{"id", LineageDirect, []ColumnSource{src("orders", "id")}},
+			},
+		},
+		{
+			name: "CREATE TABLE AS",
+			sql:  "CREATE TABLE daily AS SELECT created_at::date AS order_day, count(*) FROM orders GROUP BY 1",
+			want: []lineageCol{
+				{"order_day", LineageExpression, []ColumnSource{src("orders", "created_at")}},
+				{"count", LineageAggregate, nil},
+			},
+		},
+		{
+			name: "view with a column list",
+			sql:  "CREATE VIEW v (uid, contact) AS SELECT id, email FROM users",
+			want: []lineageCol{
+				{"uid", LineageDirect, []ColumnSource{src("users", "id")}},
+				{"contact", LineageDirect, []ColumnSource{src("users", "email")}},
+			},
+		},
+		{
+			name: "materialized view",
+			sql:  "CREATE MATERIALIZED VIEW spend AS SELECT user_id, sum(amount) AS total FROM payments GROUP BY user_id",
+			want: []lineageCol{
+				{"user_id", LineageDirect, []ColumnSource{src("payments", "user_id")}},
+				{"total", LineageAggregate, []ColumnSource{src("payments", "amount")}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, summarize(t, lineageOf(t, tt.sql)))
+		})
+	}
+
+	for _, sql := range []string{"UPDATE orders SET total = 0", "CREATE INDEX ON orders (id)"} {
+		assert.Nil(t, lineageOf(t, sql), sql)
+	}
+}
+
+func TestIR_DDL_CreateTableAs(t *testing.T) {
+	q := parseAssertNoError(t, "CREATE TEMP TABLE IF NOT EXISTS reporting.snapshot (uid) AS SELECT id FROM users WITH NO DATA")
+	assert.Equal(t, QueryCommandDDL, q.Command)
+	require.Len(t, q.DDLActions, 1)
+	act := q.DDLActions[0]
+	assert.Equal(t, DDLCreateTableAs, act.Type)
+	assert.Equal(t, "snapshot", act.ObjectName)
+	assert.Equal(t, "reporting", act.Schema)
+	assert.Equal(t, "TABLE", act.ObjectType)
+	assert.Equal(t, []string{"uid"}, act.Columns)
+	assert.Equal(t, []string{"TEMPORARY", "IF_NOT_EXISTS", "WITH_NO_DATA"}, act.Flags)
+	require.NotNil(t, act.Query)
+	assert.Equal(t, "SELECT id FROM users", act.Query.RawSQL)
+	require.Len(t, act.Query.Tables, 1)
+	assert.Equal(t, "users", act.Query.Tables[0].Name)
+	require.Len(t, q.Tables, 1)
+	assert.Equal(t, "snapshot", q.Tables[0].Name)
+}
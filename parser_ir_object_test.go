@@ -0,0 +1,237 @@
+// parser_ir_object_test.go exercises sequence, type, domain, trigger,
+// extension, and schema DDL parsing at the IR level.
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIR_DDL_Sequence(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE UNLOGGED SEQUENCE IF NOT EXISTS billing.invoice_seq AS integer INCREMENT BY 5 MINVALUE 10 NO MAXVALUE START WITH 100 CACHE 20 NO CYCLE OWNED BY billing.invoices.id")
+	assert.Equal(t, QueryCommandDDL, ir.Command)
+	require.Len(t, ir.DDLActions, 1)
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateSequence, act.Type)
+	assert.Equal(t, "SEQUENCE", act.ObjectType)
+	assert.Equal(t, "billing", act.Schema)
+	assert.Equal(t, "invoice_seq", act.ObjectName)
+	assert.Equal(t, []string{"UNLOGGED", "IF_NOT_EXISTS", "NO_MAXVALUE", "NO_CYCLE"}, act.Flags)
+	assert.Equal(t, &DDLSequence{
+		DataType:  "integer",
+		Start:     "100",
+		Increment: "5",
+		MinValue:  "10",
+		Cache:     "20",
+		OwnedBy:   "billing.invoices.id",
+	}, act.Sequence)
+
+	ir = parseAssertNoError(t, "ALTER SEQUENCE IF EXISTS invoice_seq RESTART WITH 1 MAXVALUE 9999 CYCLE")
+	require.Len(t, ir.DDLActions, 1)
+	act = ir.DDLActions[0]
+	assert.Equal(t, DDLAlterSequence, act.Type)
+	assert.Equal(t, []string{"IF_EXISTS", "CYCLE"}, act.Flags)
+	assert.Equal(t, &DDLSequence{Restart: "1", MaxValue: "9999"}, act.Sequence)
+
+	ir = parseAssertNoError(t, "DROP SEQUENCE IF EXISTS a_seq, public.b_seq CASCADE")
+	require.Len(t, ir.DDLActions, 2)
+	assert.Equal(t, DDLDropSequence, ir.DDLActions[1].Type)
+	assert.Equal(t, "public", ir.DDLActions[1].Schema)
+	assert.Equal(t, "b_seq", ir.DDLActions[1].ObjectName)
+	assert.Equal(t, []string{"IF_EXISTS", "CASCADE"}, ir.DDLActions[1].Flags)
+}
+
+func TestIR_DDL_CreateType(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want DDLType
+	}{
+		{
+			name: "enum",
+			sql:  "CREATE TYPE public.mood AS ENUM ('sad', 'ok', 'it''s fine')",
+			want: DDLType{Kind: "ENUM", Labels: []string{"sad", "ok", "it's fine"}},
+		},
+		{
+			name: "composite",
+			sql:  "CREATE TYPE public.address AS (street text, zip varchar(10) COLLATE \"C\")",
+			want: DDLType{Kind: "COMPOSITE", Attributes: []DDLColumn{
+				{Name: "street", Type: "text", Nullable: true},
+				{Name: "zip", Type: "varchar(10)", Nullable: true},
+			}},
+		},
+		{
+			name: "range",
+			sql:  "CREATE TYPE public.floatrange AS RANGE (subtype = float8, subtype_diff = float8mi)",
+			want: DDLType{Kind: "RANGE", Definition: "(subtype = float8, subtype_diff = float8mi)"},
+		},
+		{
+			name: "shell",
+			sql:  "CREATE TYPE public.box3",
+			want: DDLType{Kind: "SHELL"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			require.Len(t, ir.DDLActions, 1)
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLCreateType, act.Type)
+			assert.Equal(t, "TYPE", act.ObjectType)
+			assert.Equal(t, "public", act.Schema)
+			assert.Equal(t, &tc.want, act.TypeDef)
+		})
+	}
+}
+
+func TestIR_DDL_AlterAndDropType(t *testing.T) {
+	ir := parseAssertNoError(t, "ALTER TYPE mood ADD VALUE IF NOT EXISTS 'happy' AFTER 'ok'")
+	require.Len(t, ir.DDLActions, 1)
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLAlterType, act.Type)
+	assert.Equal(t, "mood", act.ObjectName)
+	assert.Equal(t, []string{"IF_NOT_EXISTS"}, act.Flags)
+	assert.Equal(t, &DDLType{Kind: "ENUM", Labels: []string{"happy"}, After: "ok"}, act.TypeDef)
+
+	ir = parseAssertNoError(t, "ALTER TYPE mood RENAME VALUE 'sad' TO 'blue'")
+	require.Len(t, ir.DDLActions, 1)
+	assert.Nil(t, ir.DDLActions[0].TypeDef)
+	assert.Equal(t, &DDLAlterOp{Type: DDLAlterOpRenameValue, OldName: "sad", NewName: "blue"}, ir.DDLActions[0].AlterOp)
+
+	ir = parseAssertNoError(t, "DROP TYPE IF EXISTS mood, public.address RESTRICT")
+	require.Len(t, ir.DDLActions, 2)
+	assert.Equal(t, DDLDropType, ir.DDLActions[0].Type)
+	assert.Equal(t, "mood", ir.DDLActions[0].ObjectName)
+	assert.Equal(t, "public", ir.DDLActions[1].Schema)
+	assert.Equal(t, []string{"IF_EXISTS", "RESTRICT"}, ir.DDLActions[1].Flags)
+}
+
+func TestIR_DDL_Domain(t *testing.T) {
+	ir := parseAssertNoError(t, `CREATE DOMAIN public.us_postal_code AS text COLLATE "C" DEFAULT '00000' NOT NULL
+CONSTRAINT five_digits CHECK (VALUE ~ '^\d{5}$') CHECK (length(VALUE) < 11)`)
+	require.Len(t, ir.DDLActions, 1)
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateDomain, act.Type)
+	assert.Equal(t, "DOMAIN", act.ObjectType)
+	assert.Equal(t, "public", act.Schema)
+	assert.Equal(t, "us_postal_code", act.ObjectName)
+	assert.Equal(t, &DDLType{
+		Kind:      "DOMAIN",
+		BaseType:  "text",
+		Default:   "'00000'",
+		NotNull:   true,
+		Collation: `"C"`,
+	}, act.TypeDef)
+	assert.Equal(t, []DDLConstraint{
+		{Type: DDLConstraintCheck, Name: "five_digits", Expression: `VALUE ~ '^\d{5}$'`},
+		{Type: DDLConstraintCheck, Expression: "length(VALUE) < 11"},
+	}, act.Constraints)
+
+	ir = parseAssertNoError(t, "DROP DOMAIN us_postal_code CASCADE")
+	require.Len(t, ir.DDLActions, 1)
+	assert.Equal(t, DDLDropDomain, ir.DDLActions[0].Type)
+	assert.Equal(t, "DOMAIN", ir.DDLActions[0].ObjectType)
+	assert.Equal(t, []string{"CASCADE"}, ir.DDLActions[0].Flags)
+}
+
+func TestIR_DDL_Trigger(t *testing.T) {
+	ir := parseAssertNoError(t, `CREATE TRIGGER orders_audit
+AFTER INSERT OR UPDATE OF status, total OR DELETE ON billing.orders
+FOR EACH ROW WHEN (NEW.total > 0)
+EXECUTE FUNCTION audit.log_change('orders', 1)`)
+	require.Len(t, ir.DDLActions, 1)
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateTrigger, act.Type)
+	assert.Equal(t, "TRIGGER", act.ObjectType)
+	assert.Equal(t, "orders_audit", act.ObjectName)
+	assert.Equal(t, "billing", act.Schema)
+	assert.Equal(t, []string{"status", "total"}, act.Columns)
+	assert.Equal(t, &DDLTrigger{
+		Table:        "orders",
+		TableSchema:  "billing",
+		Timing:       "AFTER",
+		Events:       []string{"INSERT", "UPDATE", "DELETE"},
+		ForEach:      "ROW",
+		When:         "NEW.total > 0",
+		Function:     "audit.log_change",
+		FunctionArgs: []string{"'orders'", "1"},
+	}, act.Trigger)
+	require.Len(t, ir.Tables, 1)
+	assert.Equal(t, "orders", ir.Tables[0].Name)
+
+	ir = parseAssertNoError(t, "CREATE TRIGGER t BEFORE TRUNCATE ON logs EXECUTE PROCEDURE stop()")
+	require.Len(t, ir.DDLActions, 1)
+	assert.Equal(t, "BEFORE", ir.DDLActions[0].Trigger.Timing)
+	assert.Equal(t, "STATEMENT", ir.DDLActions[0].Trigger.ForEach)
+	assert.Empty(t, ir.DDLActions[0].Trigger.FunctionArgs)
+
+	ir = parseAssertNoError(t, "CREATE CONSTRAINT TRIGGER check_fk AFTER INSERT ON items DEFERRABLE INITIALLY DEFERRED FOR EACH ROW EXECUTE FUNCTION check_fk()")
+	require.Len(t, ir.DDLActions, 1)
+	assert.Equal(t, []string{"CONSTRAINT", "DEFERRABLE", "INITIALLY_DEFERRED"}, ir.DDLActions[0].Flags)
+	assert.Equal(t, "ROW", ir.DDLActions[0].Trigger.ForEach)
+
+	ir = parseAssertNoError(t, "DROP TRIGGER IF EXISTS orders_audit ON billing.orders")
+	require.Len(t, ir.DDLActions, 1)
+	act = ir.DDLActions[0]
+	assert.Equal(t, DDLDropTrigger, act.Type)
+	assert.Equal(t, "orders_audit", act.ObjectName)
+	assert.Equal(t, []string{"IF_EXISTS"}, act.Flags)
+	assert.Equal(t, &DDLTrigger{Table: "orders", TableSchema: "billing"}, act.Trigger)
+}
+
+func TestIR_DDL_Extension(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE EXTENSION IF NOT EXISTS pgcrypto WITH SCHEMA extensions VERSION '1.3' CASCADE")
+	require.Len(t, ir.DDLActions, 1)
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateExtension, act.Type)
+	assert.Equal(t, "EXTENSION", act.ObjectType)
+	assert.Equal(t, "pgcrypto", act.ObjectName)
+	assert.Equal(t, "extensions", act.Schema)
+	assert.Equal(t, "1.3", act.Version)
+	assert.Equal(t, []string{"IF_NOT_EXISTS", "CASCADE"}, act.Flags)
+
+	ir = parseAssertNoError(t, "DROP EXTENSION pgcrypto, hstore")
+	require.Len(t, ir.DDLActions, 2)
+	assert.Equal(t, DDLDropExtension, ir.DDLActions[1].Type)
+	assert.Equal(t, "hstore", ir.DDLActions[1].ObjectName)
+}
+
+func TestIR_DDL_Schema(t *testing.T) {
+	ir := parseAssertNoError(t, `CREATE SCHEMA billing AUTHORIZATION finance
+CREATE TABLE invoices (id bigint PRIMARY KEY, total numeric)
+CREATE SEQUENCE invoice_seq
+CREATE VIEW open_invoices AS SELECT id FROM invoices WHERE total > 0`)
+	assert.Equal(t, QueryCommandDDL, ir.Command)
+	require.Len(t, ir.DDLActions, 4)
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateSchema, act.Type)
+	assert.Equal(t, "SCHEMA", act.ObjectType)
+	assert.Equal(t, "billing", act.ObjectName)
+	assert.Equal(t, "finance", act.Owner)
+	assert.Empty(t, act.Flags)
+
+	for i, want := range []DDLActionType{DDLCreateTable, DDLCreateSequence, DDLCreateView} {
+		assert.Equal(t, want, ir.DDLActions[i+1].Type)
+		assert.Equal(t, "billing", ir.DDLActions[i+1].Schema)
+	}
+	require.NotEmpty(t, ir.Tables)
+	assert.Equal(t, "billing", ir.Tables[0].Schema)
+	assert.Equal(t, "invoices", ir.Tables[0].Name)
+
+	ir = parseAssertNoError(t, "CREATE SCHEMA IF NOT EXISTS AUTHORIZATION alice")
+	require.Len(t, ir.DDLActions, 1)
+	assert.Equal(t, []string{"IF_NOT_EXISTS"}, ir.DDLActions[0].Flags)
+	assert.Equal(t, "alice", ir.DDLActions[0].ObjectName)
+	assert.Equal(t, "alice", ir.DDLActions[0].Owner)
+
+	ir = parseAssertNoError(t, "DROP SCHEMA IF EXISTS billing, archive CASCADE")
+	require.Len(t, ir.DDLActions, 2)
+	assert.Equal(t, DDLDropSchema, ir.DDLActions[0].Type)
+	assert.Equal(t, "SCHEMA", ir.DDLActions[0].ObjectType)
+	assert.Equal(t, "archive", ir.DDLActions[1].ObjectName)
+	assert.Equal(t, []string{"IF_EXISTS", "CASCADE"}, ir.DDLActions[1].Flags)
+}
@@ -0,0 +1,132 @@
+// parser_ir_position_test.go exercises optional source spans on IR elements
+// (ParseOptions.IncludeSourcePositions).
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spanText returns the slice of sql covered by span.
+func spanText(t *testing.T, sql string, span *SourceSpan) string {
+	t.Helper()
+	require.NotNil(t, span)
+	require.True(t, span.Start >= 0 && span.Start <= span.End && span.End <= len(sql), "span %+v out of range", span)
+	return sql[span.Start:span.End]
+}
+
+var positionOpts = ParseOptions{IncludeSourcePositions: true}
+
+func TestIR_SourcePositions_Disabled(t *testing.T) {
+	q, err := ParseSQL("SELECT u.id FROM users u WHERE u.id = $1")
+	require.NoError(t, err)
+	for _, tbl := range q.Tables {
+		assert.Nil(t, tbl.Span)
+	}
+	for _, col := range q.Columns {
+		assert.Nil(t, col.Span)
+	}
+	for _, cu := range q.ColumnUsage {
+		assert.Nil(t, cu.Span)
+	}
+	for _, p := range q.Parameters {
+		assert.Nil(t, p.Span)
+	}
+}
+
+func TestIR_SourcePositions_Select(t *testing.T) {
+	sql := "\n  -- report\n  SELECT u.id, o.total AS amount\n  FROM public.users u\n  JOIN orders o ON o.user_id = u.id\n  WHERE u.email = $1 // app metadata\n"
+	q, err := ParseSQLWithOptions(sql, positionOpts)
+	require.NoError(t, err)
+
+	require.Len(t, q.Tables, 2)
+	assert.Equal(t, "public.users", spanText(t, sql, q.Tables[0].Span))
+	assert.Equal(t, SourceSpan{Start: 53, End: 65, StartLine: 4, StartColumn: 8, EndLine: 4, EndColumn: 20}, *q.Tables[0].Span)
+	assert.Equal(t, "orders", spanText(t, sql, q.Tables[1].Span))
+
+	require.Len(t, q.Columns, 2)
+	assert.Equal(t, "u.id", spanText(t, sql, q.Columns[0].Span))
+	assert.Equal(t, "o.total AS amount", spanText(t, sql, q.Columns[1].Span))
+	assert.Equal(t, 3, q.Columns[1].Span.StartLine)
+	assert.Equal(t, 16, q.Columns[1].Span.StartColumn)
+
+	for _, cu := range q.ColumnUsage {
+		text := spanText(t, sql, cu.Span)
+		assert.Equal(t, cu.Expression, text, "usage %s/%s", cu.UsageType, cu.Column)
+	}
+
+	require.Len(t, q.Parameters, 1)
+	assert.Equal(t, "$1", spanText(t, sql, q.Parameters[0].Span))
+	assert.Equal(t, 6, q.Parameters[0].Span.StartLine)
+}
+
+func TestIR_SourcePositions_MultiByte(t *testing.T) {
+	sql := "SELECT \"naïve\".x FROM \"naïve\" WHERE \"naïve\".y = 'é'"
+	q, err := ParseSQLWithOptions(sql, positionOpts)
+	require.NoError(t, err)
+
+	require.Len(t, q.Tables, 1)
+	span := q.Tables[0].Span
+	assert.Equal(t, `"naïve"`, spanText(t, sql, span))
+	assert.Equal(t, 23, span.StartColumn)
+	assert.Equal(t, 30, span.EndColumn)
+	assert.Equal(t, span.End-span.Start-1, span.EndColumn-span.StartColumn)
+}
+
+func TestIR_SourcePositions_Batch(t *testing.T) {
+	sql := "UPDATE users SET name = $1 WHERE id = $2;\r\nALTER TABLE users\r\n  ALTER COLUMN email SET NOT NULL,\r\n  DROP COLUMN legacy;\r\nDROP TABLE sessions;"
+	batch, err := ParseSQLAllWithOptions(sql, positionOpts)
+	require.NoError(t, err)
+	require.Len(t, batch.Statements, 3)
+
+	update := batch.Statements[0].Query
+	require.NotNil(t, update)
+	assert.Equal(t, "users", spanText(t, sql, update.Tables[0].Span))
+	require.Len(t, update.Parameters, 2)
+	assert.Equal(t, "$1", spanText(t, sql, update.Parameters[0].Span))
+	assert.Equal(t, "$2", spanText(t, sql, update.Parameters[1].Span))
+	assert.Equal(t, 39, update.Parameters[1].Span.StartColumn)
+
+	alter := batch.Statements[1].Query
+	require.NotNil(t, alter)
+	require.Len(t, alter.DDLActions, 2)
+	assert.Equal(t, "ALTER COLUMN email SET NOT NULL", spanText(t, sql, alter.DDLActions[0].Span))
+	assert.Equal(t, 3, alter.DDLActions[0].Span.StartLine)
+	assert.Equal(t, 3, alter.DDLActions[0].Span.StartColumn)
+	assert.Equal(t, "DROP COLUMN legacy", spanText(t, sql, alter.DDLActions[1].Span))
+	assert.Equal(t, 4, alter.DDLActions[1].Span.StartLine)
+
+	drop := batch.Statements[2].Query
+	require.NotNil(t, drop)
+	require.Len(t, drop.DDLActions, 1)
+	assert.Equal(t, "DROP TABLE sessions", spanText(t, sql, drop.DDLActions[0].Span))
+	assert.Equal(t, "sessions", spanText(t, sql, drop.Tables[0].Span))
+	assert.Equal(t, 5, drop.DDLActions[0].Span.StartLine)
+}
+
+func TestIR_SourcePositions_NestedQueries(t *testing.T) {
+	sql := "CREATE VIEW v AS SELECT a FROM (SELECT a FROM t WHERE b = ?) s JOIN r USING (a)"
+	q, err := ParseSQLWithOptions(sql, positionOpts)
+	require.NoError(t, err)
+	require.Len(t, q.DDLActions, 1)
+	assert.Equal(t, sql, spanText(t, sql, q.DDLActions[0].Span))
+
+	view := q.DDLActions[0].Query
+	require.NotNil(t, view)
+	for _, tbl := range view.Tables {
+		assert.Equal(t, tbl.Raw, spanText(t, sql, tbl.Span))
+	}
+	require.Len(t, view.Parameters, 1)
+	assert.Equal(t, "?", spanText(t, sql, view.Parameters[0].Span))
+
+	var using int
+	for _, cu := range view.ColumnUsage {
+		if cu.UsageType == ColumnUsageTypeJoin {
+			using++
+			assert.Equal(t, "USING (a)", spanText(t, sql, cu.Span))
+		}
+	}
+	assert.Equal(t, 2, using)
+}
@@ -364,7 +364,7 @@ LEFT JOIN purchases p ON u.id = p.user_id AND p.amount > 0`
 
 // TestIR_FallbackToUnknown confirms unsupported statements still return UNKNOWN.
 func TestIR_FallbackToUnknown(t *testing.T) {
-	sql := `BEGIN`
+	sql := `VACUUM users`
 	ir := parseAssertNoError(t, sql)
 
 	assert.Equal(t, QueryCommandUnknown, ir.Command, "expected UNKNOWN command for unsupported statement")
@@ -0,0 +1,106 @@
+// parser_ir_transaction_test.go exercises transaction control statement
+// extraction and batch transaction block grouping.
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIR_Transaction_Statements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want TransactionClause
+	}{
+		{name: "begin", sql: "BEGIN", want: TransactionClause{Action: TransactionBegin}},
+		{name: "begin work", sql: "BEGIN WORK", want: TransactionClause{Action: TransactionBegin}},
+		{
+			name: "begin with modes",
+			sql:  "BEGIN ISOLATION LEVEL repeatable read READ WRITE",
+			want: TransactionClause{Action: TransactionBegin, IsolationLevel: "REPEATABLE READ", AccessMode: "READ WRITE"},
+		},
+		{
+			name: "start transaction with modes",
+			sql:  "START TRANSACTION ISOLATION LEVEL SERIALIZABLE, READ ONLY, NOT DEFERRABLE",
+			want: TransactionClause{Action: TransactionStart, IsolationLevel: "SERIALIZABLE", AccessMode: "READ ONLY", Deferrable: "NOT DEFERRABLE"},
+		},
+		{name: "commit", sql: "COMMIT", want: TransactionClause{Action: TransactionCommit}},
+		{name: "end", sql: "END TRANSACTION", want: TransactionClause{Action: TransactionCommit}},
+		{name: "commit and chain", sql: "COMMIT AND CHAIN", want: TransactionClause{Action: TransactionCommit, Chain: true}},
+		{name: "commit and no chain", sql: "COMMIT AND NO CHAIN", want: TransactionClause{Action: TransactionCommit}},
+		{name: "rollback", sql: "ROLLBACK", want: TransactionClause{Action: TransactionRollback}},
+		{name: "abort", sql: "ABORT", want: TransactionClause{Action: TransactionRollback}},
+		{name: "savepoint", sql: "SAVEPOINT before_update", want: TransactionClause{Action: TransactionSavepoint, Savepoint: "before_update"}},
+		{name: "release", sql: "RELEASE SAVEPOINT before_update", want: TransactionClause{Action: TransactionRelease, Savepoint: "before_update"}},
+		{name: "release short", sql: "RELEASE sp1", want: TransactionClause{Action: TransactionRelease, Savepoint: "sp1"}},
+		{name: "rollback to", sql: "ROLLBACK TO SAVEPOINT sp1", want: TransactionClause{Action: TransactionRollbackTo, Savepoint: "sp1"}},
+		{name: "rollback to short", sql: "ROLLBACK TO sp1", want: TransactionClause{Action: TransactionRollbackTo, Savepoint: "sp1"}},
+		{name: "prepare transaction", sql: "PREPARE TRANSACTION 'tx-42'", want: TransactionClause{Action: TransactionPrepare, GID: "tx-42"}},
+		{name: "commit prepared", sql: "COMMIT PREPARED 'tx-42'", want: TransactionClause{Action: TransactionCommitPrepared, GID: "tx-42"}},
+		{name: "rollback prepared", sql: "ROLLBACK PREPARED 'tx-42'", want: TransactionClause{Action: TransactionRollbackPrepared, GID: "tx-42"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandTransaction, ir.Command)
+			require.NotNil(t, ir.Transaction)
+			assert.Equal(t, tc.want, *ir.Transaction)
+			assert.Empty(t, ir.Tables)
+			assert.Empty(t, ir.DDLActions)
+		})
+	}
+}
+
+func TestIR_Transaction_Blocks(t *testing.T) {
+	batch, err := ParseSQLAll(`CREATE TABLE audit (id int);
+BEGIN;
+INSERT INTO users (id) VALUES (1);
+SAVEPOINT sp;
+UPDATE users SET name = 'x' WHERE id = 1;
+ROLLBACK TO SAVEPOINT sp;
+COMMIT AND CHAIN;
+DELETE FROM users WHERE id = 2;
+ROLLBACK;
+SELECT 1;
+START TRANSACTION READ ONLY;
+SELECT * FROM users;`)
+	require.NoError(t, err)
+	require.Len(t, batch.Statements, 12)
+
+	blocks := batch.TransactionBlocks()
+	require.Len(t, blocks, 5)
+
+	assert.False(t, blocks[0].Explicit)
+	assert.Empty(t, blocks[0].End)
+	require.Len(t, blocks[0].Statements, 1)
+	assert.Equal(t, QueryCommandDDL, blocks[0].Statements[0].Query.Command)
+
+	assert.True(t, blocks[1].Explicit)
+	assert.Equal(t, TransactionCommit, blocks[1].End)
+	require.Len(t, blocks[1].Statements, 6)
+	assert.Equal(t, 2, blocks[1].Statements[0].Index)
+	assert.Equal(t, 7, blocks[1].Statements[5].Index)
+
+	assert.True(t, blocks[2].Explicit, "AND CHAIN opens a new block")
+	assert.Equal(t, TransactionRollback, blocks[2].End)
+	require.Len(t, blocks[2].Statements, 2)
+	assert.Equal(t, QueryCommandDelete, blocks[2].Statements[0].Query.Command)
+
+	assert.False(t, blocks[3].Explicit)
+	require.Len(t, blocks[3].Statements, 1)
+	assert.Equal(t, QueryCommandSelect, blocks[3].Statements[0].Query.Command)
+
+	assert.True(t, blocks[4].Explicit)
+	assert.Empty(t, blocks[4].End, "unterminated block has no end action")
+	require.Len(t, blocks[4].Statements, 2)
+	assert.Equal(t, "READ ONLY", blocks[4].Statements[0].Query.Transaction.AccessMode)
+}
+
+func TestIR_Transaction_BlocksNil(t *testing.T) {
+	var batch *ParseBatchResult
+	assert.Nil(t, batch.TransactionBlocks())
+}
@@ -0,0 +1,230 @@
+// parser_ir_view_test.go exercises CREATE VIEW, CREATE MATERIALIZED VIEW, and
+// REFRESH MATERIALIZED VIEW parsing at the IR level.
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIR_DDL_CreateView(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantObject  string
+		wantSchema  string
+		wantColumns []string
+		wantFlags   []string
+		wantTables  []string
+		wantQuery   string
+	}{
+		{
+			name:       "simple",
+			sql:        "CREATE VIEW active_users AS SELECT id, email FROM users WHERE active",
+			wantObject: "active_users",
+			wantTables: []string{"users"},
+			wantQuery:  "SELECT id, email FROM users WHERE active",
+		},
+		{
+			name:        "or replace temp with column list",
+			sql:         "CREATE OR REPLACE TEMP VIEW reporting.v (user_id, total) AS SELECT u.id, o.total FROM users u JOIN orders o ON o.user_id = u.id",
+			wantObject:  "v",
+			wantSchema:  "reporting",
+			wantColumns: []string{"user_id", "total"},
+			wantFlags:   []string{"OR_REPLACE", "TEMPORARY"},
+			wantTables:  []string{"users", "orders"},
+			wantQuery:   "SELECT u.id, o.total FROM users u JOIN orders o ON o.user_id = u.id",
+		},
+		{
+			name:       "local check option",
+			sql:        "CREATE VIEW big_orders AS SELECT * FROM orders WHERE total > 100 WITH LOCAL CHECK OPTION",
+			wantObject: "big_orders",
+			wantFlags:  []string{"LOCAL_CHECK_OPTION"},
+			wantTables: []string{"orders"},
+			wantQuery:  "SELECT * FROM orders WHERE total > 100",
+		},
+		{
+			name:       "cascaded check option by default",
+			sql:        "CREATE VIEW big_orders AS SELECT * FROM orders WHERE total > 100 WITH CHECK OPTION",
+			wantObject: "big_orders",
+			wantFlags:  []string{"CASCADED_CHECK_OPTION"},
+			wantTables: []string{"orders"},
+			wantQuery:  "SELECT * FROM orders WHERE total > 100",
+		},
+		{
+			name:        "recursive",
+			sql:         "CREATE RECURSIVE VIEW subordinates (id) AS SELECT id FROM staff WHERE manager_id IS NULL UNION ALL SELECT s.id FROM staff s JOIN subordinates x ON s.manager_id = x.id",
+			wantObject:  "subordinates",
+			wantColumns: []string{"id"},
+			wantFlags:   []string{"RECURSIVE"},
+			wantTables:  []string{"staff", "staff", "subordinates"},
+			wantQuery:   "SELECT id FROM staff WHERE manager_id IS NULL UNION ALL SELECT s.id FROM staff s JOIN subordinates x ON s.manager_id = x.id",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command)
+			require.Len(t, ir.DDLActions, 1)
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLCreateView, act.Type)
+			assert.Equal(t, "VIEW", act.ObjectType)
+			assert.Equal(t, tc.wantObject, act.ObjectName)
+			assert.Equal(t, tc.wantSchema, act.Schema)
+			assert.Equal(t, tc.wantColumns, act.Columns)
+			assert.Equal(t, tc.wantFlags, act.Flags)
+
+			require.Len(t, ir.Tables, 1, "only the view itself is a top-level table")
+			assert.Equal(t, tc.wantObject, ir.Tables[0].Name)
+			assert.Equal(t, tc.wantSchema, ir.Tables[0].Schema)
+
+			require.NotNil(t, act.Query)
+			assert.Equal(t, QueryCommandSelect, act.Query.Command)
+			assert.Equal(t, tc.wantQuery, act.Query.RawSQL)
+			var tables []string
+			for _, tbl := range act.Query.Tables {
+				tables = append(tables, tbl.Name)
+			}
+			assert.ElementsMatch(t, tc.wantTables, tables)
+		})
+	}
+}
+
+func TestIR_DDL_CreateView_QueryColumnUsage(t *testing.T) {
+	ir := parseAssertNoError(t, `CREATE VIEW paid_orders AS
+SELECT o.id, c.name
+FROM orders o
+JOIN customers c ON c.id = o.customer_id
+WHERE o.status = 'paid'`)
+	require.Len(t, ir.DDLActions, 1)
+	query := ir.DDLActions[0].Query
+	require.NotNil(t, query)
+	assert.Empty(t, ir.ColumnUsage, "column usage belongs to the nested query")
+
+	assertContainsColumnUsage(t, query.ColumnUsage, "o", "id", ColumnUsageTypeProjection)
+	assertContainsColumnUsage(t, query.ColumnUsage, "c", "name", ColumnUsageTypeProjection)
+	assertContainsColumnUsage(t, query.ColumnUsage, "c", "id", ColumnUsageTypeJoin)
+	assertContainsColumnUsage(t, query.ColumnUsage, "o", "customer_id", ColumnUsageTypeJoin)
+	assertContainsColumnUsage(t, query.ColumnUsage, "o", "status", ColumnUsageTypeFilter)
+	assert.Equal(t, []string{"o.status = 'paid'"}, query.Where)
+}
+
+func TestIR_DDL_CreateMaterializedView(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantObject  string
+		wantSchema  string
+		wantColumns []string
+		wantFlags   []string
+		wantTables  []string
+	}{
+		{
+			name:       "simple",
+			sql:        "CREATE MATERIALIZED VIEW daily_totals AS SELECT day, SUM(total) FROM orders GROUP BY day",
+			wantObject: "daily_totals",
+			wantTables: []string{"orders"},
+		},
+		{
+			name:        "if not exists with columns and no data",
+			sql:         "CREATE MATERIALIZED VIEW IF NOT EXISTS stats.daily (day, total) AS SELECT day, SUM(total) FROM orders GROUP BY day WITH NO DATA",
+			wantObject:  "daily",
+			wantSchema:  "stats",
+			wantColumns: []string{"day", "total"},
+			wantFlags:   []string{"IF_NOT_EXISTS", "WITH_NO_DATA"},
+			wantTables:  []string{"orders"},
+		},
+		{
+			name:       "unlogged with data",
+			sql:        "CREATE UNLOGGED MATERIALIZED VIEW mv AS SELECT id FROM events WITH DATA",
+			wantObject: "mv",
+			wantFlags:  []string{"UNLOGGED"},
+			wantTables: []string{"events"},
+		},
+		{
+			name:       "with CTE",
+			sql:        "CREATE MATERIALIZED VIEW mv AS WITH recent AS (SELECT id FROM events) SELECT * FROM recent",
+			wantObject: "mv",
+			wantTables: []string{"events", "recent"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command)
+			require.Len(t, ir.DDLActions, 1)
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLCreateMaterializedView, act.Type)
+			assert.Equal(t, "MATERIALIZED VIEW", act.ObjectType)
+			assert.Equal(t, tc.wantObject, act.ObjectName)
+			assert.Equal(t, tc.wantSchema, act.Schema)
+			assert.Equal(t, tc.wantColumns, act.Columns)
+			assert.Equal(t, tc.wantFlags, act.Flags)
+
+			require.Len(t, ir.Tables, 1)
+			assert.Equal(t, tc.wantObject, ir.Tables[0].Name)
+
+			require.NotNil(t, act.Query)
+			var tables []string
+			for _, tbl := range act.Query.Tables {
+				tables = append(tables, tbl.Name)
+			}
+			assert.ElementsMatch(t, tc.wantTables, tables)
+		})
+	}
+}
+
+func TestIR_DDL_RefreshMaterializedView(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		wantObject string
+		wantSchema string
+		wantFlags  []string
+	}{
+		{
+			name:       "simple",
+			sql:        "REFRESH MATERIALIZED VIEW daily_totals",
+			wantObject: "daily_totals",
+		},
+		{
+			name:       "concurrently schema-qualified",
+			sql:        "REFRESH MATERIALIZED VIEW CONCURRENTLY stats.daily",
+			wantObject: "daily",
+			wantSchema: "stats",
+			wantFlags:  []string{"CONCURRENTLY"},
+		},
+		{
+			name:       "with no data",
+			sql:        "REFRESH MATERIALIZED VIEW daily_totals WITH NO DATA",
+			wantObject: "daily_totals",
+			wantFlags:  []string{"WITH_NO_DATA"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command)
+			require.Len(t, ir.DDLActions, 1)
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLRefreshMaterializedView, act.Type)
+			assert.Equal(t, "MATERIALIZED VIEW", act.ObjectType)
+			assert.Equal(t, tc.wantObject, act.ObjectName)
+			assert.Equal(t, tc.wantSchema, act.Schema)
+			assert.Equal(t, tc.wantFlags, act.Flags)
+			assert.Nil(t, act.Query)
+
+			require.Len(t, ir.Tables, 1)
+			assert.Equal(t, tc.wantObject, ir.Tables[0].Name)
+			assert.Equal(t, tc.wantSchema, ir.Tables[0].Schema)
+		})
+	}
+}
@@ -727,3 +727,153 @@ func TestParseSQLStrictWithOptions_Table(t *testing.T) {
 		})
 	}
 }
+
+// TestParseSQLWithOptions_SLLPredictionMatchesLL verifies SLL-first parsing
+// produces the same IR as the default LL mode across statement kinds.
+func TestParseSQLWithOptions_SLLPredictionMatchesLL(t *testing.T) {
+	tests := []string{
+		"SELECT id, name FROM users WHERE active = true",
+		`WITH active AS (SELECT id FROM users WHERE active)
+SELECT a.id, COUNT(o.id) FROM active a LEFT JOIN orders o ON o.user_id = a.id GROUP BY a.id ORDER BY 2 DESC LIMIT 10`,
+		"INSERT INTO t (a, b) VALUES ($1, $2) ON CONFLICT (a) DO UPDATE SET b = EXCLUDED.b RETURNING a",
+		"UPDATE inventory SET qty = qty - 1 FROM orders o WHERE inventory.id = o.item_id",
+		"DELETE FROM sessions USING users u WHERE sessions.user_id = u.id AND u.banned",
+		"MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN UPDATE SET v = s.v WHEN NOT MATCHED THEN INSERT (id, v) VALUES (s.id, s.v)",
+		"CREATE TABLE events (id bigserial PRIMARY KEY, payload jsonb NOT NULL DEFAULT '{}')",
+		"SELECT a FROM t1 UNION ALL SELECT b FROM t2 EXCEPT SELECT c FROM t3",
+		"SELECT ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary DESC) FROM employees",
+	}
+
+	for _, sql := range tests {
+		t.Run(sql, func(t *testing.T) {
+			ll, err := ParseSQLWithOptions(sql, ParseOptions{})
+			require.NoError(t, err)
+			sll, err := ParseSQLWithOptions(sql, ParseOptions{UseSLLPrediction: true})
+			require.NoError(t, err)
+			assert.Equal(t, ll, sll)
+		})
+	}
+}
+
+// TestParseSQLWithOptions_SLLPredictionFallback verifies SLL-first parsing
+// reports the same syntax errors and batch warnings as LL mode.
+func TestParseSQLWithOptions_SLLPredictionFallback(t *testing.T) {
+	opts := ParseOptions{UseSLLPrediction: true}
+
+	t.Run("single statement error", func(t *testing.T) {
+		sql := "SELECT FROM WHERE"
+		_, llErr := ParseSQL(sql)
+		require.Error(t, llErr)
+		_, sllErr := ParseSQLWithOptions(sql, opts)
+		require.Error(t, sllErr)
+
+		var llParseErrs, sllParseErrs *ParseErrors
+		require.ErrorAs(t, llErr, &llParseErrs)
+		require.ErrorAs(t, sllErr, &sllParseErrs)
+		assert.Equal(t, llParseErrs.Errors, sllParseErrs.Errors)
+	})
+
+	t.Run("batch keeps valid statements", func(t *testing.T) {
+		sql := "SELECT 1; SELECT FROM; SELECT 3;"
+		llBatch, err := ParseSQLAll(sql)
+		require.NoError(t, err)
+		sllBatch, err := ParseSQLAllWithOptions(sql, opts)
+		require.NoError(t, err)
+		assert.Equal(t, llBatch, sllBatch)
+		assert.True(t, sllBatch.HasFailures)
+	})
+
+	t.Run("strict mode", func(t *testing.T) {
+		_, err := ParseSQLStrictWithOptions("SELECT 1; SELECT 2;", opts)
+		assert.ErrorIs(t, err, ErrMultipleStatements)
+	})
+}
+
+// TestParser_MatchesPackageFunctions verifies a reused Parser returns the same
+// results and errors as the package-level entry points, call after call.
+func TestParser_MatchesPackageFunctions(t *testing.T) {
+	inputs := []string{
+		"SELECT id, name FROM users WHERE active = true",
+		"SELECT $tag$ unterminated dollar quote",
+		"SELECT $fn$body$fn$ AS src FROM t",
+		"SELECT FROM WHERE",
+		"INSERT INTO t (a) VALUES ($1); UPDATE t SET a = 2 WHERE a = 1;",
+		"SELECT 1; SELECT FROM; SELECT 3;",
+		"CREATE TABLE t (\n  -- identifier\n  id int\n)",
+		"",
+	}
+
+	for _, opts := range []ParseOptions{
+		{},
+		{UseSLLPrediction: true},
+		{IncludeCreateTableFieldComments: true},
+	} {
+		p := NewParser(opts)
+		assert.Equal(t, opts, p.Options())
+		for round := 0; round < 2; round++ {
+			for _, sql := range inputs {
+				wantQuery, wantErr := ParseSQLWithOptions(sql, opts)
+				gotQuery, gotErr := p.Parse(sql)
+				assert.Equal(t, wantQuery, gotQuery, "Parse(%q)", sql)
+				assert.Equal(t, wantErr, gotErr, "Parse(%q)", sql)
+
+				wantBatch, wantErr := ParseSQLAllWithOptions(sql, opts)
+				gotBatch, gotErr := p.ParseAll(sql)
+				assert.Equal(t, wantBatch, gotBatch, "ParseAll(%q)", sql)
+				assert.Equal(t, wantErr, gotErr, "ParseAll(%q)", sql)
+
+				wantQuery, wantErr = ParseSQLStrictWithOptions(sql, opts)
+				gotQuery, gotErr = p.ParseStrict(sql)
+				assert.Equal(t, wantQuery, gotQuery, "ParseStrict(%q)", sql)
+				assert.Equal(t, wantErr, gotErr, "ParseStrict(%q)", sql)
+			}
+		}
+	}
+}
+
+// TestParser_ZeroValue verifies the zero Parser uses default options.
+func TestParser_ZeroValue(t *testing.T) {
+	var p Parser
+	assert.Equal(t, ParseOptions{}, p.Options())
+
+	pq, err := p.Parse("SELECT id FROM users")
+	require.NoError(t, err)
+	assert.Equal(t, QueryCommandSelect, pq.Command)
+	require.Len(t, pq.Tables, 1)
+	assert.Equal(t, "users", pq.Tables[0].Name)
+}
+
+// TestParser_ConcurrentUse exercises one Parser from many goroutines; run with
+// -race to check that pooled engines are never shared.
+func TestParser_ConcurrentUse(t *testing.T) {
+	inputs := []string{
+		"SELECT id FROM users WHERE id = $1",
+		"UPDATE orders SET status = 'shipped' WHERE id = $1",
+		"DELETE FROM sessions WHERE expires_at < NOW()",
+		"SELECT FROM WHERE",
+	}
+	want := make([]*ParsedQuery, len(inputs))
+	for i, sql := range inputs {
+		want[i], _ = ParseSQL(sql)
+	}
+
+	p := NewParser(ParseOptions{UseSLLPrediction: true})
+	const workers = 8
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			for i := 0; i < 20; i++ {
+				idx := (w + i) % len(inputs)
+				got, _ := p.Parse(inputs[idx])
+				if !assert.ObjectsAreEqual(want[idx], got) {
+					errs <- errors.New("mismatched result for " + inputs[idx])
+					return
+				}
+			}
+			errs <- nil
+		}(w)
+	}
+	for w := 0; w < workers; w++ {
+		assert.NoError(t, <-errs)
+	}
+}
@@ -0,0 +1,882 @@
+// plpgsql.go walks PL/pgSQL block structure (DECLARE/BEGIN/EXCEPTION/END and
+// the control statements) and extracts the SQL embedded in function,
+// procedure, and DO bodies so it can be parsed like top-level SQL.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// PLpgSQLStatementKind identifies where a SQL statement is embedded in a
+// PL/pgSQL body.
+type PLpgSQLStatementKind string
+
+const (
+	// PLpgSQLStatementSQL is a plain SQL statement such as INSERT, UPDATE,
+	// DELETE, or SELECT ... INTO.
+	PLpgSQLStatementSQL PLpgSQLStatementKind = "SQL"
+	// PLpgSQLStatementPerform is PERFORM query, which runs as a SELECT.
+	PLpgSQLStatementPerform PLpgSQLStatementKind = "PERFORM"
+	// PLpgSQLStatementReturnQuery is RETURN QUERY query.
+	PLpgSQLStatementReturnQuery PLpgSQLStatementKind = "RETURN_QUERY"
+	// PLpgSQLStatementLoopQuery is the query of FOR target IN query LOOP.
+	PLpgSQLStatementLoopQuery PLpgSQLStatementKind = "LOOP_QUERY"
+	// PLpgSQLStatementCursor is the query of a cursor declaration or of
+	// OPEN cursor FOR query.
+	PLpgSQLStatementCursor PLpgSQLStatementKind = "CURSOR"
+	// PLpgSQLStatementSubquery is a subquery inside an expression, such as an
+	// IF condition, an assignment, or a variable default.
+	PLpgSQLStatementSubquery PLpgSQLStatementKind = "SUBQUERY"
+	// PLpgSQLStatementExecute is dynamic SQL: EXECUTE, RETURN QUERY EXECUTE,
+	// FOR ... IN EXECUTE, and OPEN ... FOR EXECUTE.
+	PLpgSQLStatementExecute PLpgSQLStatementKind = "EXECUTE"
+)
+
+// PLpgSQLStatement is one SQL statement or query found in a PL/pgSQL body.
+type PLpgSQLStatement struct {
+	Kind PLpgSQLStatementKind
+	// SQL is the text given to the SQL parser: the statement as written,
+	// without its INTO targets, and prefixed with SELECT for PERFORM. For
+	// EXECUTE it is the expression that builds the command.
+	SQL    string
+	Offset int      // Byte offset of the statement in the body
+	Line   int      // 1-based line of the statement in the body
+	Into   []string // INTO targets of SELECT INTO, RETURNING ... INTO, and EXECUTE ... INTO
+	// Query is the parsed statement. It is nil when parsing failed (see
+	// Warnings) and for EXECUTE unless the command is a string constant.
+	Query    *ParsedQuery
+	Warnings []ParseWarning
+}
+
+// PLpgSQLVariable is one declaration of a DECLARE section.
+type PLpgSQLVariable struct {
+	Name string
+	Type string // Type as written, e.g. "integer" or "orders%ROWTYPE"; CURSOR or ALIAS for those forms
+}
+
+// PLpgSQLBody is the result of ParsePLpgSQL.
+type PLpgSQLBody struct {
+	Label      string             // Label of the outermost block
+	Variables  []PLpgSQLVariable  // Declarations of every block, in source order
+	Statements []PLpgSQLStatement // Embedded SQL in source order
+	Tables     []TableRef         // Tables of every parsed statement, in source order
+}
+
+// ParsePLpgSQL parses the body of a LANGUAGE plpgsql function, procedure, or
+// DO block and extracts its embedded SQL. It returns *ParseErrors when the
+// block structure is malformed; a statement that fails to parse as SQL is
+// reported in its Warnings instead.
+func ParsePLpgSQL(body string) (*PLpgSQLBody, error) {
+	return ParsePLpgSQLWithOptions(body, ParseOptions{})
+}
+
+// ParsePLpgSQLWithOptions parses a PL/pgSQL body and applies opts to each
+// embedded statement. Source spans in the statements are relative to their SQL.
+func ParsePLpgSQLWithOptions(body string, opts ParseOptions) (*PLpgSQLBody, error) {
+	p := &plParser{
+		body:   body,
+		toks:   lexPLpgSQL(body),
+		engine: newParseEngine(),
+		opts:   opts,
+		out:    &PLpgSQLBody{},
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.out, nil
+}
+
+// plToken is one significant lexical token of a PL/pgSQL body.
+type plToken struct {
+	text  string
+	word  string // Upper-cased text, for keyword comparison
+	start int    // Byte offsets of text in the body
+	end   int
+	line  int
+	col   int
+}
+
+// lexPLpgSQL lexes body with the SQL lexer, dropping whitespace and comments
+// and merging dollar-quoted strings into single tokens.
+func lexPLpgSQL(body string) []plToken {
+	offsets := make([]int, 0, len(body)+1)
+	for i := range body {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(body))
+	byteOffset := func(runeIndex int) int {
+		if runeIndex >= len(offsets) {
+			return len(body)
+		}
+		return offsets[runeIndex]
+	}
+
+	lexer := gen.NewPostgreSQLLexer(antlr.NewInputStream(body))
+	lexer.RemoveErrorListeners()
+	var toks []plToken
+	for {
+		tok := lexer.NextToken()
+		if tok == nil || tok.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		if tok.GetChannel() != antlr.TokenDefaultChannel {
+			continue
+		}
+		start, stop := tok.GetStart(), tok.GetStop()
+		if tok.GetTokenType() == gen.PostgreSQLLexerBeginDollarStringConstant {
+			for {
+				next := lexer.NextToken()
+				if next == nil || next.GetTokenType() == antlr.TokenEOF {
+					break
+				}
+				stop = next.GetStop()
+				if next.GetTokenType() == gen.PostgreSQLLexerEndDollarStringConstant {
+					break
+				}
+			}
+		}
+		from, to := byteOffset(start), byteOffset(stop+1)
+		text := body[from:to]
+		toks = append(toks, plToken{
+			text:  text,
+			word:  strings.ToUpper(text),
+			start: from,
+			end:   to,
+			line:  tok.GetLine(),
+			col:   tok.GetColumn(),
+		})
+	}
+	return toks
+}
+
+// plParser is a recursive-descent walker over PL/pgSQL statements. It follows
+// the block and control structure only; everything else is SQL or an
+// expression handed to the SQL parser.
+type plParser struct {
+	body   string
+	toks   []plToken
+	pos    int
+	engine *parseEngine
+	opts   ParseOptions
+	out    *PLpgSQLBody
+}
+
+// plBlockEnd lists the words that end a statement list.
+var plBlockEnd = map[string]bool{
+	"END": true, "ELSIF": true, "ELSEIF": true, "ELSE": true, "EXCEPTION": true, "WHEN": true,
+}
+
+// parse walks the outermost block, which may be followed by a semicolon.
+func (p *plParser) parse() error {
+	if len(p.toks) == 0 {
+		return ErrNoStatements
+	}
+	label, err := p.block()
+	if err != nil {
+		return err
+	}
+	p.out.Label = label
+	if !p.eof() {
+		return p.errorf("expected end of body, found %q", p.cur().text)
+	}
+	return nil
+}
+
+// block parses [<<label>>] [DECLARE ...] BEGIN ... [EXCEPTION ...] END [label] [;]
+// and returns the block label.
+func (p *plParser) block() (string, error) {
+	label := p.label()
+	if p.is("DECLARE") {
+		p.pos++
+		if err := p.declarations(); err != nil {
+			return "", err
+		}
+	}
+	if err := p.expect("BEGIN"); err != nil {
+		return "", err
+	}
+	if err := p.statements(); err != nil {
+		return "", err
+	}
+	if p.is("EXCEPTION") {
+		p.pos++
+		for p.is("WHEN") {
+			p.pos++
+			then, err := p.until("THEN")
+			if err != nil {
+				return "", err
+			}
+			p.pos = then + 1
+			if err := p.statements(); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := p.expect("END"); err != nil {
+		return "", err
+	}
+	if !p.eof() && !p.is(";") {
+		p.pos++
+	}
+	if p.is(";") {
+		p.pos++
+	}
+	return label, nil
+}
+
+// label consumes an optional <<label>> and returns its name.
+func (p *plParser) label() string {
+	if !p.is("<<") || p.pos+2 >= len(p.toks) || p.toks[p.pos+2].text != ">>" {
+		return ""
+	}
+	name := p.toks[p.pos+1].text
+	p.pos += 3
+	return name
+}
+
+// declarations parses a DECLARE section up to BEGIN. Cursor queries and
+// subqueries of defaults are extracted.
+func (p *plParser) declarations() error {
+	for !p.is("BEGIN") {
+		if p.eof() {
+			return p.errorf("expected BEGIN, found end of body")
+		}
+		if p.is("DECLARE") {
+			p.pos++
+			continue
+		}
+		name := p.cur()
+		p.pos++
+		end, err := p.until(";")
+		if err != nil {
+			return err
+		}
+		decl := PLpgSQLVariable{Name: name.text}
+		from := p.pos
+		p.pos = end + 1
+
+		if from < end && p.toks[from].word == "ALIAS" {
+			decl.Type = "ALIAS"
+		} else if cursor := p.find(from, min(from+3, end), "CURSOR"); cursor >= 0 {
+			decl.Type = "CURSOR"
+			if query := p.find(cursor+1, end, "FOR", "IS"); query >= 0 {
+				p.emit(PLpgSQLStatementCursor, query+1, end, "")
+			}
+		} else {
+			if from < end && p.toks[from].word == "CONSTANT" {
+				from++
+			}
+			typeEnd := p.find(from, end, ":=", "=", "DEFAULT", "NOT", "COLLATE")
+			if typeEnd < 0 {
+				typeEnd = end
+			}
+			if from < typeEnd {
+				decl.Type = normalizeSpace(p.text(from, typeEnd))
+			}
+			if def := p.find(typeEnd, end, ":=", "=", "DEFAULT"); def >= 0 {
+				p.expression(def+1, end)
+			}
+		}
+		p.out.Variables = append(p.out.Variables, decl)
+	}
+	return nil
+}
+
+// statements parses statements until a word that ends a statement list.
+func (p *plParser) statements() error {
+	for {
+		if p.eof() {
+			return p.errorf("expected END, found end of body")
+		}
+		if plBlockEnd[p.cur().word] {
+			return nil
+		}
+		if err := p.statement(); err != nil {
+			return err
+		}
+	}
+}
+
+// statement parses one PL/pgSQL statement.
+func (p *plParser) statement() error {
+	save := p.pos
+	// Loop labels are skipped; a block reads its own label.
+	if p.label(); p.is("DECLARE") || p.is("BEGIN") {
+		p.pos = save
+		_, err := p.block()
+		return err
+	}
+	start := p.pos
+	word := p.cur().word
+	switch word {
+	case "IF":
+		return p.ifStatement()
+	case "CASE":
+		return p.caseStatement()
+	case "LOOP":
+		p.pos++
+		return p.loopBody()
+	case "WHILE":
+		p.pos++
+		end, err := p.until("LOOP")
+		if err != nil {
+			return err
+		}
+		p.expression(start+1, end)
+		p.pos = end + 1
+		return p.loopBody()
+	case "FOR":
+		return p.forStatement()
+	case "FOREACH":
+		end, err := p.until("LOOP")
+		if err != nil {
+			return err
+		}
+		if array := p.find(start, end, "ARRAY"); array >= 0 {
+			p.expression(array+1, end)
+		}
+		p.pos = end + 1
+		return p.loopBody()
+	case "RETURN":
+		return p.returnStatement()
+	case "PERFORM":
+		end, err := p.until(";")
+		if err != nil {
+			return err
+		}
+		p.emit(PLpgSQLStatementPerform, start+1, end, "SELECT ")
+		p.pos = end + 1
+		return nil
+	case "EXECUTE":
+		p.pos++
+		return p.execute(";")
+	case "OPEN":
+		return p.openStatement()
+	case "EXIT", "CONTINUE", "ASSERT", "RAISE", "FETCH", "MOVE", "CLOSE", "GET", "NULL":
+		end, err := p.until(";")
+		if err != nil {
+			return err
+		}
+		switch word {
+		case "EXIT", "CONTINUE":
+			if when := p.find(start, end, "WHEN"); when >= 0 {
+				p.expression(when+1, end)
+			}
+		case "ASSERT":
+			p.expression(start+1, end)
+		}
+		p.pos = end + 1
+		return nil
+	}
+
+	end, err := p.until(";")
+	if err != nil {
+		return err
+	}
+	if assign := p.assignment(start, end); assign >= 0 {
+		p.expression(assign+1, end)
+	} else {
+		p.emit(PLpgSQLStatementSQL, start, end, "")
+	}
+	p.pos = end + 1
+	return nil
+}
+
+// ifStatement parses IF ... THEN ... [ELSIF ... THEN ...] [ELSE ...] END IF;
+func (p *plParser) ifStatement() error {
+	for p.is("IF") || p.is("ELSIF") || p.is("ELSEIF") {
+		p.pos++
+		end, err := p.until("THEN")
+		if err != nil {
+			return err
+		}
+		p.expression(p.pos, end)
+		p.pos = end + 1
+		if err := p.statements(); err != nil {
+			return err
+		}
+	}
+	if p.is("ELSE") {
+		p.pos++
+		if err := p.statements(); err != nil {
+			return err
+		}
+	}
+	return p.expectEnd("IF")
+}
+
+// caseStatement parses CASE [expr] WHEN ... THEN ... [ELSE ...] END CASE;
+func (p *plParser) caseStatement() error {
+	p.pos++
+	if !p.is("WHEN") {
+		end, err := p.until("WHEN")
+		if err != nil {
+			return err
+		}
+		p.expression(p.pos, end)
+		p.pos = end
+	}
+	for p.is("WHEN") {
+		p.pos++
+		end, err := p.until("THEN")
+		if err != nil {
+			return err
+		}
+		p.expression(p.pos, end)
+		p.pos = end + 1
+		if err := p.statements(); err != nil {
+			return err
+		}
+	}
+	if p.is("ELSE") {
+		p.pos++
+		if err := p.statements(); err != nil {
+			return err
+		}
+	}
+	return p.expectEnd("CASE")
+}
+
+// forStatement parses FOR target IN {range | query | EXECUTE expr | cursor} LOOP ... END LOOP;
+func (p *plParser) forStatement() error {
+	in, err := p.until("IN")
+	if err != nil {
+		return err
+	}
+	p.pos = in + 1
+	if p.is("EXECUTE") {
+		p.pos++
+		if err := p.execute("LOOP"); err != nil {
+			return err
+		}
+		return p.loopBody()
+	}
+	end, err := p.until("LOOP")
+	if err != nil {
+		return err
+	}
+	if p.startsQuery(p.pos) {
+		p.emit(PLpgSQLStatementLoopQuery, p.pos, end, "")
+	} else {
+		p.expression(p.pos, end)
+	}
+	p.pos = end + 1
+	return p.loopBody()
+}
+
+// returnStatement parses RETURN [expr], RETURN NEXT expr, and RETURN QUERY
+// [EXECUTE] ....
+func (p *plParser) returnStatement() error {
+	p.pos++
+	switch {
+	case p.is("QUERY"):
+		p.pos++
+		if p.is("EXECUTE") {
+			p.pos++
+			return p.execute(";")
+		}
+		end, err := p.until(";")
+		if err != nil {
+			return err
+		}
+		p.emit(PLpgSQLStatementReturnQuery, p.pos, end, "")
+		p.pos = end + 1
+		return nil
+	case p.is("NEXT"):
+		p.pos++
+	}
+	end, err := p.until(";")
+	if err != nil {
+		return err
+	}
+	p.expression(p.pos, end)
+	p.pos = end + 1
+	return nil
+}
+
+// openStatement parses OPEN cursor [[NO] SCROLL] FOR {query | EXECUTE expr}
+// and OPEN cursor [(args)].
+func (p *plParser) openStatement() error {
+	end, err := p.until(";")
+	if err != nil {
+		return err
+	}
+	if query := p.find(p.pos, end, "FOR"); query >= 0 {
+		if query+1 < end && p.toks[query+1].word == "EXECUTE" {
+			p.pos = query + 2
+			return p.execute(";")
+		}
+		p.emit(PLpgSQLStatementCursor, query+1, end, "")
+	}
+	p.pos = end + 1
+	return nil
+}
+
+// execute parses the command expression of a dynamic EXECUTE and its INTO
+// and USING clauses, up to and including stop. A command given as a single
+// string constant is parsed as SQL.
+func (p *plParser) execute(stop string) error {
+	end, err := p.until("INTO", "USING", stop)
+	if err != nil {
+		return err
+	}
+	stmt := p.newStatement(PLpgSQLStatementExecute, p.pos, end)
+	stmt.SQL = p.text(p.pos, end)
+	if end == p.pos+1 && isStringConstant(p.toks[p.pos].text) {
+		stmt.Query, stmt.Warnings = p.parseSQL(decodeCommentStringLiteral(p.toks[p.pos].text))
+	}
+	p.pos = end
+
+	for !p.is(stop) {
+		clause := p.cur().word
+		p.pos++
+		next, err := p.until("INTO", "USING", stop)
+		if err != nil {
+			return err
+		}
+		if clause == "INTO" {
+			stmt.Into = p.targets(p.pos, next)
+		}
+		p.pos = next
+	}
+	p.pos++
+	p.add(stmt)
+	return nil
+}
+
+// loopBody parses the statements of a loop and END LOOP [label];
+func (p *plParser) loopBody() error {
+	if err := p.statements(); err != nil {
+		return err
+	}
+	return p.expectEnd("LOOP")
+}
+
+// expectEnd consumes END keyword [label] ;
+func (p *plParser) expectEnd(keyword string) error {
+	if err := p.expect("END"); err != nil {
+		return err
+	}
+	if err := p.expect(keyword); err != nil {
+		return err
+	}
+	if !p.is(";") && !p.eof() {
+		p.pos++
+	}
+	return p.expect(";")
+}
+
+// assignment returns the index of the := or = of an assignment statement
+// spanning toks[start:end], or -1 when the statement is not an assignment.
+func (p *plParser) assignment(start, end int) int {
+	i := start + 1
+	for i < end {
+		switch p.toks[i].text {
+		case ":=", "=":
+			return i
+		case ".":
+			i += 2
+		case "[":
+			depth := 0
+			for ; i < end; i++ {
+				if p.toks[i].text == "[" {
+					depth++
+				} else if p.toks[i].text == "]" {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			i++
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// startsQuery reports whether toks[i] begins a query.
+func (p *plParser) startsQuery(i int) bool {
+	for i < len(p.toks) && p.toks[i].text == "(" {
+		i++
+	}
+	if i >= len(p.toks) {
+		return false
+	}
+	switch p.toks[i].word {
+	case "SELECT", "WITH", "VALUES", "TABLE":
+		return true
+	}
+	return false
+}
+
+// expression records each subquery of the expression in toks[start:end].
+// Other expressions have no table dependencies.
+func (p *plParser) expression(start, end int) {
+	for i := start; i < end; i++ {
+		if p.toks[i].text != "(" || !p.startsQuery(i+1) {
+			continue
+		}
+		depth := 0
+		for j := i; j < end; j++ {
+			switch p.toks[j].text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			}
+			if depth == 0 {
+				p.emit(PLpgSQLStatementSubquery, i+1, j, "")
+				i = j
+				break
+			}
+		}
+	}
+}
+
+// emit parses toks[start:end] as SQL, after removing INTO targets and adding
+// prefix, and records the statement.
+func (p *plParser) emit(kind PLpgSQLStatementKind, start, end int, prefix string) {
+	if start >= end {
+		return
+	}
+	stmt := p.newStatement(kind, start, end)
+	sql := p.text(start, end)
+	if into := p.intoClause(start, end); into >= 0 {
+		targetEnd := into + 1
+		if targetEnd < end && p.toks[targetEnd].word == "STRICT" {
+			targetEnd++
+		}
+		stmt.Into, targetEnd = p.targetList(targetEnd, end)
+		sql = strings.TrimSpace(p.body[p.toks[start].start:p.toks[into].start])
+		if targetEnd < end {
+			sql += " " + p.text(targetEnd, end)
+		}
+	}
+	stmt.SQL = prefix + sql
+	stmt.Query, stmt.Warnings = p.parseSQL(stmt.SQL)
+	p.add(stmt)
+}
+
+// intoClause returns the index of a PL/pgSQL INTO in toks[start:end], i.e. a
+// top-level INTO that does not follow INSERT or MERGE, or -1.
+func (p *plParser) intoClause(start, end int) int {
+	depth := 0
+	for i := start; i < end; i++ {
+		switch p.toks[i].text {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		}
+		if depth == 0 && p.toks[i].word == "INTO" && i > start {
+			if prev := p.toks[i-1].word; prev != "INSERT" && prev != "MERGE" {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// targets returns the comma-separated INTO targets in toks[start:end].
+func (p *plParser) targets(start, end int) []string {
+	if start < end && p.toks[start].word == "STRICT" {
+		start++
+	}
+	names, _ := p.targetList(start, end)
+	return names
+}
+
+// targetList reads a comma-separated list of possibly qualified names from
+// toks[start:end] and returns them with the index following the list.
+func (p *plParser) targetList(start, end int) ([]string, int) {
+	var names []string
+	i := start
+	for i < end {
+		from := i
+		i++
+		for i+1 < end && p.toks[i].text == "." {
+			i += 2
+		}
+		names = append(names, p.text(from, i))
+		if i >= end || p.toks[i].text != "," {
+			break
+		}
+		i++
+	}
+	return names, i
+}
+
+// newStatement returns a statement located at toks[start].
+func (p *plParser) newStatement(kind PLpgSQLStatementKind, start, end int) PLpgSQLStatement {
+	stmt := PLpgSQLStatement{Kind: kind}
+	if start < end {
+		stmt.Offset = p.toks[start].start
+		stmt.Line = p.toks[start].line
+	}
+	return stmt
+}
+
+// add records stmt and the tables of its query.
+func (p *plParser) add(stmt PLpgSQLStatement) {
+	if stmt.Query != nil {
+		p.out.Tables = append(p.out.Tables, stmt.Query.Tables...)
+	}
+	p.out.Statements = append(p.out.Statements, stmt)
+}
+
+// parseSQL parses one embedded statement, reporting failures as warnings.
+func (p *plParser) parseSQL(sql string) (*ParsedQuery, []ParseWarning) {
+	state, err := prepareParseState(p.engine, sql, false, p.opts)
+	if err == nil {
+		var query *ParsedQuery
+		query, err = parseStatementToIR(state.stmts[0], state.stream, state.cleanSQL, p.opts)
+		if err == nil {
+			return query, nil
+		}
+	}
+	return nil, []ParseWarning{{Code: ParseWarningCodeSyntaxError, Message: err.Error()}}
+}
+
+// until returns the index of the first token at or after the current
+// position that matches one of words outside parentheses, brackets, and CASE
+// expressions.
+func (p *plParser) until(words ...string) (int, error) {
+	depth, cases := 0, 0
+	for i := p.pos; i < len(p.toks); i++ {
+		tok := p.toks[i]
+		switch tok.word {
+		case "(", "[":
+			depth++
+			continue
+		case ")", "]":
+			depth--
+			continue
+		case "CASE":
+			if depth == 0 {
+				cases++
+			}
+		case "END":
+			if depth == 0 && cases > 0 {
+				cases--
+				continue
+			}
+		}
+		if depth > 0 || cases > 0 {
+			continue
+		}
+		for _, w := range words {
+			if tok.word == w {
+				return i, nil
+			}
+		}
+		if tok.word == ";" {
+			return 0, p.errorAt(tok, "expected %s, found \";\"", strings.Join(words, " or "))
+		}
+	}
+	return 0, p.errorf("expected %s, found end of body", strings.Join(words, " or "))
+}
+
+// find returns the index of the first token in toks[start:end] that matches
+// one of words outside parentheses and brackets, or -1.
+func (p *plParser) find(start, end int, words ...string) int {
+	depth := 0
+	for i := start; i < end; i++ {
+		switch p.toks[i].word {
+		case "(", "[":
+			depth++
+			continue
+		case ")", "]":
+			depth--
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+		for _, w := range words {
+			if p.toks[i].word == w {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// text returns the source text of toks[start:end].
+func (p *plParser) text(start, end int) string {
+	if start >= end {
+		return ""
+	}
+	return p.body[p.toks[start].start:p.toks[end-1].end]
+}
+
+func (p *plParser) eof() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *plParser) cur() plToken {
+	if p.eof() {
+		return plToken{}
+	}
+	return p.toks[p.pos]
+}
+
+// is reports whether the current token is word.
+func (p *plParser) is(word string) bool {
+	return !p.eof() && p.toks[p.pos].word == word
+}
+
+// expect consumes word or returns a syntax error.
+func (p *plParser) expect(word string) error {
+	if p.eof() {
+		return p.errorf("expected %s, found end of body", word)
+	}
+	if !p.is(word) {
+		return p.errorAt(p.cur(), "expected %s, found %q", word, p.cur().text)
+	}
+	p.pos++
+	return nil
+}
+
+// errorf reports a syntax error at the current token, or at the end of the
+// body.
+func (p *plParser) errorf(format string, args ...any) error {
+	if !p.eof() {
+		return p.errorAt(p.cur(), format, args...)
+	}
+	tok := plToken{line: 1}
+	if n := len(p.toks); n > 0 {
+		tok = p.toks[n-1]
+	}
+	return p.errorAt(tok, format, args...)
+}
+
+// errorAt reports a syntax error at tok.
+func (p *plParser) errorAt(tok plToken, format string, args ...any) error {
+	return &ParseErrors{SQL: p.body, Errors: []SyntaxError{{
+		Line:       tok.line,
+		Column:     tok.col,
+		Message:    "plpgsql: " + fmt.Sprintf(format, args...),
+		TokenIndex: -1,
+	}}}
+}
+
+// isStringConstant reports whether text is a single-quoted (including E and U&)
+// or dollar-quoted string constant, as opposed to a $n parameter.
+func isStringConstant(text string) bool {
+	upper := strings.ToUpper(text)
+	switch {
+	case strings.HasPrefix(upper, "'"), strings.HasPrefix(upper, "E'"),
+		strings.HasPrefix(upper, "N'"), strings.HasPrefix(upper, "U&'"):
+		return true
+	case strings.HasPrefix(upper, "$"):
+		return len(upper) > 1 && (upper[1] < '0' || upper[1] > '9')
+	}
+	return false
+}
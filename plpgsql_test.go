@@ -0,0 +1,169 @@
+package postgresparser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPLpgSQLBody = `
+<<main>>
+DECLARE
+  total numeric := 0;
+  r record;
+  c CURSOR (k int) FOR SELECT * FROM audit WHERE id = k;
+BEGIN
+  SELECT sum(amount) INTO STRICT total FROM order_lines WHERE order_id = p_id;
+  IF EXISTS (SELECT 1 FROM blocked WHERE user_id = p_user) THEN
+    RAISE EXCEPTION 'blocked %', p_user;
+  ELSIF total > 100 THEN
+    UPDATE orders SET flagged = true WHERE id = p_id RETURNING id INTO r;
+  END IF;
+  FOR r IN SELECT id FROM items WHERE order_id = p_id LOOP
+    PERFORM log_item(r.id);
+  END LOOP;
+  BEGIN
+    INSERT INTO history (order_id, total) VALUES (p_id, total);
+  EXCEPTION WHEN unique_violation THEN
+    NULL;
+  END;
+  total := CASE WHEN total > 1 THEN 2 ELSE 3 END;
+  EXECUTE 'DELETE FROM tmp_' || p_id USING p_id;
+  EXECUTE 'TRUNCATE staging';
+  RETURN QUERY SELECT * FROM orders WHERE id = p_id;
+END main;
+`
+
+func TestParsePLpgSQL(t *testing.T) {
+	body, err := ParsePLpgSQL(testPLpgSQLBody)
+	require.NoError(t, err)
+	assert.Equal(t, "main", body.Label)
+	assert.Equal(t, []PLpgSQLVariable{
+		{Name: "total", Type: "numeric"},
+		{Name: "r", Type: "record"},
+		{Name: "c", Type: "CURSOR"},
+	}, body.Variables)
+
+	type want struct {
+		kind PLpgSQLStatementKind
+		sql  string
+		into []string
+		line int
+	}
+	wants := []want{
+		{PLpgSQLStatementCursor, "SELECT * FROM audit WHERE id = k", nil, 6},
+		{PLpgSQLStatementSQL, "SELECT sum(amount) FROM order_lines WHERE order_id = p_id", []string{"total"}, 8},
+		{PLpgSQLStatementSubquery, "SELECT 1 FROM blocked WHERE user_id = p_user", nil, 9},
+		{PLpgSQLStatementSQL, "UPDATE orders SET flagged = true WHERE id = p_id RETURNING id", []string{"r"}, 12},
+		{PLpgSQLStatementLoopQuery, "SELECT id FROM items WHERE order_id = p_id", nil, 14},
+		{PLpgSQLStatementPerform, "SELECT log_item(r.id)", nil, 15},
+		{PLpgSQLStatementSQL, "INSERT INTO history (order_id, total) VALUES (p_id, total)", nil, 18},
+		{PLpgSQLStatementExecute, "'DELETE FROM tmp_' || p_id", nil, 23},
+		{PLpgSQLStatementExecute, "'TRUNCATE staging'", nil, 24},
+		{PLpgSQLStatementReturnQuery, "SELECT * FROM orders WHERE id = p_id", nil, 25},
+	}
+	require.Len(t, body.Statements, len(wants))
+	for i, w := range wants {
+		stmt := body.Statements[i]
+		assert.Equal(t, w.kind, stmt.Kind, "statement %d", i)
+		assert.Equal(t, w.sql, stmt.SQL, "statement %d", i)
+		assert.Equal(t, w.into, stmt.Into, "statement %d", i)
+		assert.Equal(t, w.line, stmt.Line, "statement %d", i)
+		assert.Empty(t, stmt.Warnings, "statement %d", i)
+	}
+
+	insert := body.Statements[6]
+	assert.Equal(t, "INSERT INTO history", testPLpgSQLBody[insert.Offset:insert.Offset+19])
+	require.NotNil(t, insert.Query)
+	assert.Equal(t, QueryCommandInsert, insert.Query.Command)
+
+	assert.Nil(t, body.Statements[7].Query, "dynamic commands are not parsed")
+	require.NotNil(t, body.Statements[8].Query, "constant commands are parsed")
+	assert.Equal(t, QueryCommandDDL, body.Statements[8].Query.Command)
+
+	var tables []string
+	for _, tbl := range body.Tables {
+		tables = append(tables, tbl.Name)
+	}
+	assert.ElementsMatch(t, []string{"audit", "order_lines", "blocked", "orders", "items", "history", "staging", "orders"}, tables)
+}
+
+func TestParsePLpgSQL_Warnings(t *testing.T) {
+	body, err := ParsePLpgSQL("BEGIN UPDATE SET x = 1; DELETE FROM t; END")
+	require.NoError(t, err)
+	require.Len(t, body.Statements, 2)
+	assert.Nil(t, body.Statements[0].Query)
+	require.Len(t, body.Statements[0].Warnings, 1)
+	assert.Equal(t, ParseWarningCodeSyntaxError, body.Statements[0].Warnings[0].Code)
+	require.NotNil(t, body.Statements[1].Query)
+}
+
+func TestParsePLpgSQL_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"missing end", "BEGIN SELECT 1;", "line 1:14 plpgsql: expected END, found end of body"},
+		{"missing begin", "DECLARE x int;", "line 1:13 plpgsql: expected BEGIN, found end of body"},
+		{"mismatched end", "BEGIN IF x THEN SELECT 1; END LOOP; END", `line 1:30 plpgsql: expected IF, found "LOOP"`},
+		{"trailing statement", "BEGIN END; SELECT 1;", `line 1:11 plpgsql: expected end of body, found "SELECT"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePLpgSQL(tt.body)
+			var parseErrs *ParseErrors
+			require.True(t, errors.As(err, &parseErrs), "got %v", err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	_, err := ParsePLpgSQL("  ")
+	assert.ErrorIs(t, err, ErrNoStatements)
+}
+
+func TestIR_DDL_CreateFunction_PLpgSQLBody(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE FUNCTION touch(p_id bigint) RETURNS void LANGUAGE plpgsql AS $$\n"+
+		"BEGIN\n  UPDATE accounts SET seen_at = now() WHERE id = p_id;\n  EXECUTE format('ANALYZE %I', 'accounts');\nEND;\n$$")
+	require.Len(t, ir.DDLActions, 1)
+	fn := ir.DDLActions[0].Function
+	require.NotNil(t, fn)
+	require.NotNil(t, fn.PLpgSQL)
+	require.Len(t, fn.PLpgSQL.Statements, 2)
+	assert.Equal(t, PLpgSQLStatementExecute, fn.PLpgSQL.Statements[1].Kind)
+	require.Len(t, fn.Statements, 1)
+	assert.Equal(t, QueryCommandUpdate, fn.Statements[0].Command)
+	require.Len(t, ir.Tables, 1)
+	assert.Equal(t, "accounts", ir.Tables[0].Name)
+}
+
+func TestIR_DDL_Do(t *testing.T) {
+	ir := parseAssertNoError(t, `DO $$
+DECLARE r record;
+BEGIN
+  FOR r IN SELECT tablename FROM pg_tables WHERE schemaname = 'archive' LOOP
+    EXECUTE 'DROP TABLE archive.' || quote_ident(r.tablename);
+  END LOOP;
+END $$`)
+	assert.Equal(t, QueryCommandDDL, ir.Command)
+	require.Len(t, ir.DDLActions, 1)
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLDo, act.Type)
+	require.NotNil(t, act.Function)
+	assert.Equal(t, "plpgsql", act.Function.Language)
+	require.NotNil(t, act.Function.PLpgSQL)
+	require.Len(t, act.Function.PLpgSQL.Statements, 2)
+	assert.Equal(t, PLpgSQLStatementLoopQuery, act.Function.PLpgSQL.Statements[0].Kind)
+	assert.Equal(t, PLpgSQLStatementExecute, act.Function.PLpgSQL.Statements[1].Kind)
+	require.Len(t, ir.Tables, 1)
+	assert.Equal(t, "pg_tables", ir.Tables[0].Name)
+
+	ir = parseAssertNoError(t, "DO LANGUAGE plperl 'print 1'")
+	require.Len(t, ir.DDLActions, 1)
+	assert.Equal(t, "plperl", ir.DDLActions[0].Function.Language)
+	assert.Equal(t, "print 1", ir.DDLActions[0].Function.Body)
+	assert.Nil(t, ir.DDLActions[0].Function.PLpgSQL)
+}
@@ -0,0 +1,180 @@
+// positions.go maps ANTLR token positions back to byte offsets and
+// line/column locations in the SQL text passed to the parser. Mapping is only
+// active when ParseOptions.IncludeSourcePositions is set.
+//
+// It also defines optionTokenStream, which carries the extraction options that
+// are consulted deep inside the IR builders.
+package postgresparser
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// optionTokenStream wraps the shared token stream with the optional extraction
+// settings so helpers can attach spans and expression trees without threading
+// extra parameters.
+type optionTokenStream struct {
+	antlr.TokenStream
+	source    *sourceMap // Set with ParseOptions.IncludeSourcePositions
+	exprTrees bool       // ParseOptions.IncludeExpressionTrees
+}
+
+// newOptionTokenStream wraps stream for the original sql and its preprocessed
+// form cleanSQL, which is what the lexer actually saw. It returns stream
+// unchanged when opts enables no optional extraction.
+func newOptionTokenStream(stream antlr.TokenStream, sql, cleanSQL string, opts ParseOptions) antlr.TokenStream {
+	if !opts.IncludeSourcePositions && !opts.IncludeExpressionTrees {
+		return stream
+	}
+	ots := &optionTokenStream{TokenStream: stream, exprTrees: opts.IncludeExpressionTrees}
+	if opts.IncludeSourcePositions {
+		ots.source = newSourceMap(sql, cleanSQL)
+	}
+	return ots
+}
+
+// wantExprTrees reports whether expression trees should be built for tokens.
+func wantExprTrees(tokens antlr.TokenStream) bool {
+	ots, ok := tokens.(*optionTokenStream)
+	return ok && ots.exprTrees
+}
+
+// sourceMap translates rune indices in the preprocessed SQL to byte offsets,
+// lines, and columns in the original SQL.
+//
+// Preprocessing only truncates line tails and trims leading whitespace from
+// the whole input, so an offset in the preprocessed text maps to the same
+// line and byte column of the original text once the trimmed prefix is added
+// back.
+type sourceMap struct {
+	sql               string
+	lineStarts        []int // byte offset of each line in sql
+	strippedLineStart []int // byte offset of each line in the untrimmed preprocessed text
+	lead              int   // bytes removed by the leading trim
+	runeBytes         []int // byte offset in the preprocessed text of each rune, plus its length
+}
+
+// newSourceMap builds the lookup tables for one parse.
+func newSourceMap(sql, cleanSQL string) *sourceMap {
+	m := &sourceMap{sql: sql}
+
+	offset := 0
+	for _, line := range strings.Split(sql, "\n") {
+		m.lineStarts = append(m.lineStarts, offset)
+		offset += len(line) + 1
+	}
+
+	stripped := stripSQLLines(sql)
+	offset = 0
+	for _, line := range stripped {
+		m.strippedLineStart = append(m.strippedLineStart, offset)
+		offset += len(line) + 1
+	}
+	joined := strings.Join(stripped, "\n")
+	m.lead = len(joined) - len(strings.TrimLeftFunc(joined, unicode.IsSpace))
+
+	m.runeBytes = make([]int, 0, utf8.RuneCountInString(cleanSQL)+1)
+	for i := range cleanSQL {
+		m.runeBytes = append(m.runeBytes, i)
+	}
+	m.runeBytes = append(m.runeBytes, len(cleanSQL))
+	return m
+}
+
+// offset converts a rune index in the preprocessed SQL to a byte offset in
+// the original SQL.
+func (m *sourceMap) offset(runeIndex int) int {
+	if runeIndex < 0 {
+		runeIndex = 0
+	}
+	if runeIndex >= len(m.runeBytes) {
+		runeIndex = len(m.runeBytes) - 1
+	}
+	pos := m.runeBytes[runeIndex] + m.lead
+	line := sort.SearchInts(m.strippedLineStart, pos+1) - 1
+	return m.lineStarts[line] + pos - m.strippedLineStart[line]
+}
+
+// lineColumn returns the 1-based line and rune column of a byte offset in
+// the original SQL.
+func (m *sourceMap) lineColumn(offset int) (int, int) {
+	line := sort.SearchInts(m.lineStarts, offset+1) - 1
+	return line + 1, utf8.RuneCountInString(m.sql[m.lineStarts[line]:offset]) + 1
+}
+
+// span builds a SourceSpan for the preprocessed rune range [start, stop).
+func (m *sourceMap) span(start, stop int) *SourceSpan {
+	s := &SourceSpan{Start: m.offset(start), End: m.offset(stop)}
+	s.StartLine, s.StartColumn = m.lineColumn(s.Start)
+	s.EndLine, s.EndColumn = m.lineColumn(s.End)
+	return s
+}
+
+// spanFor returns the source span covered by ctx, or nil when positions are
+// disabled or ctx has no tokens.
+func spanFor(tokens antlr.TokenStream, ctx antlr.RuleContext) *SourceSpan {
+	pts, ok := tokens.(*optionTokenStream)
+	if !ok || pts.source == nil || ctx == nil {
+		return nil
+	}
+	ruleCtx, ok := ctx.(antlr.ParserRuleContext)
+	if !ok {
+		return nil
+	}
+	start, stop := ruleCtx.GetStart(), ruleCtx.GetStop()
+	if start == nil || stop == nil || stop.GetTokenIndex() < start.GetTokenIndex() {
+		return nil
+	}
+	return pts.source.span(start.GetStart(), stop.GetStop()+1)
+}
+
+// setActionSpans assigns span to every action that does not have one yet.
+func setActionSpans(actions []DDLAction, span *SourceSpan) {
+	if span == nil {
+		return
+	}
+	for i := range actions {
+		if actions[i].Span == nil {
+			s := *span
+			actions[i].Span = &s
+		}
+	}
+}
+
+// setParameterSpans assigns spans to params, which were extracted in order from
+// text starting at the first token of ctx.
+func setParameterSpans(params []Parameter, ctx antlr.ParserRuleContext, tokens antlr.TokenStream) {
+	pts, ok := tokens.(*optionTokenStream)
+	if !ok || pts.source == nil || len(params) == 0 || ctx == nil || ctx.GetStart() == nil {
+		return
+	}
+	next := 0
+	for i := ctx.GetStart().GetTokenIndex(); i < pts.Size() && next < len(params); i++ {
+		tok := pts.Get(i)
+		if tok.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		if tok.GetTokenType() != gen.PostgreSQLLexerPARAM {
+			continue
+		}
+		params[next].Span = pts.source.span(tok.GetStart(), tok.GetStop()+1)
+		next++
+	}
+}
+
+// commonTokenStream returns the underlying CommonTokenStream, unwrapping a
+// optionTokenStream when present.
+func commonTokenStream(tokens antlr.TokenStream) (*antlr.CommonTokenStream, bool) {
+	if pts, ok := tokens.(*optionTokenStream); ok {
+		tokens = pts.TokenStream
+	}
+	stream, ok := tokens.(*antlr.CommonTokenStream)
+	return stream, ok
+}
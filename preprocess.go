@@ -23,7 +23,13 @@ func preprocessSQLInput(sql string) string {
 	if sql == "" {
 		return ""
 	}
+	return strings.TrimSpace(strings.Join(stripSQLLines(sql), "\n"))
+}
 
+// stripSQLLines splits sql on newlines and removes trailing // comments and
+// trailing whitespace from each line. Every returned line is a prefix of the
+// corresponding input line, which source-position mapping relies on.
+func stripSQLLines(sql string) []string {
 	state := &quoteState{}
 	lines := strings.Split(sql, "\n")
 	for i, line := range lines {
@@ -31,9 +37,7 @@ func preprocessSQLInput(sql string) string {
 		stripped = strings.TrimRightFunc(stripped, unicode.IsSpace)
 		lines[i] = stripped
 	}
-
-	cleaned := strings.Join(lines, "\n")
-	return strings.TrimSpace(cleaned)
+	return lines
 }
 
 // stripDoubleSlashComment removes a trailing // comment from a line while respecting string literals
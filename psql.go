@@ -0,0 +1,419 @@
+// psql.go implements psql script mode for ParseSQLAll: backslash
+// meta-commands, variable interpolation, \i/\ir includes, and \if blocks.
+package postgresparser
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// PSQLOptions enables psql script mode in ParseSQLAll and Parser.ParseAll.
+//
+// In script mode the input is split into statements the way psql splits it,
+// and each statement is parsed on its own. Lines starting with a backslash
+// are meta-commands and are returned as separate StatementParseResult entries
+// with MetaCommand set; \set, \unset, \i, \ir, \if, \elif, \else, and \endif
+// are evaluated, other meta-commands are only reported. :name, :'name', and
+// :"name" are replaced outside quotes and comments by the variable's value,
+// quoted as a literal, or quoted as an identifier; references to undefined
+// variables are left as written.
+type PSQLOptions struct {
+	// Variables holds the initial psql variables. The map is not modified;
+	// \set and \unset work on a copy.
+	Variables map[string]string
+
+	// FS resolves \i and \include paths, and \ir and \include_relative paths
+	// relative to the directory of the including file. Includes fail when FS
+	// is nil.
+	FS fs.FS
+}
+
+// psqlScript holds the state of one psql script evaluation.
+type psqlScript struct {
+	engine  *parseEngine
+	opts    ParseOptions // Options for parsing each statement, without PSQL
+	fsys    fs.FS
+	vars    map[string]string
+	files   []string // Files being included, outermost first
+	results []StatementParseResult
+}
+
+// psqlFile tracks the \if blocks of the file being evaluated; psql requires
+// every block to end in the file where it starts.
+type psqlFile struct {
+	name  string // Path within PSQLOptions.FS; empty for the input SQL
+	conds []psqlCond
+}
+
+// psqlCond is one open \if block.
+type psqlCond struct {
+	outer  bool // The enclosing block is active
+	active bool // The current branch is active
+	taken  bool // A branch of the block has been active
+	inElse bool
+}
+
+// parsePSQLScript implements ParseSQLAll for opts.PSQL.
+func parsePSQLScript(engine *parseEngine, sql string, opts ParseOptions) (*ParseBatchResult, error) {
+	script := &psqlScript{
+		engine: engine,
+		fsys:   opts.PSQL.FS,
+		vars:   make(map[string]string, len(opts.PSQL.Variables)),
+	}
+	for name, value := range opts.PSQL.Variables {
+		script.vars[name] = value
+	}
+	script.opts = opts
+	script.opts.PSQL = nil
+
+	if err := script.run(sql, ""); err != nil {
+		return nil, err
+	}
+	if len(script.results) == 0 {
+		return nil, ErrNoStatements
+	}
+
+	var hasFailures bool
+	for _, res := range script.results {
+		if (res.Query == nil && res.MetaCommand == nil) || len(res.Warnings) > 0 {
+			hasFailures = true
+			break
+		}
+	}
+	return &ParseBatchResult{
+		Statements:  script.results,
+		HasFailures: hasFailures,
+	}, nil
+}
+
+// run evaluates the script text of one file.
+func (p *psqlScript) run(text, name string) error {
+	file := &psqlFile{name: name}
+	scan := statementScanner{vars: p.vars}
+	lineNo := 0
+	for text != "" {
+		line := text
+		text = ""
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line, text = line[:i+1], line[i+1:]
+		}
+		lineNo++
+
+		if scan.quote == 0 && !scan.inDollar && scan.comment == 0 && strings.HasPrefix(strings.TrimLeft(line, " \t"), `\`) {
+			if err := p.metaCommand(file, &scan, line, lineNo); err != nil {
+				return err
+			}
+			continue
+		}
+		if !file.active() {
+			continue
+		}
+		for rest := line; rest != ""; {
+			stmt, next, done := scan.feed(rest, lineNo)
+			rest = next
+			if done {
+				p.statement(stmt, name)
+			}
+		}
+	}
+	if scan.started {
+		p.statement(scan.flush(), name)
+	}
+	if len(file.conds) > 0 {
+		return file.errorf(lineNo, `reached end of file without finding closing \endif`)
+	}
+	return nil
+}
+
+// statement parses one SQL statement of the script.
+func (p *psqlScript) statement(stmt scannedStatement, name string) {
+	text := strings.TrimSpace(stmt.text)
+	if text == "" {
+		return
+	}
+	batch, err := parseAllStatements(p.engine, text, p.opts)
+	if err != nil {
+		p.add(StatementParseResult{
+			RawSQL:   text,
+			File:     name,
+			Warnings: []ParseWarning{{Code: ParseWarningCodeSyntaxError, Message: err.Error()}},
+		})
+		return
+	}
+	for _, res := range batch.Statements {
+		res.File = name
+		p.add(res)
+	}
+}
+
+// add appends a result, numbering it in script order.
+func (p *psqlScript) add(res StatementParseResult) {
+	res.Index = len(p.results) + 1
+	p.results = append(p.results, res)
+}
+
+// metaCommand evaluates a backslash command line.
+func (p *psqlScript) metaCommand(file *psqlFile, scan *statementScanner, line string, lineNo int) error {
+	text := strings.TrimSpace(line)
+	cmd := parseMetaCommand(interpolateMetaCommand(text, p.vars))
+
+	switch cmd.Name {
+	case "if", "elif", "else", "endif":
+		// Conditionals are reported when the block containing them is active.
+		report := file.active()
+		if cmd.Name != "if" {
+			report = file.outerActive()
+		}
+		if err := file.conditional(cmd, lineNo); err != nil {
+			return err
+		}
+		if report {
+			p.add(StatementParseResult{RawSQL: text, File: file.name, MetaCommand: cmd})
+		}
+		return nil
+	}
+	if !file.active() {
+		return nil
+	}
+
+	// \g and its variants send the statement buffered so far.
+	if psqlSendCommands[cmd.Name] && scan.started {
+		p.statement(scan.flush(), file.name)
+	}
+	p.add(StatementParseResult{RawSQL: text, File: file.name, MetaCommand: cmd})
+
+	switch cmd.Name {
+	case "set":
+		if len(cmd.Args) > 0 {
+			p.vars[cmd.Args[0]] = strings.Join(cmd.Args[1:], "")
+		}
+	case "unset":
+		if len(cmd.Args) > 0 {
+			delete(p.vars, cmd.Args[0])
+		}
+	case "i", "include", "ir", "include_relative":
+		return p.include(file, cmd, lineNo)
+	}
+	return nil
+}
+
+// psqlSendCommands are the meta-commands that end the statement before them.
+var psqlSendCommands = map[string]bool{"g": true, "gx": true, "gset": true, "gexec": true, "gdesc": true}
+
+// include evaluates the file named by an \i or \ir command.
+func (p *psqlScript) include(file *psqlFile, cmd *MetaCommand, lineNo int) error {
+	if len(cmd.Args) == 0 {
+		return file.errorf(lineNo, `\%s: missing required argument`, cmd.Name)
+	}
+	if p.fsys == nil {
+		return file.errorf(lineNo, `\%s %s: PSQLOptions.FS is not set`, cmd.Name, cmd.Args[0])
+	}
+	name := cmd.Args[0]
+	if cmd.Name == "ir" || cmd.Name == "include_relative" {
+		name = path.Join(path.Dir(file.name), name)
+	}
+	name = path.Clean(name)
+	if !fs.ValidPath(name) {
+		return file.errorf(lineNo, `\%s %s: invalid path`, cmd.Name, cmd.Args[0])
+	}
+	for _, open := range p.files {
+		if open == name {
+			return file.errorf(lineNo, `\%s %s: recursive include of %s`, cmd.Name, cmd.Args[0], name)
+		}
+	}
+
+	data, err := fs.ReadFile(p.fsys, name)
+	if err != nil {
+		return file.errorf(lineNo, `\%s: %w`, cmd.Name, err)
+	}
+	p.files = append(p.files, name)
+	defer func() { p.files = p.files[:len(p.files)-1] }()
+	return p.run(string(data), name)
+}
+
+// active reports whether lines at the current position are evaluated.
+func (f *psqlFile) active() bool {
+	return len(f.conds) == 0 || f.conds[len(f.conds)-1].active
+}
+
+// outerActive reports whether the block enclosing the innermost \if is
+// active.
+func (f *psqlFile) outerActive() bool {
+	return len(f.conds) == 0 || f.conds[len(f.conds)-1].outer
+}
+
+// conditional applies an \if, \elif, \else, or \endif command.
+func (f *psqlFile) conditional(cmd *MetaCommand, lineNo int) error {
+	if cmd.Name == "if" {
+		outer := f.active()
+		value, err := f.condition(cmd, lineNo, outer)
+		if err != nil {
+			return err
+		}
+		f.conds = append(f.conds, psqlCond{outer: outer, active: value, taken: value})
+		return nil
+	}
+
+	if len(f.conds) == 0 {
+		return f.errorf(lineNo, `\%s: no matching \if`, cmd.Name)
+	}
+	cond := &f.conds[len(f.conds)-1]
+	switch cmd.Name {
+	case "elif":
+		if cond.inElse {
+			return f.errorf(lineNo, `\elif: cannot occur after \else`)
+		}
+		value, err := f.condition(cmd, lineNo, cond.outer && !cond.taken)
+		if err != nil {
+			return err
+		}
+		cond.active = value
+		cond.taken = cond.taken || value
+	case "else":
+		if cond.inElse {
+			return f.errorf(lineNo, `\else: cannot occur after \else`)
+		}
+		cond.inElse = true
+		cond.active = cond.outer && !cond.taken
+		cond.taken = true
+	case "endif":
+		f.conds = f.conds[:len(f.conds)-1]
+	}
+	return nil
+}
+
+// condition evaluates the boolean argument of \if or \elif. Like psql, it
+// does not evaluate the argument when evaluate is false.
+func (f *psqlFile) condition(cmd *MetaCommand, lineNo int, evaluate bool) (bool, error) {
+	if !evaluate {
+		return false, nil
+	}
+	if len(cmd.Args) == 0 {
+		return false, f.errorf(lineNo, `\%s: missing expression`, cmd.Name)
+	}
+	expr := strings.Join(cmd.Args, " ")
+	value, ok := parsePSQLBool(expr)
+	if !ok {
+		return false, f.errorf(lineNo, `\%s: unrecognized value %q: Boolean expected`, cmd.Name, expr)
+	}
+	return value, nil
+}
+
+// errorf returns a script error located at lineNo of the file.
+func (f *psqlFile) errorf(lineNo int, format string, args ...any) error {
+	where := fmt.Sprintf("line %d", lineNo)
+	if f.name != "" {
+		where = fmt.Sprintf("%s:%d", f.name, lineNo)
+	}
+	return fmt.Errorf("psql: %s: "+format, append([]any{where}, args...)...)
+}
+
+// parsePSQLBool parses a psql boolean: an unambiguous prefix of true, false,
+// yes, or no, on, off, 1, or 0, in any case.
+func parsePSQLBool(value string) (bool, bool) {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case lower == "":
+		return false, false
+	case strings.HasPrefix("true", lower), strings.HasPrefix("yes", lower), lower == "1":
+		return true, true
+	case strings.HasPrefix("false", lower), strings.HasPrefix("no", lower), lower == "0":
+		return false, true
+	case len(lower) >= 2 && strings.HasPrefix("on", lower):
+		return true, true
+	case len(lower) >= 2 && strings.HasPrefix("off", lower):
+		return false, true
+	}
+	return false, false
+}
+
+// lookupVariable reads a variable reference at the start of text: :name,
+// :'name', or :"name". It returns the variable's value, the quote of the
+// reference (0 when unquoted), and the length of the reference; ok is false
+// when text does not start with a reference to a defined variable.
+func lookupVariable(text string, vars map[string]string) (value string, quote byte, n int, ok bool) {
+	if len(text) < 2 || text[0] != ':' {
+		return "", 0, 0, false
+	}
+	start := 1
+	if text[1] == '\'' || text[1] == '"' {
+		quote = text[1]
+		start = 2
+	}
+	end := start
+	for end < len(text) && isVariableByte(text[end]) {
+		end++
+	}
+	if end == start {
+		return "", 0, 0, false
+	}
+	n = end
+	if quote != 0 {
+		if end == len(text) || text[end] != quote {
+			return "", 0, 0, false
+		}
+		n++
+	}
+	value, ok = vars[text[start:end]]
+	return value, quote, n, ok
+}
+
+// expandSQLVariable expands a variable reference at the start of SQL text.
+// :'name' becomes a string literal and :"name" a quoted identifier.
+func expandSQLVariable(text string, vars map[string]string) (string, int, bool) {
+	value, quote, n, ok := lookupVariable(text, vars)
+	if !ok {
+		return "", 0, false
+	}
+	switch quote {
+	case '\'':
+		literal := "'" + strings.ReplaceAll(value, "'", "''") + "'"
+		if strings.Contains(value, `\`) {
+			literal = "E" + strings.ReplaceAll(literal, `\`, `\\`)
+		}
+		return literal, n, true
+	case '"':
+		return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`, n, true
+	}
+	return value, n, true
+}
+
+// interpolateMetaCommand expands variable references outside quotes in a
+// meta-command line. Quoted references are quoted so that parseMetaCommand
+// returns the value as a single argument.
+func interpolateMetaCommand(text string, vars map[string]string) string {
+	if !strings.Contains(text, ":") {
+		return text
+	}
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ':':
+			if value, q, n, ok := lookupVariable(text[i:], vars); ok {
+				if q != 0 {
+					value = string(q) + strings.ReplaceAll(value, string(q), string(q)+string(q)) + string(q)
+				}
+				b.WriteString(value)
+				i += n - 1
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isVariableByte reports whether c can be part of a psql variable name.
+func isVariableByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,179 @@
+package postgresparser
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parsePSQL(t *testing.T, sql string, psql PSQLOptions) *ParseBatchResult {
+	t.Helper()
+	batch, err := ParseSQLAllWithOptions(sql, ParseOptions{PSQL: &psql})
+	require.NoError(t, err)
+	return batch
+}
+
+func TestParseSQLAll_PSQL(t *testing.T) {
+	vars := map[string]string{"use_audit": "on"}
+	batch := parsePSQL(t, `\set schema app
+\set owner 'O''Brien'
+CREATE TABLE :"schema".accounts (id int, note text DEFAULT :'owner');
+SELECT id::text, ':schema' FROM :schema.accounts;
+\if :use_audit
+  \i audit/setup.sql
+\else
+  SELECT 'no audit';
+\endif
+\connect other
+`, PSQLOptions{
+		Variables: vars,
+		FS: fstest.MapFS{
+			"audit/setup.sql":  {Data: []byte("\\ir grants.sql\nCREATE TABLE :schema.audit_log (id int);\n")},
+			"audit/grants.sql": {Data: []byte("GRANT SELECT ON :schema.audit_log TO reporting;\n")},
+		},
+	})
+	assert.False(t, batch.HasFailures)
+
+	type want struct {
+		sql  string
+		file string
+		meta *MetaCommand
+	}
+	wants := []want{
+		{`\set schema app`, "", &MetaCommand{Name: "set", Args: []string{"schema", "app"}}},
+		{`\set owner 'O''Brien'`, "", &MetaCommand{Name: "set", Args: []string{"owner", "O'Brien"}}},
+		{`CREATE TABLE "app".accounts (id int, note text DEFAULT 'O''Brien')`, "", nil},
+		{`SELECT id::text, ':schema' FROM app.accounts`, "", nil},
+		{`\if :use_audit`, "", &MetaCommand{Name: "if", Args: []string{"on"}}},
+		{`\i audit/setup.sql`, "", &MetaCommand{Name: "i", Args: []string{"audit/setup.sql"}}},
+		{`\ir grants.sql`, "audit/setup.sql", &MetaCommand{Name: "ir", Args: []string{"grants.sql"}}},
+		{`GRANT SELECT ON app.audit_log TO reporting`, "audit/grants.sql", nil},
+		{`CREATE TABLE app.audit_log (id int)`, "audit/setup.sql", nil},
+		{`\else`, "", &MetaCommand{Name: "else"}},
+		{`\endif`, "", &MetaCommand{Name: "endif"}},
+		{`\connect other`, "", &MetaCommand{Name: "connect", Args: []string{"other"}}},
+	}
+	require.Len(t, batch.Statements, len(wants))
+	for i, w := range wants {
+		stmt := batch.Statements[i]
+		assert.Equal(t, i+1, stmt.Index, "statement %d", i)
+		assert.Equal(t, w.sql, stmt.RawSQL, "statement %d", i)
+		assert.Equal(t, w.file, stmt.File, "statement %d", i)
+		assert.Equal(t, w.meta, stmt.MetaCommand, "statement %d", i)
+		if w.meta != nil {
+			assert.Nil(t, stmt.Query, "statement %d", i)
+		} else {
+			assert.NotNil(t, stmt.Query, "statement %d", i)
+		}
+	}
+	assert.Equal(t, map[string]string{"use_audit": "on"}, vars, "caller variables are not modified")
+}
+
+func TestParseSQLAll_PSQLInterpolation(t *testing.T) {
+	vars := map[string]string{"v": "x", "path": `C:\tmp`, "id": `my"col`}
+	batch := parsePSQL(t, `SELECT :v, :'path', :"id", a::int, ':v', "a:v", $$:v$$ -- :v
+  /* :v */ FROM t;
+\set v y
+SELECT :v
+\g
+\unset v
+\echo :v :'path' ':v' :undefined`, PSQLOptions{Variables: vars})
+
+	var got []string
+	for _, stmt := range batch.Statements {
+		got = append(got, stmt.RawSQL)
+	}
+	assert.Equal(t, []string{
+		"SELECT x, E'C:\\\\tmp', \"my\"\"col\", a::int, ':v', \"a:v\", $$:v$$ -- :v\n  /* :v */ FROM t",
+		`\set v y`,
+		"SELECT y",
+		`\g`,
+		`\unset v`,
+		`\echo :v :'path' ':v' :undefined`,
+	}, got)
+	assert.Equal(t, []string{":v", `C:\tmp`, ":v", ":undefined"}, batch.Statements[5].MetaCommand.Args)
+}
+
+func TestParseSQLAll_PSQLConditionals(t *testing.T) {
+	batch := parsePSQL(t, `\if false
+  SELECT 1;
+  \if true
+    SELECT 2;
+  \else
+    SELECT 3;
+  \endif
+\elif :ready
+  SELECT 4;
+\elif yes
+  SELECT 5;
+\else
+  SELECT 6;
+\endif
+\if no
+  \set skipped 1
+\elif 1
+  SELECT 7;
+\endif
+`, PSQLOptions{Variables: map[string]string{"ready": "ON"}})
+
+	var got []string
+	for _, stmt := range batch.Statements {
+		got = append(got, stmt.RawSQL)
+	}
+	assert.Equal(t, []string{
+		`\if false`, `\elif :ready`, "SELECT 4", `\elif yes`, `\else`, `\endif`,
+		`\if no`, `\elif 1`, "SELECT 7", `\endif`,
+	}, got)
+}
+
+func TestParseSQLAll_PSQLErrors(t *testing.T) {
+	files := fstest.MapFS{
+		"loop.sql": {Data: []byte("\\ir loop.sql\n")},
+	}
+	tests := []struct {
+		name string
+		sql  string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"include without fs", `\i setup.sql`, nil, `psql: line 1: \i setup.sql: PSQLOptions.FS is not set`},
+		{"missing include", "SELECT 1;\n\\i missing.sql", files, `psql: line 2: \i: open missing.sql: file does not exist`},
+		{"invalid path", `\i ../setup.sql`, files, `psql: line 1: \i ../setup.sql: invalid path`},
+		{"recursive include", `\i loop.sql`, files, `psql: loop.sql:1: \ir loop.sql: recursive include of loop.sql`},
+		{"unterminated if", "\\if on\nSELECT 1;", nil, `psql: line 2: reached end of file without finding closing \endif`},
+		{"unmatched endif", `\endif`, nil, `psql: line 1: \endif: no matching \if`},
+		{"else after else", "\\if on\n\\else\n\\else", nil, `psql: line 3: \else: cannot occur after \else`},
+		{"invalid boolean", `\if maybe`, nil, `psql: line 1: \if: unrecognized value "maybe": Boolean expected`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := PSQLOptions{}
+			if tt.fsys != nil {
+				opts.FS = tt.fsys
+			}
+			_, err := ParseSQLAllWithOptions(tt.sql, ParseOptions{PSQL: &opts})
+			require.Error(t, err)
+			assert.Equal(t, tt.want, err.Error())
+		})
+	}
+
+	_, err := ParseSQLAllWithOptions("-- nothing to run\n", ParseOptions{PSQL: &PSQLOptions{}})
+	assert.ErrorIs(t, err, ErrNoStatements)
+}
+
+func TestParseSQLWithOptions_PSQLIgnoredInRoutineBody(t *testing.T) {
+	body := "\nSELECT id FROM :tbl;\n\\i evil.sql\n"
+	q, err := ParseSQLWithOptions("CREATE FUNCTION f() RETURNS SETOF int LANGUAGE sql AS $$"+body+"$$", ParseOptions{PSQL: &PSQLOptions{
+		Variables: map[string]string{"tbl": "users"},
+		FS:        fstest.MapFS{"evil.sql": {Data: []byte("SELECT * FROM secrets;\n")}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, q.DDLActions, 1)
+	require.NotNil(t, q.DDLActions[0].Function)
+	assert.Equal(t, body, q.DDLActions[0].Function.Body)
+	for _, tbl := range q.Tables {
+		assert.NotContains(t, []string{"users", "secrets"}, tbl.Name, "the body is not interpolated or included")
+	}
+}
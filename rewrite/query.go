@@ -0,0 +1,1027 @@
+// query.go walks the structure of a statement — query blocks, FROM lists,
+// joins, and expressions — over its significant tokens and records the
+// edits each rule needs.
+package rewrite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// node is a significant token or a bracketed group.
+type node struct {
+	tok   *postgresparser.Token // The token, or the opening bracket of a group
+	close *postgresparser.Token // Closing bracket; nil for a token
+	kids  []node                // Contents of a group
+}
+
+// isGroup reports whether n is a bracketed group.
+func (n node) isGroup() bool {
+	return n.close != nil
+}
+
+// keyword reports whether n is one of the keywords in words.
+func (n node) keyword(words ...string) bool {
+	if n.close != nil || n.tok.Kind != postgresparser.TokenKeyword {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(n.tok.Text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// punct reports whether n is the punctuation text.
+func (n node) punct(text string) bool {
+	return n.close == nil && n.tok.Kind == postgresparser.TokenPunctuation && n.tok.Text == text
+}
+
+// ident reports whether n is an identifier.
+func (n node) ident() bool {
+	return n.close == nil && n.tok.Kind == postgresparser.TokenIdentifier
+}
+
+// last returns the last token of n.
+func (n node) last() *postgresparser.Token {
+	if n.close != nil {
+		return n.close
+	}
+	return n.tok
+}
+
+// lastToken returns the last token of nodes, or nil when there are none.
+func lastToken(nodes []node) *postgresparser.Token {
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[len(nodes)-1].last()
+}
+
+// nest drops whitespace and comments and groups the tokens inside brackets.
+func nest(tokens []postgresparser.Token) []node {
+	var stack [][]node
+	var opens []*postgresparser.Token
+	var cur []node
+	for i := range tokens {
+		tok := &tokens[i]
+		switch {
+		case tok.Kind == postgresparser.TokenWhitespace || tok.Kind == postgresparser.TokenComment:
+		case tok.Kind == postgresparser.TokenPunctuation && (tok.Text == "(" || tok.Text == "["):
+			stack = append(stack, cur)
+			opens = append(opens, tok)
+			cur = nil
+		case tok.Kind == postgresparser.TokenPunctuation && (tok.Text == ")" || tok.Text == "]") && len(stack) > 0:
+			g := node{tok: opens[len(opens)-1], close: tok, kids: cur}
+			cur = append(stack[len(stack)-1], g)
+			stack, opens = stack[:len(stack)-1], opens[:len(opens)-1]
+		default:
+			cur = append(cur, node{tok: tok})
+		}
+	}
+	return cur
+}
+
+// startsQuery reports whether nodes begin a SELECT, VALUES, TABLE, or WITH
+// query, possibly in parentheses.
+func startsQuery(nodes []node) bool {
+	if len(nodes) == 0 {
+		return false
+	}
+	if nodes[0].isGroup() {
+		return nodes[0].tok.Text == "(" && startsQuery(nodes[0].kids)
+	}
+	return nodes[0].keyword("SELECT", "VALUES", "TABLE", "WITH")
+}
+
+// startsStatement reports whether nodes begin a query or a DML statement.
+func startsStatement(nodes []node) bool {
+	return startsQuery(nodes) || (len(nodes) > 0 && nodes[0].keyword("INSERT", "UPDATE", "DELETE", "MERGE"))
+}
+
+// find returns the index of the first keyword word in nodes at or after
+// from, or len(nodes).
+func find(nodes []node, from int, word string) int {
+	for i := from; i < len(nodes); i++ {
+		if nodes[i].keyword(word) {
+			return i
+		}
+	}
+	return len(nodes)
+}
+
+// clause is a clause keyword and the nodes up to the next clause.
+type clause struct {
+	head node
+	body []node
+}
+
+// clauses splits nodes before every node isHead accepts. The first node
+// always heads a clause.
+func clauses(nodes []node, isHead func(nodes []node, i int) bool) []clause {
+	var out []clause
+	for i, n := range nodes {
+		if i == 0 || isHead(nodes, i) {
+			out = append(out, clause{head: n})
+			continue
+		}
+		out[len(out)-1].body = append(out[len(out)-1].body, n)
+	}
+	return out
+}
+
+// target collects the predicates for one WHERE or ON condition.
+type target struct {
+	cond  []node                // Existing condition, if any
+	after *postgresparser.Token // Token a new WHERE clause follows
+	preds []string
+	refs  []*tableRef // The table each predicate filters
+}
+
+// absorb moves the predicates of src to dst. A nil dst drops them.
+func (dst *target) absorb(src *target) {
+	if dst == nil || src == nil {
+		return
+	}
+	dst.preds = append(dst.preds, src.preds...)
+	dst.refs = append(dst.refs, src.refs...)
+	src.preds, src.refs = nil, nil
+}
+
+// tableRef is a base table reference.
+type tableRef struct {
+	first *postgresparser.Token   // First token, ONLY when written
+	parts []*postgresparser.Token // Name parts as written; the last is the table
+	end   *postgresparser.Token   // Last token before the alias
+	alias *postgresparser.Token
+	// newSchema and newTable are the names written in place of the schema
+	// and table; empty when unchanged.
+	newSchema string
+	newTable  string
+}
+
+// qualifier returns the table name as written after renaming, which
+// qualifies its columns when the reference has no alias.
+func (t *tableRef) qualifier() string {
+	if t.newTable != "" {
+		return t.newTable
+	}
+	return t.parts[len(t.parts)-1].Text
+}
+
+// name returns the normalized name of the reference.
+func (t *tableRef) name() name {
+	n := name{table: normalize(t.parts[len(t.parts)-1].Text)}
+	if len(t.parts) > 1 {
+		n.schema = normalize(t.parts[len(t.parts)-2].Text)
+	}
+	return n
+}
+
+// rewriter rewrites one statement.
+type rewriter struct {
+	rules  Rules
+	keys   ruleKeys
+	tokens []postgresparser.Token
+	upper  bool // The statement writes keywords in upper case
+
+	maxParam int    // Highest $n in the statement
+	question bool   // The statement uses ? placeholders
+	value    string // Predicate value, chosen when the first predicate is added
+	param    int    // Number of the generated placeholder, or 0
+
+	scopes  []map[string]bool         // CTE names in scope, innermost last
+	aliases map[string]bool           // Aliases and CTE names in the statement
+	renamed []*tableRef               // Renamed references without an alias
+	quals   [][]*postgresparser.Token // Column qualifiers, renamed at the end
+
+	edits []Edit
+	err   error
+}
+
+// newRewriter prepares to rewrite the statement made of tokens.
+func newRewriter(rules Rules, keys ruleKeys, tokens []postgresparser.Token) *rewriter {
+	r := &rewriter{rules: rules, keys: keys, tokens: tokens, upper: true, aliases: make(map[string]bool)}
+	seenKeyword := false
+	for _, tok := range tokens {
+		switch {
+		case tok.Kind == postgresparser.TokenKeyword && !seenKeyword:
+			r.upper = tok.Text == strings.ToUpper(tok.Text)
+			seenKeyword = true
+		case tok.Kind == postgresparser.TokenParam && tok.Text == "?":
+			r.question = true
+		case tok.Kind == postgresparser.TokenParam:
+			if n, err := strconv.Atoi(tok.Text[1:]); err == nil && n > r.maxParam {
+				r.maxParam = n
+			}
+		}
+	}
+	return r
+}
+
+// fail records the first reason a predicate cannot be added.
+func (r *rewriter) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+// kw returns keyword in the case the statement uses.
+func (r *rewriter) kw(keyword string) string {
+	if r.upper {
+		return keyword
+	}
+	return strings.ToLower(keyword)
+}
+
+// insert records text to be inserted at byte offset pos.
+func (r *rewriter) insert(pos int, text string) {
+	r.edits = append(r.edits, Edit{Start: pos, End: pos, Text: text})
+}
+
+// replace records text replacing the bytes [start, end).
+func (r *rewriter) replace(start, end int, text string) {
+	r.edits = append(r.edits, Edit{Start: start, End: end, Text: text})
+}
+
+// statement rewrites the statement, skipping an EXPLAIN prefix. Statements
+// other than queries and DML are left alone.
+func (r *rewriter) statement() {
+	nodes := nest(r.tokens)
+	for len(nodes) > 0 && nodes[0].punct(";") {
+		nodes = nodes[1:]
+	}
+	for len(nodes) > 0 && nodes[len(nodes)-1].punct(";") {
+		nodes = nodes[:len(nodes)-1]
+	}
+	if len(nodes) > 0 && nodes[0].keyword("EXPLAIN") {
+		i := 1
+		for i < len(nodes) && !startsStatement(nodes[i:]) {
+			i++
+		}
+		nodes = nodes[i:]
+	}
+	if !startsStatement(nodes) {
+		return
+	}
+	r.query(nodes, true)
+	r.renameQualifiers()
+}
+
+// query rewrites a query or DML statement with optional WITH clause. Only
+// the top-level query of a statement has its LIMIT capped.
+func (r *rewriter) query(nodes []node, top bool) {
+	if len(nodes) > 0 && nodes[0].keyword("WITH") {
+		nodes = r.with(nodes[1:])
+		defer func() { r.scopes = r.scopes[:len(r.scopes)-1] }()
+	}
+	if len(nodes) == 0 {
+		return
+	}
+	switch {
+	case nodes[0].keyword("INSERT"):
+		r.insertStatement(nodes)
+	case nodes[0].keyword("UPDATE"):
+		r.updateStatement(nodes)
+	case nodes[0].keyword("DELETE"):
+		r.deleteStatement(nodes)
+	case nodes[0].keyword("MERGE"):
+		r.mergeStatement(nodes)
+	default:
+		r.selectQuery(nodes)
+		if top && r.rules.MaxLimit > 0 {
+			r.limit(nodes)
+		}
+	}
+}
+
+// with rewrites the CTEs of a WITH clause, following "WITH", and brings
+// their names into scope. It returns the nodes of the main statement; the
+// caller pops the scope when done with them.
+func (r *rewriter) with(nodes []node) []node {
+	recursive := len(nodes) > 0 && nodes[0].keyword("RECURSIVE")
+	if recursive {
+		nodes = nodes[1:]
+	}
+	type cte struct {
+		name *postgresparser.Token
+		body node
+	}
+	var ctes []cte
+	i := 0
+	for i < len(nodes) && nodes[i].ident() {
+		c := cte{name: nodes[i].tok}
+		// Skip the column list and MATERIALIZED options to the body.
+		for i++; i < len(nodes) && !(nodes[i].isGroup() && nodes[i-1].keyword("AS", "MATERIALIZED")); i++ {
+		}
+		if i == len(nodes) {
+			break
+		}
+		c.body = nodes[i]
+		ctes = append(ctes, c)
+		// Skip SEARCH and CYCLE clauses.
+		for i++; i < len(nodes) && !nodes[i].punct(",") && !startsStatement(nodes[i:]); i++ {
+		}
+		if i == len(nodes) || !nodes[i].punct(",") {
+			break
+		}
+		i++
+	}
+
+	scope := make(map[string]bool)
+	r.scopes = append(r.scopes, scope)
+	for _, c := range ctes {
+		r.aliases[normalize(c.name.Text)] = true
+		if recursive {
+			scope[normalize(c.name.Text)] = true
+		}
+	}
+	// A non-recursive CTE is visible only to the CTEs after it.
+	for _, c := range ctes {
+		r.query(c.body.kids, false)
+		scope[normalize(c.name.Text)] = true
+	}
+	return nodes[i:]
+}
+
+// isCTE reports whether an unqualified table name refers to a CTE in scope.
+func (r *rewriter) isCTE(table string) bool {
+	for _, scope := range r.scopes {
+		if scope[table] {
+			return true
+		}
+	}
+	return false
+}
+
+// selectQuery rewrites each arm of a set operation separately.
+func (r *rewriter) selectQuery(nodes []node) {
+	start := 0
+	for i, n := range nodes {
+		if n.keyword("UNION", "INTERSECT", "EXCEPT") {
+			r.selectArm(nodes[start:i])
+			start = i + 1
+		}
+	}
+	r.selectArm(nodes[start:])
+}
+
+// selectArm rewrites one SELECT, VALUES, TABLE, or parenthesized query.
+func (r *rewriter) selectArm(nodes []node) {
+	for len(nodes) > 0 && nodes[0].keyword("ALL", "DISTINCT") {
+		nodes = nodes[1:]
+	}
+	switch {
+	case len(nodes) == 0:
+	case startsQuery(nodes) && nodes[0].isGroup():
+		r.query(nodes[0].kids, false)
+		r.expr(nodes[1:])
+	case nodes[0].keyword("SELECT"):
+		r.selectClauses(nodes)
+	case nodes[0].keyword("TABLE"):
+		blocked := &target{}
+		r.primary(nodes[1:], blocked)
+		if len(blocked.preds) > 0 {
+			r.fail("TABLE statements cannot be filtered")
+		}
+	default:
+		r.expr(nodes)
+	}
+}
+
+// selectHead reports whether nodes[i] starts a clause of a SELECT.
+func selectHead(nodes []node, i int) bool {
+	n := nodes[i]
+	switch {
+	case n.keyword("FROM"):
+		return !nodes[i-1].keyword("DISTINCT")
+	case n.keyword("GROUP"):
+		return !nodes[i-1].keyword("WITHIN")
+	}
+	return n.keyword("WHERE", "HAVING", "WINDOW", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "INTO")
+}
+
+// selectClauses rewrites the clauses of a SELECT.
+func (r *rewriter) selectClauses(nodes []node) {
+	cs := clauses(nodes, selectHead)
+	where := &target{}
+	for _, c := range cs {
+		switch {
+		case c.head.keyword("FROM"):
+			where.after = lastToken(c.body)
+		case c.head.keyword("WHERE"):
+			where.cond = c.body
+		}
+	}
+	for _, c := range cs {
+		switch {
+		case c.head.keyword("FROM"):
+			r.fromList(c.body, where)
+		case c.head.keyword("FOR"):
+			r.lockedTables(c.body)
+		case c.head.keyword("INTO"):
+			// The target is a new table.
+		default:
+			r.expr(c.body)
+		}
+	}
+	r.flush(where)
+}
+
+// lockedTables records the table names after OF in a locking clause, which
+// are qualifiers like those of columns.
+func (r *rewriter) lockedTables(nodes []node) {
+	of := false
+	for _, n := range nodes {
+		switch {
+		case n.keyword("OF"):
+			of = true
+		case of && n.ident():
+			r.quals = append(r.quals, []*postgresparser.Token{n.tok})
+		case of && !n.punct(","):
+			of = false
+		}
+	}
+}
+
+// updateStatement rewrites UPDATE target SET ... [FROM ...] [WHERE ...]
+// [RETURNING ...].
+func (r *rewriter) updateStatement(nodes []node) {
+	seen := make(map[string]bool)
+	cs := clauses(nodes, func(nodes []node, i int) bool {
+		for _, word := range []string{"SET", "FROM", "WHERE", "RETURNING"} {
+			if nodes[i].keyword(word) && !seen[word] && !nodes[i-1].keyword("DISTINCT") {
+				seen[word] = true
+				return true
+			}
+		}
+		return false
+	})
+	where := &target{}
+	for _, c := range cs {
+		switch {
+		case c.head.keyword("SET", "FROM"):
+			where.after = lastToken(c.body)
+		case c.head.keyword("WHERE"):
+			where.cond = c.body
+		}
+	}
+	for _, c := range cs {
+		switch {
+		case c.head.keyword("UPDATE"):
+			r.primary(c.body, where)
+		case c.head.keyword("FROM"):
+			r.fromList(c.body, where)
+		default:
+			r.expr(c.body)
+		}
+	}
+	r.flush(where)
+}
+
+// deleteStatement rewrites DELETE FROM target [USING ...] [WHERE ...]
+// [RETURNING ...].
+func (r *rewriter) deleteStatement(nodes []node) {
+	seen := make(map[string]bool)
+	cs := clauses(nodes, func(nodes []node, i int) bool {
+		for _, word := range []string{"USING", "WHERE", "RETURNING"} {
+			if nodes[i].keyword(word) && !seen[word] {
+				seen[word] = true
+				return true
+			}
+		}
+		return false
+	})
+	where := &target{}
+	for _, c := range cs {
+		switch {
+		case c.head.keyword("DELETE", "USING"):
+			where.after = lastToken(c.body)
+		case c.head.keyword("WHERE"):
+			where.cond = c.body
+		}
+	}
+	for _, c := range cs {
+		switch {
+		case c.head.keyword("DELETE"):
+			body := c.body
+			if len(body) > 0 && body[0].keyword("FROM") {
+				body = body[1:]
+			}
+			r.primary(body, where)
+		case c.head.keyword("USING"):
+			r.fromList(c.body, where)
+		default:
+			r.expr(c.body)
+		}
+	}
+	r.flush(where)
+}
+
+// insertStatement rewrites INSERT INTO target [(columns)] source
+// [ON CONFLICT ...] [RETURNING ...]. The target is renamed but not
+// filtered.
+func (r *rewriter) insertStatement(nodes []node) {
+	i := 1
+	if i < len(nodes) && nodes[i].keyword("INTO") {
+		i++
+	}
+	src := i
+	for src < len(nodes) && !startsQuery(nodes[src:]) && !nodes[src].keyword("DEFAULT", "OVERRIDING") {
+		src++
+	}
+	targetNodes := nodes[i:src]
+	if len(targetNodes) > 0 && targetNodes[len(targetNodes)-1].isGroup() {
+		targetNodes = targetNodes[:len(targetNodes)-1]
+	}
+	r.primary(targetNodes, nil)
+
+	end := src
+	for end < len(nodes) && !nodes[end].keyword("RETURNING") &&
+		!(nodes[end].keyword("ON") && end+1 < len(nodes) && nodes[end+1].keyword("CONFLICT")) {
+		end++
+	}
+	source := nodes[src:end]
+	if len(source) > 0 && source[0].keyword("OVERRIDING") {
+		source = source[min(3, len(source)):]
+	}
+	if startsQuery(source) {
+		r.query(source, false)
+	}
+	r.expr(nodes[end:])
+}
+
+// mergeStatement renames the tables of MERGE INTO target USING source ON
+// ... WHEN .... MERGE statements cannot be filtered.
+func (r *rewriter) mergeStatement(nodes []node) {
+	i := 1
+	if i < len(nodes) && nodes[i].keyword("INTO") {
+		i++
+	}
+	using := find(nodes, i, "USING")
+	on := find(nodes, using, "ON")
+	blocked := &target{}
+	r.primary(nodes[i:using], blocked)
+	if using < on {
+		r.primary(nodes[using+1:on], blocked)
+	}
+	if on < len(nodes) {
+		r.expr(nodes[on+1:])
+	}
+	if len(blocked.preds) > 0 {
+		r.fail("MERGE statements cannot be filtered")
+	}
+}
+
+// fromList rewrites the comma-separated items of a FROM or USING list.
+// Predicates for tables not joined with ON go to tgt.
+func (r *rewriter) fromList(nodes []node, tgt *target) {
+	start := 0
+	for i, n := range nodes {
+		if n.punct(",") {
+			r.fromItem(nodes[start:i], tgt)
+			start = i + 1
+		}
+	}
+	r.fromItem(nodes[start:], tgt)
+}
+
+// joinWords start a join.
+var joinWords = []string{"NATURAL", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "JOIN"}
+
+// joinKind is the outer join keyword of a join, or "" for inner and cross
+// joins.
+func joinKind(words []node) string {
+	for _, n := range words {
+		switch {
+		case n.keyword("LEFT"):
+			return "LEFT"
+		case n.keyword("RIGHT"):
+			return "RIGHT"
+		case n.keyword("FULL"):
+			return "FULL"
+		}
+	}
+	return ""
+}
+
+// nextJoin returns the index of the first join keyword at or after from, or
+// len(nodes).
+func nextJoin(nodes []node, from int) int {
+	for i := from; i < len(nodes); i++ {
+		if nodes[i].keyword(joinWords...) {
+			return i
+		}
+	}
+	return len(nodes)
+}
+
+// fromItem rewrites a table reference and the joins that follow it. The
+// predicates of tables whose rows the joins always keep go to tgt once the
+// last join is known.
+func (r *rewriter) fromItem(nodes []node, tgt *target) {
+	var kept *target
+	if tgt != nil {
+		kept = &target{}
+	}
+	end := nextJoin(nodes, 0)
+	r.primary(nodes[:end], kept)
+	for end < len(nodes) {
+		join := end
+		for join < len(nodes) && !nodes[join].keyword("JOIN") {
+			join++
+		}
+		if join == len(nodes) {
+			r.expr(nodes[end:])
+			break
+		}
+		kind := joinKind(nodes[end:join])
+		end = nextJoin(nodes, join+1)
+		r.join(nodes[join+1:end], kind, kept)
+	}
+	tgt.absorb(kept)
+}
+
+// join rewrites the table reference after JOIN and its join condition. kept
+// holds the predicates of the tables before the join that are not yet
+// null-padded. A predicate may go to the ON condition only for a table the
+// join null-pads, and to WHERE only for a table whose rows it keeps; a
+// null-padded table joined without ON, and both sides of a FULL JOIN, are
+// wrapped in a filtered subquery instead.
+func (r *rewriter) join(nodes []node, kind string, kept *target) {
+	var right *target
+	if kept != nil {
+		right = &target{}
+	}
+	on := find(nodes, 0, "ON")
+	var cond *target
+	if on < len(nodes) {
+		cond = &target{cond: nodes[on+1:]}
+	}
+	r.primary(nodes[:on], right)
+	if cond != nil {
+		r.expr(cond.cond)
+	}
+
+	switch {
+	case kind == "FULL":
+		r.wrap(kept)
+		r.wrap(right)
+	case kind == "RIGHT" && cond != nil:
+		cond.absorb(kept)
+		kept.absorb(right)
+	case kind == "RIGHT":
+		r.wrap(kept)
+		kept.absorb(right)
+	case cond != nil:
+		cond.absorb(right)
+	case kind == "LEFT":
+		r.wrap(right)
+	default:
+		kept.absorb(right)
+	}
+	if cond != nil {
+		r.flush(cond)
+	}
+}
+
+// wrap replaces each table filtered by tgt with a subquery that applies its
+// predicate, "(SELECT * FROM t WHERE t.col = $1) t", keeping the alias, and
+// drops the predicates from tgt.
+func (r *rewriter) wrap(tgt *target) {
+	if tgt == nil {
+		return
+	}
+	for _, ref := range tgt.refs {
+		qualifier := ref.qualifier()
+		r.insert(ref.first.Start, "("+r.kw("SELECT")+" * "+r.kw("FROM")+" ")
+		text := " " + r.kw("WHERE") + " " + r.predicate(qualifier) + ")"
+		if ref.alias == nil {
+			text += " " + qualifier
+		}
+		r.insert(ref.end.End, text)
+	}
+	tgt.preds, tgt.refs = nil, nil
+}
+
+// primary rewrites one table reference: a table, a subquery, a function, or
+// a parenthesized join. A nil tgt means the table is not filtered.
+func (r *rewriter) primary(nodes []node, tgt *target) {
+	i := 0
+	if i < len(nodes) && nodes[i].keyword("LATERAL", "ONLY") {
+		i++
+	}
+	if i == len(nodes) {
+		return
+	}
+	if n := nodes[i]; n.isGroup() {
+		if startsQuery(n.kids) {
+			r.query(n.kids, false)
+		} else {
+			r.fromList(n.kids, tgt)
+		}
+		r.rest(nodes[i+1:])
+		return
+	}
+	if !nodes[i].ident() {
+		r.expr(nodes[i:])
+		return
+	}
+
+	ref := &tableRef{first: nodes[0].tok, parts: []*postgresparser.Token{nodes[i].tok}}
+	for i+2 < len(nodes) && nodes[i+1].punct(".") && nodes[i+2].ident() {
+		ref.parts = append(ref.parts, nodes[i+2].tok)
+		i += 2
+	}
+	ref.end = nodes[i].tok
+	i++
+	if i < len(nodes) && nodes[i].isGroup() {
+		// A function call; the name is not a table.
+		r.expr(nodes[i : i+1])
+		r.rest(nodes[i+1:])
+		return
+	}
+	if i < len(nodes) && nodes[i].punct("*") {
+		ref.end = nodes[i].tok
+		i++
+	}
+	ref.alias, i = aliasAt(nodes, i)
+	r.table(ref, tgt)
+	r.expr(nodes[i:])
+}
+
+// aliasAt returns the alias at nodes[i], written with or without AS, and the
+// index after it.
+func aliasAt(nodes []node, i int) (*postgresparser.Token, int) {
+	switch {
+	case i+1 < len(nodes) && nodes[i].keyword("AS") && nodes[i+1].ident():
+		return nodes[i+1].tok, i + 2
+	case i < len(nodes) && nodes[i].ident():
+		return nodes[i].tok, i + 1
+	}
+	return nil, i
+}
+
+// rest records the alias that follows a subquery or function and rewrites
+// the nodes after it.
+func (r *rewriter) rest(nodes []node) {
+	alias, i := aliasAt(nodes, 0)
+	if alias != nil {
+		r.aliases[normalize(alias.Text)] = true
+	}
+	r.expr(nodes[i:])
+}
+
+// table renames a base table reference and adds its predicate to tgt.
+// Unqualified references to CTEs are left alone.
+func (r *rewriter) table(ref *tableRef, tgt *target) {
+	n := ref.name()
+	if n.schema == "" && r.isCTE(n.table) {
+		return
+	}
+	r.rename(ref)
+	switch {
+	case ref.alias != nil:
+		r.aliases[normalize(ref.alias.Text)] = true
+	case ref.newSchema != "" || ref.newTable != "":
+		r.renamed = append(r.renamed, ref)
+	}
+	if tgt != nil && r.rules.Predicate != nil && r.filtered(n) {
+		r.addPredicate(ref, tgt)
+	}
+}
+
+// rename records the edits that rename ref's table and schema.
+func (r *rewriter) rename(ref *tableRef) {
+	n := ref.name()
+	table := ref.parts[len(ref.parts)-1]
+	v, ok := r.keys.tables[n]
+	if !ok && n.schema != "" {
+		v, ok = r.keys.tables[name{table: n.table}]
+	}
+	if ok {
+		parts := splitName(v)
+		ref.newTable = parts[len(parts)-1]
+		if len(parts) > 1 {
+			ref.newSchema = parts[len(parts)-2]
+			r.replace(ref.parts[0].Start, table.End, v)
+			return
+		}
+		r.replace(table.Start, table.End, v)
+	}
+	if n.schema == "" {
+		return
+	}
+	if s, ok := r.keys.schemas[n.schema]; ok {
+		schema := ref.parts[len(ref.parts)-2]
+		ref.newSchema = s
+		r.replace(schema.Start, schema.End, s)
+	}
+}
+
+// filtered reports whether Predicate.Tables selects the table n.
+func (r *rewriter) filtered(n name) bool {
+	if len(r.keys.predicate) == 0 {
+		return true
+	}
+	for _, p := range r.keys.predicate {
+		if p.table == n.table && (p.schema == "" || p.schema == n.schema) {
+			return true
+		}
+	}
+	return false
+}
+
+// addPredicate adds the predicate for ref to tgt, choosing the value on
+// first use.
+func (r *rewriter) addPredicate(ref *tableRef, tgt *target) {
+	if r.value == "" {
+		switch {
+		case r.rules.Predicate.Value != "":
+			r.value = r.rules.Predicate.Value
+		case r.question:
+			r.fail("the statement uses ? placeholders; set Predicate.Value")
+			return
+		default:
+			r.param = r.maxParam + 1
+			r.value = fmt.Sprintf("$%d", r.param)
+		}
+	}
+	qualifier := ref.qualifier()
+	if ref.alias != nil {
+		qualifier = ref.alias.Text
+	}
+	tgt.preds = append(tgt.preds, r.predicate(qualifier))
+	tgt.refs = append(tgt.refs, ref)
+}
+
+// predicate returns the predicate for the table referenced as qualifier.
+func (r *rewriter) predicate(qualifier string) string {
+	return qualifier + "." + r.rules.Predicate.Column + " = " + r.value
+}
+
+// flush records the edit that adds the predicates collected in tgt: they are
+// ANDed to the existing condition, which is parenthesized if it contains a
+// top-level OR, or become a new WHERE clause.
+func (r *rewriter) flush(tgt *target) {
+	if len(tgt.preds) == 0 {
+		return
+	}
+	pred := strings.Join(tgt.preds, " "+r.kw("AND")+" ")
+	cond := tgt.cond
+	switch {
+	case len(cond) >= 2 && cond[0].keyword("CURRENT") && cond[1].keyword("OF"):
+		r.fail("WHERE CURRENT OF cannot be filtered")
+	case len(cond) == 0:
+		if tgt.after != nil {
+			r.insert(tgt.after.End, " "+r.kw("WHERE")+" "+pred)
+		}
+	case hasOr(cond):
+		r.insert(cond[0].tok.Start, "(")
+		r.insert(lastToken(cond).End, ") "+r.kw("AND")+" "+pred)
+	default:
+		r.insert(lastToken(cond).End, " "+r.kw("AND")+" "+pred)
+	}
+}
+
+// hasOr reports whether nodes contain OR outside brackets.
+func hasOr(nodes []node) bool {
+	for _, n := range nodes {
+		if n.keyword("OR") {
+			return true
+		}
+	}
+	return false
+}
+
+// limit caps the row count of a top-level query.
+func (r *rewriter) limit(nodes []node) {
+	for i, n := range nodes {
+		switch {
+		case n.keyword("LIMIT"):
+			end := i + 1
+			for end < len(nodes) && !nodes[end].keyword("OFFSET", "FETCH", "FOR") {
+				end++
+			}
+			r.capCount(nodes[i+1 : end])
+			return
+		case n.keyword("FETCH"):
+			start := i + 1
+			if start < len(nodes) && nodes[start].keyword("FIRST", "NEXT") {
+				start++
+			}
+			end := start
+			for end < len(nodes) && !nodes[end].keyword("ROW", "ROWS") {
+				end++
+			}
+			// Without a count, FETCH returns one row.
+			if end > start && end < len(nodes) {
+				r.capCount(nodes[start:end])
+			}
+			return
+		}
+	}
+	if last := lastToken(nodes); last != nil {
+		r.insert(last.End, fmt.Sprintf(" %s %d", r.kw("LIMIT"), r.rules.MaxLimit))
+	}
+}
+
+// capCount lowers a constant row count above MaxLimit, replaces ALL, and
+// wraps any other expression in LEAST.
+func (r *rewriter) capCount(count []node) {
+	if len(count) == 0 {
+		return
+	}
+	maxText := strconv.Itoa(r.rules.MaxLimit)
+	if len(count) == 1 {
+		tok := count[0].tok
+		switch {
+		case count[0].keyword("ALL"):
+			r.replace(tok.Start, tok.End, maxText)
+			return
+		case !count[0].isGroup() && tok.Kind == postgresparser.TokenLiteral:
+			if n, ok := parseCount(tok.Text); ok {
+				if n > int64(r.rules.MaxLimit) {
+					r.replace(tok.Start, tok.End, maxText)
+				}
+				return
+			}
+		}
+	}
+	r.insert(count[0].tok.Start, r.kw("LEAST")+"(")
+	r.insert(lastToken(count).End, ", "+maxText+")")
+}
+
+// parseCount parses an integer literal.
+func parseCount(text string) (int64, bool) {
+	text = strings.ReplaceAll(text, "_", "")
+	base := 10
+	if len(text) > 2 && text[0] == '0' && strings.ContainsRune("xXoObB", rune(text[1])) {
+		base = 0
+	}
+	n, err := strconv.ParseInt(text, base, 64)
+	return n, err == nil
+}
+
+// expr rewrites the subqueries in nodes and records their column qualifiers.
+func (r *rewriter) expr(nodes []node) {
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		if n.isGroup() {
+			if startsQuery(n.kids) {
+				r.query(n.kids, false)
+			} else {
+				r.expr(n.kids)
+			}
+			continue
+		}
+		// Field selections and type names are not qualified column names.
+		if !n.ident() || (i > 0 && (nodes[i-1].punct(".") || nodes[i-1].punct("::"))) {
+			continue
+		}
+		parts := []*postgresparser.Token{n.tok}
+		for i+2 < len(nodes) && nodes[i+1].punct(".") && nodes[i+2].ident() {
+			parts = append(parts, nodes[i+2].tok)
+			i += 2
+		}
+		switch {
+		case i+1 < len(nodes) && nodes[i+1].isGroup() && nodes[i+1].tok.Text == "(":
+			// A function name.
+		case i+2 < len(nodes) && nodes[i+1].punct(".") && nodes[i+2].punct("*"):
+			r.quals = append(r.quals, parts)
+		case len(parts) > 1:
+			r.quals = append(r.quals, parts[:len(parts)-1])
+		}
+	}
+}
+
+// renameQualifiers renames column qualifiers that name a renamed table
+// referenced without an alias.
+func (r *rewriter) renameQualifiers() {
+	for _, q := range r.quals {
+		table := q[len(q)-1]
+		var schema *postgresparser.Token
+		if len(q) > 1 {
+			schema = q[len(q)-2]
+		} else if r.aliases[normalize(table.Text)] {
+			continue
+		}
+		for _, ref := range r.renamed {
+			n := ref.name()
+			if n.table != normalize(table.Text) || (schema != nil && n.schema != normalize(schema.Text)) {
+				continue
+			}
+			if ref.newTable != "" {
+				r.replace(table.Start, table.End, ref.newTable)
+			}
+			if schema != nil && ref.newSchema != "" {
+				r.replace(schema.Start, schema.End, ref.newSchema)
+			}
+			break
+		}
+	}
+}
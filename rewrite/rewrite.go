@@ -0,0 +1,205 @@
+// Package rewrite edits SQL statements in place: it adds filter predicates to
+// base table references, renames tables and schemas, and caps LIMIT.
+//
+// Rewriting works on the token stream from postgresparser.Tokenize. Every
+// change is a byte-range Edit of the input, so everything outside the edits,
+// including whitespace, comments, and keyword case, is kept byte for byte.
+// SELECT, INSERT, UPDATE, DELETE, and MERGE statements are rewritten, with
+// their CTEs and subqueries; other statements are returned unchanged.
+//
+// This file defines the rules and results and applies the edits.
+package rewrite
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// ErrUnsupported is returned when a predicate cannot be added to a statement
+// that references a filtered table, so the statement would read or modify
+// unfiltered rows.
+var ErrUnsupported = errors.New("cannot add predicate")
+
+// Predicate describes a "column = value" filter added for each base table
+// reference. References in a FROM list, an UPDATE target, or a DELETE target
+// are filtered in the WHERE clause of their query; tables joined with ON are
+// filtered in the ON condition, which keeps outer joins intact. INSERT
+// targets are not filtered.
+type Predicate struct {
+	// Column is the filtered column, written as SQL (e.g. "tenant_id"). It is
+	// qualified with the table's alias, or its name when it has none.
+	Column string
+	// Value is the SQL text compared with Column. When empty, each statement
+	// gets a new $n placeholder numbered after its highest existing one, and
+	// Result.Params records the number.
+	Value string
+	// Tables limits the predicate to the named tables, matched like the keys
+	// of Rules.Tables. Empty means every base table.
+	Tables []string
+}
+
+// Rules selects the rewrites to apply. The zero value changes nothing.
+type Rules struct {
+	// Predicate, when set, filters every base table reference.
+	Predicate *Predicate
+	// Tables renames tables. A key is "table", matching the table in any
+	// schema, or "schema.table", matching only references qualified with
+	// that schema; names are case-insensitive unless double-quoted. A value
+	// is the new name written as SQL. An unqualified value replaces only the
+	// table name; a qualified one replaces the whole reference. Column
+	// qualifiers that use the old table name are renamed too.
+	Tables map[string]string
+	// Schemas renames the schema of qualified table references.
+	Schemas map[string]string
+	// MaxLimit caps the rows returned by SELECT statements. A larger LIMIT or
+	// FETCH count is lowered, a non-constant one is wrapped in LEAST, and a
+	// statement without one gets LIMIT MaxLimit. Zero disables the cap.
+	MaxLimit int
+}
+
+// Edit replaces the input bytes [Start, End) with Text. Start equals End for
+// an insertion.
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// Result is the rewritten SQL and the edits that produced it.
+type Result struct {
+	SQL string
+	// Edits are sorted by Start; offsets refer to the input.
+	Edits []Edit
+	// Params maps the 1-based index of each statement that received a
+	// generated predicate placeholder to the placeholder's number.
+	Params map[int]int
+}
+
+// SQL applies rules to every statement in sql. The input must parse; syntax
+// errors are returned as *postgresparser.ParseErrors. A statement that needs
+// a predicate that cannot be added returns an error wrapping ErrUnsupported.
+func SQL(sql string, rules Rules) (*Result, error) {
+	tokens, err := postgresparser.Tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Params: make(map[int]int)}
+	keys := parseRules(rules)
+	for start := 0; start < len(tokens); {
+		end := start
+		for end < len(tokens) && tokens[end].Statement == tokens[start].Statement {
+			end++
+		}
+		index := tokens[start].Statement
+		r := newRewriter(rules, keys, tokens[start:end])
+		r.statement()
+		if r.err != nil {
+			return nil, fmt.Errorf("statement %d: %w: %s", index, ErrUnsupported, r.err)
+		}
+		if r.param > 0 {
+			res.Params[index] = r.param
+		}
+		res.Edits = append(res.Edits, r.edits...)
+		start = end
+	}
+
+	// Insertions go before a replacement at the same offset; otherwise edits
+	// keep the order they were made in.
+	sort.SliceStable(res.Edits, func(i, j int) bool {
+		a, b := res.Edits[i], res.Edits[j]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		return a.End == a.Start && b.End != b.Start
+	})
+	var b strings.Builder
+	pos := 0
+	for _, e := range res.Edits {
+		b.WriteString(sql[pos:e.Start])
+		b.WriteString(e.Text)
+		pos = e.End
+	}
+	b.WriteString(sql[pos:])
+	res.SQL = b.String()
+	return res, nil
+}
+
+// Query applies rules to the statement text q was parsed from.
+func Query(q *postgresparser.ParsedQuery, rules Rules) (*Result, error) {
+	if q == nil {
+		return nil, postgresparser.ErrNoStatements
+	}
+	return SQL(q.RawSQL, rules)
+}
+
+// name is a table name split into normalized parts.
+type name struct {
+	schema string // Empty when unqualified
+	table  string
+}
+
+// ruleKeys holds the parsed names of the rules.
+type ruleKeys struct {
+	tables    map[name]string
+	schemas   map[string]string
+	predicate []name // Predicate.Tables
+}
+
+// parseRules normalizes the names in rules for matching.
+func parseRules(rules Rules) ruleKeys {
+	keys := ruleKeys{tables: make(map[name]string), schemas: make(map[string]string)}
+	for k, v := range rules.Tables {
+		keys.tables[parseName(k)] = v
+	}
+	for k, v := range rules.Schemas {
+		keys.schemas[normalize(k)] = v
+	}
+	if rules.Predicate != nil {
+		for _, t := range rules.Predicate.Tables {
+			keys.predicate = append(keys.predicate, parseName(t))
+		}
+	}
+	return keys
+}
+
+// parseName splits a possibly qualified name and normalizes its last two
+// parts.
+func parseName(s string) name {
+	parts := splitName(s)
+	n := name{table: normalize(parts[len(parts)-1])}
+	if len(parts) > 1 {
+		n.schema = normalize(parts[len(parts)-2])
+	}
+	return n
+}
+
+// splitName splits s at the dots outside double quotes.
+func splitName(s string) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '.':
+			if !quoted {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// normalize folds an identifier the way PostgreSQL does: quoted identifiers
+// keep their case, others are lower-cased.
+func normalize(ident string) string {
+	if len(ident) >= 2 && ident[0] == '"' && ident[len(ident)-1] == '"' {
+		return strings.ReplaceAll(ident[1:len(ident)-1], `""`, `"`)
+	}
+	return strings.ToLower(ident)
+}
@@ -0,0 +1,253 @@
+package rewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+var tenant = &Predicate{Column: "tenant_id"}
+
+func TestSQL_Predicate(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "appended to WHERE after the last parameter",
+			sql:  "SELECT * FROM orders WHERE status = $1",
+			want: "SELECT * FROM orders WHERE status = $1 AND orders.tenant_id = $2",
+		},
+		{
+			name: "new WHERE before later clauses, using the alias",
+			sql:  "SELECT id FROM orders o ORDER BY id",
+			want: "SELECT id FROM orders o WHERE o.tenant_id = $1 ORDER BY id",
+		},
+		{
+			name: "OR condition is parenthesized, comments and case kept",
+			sql:  "select * from t\nwhere a = 1 or b = 2 -- note\n",
+			want: "select * from t\nwhere (a = 1 or b = 2) and t.tenant_id = $1 -- note\n",
+		},
+		{
+			name: "joined tables are filtered in ON",
+			sql:  "SELECT * FROM users u LEFT JOIN orders o ON o.user_id = u.id, items i WHERE i.id = o.item_id",
+			want: "SELECT * FROM users u LEFT JOIN orders o ON o.user_id = u.id AND o.tenant_id = $1, items i " +
+				"WHERE i.id = o.item_id AND u.tenant_id = $1 AND i.tenant_id = $1",
+		},
+		{
+			name: "RIGHT JOIN keeps the right table's rows, so it is filtered in WHERE",
+			sql:  "SELECT * FROM users u RIGHT JOIN orders o ON o.user_id = u.id",
+			want: "SELECT * FROM users u RIGHT JOIN orders o ON o.user_id = u.id AND u.tenant_id = $1 WHERE o.tenant_id = $1",
+		},
+		{
+			name: "tables before a RIGHT JOIN are filtered in its ON",
+			sql:  "SELECT * FROM a LEFT JOIN b ON b.id = a.id RIGHT JOIN c ON c.id = a.id",
+			want: "SELECT * FROM a LEFT JOIN b ON b.id = a.id AND b.tenant_id = $1 " +
+				"RIGHT JOIN c ON c.id = a.id AND a.tenant_id = $1 WHERE c.tenant_id = $1",
+		},
+		{
+			name: "both sides of a FULL JOIN are wrapped",
+			sql:  "SELECT * FROM a FULL JOIN b AS x ON a.id = x.id",
+			want: "SELECT * FROM (SELECT * FROM a WHERE a.tenant_id = $1) a FULL JOIN (SELECT * FROM b WHERE b.tenant_id = $1) AS x ON a.id = x.id",
+		},
+		{
+			name: "LEFT JOIN USING wraps the joined table",
+			sql:  "SELECT * FROM users u LEFT JOIN orders o USING (user_id)",
+			want: "SELECT * FROM users u LEFT JOIN (SELECT * FROM orders WHERE orders.tenant_id = $1) o USING (user_id) WHERE u.tenant_id = $1",
+		},
+		{
+			name: "NATURAL RIGHT JOIN wraps the left table",
+			sql:  "select * from a natural right join b",
+			want: "select * from (select * from a where a.tenant_id = $1) a natural right join b where b.tenant_id = $1",
+		},
+		{
+			name: "CTEs and subqueries",
+			sql:  "WITH recent AS (SELECT * FROM orders WHERE created_at > now()) SELECT * FROM recent WHERE user_id IN (SELECT id FROM users)",
+			want: "WITH recent AS (SELECT * FROM orders WHERE created_at > now() AND orders.tenant_id = $1) " +
+				"SELECT * FROM recent WHERE user_id IN (SELECT id FROM users WHERE users.tenant_id = $1)",
+		},
+		{
+			name: "each set operation arm",
+			sql:  "SELECT id FROM a UNION ALL SELECT id FROM b ORDER BY id",
+			want: "SELECT id FROM a WHERE a.tenant_id = $1 UNION ALL SELECT id FROM b WHERE b.tenant_id = $1 ORDER BY id",
+		},
+		{
+			name: "UPDATE",
+			sql:  "UPDATE accounts SET balance = 0 WHERE id = $1 RETURNING id",
+			want: "UPDATE accounts SET balance = 0 WHERE id = $1 AND accounts.tenant_id = $2 RETURNING id",
+		},
+		{
+			name: "UPDATE with FROM",
+			sql:  "UPDATE t SET x = s.x FROM s",
+			want: "UPDATE t SET x = s.x FROM s WHERE t.tenant_id = $1 AND s.tenant_id = $1",
+		},
+		{
+			name: "DELETE with USING",
+			sql:  "DELETE FROM sessions s USING users u WHERE s.user_id = u.id",
+			want: "DELETE FROM sessions s USING users u WHERE s.user_id = u.id AND s.tenant_id = $1 AND u.tenant_id = $1",
+		},
+		{
+			name: "DELETE without WHERE",
+			sql:  "DELETE FROM logs;",
+			want: "DELETE FROM logs WHERE logs.tenant_id = $1;",
+		},
+		{
+			name: "INSERT target is not filtered",
+			sql:  "INSERT INTO archive (id) SELECT id FROM orders",
+			want: "INSERT INTO archive (id) SELECT id FROM orders WHERE orders.tenant_id = $1",
+		},
+		{
+			name: "EXPLAIN",
+			sql:  "EXPLAIN ANALYZE SELECT * FROM t",
+			want: "EXPLAIN ANALYZE SELECT * FROM t WHERE t.tenant_id = $1",
+		},
+		{
+			name: "other statements are unchanged",
+			sql:  "CREATE INDEX ON t (a)",
+			want: "CREATE INDEX ON t (a)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := SQL(tt.sql, Rules{Predicate: tenant})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, res.SQL)
+		})
+	}
+}
+
+func TestSQL_PredicateOptions(t *testing.T) {
+	res, err := SQL("SELECT * FROM orders o JOIN countries c ON c.code = o.country",
+		Rules{Predicate: &Predicate{Column: "tenant_id", Value: "current_setting('app.tenant')::int", Tables: []string{"orders"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM orders o JOIN countries c ON c.code = o.country WHERE o.tenant_id = current_setting('app.tenant')::int", res.SQL)
+	assert.Empty(t, res.Params)
+
+	res, err = SQL("SELECT * FROM a WHERE x = $1; SELECT * FROM b", Rules{Predicate: tenant})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM a WHERE x = $1 AND a.tenant_id = $2; SELECT * FROM b WHERE b.tenant_id = $1", res.SQL)
+	assert.Equal(t, map[int]int{1: 2, 2: 1}, res.Params)
+}
+
+func TestSQL_PredicateUnsupported(t *testing.T) {
+	for _, sql := range []string{
+		"SELECT * FROM t WHERE a = ?",
+		"TABLE orders",
+		"UPDATE t SET a = 1 WHERE CURRENT OF c",
+		"MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN DELETE",
+	} {
+		_, err := SQL(sql, Rules{Predicate: tenant})
+		assert.ErrorIs(t, err, ErrUnsupported, sql)
+	}
+
+	// Statements that reference no filtered table are fine.
+	_, err := SQL("TABLE orders", Rules{Predicate: &Predicate{Column: "tenant_id", Tables: []string{"users"}}})
+	assert.NoError(t, err)
+}
+
+func TestSQL_Rename(t *testing.T) {
+	tests := []struct {
+		name  string
+		sql   string
+		rules Rules
+		want  string
+	}{
+		{
+			name:  "tables, schemas, and column qualifiers",
+			sql:   "SELECT orders.id, public.orders.total FROM public.orders JOIN users ON users.id = orders.user_id",
+			rules: Rules{Tables: map[string]string{"orders": "orders_v2"}, Schemas: map[string]string{"public": "app"}},
+			want:  "SELECT orders_v2.id, app.orders_v2.total FROM app.orders_v2 JOIN users ON users.id = orders_v2.user_id",
+		},
+		{
+			name:  "aliased reference keeps qualifiers",
+			sql:   "UPDATE orders AS o SET total = 0 WHERE o.id = 1",
+			rules: Rules{Tables: map[string]string{"orders": "orders_v2"}},
+			want:  "UPDATE orders_v2 AS o SET total = 0 WHERE o.id = 1",
+		},
+		{
+			name:  "qualified key and value",
+			sql:   "SELECT * FROM public.orders, orders",
+			rules: Rules{Tables: map[string]string{"public.orders": "archive.orders_2023"}},
+			want:  "SELECT * FROM archive.orders_2023, orders",
+		},
+		{
+			name:  "CTE of the same name",
+			sql:   "WITH orders AS (SELECT * FROM orders) SELECT orders.id FROM orders",
+			rules: Rules{Tables: map[string]string{"orders": "orders_v2"}},
+			want:  "WITH orders AS (SELECT * FROM orders_v2) SELECT orders.id FROM orders",
+		},
+		{
+			name:  "quoted names match exactly",
+			sql:   `INSERT INTO "Orders" SELECT * FROM orders`,
+			rules: Rules{Tables: map[string]string{`"Orders"`: "orders_v2"}},
+			want:  `INSERT INTO orders_v2 SELECT * FROM orders`,
+		},
+		{
+			name:  "predicate uses the new name",
+			sql:   "DELETE FROM orders",
+			rules: Rules{Tables: map[string]string{"orders": "orders_v2"}, Predicate: tenant},
+			want:  "DELETE FROM orders_v2 WHERE orders_v2.tenant_id = $1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := SQL(tt.sql, tt.rules)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, res.SQL)
+		})
+	}
+}
+
+func TestSQL_MaxLimit(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT * FROM t", "SELECT * FROM t LIMIT 100"},
+		{"SELECT * FROM t LIMIT 500 OFFSET 10", "SELECT * FROM t LIMIT 100 OFFSET 10"},
+		{"SELECT * FROM t LIMIT 50", "SELECT * FROM t LIMIT 50"},
+		{"select * from t limit $1", "select * from t limit least($1, 100)"},
+		{"SELECT * FROM t LIMIT ALL;", "SELECT * FROM t LIMIT 100;"},
+		{"SELECT 1 FETCH FIRST 1000 ROWS ONLY", "SELECT 1 FETCH FIRST 100 ROWS ONLY"},
+		{"SELECT a FROM t UNION SELECT a FROM u -- all\n", "SELECT a FROM t UNION SELECT a FROM u LIMIT 100 -- all\n"},
+		{"SELECT * FROM (SELECT * FROM t) s", "SELECT * FROM (SELECT * FROM t) s LIMIT 100"},
+		{"UPDATE t SET a = 1", "UPDATE t SET a = 1"},
+	}
+	for _, tt := range tests {
+		res, err := SQL(tt.sql, Rules{MaxLimit: 100})
+		require.NoError(t, err, tt.sql)
+		assert.Equal(t, tt.want, res.SQL)
+	}
+}
+
+func TestSQL_Edits(t *testing.T) {
+	sql := "SELECT * FROM orders WHERE id = $1 -- by id\n"
+	res, err := SQL(sql, Rules{Predicate: tenant, MaxLimit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM orders WHERE id = $1 AND orders.tenant_id = $2 LIMIT 10 -- by id\n", res.SQL)
+	assert.Equal(t, []Edit{
+		{Start: 34, End: 34, Text: " AND orders.tenant_id = $2"},
+		{Start: 34, End: 34, Text: " LIMIT 10"},
+	}, res.Edits)
+
+	res, err = SQL(sql, Rules{})
+	require.NoError(t, err)
+	assert.Equal(t, sql, res.SQL)
+	assert.Empty(t, res.Edits)
+}
+
+func TestQuery(t *testing.T) {
+	q, err := postgresparser.ParseSQL("SELECT * FROM t")
+	require.NoError(t, err)
+	res, err := Query(q, Rules{Predicate: tenant})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM t WHERE t.tenant_id = $1", res.SQL)
+
+	_, err = SQL("SELECT FROM WHERE (", Rules{})
+	var parseErrs *postgresparser.ParseErrors
+	assert.ErrorAs(t, err, &parseErrs)
+}
@@ -256,6 +256,53 @@ func extractTablesFromPreparableStmt(stmt gen.IPreparablestmtContext, tokens ant
 	return tables
 }
 
+// targetColumn builds the SelectColumn for one target list entry.
+func targetColumn(item gen.ITarget_elContext, tokens antlr.TokenStream) (SelectColumn, bool) {
+	switch col := item.(type) {
+	case *gen.Target_labelContext:
+		expr := ""
+		if col.A_expr() != nil {
+			if prc, ok := col.A_expr().(antlr.ParserRuleContext); ok {
+				expr = strings.TrimSpace(ctxText(tokens, prc))
+			}
+		}
+		alias := ""
+		switch {
+		case col.ColLabel() != nil:
+			if prc, ok := col.ColLabel().(antlr.ParserRuleContext); ok {
+				alias = strings.TrimSpace(ctxText(tokens, prc))
+			}
+		case col.BareColLabel() != nil:
+			if prc, ok := col.BareColLabel().(antlr.ParserRuleContext); ok {
+				alias = strings.TrimSpace(ctxText(tokens, prc))
+			}
+		}
+		return SelectColumn{
+			Expression: expr,
+			Alias:      alias,
+			Span:       spanFor(tokens, col),
+			Expr:       buildExprTree(col.A_expr(), tokens),
+		}, true
+	case *gen.Target_starContext:
+		star := SelectColumn{
+			Expression: strings.TrimSpace(ctxText(tokens, col)),
+			Span:       spanFor(tokens, col),
+		}
+		if wantExprTrees(tokens) {
+			star.Expr = &Expr{Kind: ExprKindColumn, Text: star.Expression, Column: "*"}
+		}
+		return star, true
+	default:
+		if prc, ok := col.(antlr.ParserRuleContext); ok {
+			return SelectColumn{
+				Expression: strings.TrimSpace(ctxText(tokens, prc)),
+				Span:       spanFor(tokens, prc),
+			}, true
+		}
+	}
+	return SelectColumn{}, false
+}
+
 // extractProjection records projection expressions and aliases for the SELECT list.
 func extractProjection(result *ParsedQuery, simple gen.ISimple_select_pramaryContext, tokens antlr.TokenStream) {
 	if simple == nil {
@@ -277,44 +324,17 @@ func extractProjection(result *ParsedQuery, simple gen.ISimple_select_pramaryCon
 	}
 
 	for _, item := range targetList.AllTarget_el() {
-		switch col := item.(type) {
-		case *gen.Target_labelContext:
-			expr := ""
-			if col.A_expr() != nil {
-				if prc, ok := col.A_expr().(antlr.ParserRuleContext); ok {
-					expr = strings.TrimSpace(ctxText(tokens, prc))
-				}
-				findAndRecordUsage(result, col.A_expr(), ColumnUsageTypeProjection, tokens)
-			}
-			alias := ""
-			switch {
-			case col.ColLabel() != nil:
-				if prc, ok := col.ColLabel().(antlr.ParserRuleContext); ok {
-					alias = strings.TrimSpace(ctxText(tokens, prc))
-				}
-			case col.BareColLabel() != nil:
-				if prc, ok := col.BareColLabel().(antlr.ParserRuleContext); ok {
-					alias = strings.TrimSpace(ctxText(tokens, prc))
-				}
-			}
-			result.Columns = append(result.Columns, SelectColumn{
-				Expression: expr,
-				Alias:      alias,
-			})
-			// Track derived columns (alias -> expression mapping)
-			if alias != "" && expr != "" && alias != expr {
-				result.DerivedColumns[alias] = expr
-			}
-		case *gen.Target_starContext:
-			result.Columns = append(result.Columns, SelectColumn{
-				Expression: strings.TrimSpace(ctxText(tokens, col)),
-			})
-		default:
-			if prc, ok := col.(antlr.ParserRuleContext); ok {
-				result.Columns = append(result.Columns, SelectColumn{
-					Expression: strings.TrimSpace(ctxText(tokens, prc)),
-				})
-			}
+		if col, ok := item.(*gen.Target_labelContext); ok && col.A_expr() != nil {
+			findAndRecordUsage(result, col.A_expr(), ColumnUsageTypeProjection, tokens)
+		}
+		sc, ok := targetColumn(item, tokens)
+		if !ok {
+			continue
+		}
+		result.Columns = append(result.Columns, sc)
+		// Track derived columns (alias -> expression mapping)
+		if sc.Alias != "" && sc.Expression != "" && sc.Alias != sc.Expression {
+			result.DerivedColumns[sc.Alias] = sc.Expression
 		}
 	}
 
@@ -370,6 +390,7 @@ func collectTableRefs(result *ParsedQuery, ref gen.ITable_refContext, tokens ant
 			Alias:  alias,
 			Type:   tableType,
 			Raw:    rawText,
+			Span:   spanFor(tokens, rel),
 		})
 	} else if fn := ref.Func_table(); fn != nil {
 		tableName := ""
@@ -382,6 +403,7 @@ func collectTableRefs(result *ParsedQuery, ref gen.ITable_refContext, tokens ant
 			Alias: alias,
 			Type:  TableTypeFunction,
 			Raw:   tableName,
+			Span:  spanFor(tokens, fn),
 		})
 		// Check for LATERAL correlation
 		if prc, ok := ref.(antlr.ParserRuleContext); ok {
@@ -400,6 +422,7 @@ func collectTableRefs(result *ParsedQuery, ref gen.ITable_refContext, tokens ant
 			Alias: alias,
 			Type:  TableTypeSubquery,
 			Raw:   raw,
+			Span:  spanFor(tokens, sub),
 		})
 		// Use buildSubqueryRefWithResult to propagate column usage from nested subqueries
 		if subRef, err := buildSubqueryRefWithResult(alias, sub, tokens, result); err == nil && subRef != nil {
@@ -420,9 +443,20 @@ func collectTableRefs(result *ParsedQuery, ref gen.ITable_refContext, tokens ant
 		clauseText := strings.TrimSpace(ctxText(tokens, joinCtx))
 		if clauseText != "" {
 			result.JoinConditions = append(result.JoinConditions, clauseText)
+			if wantExprTrees(tokens) {
+				result.JoinExprs = append(result.JoinExprs, buildExprTree(join.A_expr(), tokens))
+			}
 		}
 		if join.USING() != nil {
+			first := len(result.ColumnUsage)
 			recordUsingJoinFromString(result, clauseText)
+			// USING columns are parsed from text, so their spans cover the whole clause.
+			if span := spanFor(tokens, joinCtx); span != nil {
+				for i := first; i < len(result.ColumnUsage); i++ {
+					s := *span
+					result.ColumnUsage[i].Span = &s
+				}
+			}
 		} else {
 			findAndRecordUsage(result, joinCtx, ColumnUsageTypeJoin, tokens)
 		}
@@ -441,6 +475,9 @@ func extractWhereClause(result *ParsedQuery, whereCtx gen.IWhere_clauseContext,
 		}
 		clauseText := strings.TrimSpace(ctxText(tokens, prc))
 		result.Where = append(result.Where, clauseText)
+		if wantExprTrees(tokens) {
+			result.WhereExprs = append(result.WhereExprs, buildExprTree(expr, tokens))
+		}
 		// Use the new comparison-aware extraction for WHERE clauses
 		findAndRecordComparisons(result, expr, ColumnUsageTypeFilter, tokens)
 	}
@@ -454,6 +491,9 @@ func extractHavingClause(result *ParsedQuery, havingCtx gen.IHaving_clauseContex
 	if expr := havingCtx.A_expr(); expr != nil {
 		if prc, ok := expr.(antlr.ParserRuleContext); ok {
 			result.Having = append(result.Having, strings.TrimSpace(ctxText(tokens, prc)))
+			if wantExprTrees(tokens) {
+				result.HavingExprs = append(result.HavingExprs, buildExprTree(expr, tokens))
+			}
 		}
 		// Use the new comparison-aware extraction for HAVING clauses
 		findAndRecordComparisons(result, expr, ColumnUsageTypeFilter, tokens)
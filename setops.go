@@ -296,6 +296,7 @@ func extractTablesAndUsageForPrimary(primary gen.ISimple_select_pramaryContext,
 			Name:   relation,
 			Type:   tableType,
 			Raw:    name,
+			Span:   spanFor(tokens, primary.Relation_expr()),
 		})
 	}
 	if primary.Select_with_parens() != nil {
@@ -0,0 +1,179 @@
+// tokens.go exposes the lexical token stream of a SQL input, including
+// whitespace and comments, with keywords that are used as names reclassified
+// from the parse tree. Formatters and rewriters build on it.
+package postgresparser
+
+import (
+	"fmt"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// TokenKind classifies a lexical token.
+type TokenKind string
+
+const (
+	// TokenKeyword is a SQL keyword in keyword position.
+	TokenKeyword TokenKind = "KEYWORD"
+	// TokenIdentifier is a plain or quoted identifier, or a non-reserved
+	// keyword used as a name (e.g. a column called "name").
+	TokenIdentifier TokenKind = "IDENTIFIER"
+	// TokenLiteral is a numeric, string, bit-string, or dollar-quoted constant.
+	TokenLiteral TokenKind = "LITERAL"
+	// TokenParam is a $n or ? placeholder.
+	TokenParam TokenKind = "PARAM"
+	// TokenPunctuation is an operator or punctuation such as "(", ",", or "::".
+	TokenPunctuation TokenKind = "PUNCTUATION"
+	// TokenComment is a -- line comment or a /* block */ comment.
+	TokenComment TokenKind = "COMMENT"
+	// TokenWhitespace is a run of spaces, tabs, or a newline.
+	TokenWhitespace TokenKind = "WHITESPACE"
+)
+
+// Token is one lexical token of the input. Concatenating the Text of every
+// token returned by Tokenize reproduces the input exactly.
+type Token struct {
+	Kind TokenKind
+	Text string
+	// Start and End are the byte offsets of Text in the input.
+	Start int
+	End   int
+	// Statement is the 1-based index of the statement the token belongs to,
+	// matching StatementParseResult.Index. Whitespace and comments between
+	// statements belong to the following statement; a terminating semicolon
+	// belongs to the statement it ends.
+	Statement int
+}
+
+// Tokenize lexes and parses sql and returns its complete token stream. Unlike
+// the Parse functions, Tokenize does not preprocess the input, so trailing //
+// comments are reported as syntax errors. Dollar-quoted strings are returned
+// as single literal tokens.
+func Tokenize(sql string) ([]Token, error) {
+	engine := newParseEngine()
+	root, stream, syntaxErrs := engine.parse(sql, false)
+	if len(syntaxErrs) > 0 {
+		return nil, &ParseErrors{SQL: sql, Errors: syntaxErrs}
+	}
+	if root == nil || root.Stmtblock() == nil || root.Stmtblock().Stmtmulti() == nil ||
+		len(root.Stmtblock().Stmtmulti().AllStmt()) == 0 {
+		return nil, ErrNoStatements
+	}
+
+	names := make(map[int]bool)
+	markKeywordNames(root, names)
+
+	offsets := make([]int, 0, len(sql)+1)
+	for i := range sql {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(sql))
+	byteOffset := func(runeIndex int) int {
+		if runeIndex >= len(offsets) {
+			return len(sql)
+		}
+		return offsets[runeIndex]
+	}
+
+	cts, ok := stream.(*antlr.CommonTokenStream)
+	if !ok {
+		return nil, fmt.Errorf("unexpected token stream %T", stream)
+	}
+	all := cts.GetAllTokens()
+
+	var out []Token
+	var malformed []SyntaxError
+	statement, significant := 1, false
+	for i := 0; i < len(all); i++ {
+		tok := all[i]
+		typ := tok.GetTokenType()
+		if typ == antlr.TokenEOF {
+			break
+		}
+		if isMalformedToken(engine.lexer, typ) {
+			malformed = append(malformed, SyntaxError{
+				Line:       tok.GetLine(),
+				Column:     tok.GetColumn(),
+				Message:    fmt.Sprintf("malformed token %q", tok.GetText()),
+				TokenIndex: tok.GetTokenIndex(),
+			})
+			continue
+		}
+		start, stop := tok.GetStart(), tok.GetStop()
+		kind := classifyToken(typ)
+		if typ == gen.PostgreSQLLexerBeginDollarStringConstant {
+			for i+1 < len(all) && all[i+1].GetTokenType() != antlr.TokenEOF {
+				i++
+				stop = all[i].GetStop()
+				if all[i].GetTokenType() == gen.PostgreSQLLexerEndDollarStringConstant {
+					break
+				}
+			}
+		} else if kind == TokenKeyword && names[tok.GetTokenIndex()] {
+			kind = TokenIdentifier
+		}
+		from, to := byteOffset(start), byteOffset(stop+1)
+		out = append(out, Token{Kind: kind, Text: sql[from:to], Start: from, End: to, Statement: statement})
+		switch {
+		case typ == gen.PostgreSQLLexerSEMI && significant:
+			statement++
+			significant = false
+		case kind != TokenWhitespace && kind != TokenComment && typ != gen.PostgreSQLLexerSEMI:
+			significant = true
+		}
+	}
+	if len(malformed) > 0 {
+		return nil, &ParseErrors{SQL: sql, Errors: malformed}
+	}
+	// Tokens after the last statement belong to it rather than opening
+	// another, empty statement.
+	if !significant && statement > 1 {
+		for i := len(out) - 1; i >= 0 && out[i].Statement == statement; i-- {
+			out[i].Statement = statement - 1
+		}
+	}
+	return out, nil
+}
+
+// classifyToken maps a lexer token type to its TokenKind.
+func classifyToken(typ int) TokenKind {
+	switch typ {
+	case gen.PostgreSQLLexerWhitespace, gen.PostgreSQLLexerNewline:
+		return TokenWhitespace
+	case gen.PostgreSQLLexerLineComment, gen.PostgreSQLLexerBlockComment:
+		return TokenComment
+	case gen.PostgreSQLLexerBeginDollarStringConstant:
+		return TokenLiteral
+	}
+	switch classifyNormToken(typ) {
+	case normLiteral:
+		return TokenLiteral
+	case normParam:
+		return TokenParam
+	case normIdent:
+		return TokenIdentifier
+	case normKeyword:
+		return TokenKeyword
+	}
+	return TokenPunctuation
+}
+
+// markKeywordNames records the token indices of keywords that the parse tree
+// places in a name position, i.e. below one of the grammar's keyword
+// category rules that colid, collabel, and type_function_name accept.
+func markKeywordNames(node antlr.Tree, names map[int]bool) {
+	switch node.(type) {
+	case *gen.Unreserved_keywordContext, *gen.Col_name_keywordContext,
+		*gen.Type_func_name_keywordContext, *gen.Reserved_keywordContext,
+		*gen.Bare_label_keywordContext:
+		if prc, ok := node.(antlr.ParserRuleContext); ok && prc.GetStart() != nil {
+			names[prc.GetStart().GetTokenIndex()] = true
+		}
+		return
+	}
+	for _, child := range node.GetChildren() {
+		markKeywordNames(child, names)
+	}
+}
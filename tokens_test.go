@@ -0,0 +1,95 @@
+package postgresparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizeRoundTrip(t *testing.T) {
+	sql := "SELECT a, 'é' AS \"Ünï\" -- note\nFROM t /* x */ WHERE b = $1;\n\nSELECT $tag$body; $x$ $tag$;\n"
+	tokens, err := Tokenize(sql)
+	require.NoError(t, err)
+
+	var b strings.Builder
+	for _, tok := range tokens {
+		assert.Equal(t, tok.Text, sql[tok.Start:tok.End])
+		b.WriteString(tok.Text)
+	}
+	assert.Equal(t, sql, b.String())
+}
+
+func TestTokenizeKinds(t *testing.T) {
+	tokens, err := Tokenize("SELECT name, count(*) FROM t WHERE x::int = $1 -- c\n AND y = 'v'")
+	require.NoError(t, err)
+
+	type kt struct {
+		Kind TokenKind
+		Text string
+	}
+	var got []kt
+	for _, tok := range tokens {
+		if tok.Kind != TokenWhitespace {
+			got = append(got, kt{tok.Kind, tok.Text})
+		}
+	}
+	assert.Equal(t, []kt{
+		{TokenKeyword, "SELECT"},
+		{TokenIdentifier, "name"},
+		{TokenPunctuation, ","},
+		{TokenIdentifier, "count"},
+		{TokenPunctuation, "("},
+		{TokenPunctuation, "*"},
+		{TokenPunctuation, ")"},
+		{TokenKeyword, "FROM"},
+		{TokenIdentifier, "t"},
+		{TokenKeyword, "WHERE"},
+		{TokenIdentifier, "x"},
+		{TokenPunctuation, "::"},
+		{TokenKeyword, "int"},
+		{TokenPunctuation, "="},
+		{TokenParam, "$1"},
+		{TokenComment, "-- c"},
+		{TokenKeyword, "AND"},
+		{TokenIdentifier, "y"},
+		{TokenPunctuation, "="},
+		{TokenLiteral, "'v'"},
+	}, got)
+}
+
+func TestTokenizeDollarQuotedLiteral(t *testing.T) {
+	tokens, err := Tokenize("SELECT $fn$ a; 'b' $fn$")
+	require.NoError(t, err)
+	last := tokens[len(tokens)-1]
+	assert.Equal(t, TokenLiteral, last.Kind)
+	assert.Equal(t, "$fn$ a; 'b' $fn$", last.Text)
+}
+
+func TestTokenizeStatementIndexes(t *testing.T) {
+	tokens, err := Tokenize("SELECT 1;\n-- second\nSELECT 2; -- end\n")
+	require.NoError(t, err)
+
+	byText := make(map[string]int)
+	for _, tok := range tokens {
+		if tok.Kind != TokenWhitespace {
+			byText[tok.Text] = tok.Statement
+		}
+	}
+	assert.Equal(t, 1, byText["1"])
+	assert.Equal(t, 2, byText["-- second"])
+	assert.Equal(t, 2, byText["2"])
+	assert.Equal(t, 2, byText["-- end"])
+	assert.Equal(t, 2, tokens[len(tokens)-1].Statement)
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	_, err := Tokenize("SELECT FROM WHERE (")
+	var parseErrs *ParseErrors
+	assert.ErrorAs(t, err, &parseErrs)
+
+	_, err = Tokenize("-- only a comment")
+	assert.True(t, errors.Is(err, ErrNoStatements))
+}
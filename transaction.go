@@ -0,0 +1,131 @@
+// transaction.go implements transaction control statement extraction and the
+// grouping of batch statements into transaction blocks.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateTransaction handles BEGIN, START TRANSACTION, COMMIT/END,
+// ROLLBACK/ABORT, SAVEPOINT, RELEASE, ROLLBACK TO, and two-phase commit statements.
+func populateTransaction(result *ParsedQuery, ctx gen.ITransactionstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("transaction statement: %w", ErrNilContext)
+	}
+
+	clause := &TransactionClause{}
+	switch {
+	case ctx.BEGIN_P() != nil:
+		clause.Action = TransactionBegin
+	case ctx.START() != nil:
+		clause.Action = TransactionStart
+	case ctx.SAVEPOINT() != nil && ctx.ROLLBACK() == nil && ctx.RELEASE() == nil:
+		clause.Action = TransactionSavepoint
+	case ctx.RELEASE() != nil:
+		clause.Action = TransactionRelease
+	case ctx.PREPARE() != nil:
+		clause.Action = TransactionPrepare
+	case ctx.PREPARED() != nil && ctx.COMMIT() != nil:
+		clause.Action = TransactionCommitPrepared
+	case ctx.PREPARED() != nil:
+		clause.Action = TransactionRollbackPrepared
+	case ctx.TO() != nil:
+		clause.Action = TransactionRollbackTo
+	case ctx.COMMIT() != nil || ctx.END_P() != nil:
+		clause.Action = TransactionCommit
+	default:
+		clause.Action = TransactionRollback
+	}
+
+	if ctx.Colid() != nil {
+		clause.Savepoint = contextText(tokens, ctx.Colid())
+	}
+	if ctx.Sconst() != nil {
+		clause.GID = decodeCommentStringLiteral(contextText(tokens, ctx.Sconst()))
+	}
+	if chain := ctx.Transaction_chain_(); chain != nil && chain.NO() == nil {
+		clause.Chain = true
+	}
+	if modes := ctx.Transaction_mode_list_or_empty(); modes != nil && modes.Transaction_mode_list() != nil {
+		for _, item := range modes.Transaction_mode_list().AllTransaction_mode_item() {
+			switch {
+			case item.Iso_level() != nil:
+				clause.IsolationLevel = strings.ToUpper(normalizeSpace(contextText(tokens, item.Iso_level())))
+			case item.ONLY() != nil:
+				clause.AccessMode = "READ ONLY"
+			case item.WRITE() != nil:
+				clause.AccessMode = "READ WRITE"
+			case item.NOT() != nil:
+				clause.Deferrable = "NOT DEFERRABLE"
+			case item.DEFERRABLE() != nil:
+				clause.Deferrable = "DEFERRABLE"
+			}
+		}
+	}
+
+	result.Transaction = clause
+	return nil
+}
+
+// TransactionBlock is a run of batch statements that execute in one
+// transaction, either an explicit BEGIN ... COMMIT block or a single
+// autocommit statement.
+type TransactionBlock struct {
+	Statements []StatementParseResult
+	// Explicit is true when the block was opened by BEGIN or START TRANSACTION
+	// (or by COMMIT/ROLLBACK AND CHAIN).
+	Explicit bool
+	// End is the action that closed an explicit block: COMMIT, ROLLBACK, or
+	// PREPARE_TRANSACTION. It is empty for autocommit statements and for an
+	// explicit block still open at the end of the batch.
+	End TransactionActionType
+}
+
+// TransactionBlocks groups the batch statements into transaction blocks in
+// source order. Statements outside BEGIN/COMMIT, including ones that failed to
+// parse, each form their own autocommit block.
+func (b *ParseBatchResult) TransactionBlocks() []TransactionBlock {
+	if b == nil {
+		return nil
+	}
+	var blocks []TransactionBlock
+	var open *TransactionBlock
+	for _, stmt := range b.Statements {
+		var tx *TransactionClause
+		if stmt.Query != nil {
+			tx = stmt.Query.Transaction
+		}
+
+		if open == nil {
+			if tx != nil && (tx.Action == TransactionBegin || tx.Action == TransactionStart) {
+				open = &TransactionBlock{Explicit: true}
+			} else {
+				blocks = append(blocks, TransactionBlock{Statements: []StatementParseResult{stmt}})
+				continue
+			}
+		}
+
+		open.Statements = append(open.Statements, stmt)
+		if tx == nil {
+			continue
+		}
+		switch tx.Action {
+		case TransactionCommit, TransactionRollback, TransactionPrepare:
+			open.End = tx.Action
+			blocks = append(blocks, *open)
+			open = nil
+			if tx.Chain && tx.Action != TransactionPrepare {
+				open = &TransactionBlock{Explicit: true}
+			}
+		}
+	}
+	if open != nil && len(open.Statements) > 0 {
+		blocks = append(blocks, *open)
+	}
+	return blocks
+}